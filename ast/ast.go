@@ -71,18 +71,21 @@ func (*BadStmt) stmtNode()     {}
 func (*EmptyStmt) stmtNode()   {}
 func (*LabeledStmt) stmtNode() {}
 
-func (s *BadStmt) Flatten() []Node     { return []Node{s} }
-func (s *EmptyStmt) Flatten() []Node   { return []Node{s} }
-func (s *LabeledStmt) Flatten() []Node { return []Node{s} }
+func (s *BadStmt) Flatten() []Node   { return []Node{s} }
+func (s *EmptyStmt) Flatten() []Node { return []Node{s} }
+func (s *LabeledStmt) Flatten() []Node {
+	return append([]Node{s.Label}, s.Stmt.Flatten()...)
+}
 
 type Module struct {
 	Type      ModuleType
 	Specifier string
 	Scheme    string
 
-	Size  int
-	Stmts []Stmt
-	Nodes []Node
+	Size     int
+	Stmts    []Stmt
+	Nodes    []Node
+	Comments []*CommentGroup // every comment in the module, in source order
 }
 
 func (m *Module) Pos() token.Pos {
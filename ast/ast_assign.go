@@ -4,15 +4,21 @@ import "github.com/calico32/goose/token"
 
 type (
 	ConstStmt struct {
+		Doc      *CommentGroup
 		ConstPos token.Pos
 		Ident    *Ident
+		Pattern  PatternExpr // destructuring pattern, if Ident is nil
+		Type     TypeExpr
 		TokPos   token.Pos
 		Value    Expr
 	}
 
 	LetStmt struct {
-		LetPos token.Pos
-		Ident  *Ident
+		Doc    *CommentGroup
+		LetPos  token.Pos
+		Ident   *Ident
+		Pattern PatternExpr // destructuring pattern, if Ident is nil
+		Type    TypeExpr
 		TokPos token.Pos
 		Value  Expr
 	}
@@ -36,6 +42,9 @@ func (s *LetStmt) End() token.Pos {
 	if s.Value != nil {
 		return s.Value.End()
 	}
+	if s.Ident == nil {
+		return s.Pattern.End()
+	}
 	return s.Ident.End()
 }
 func (s *AssignStmt) End() token.Pos { return s.Rhs.End() }
@@ -53,13 +62,21 @@ func (*IncDecStmt) stmtNode() {}
 
 func (s *ConstStmt) Flatten() []Node {
 	nodes := make([]Node, 0, 2)
-	nodes = append(nodes, s.Ident.Flatten()...)
+	if s.Ident != nil {
+		nodes = append(nodes, s.Ident.Flatten()...)
+	} else {
+		nodes = append(nodes, s.Pattern.Flatten()...)
+	}
 	nodes = append(nodes, s.Value.Flatten()...)
 	return nodes
 }
 func (s *LetStmt) Flatten() []Node {
 	nodes := make([]Node, 0, 2)
-	nodes = append(nodes, s.Ident.Flatten()...)
+	if s.Ident != nil {
+		nodes = append(nodes, s.Ident.Flatten()...)
+	} else {
+		nodes = append(nodes, s.Pattern.Flatten()...)
+	}
 	if s.Value != nil {
 		nodes = append(nodes, s.Value.Flatten()...)
 	}
@@ -78,3 +78,127 @@ func (g *CommentGroup) Text() string {
 
 	return strings.Join(lines, "\n")
 }
+
+// IsDoc reports whether every comment in the group is a `///` doc comment.
+func (g *CommentGroup) IsDoc() bool {
+	if g == nil || len(g.List) == 0 {
+		return false
+	}
+	for _, c := range g.List {
+		if !strings.HasPrefix(c.Text, "///") {
+			return false
+		}
+	}
+	return true
+}
+
+// Doc is a parsed `///` doc comment. Text is the description, and the
+// @param and @returns tags are collected into Params and Returns.
+type Doc struct {
+	Text    string
+	Params  []*DocParam
+	Returns string
+}
+
+// DocParam is the description given to a parameter or field by an @param
+// tag.
+type DocParam struct {
+	Name string
+	Text string
+}
+
+// ParseDoc parses a doc comment group. It returns nil if g is nil.
+func ParseDoc(g *CommentGroup) *Doc {
+	if g == nil {
+		return nil
+	}
+	doc := &Doc{}
+	var body []string
+	for _, c := range g.List {
+		line := strings.TrimPrefix(c.Text, "///")
+		line = trimRight(strings.TrimPrefix(line, " "))
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "@param "):
+			name, text, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(trimmed, "@param ")), " ")
+			doc.Params = append(doc.Params, &DocParam{
+				Name: strings.TrimPrefix(name, "..."),
+				Text: strings.TrimSpace(text),
+			})
+		case strings.HasPrefix(trimmed, "@returns ") || strings.HasPrefix(trimmed, "@return "):
+			_, text, _ := strings.Cut(trimmed, " ")
+			doc.Returns = strings.TrimSpace(text)
+		case len(doc.Params) > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "@"):
+			// continuation of the previous @param
+			param := doc.Params[len(doc.Params)-1]
+			param.Text = strings.TrimSpace(param.Text + " " + trimmed)
+		default:
+			body = append(body, line)
+		}
+	}
+	doc.Text = strings.TrimSpace(strings.Join(body, "\n"))
+	return doc
+}
+
+// Param returns the description of the parameter or field name, or "" if it
+// has none.
+func (d *Doc) Param(name string) string {
+	if d == nil {
+		return ""
+	}
+	for _, param := range d.Params {
+		if param.Name == name {
+			return param.Text
+		}
+	}
+	return ""
+}
+
+// DocOf returns the doc comment attached to a declaration, or nil.
+func DocOf(node Node) *CommentGroup {
+	switch node := node.(type) {
+	case *FuncExpr:
+		return node.Doc
+	case *GeneratorExpr:
+		return node.Doc
+	case *StructStmt:
+		return node.Doc
+	case *OperatorStmt:
+		return node.Doc
+	case *TraitStmt:
+		return node.Doc
+	case *EnumStmt:
+		return node.Doc
+	case *EnumVariant:
+		return node.Doc
+	case *MethodSpec:
+		return node.Doc
+	case *OperatorSpec:
+		return node.Doc
+	case *ConstStmt:
+		return node.Doc
+	case *LetStmt:
+		return node.Doc
+	case *NativeConst:
+		return node.Doc
+	case *NativeStruct:
+		return node.Doc
+	case *NativeFunc:
+		return node.Doc
+	case *NativeOperator:
+		return node.Doc
+	case *NativeType:
+		return node.Doc
+	case *TypeStmt:
+		return node.Doc
+	case *MacroStmt:
+		return node.Doc
+	case *ProcMacroStmt:
+		return node.Doc
+	case *ExportDeclStmt:
+		return DocOf(node.Stmt)
+	case *ExprStmt:
+		return DocOf(node.X)
+	}
+	return nil
+}
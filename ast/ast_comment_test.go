@@ -0,0 +1,41 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDoc(t *testing.T) {
+	t.Parallel()
+
+	group := &CommentGroup{}
+	for _, line := range []string{
+		"/// Splits s around each sep.",
+		"///",
+		"///   Indented text is kept.",
+		"/// @param s the string",
+		"/// @param ...sep the separators, which",
+		"///   may be empty",
+		"/// @returns the parts",
+	} {
+		group.List = append(group.List, &Comment{Text: line})
+	}
+
+	want := &Doc{
+		Text: "Splits s around each sep.\n\n  Indented text is kept.",
+		Params: []*DocParam{
+			{Name: "s", Text: "the string"},
+			{Name: "sep", Text: "the separators, which may be empty"},
+		},
+		Returns: "the parts",
+	}
+	if got := ParseDoc(group); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := want.Param("sep"); got != "the separators, which may be empty" {
+		t.Errorf("got %q for sep", got)
+	}
+	if ParseDoc(nil) != nil || (*Doc)(nil).Param("s") != "" {
+		t.Errorf("nil doc comments are not empty")
+	}
+}
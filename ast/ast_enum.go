@@ -0,0 +1,51 @@
+package ast
+
+import "github.com/calico32/goose/token"
+
+type (
+	// enum Shape ... end
+	EnumStmt struct {
+		Doc      *CommentGroup
+		Enum     token.Pos
+		Name     *Ident
+		Variants []*EnumVariant
+		BlockEnd token.Pos
+	}
+
+	// Circle(r) or Empty
+	EnumVariant struct {
+		Doc    *CommentGroup
+		Name   *Ident
+		Fields *StructFieldList // nil for variants without fields
+	}
+)
+
+func (s *EnumStmt) Pos() token.Pos    { return s.Enum }
+func (s *EnumVariant) Pos() token.Pos { return s.Name.Pos() }
+
+func (s *EnumStmt) End() token.Pos { return s.BlockEnd + 3 }
+func (s *EnumVariant) End() token.Pos {
+	if s.Fields != nil {
+		return s.Fields.End()
+	}
+	return s.Name.End()
+}
+
+func (*EnumStmt) stmtNode() {}
+
+func (s *EnumStmt) Flatten() []Node {
+	nodes := make([]Node, 0, len(s.Variants))
+	for _, variant := range s.Variants {
+		nodes = append(nodes, variant.Flatten()...)
+	}
+	return nodes
+}
+func (s *EnumVariant) Flatten() []Node {
+	nodes := []Node{s.Name}
+	if s.Fields != nil {
+		for _, field := range s.Fields.List {
+			nodes = append(nodes, field.Flatten()...)
+		}
+	}
+	return nodes
+}
@@ -97,11 +97,16 @@ func (x *SelectorExpr) Pos() token.Pos        { return x.X.Pos() }
 func (x *BracketSelectorExpr) Pos() token.Pos { return x.X.Pos() }
 func (x *BindExpr) Pos() token.Pos            { return x.X.Pos() }
 func (x *CallExpr) Pos() token.Pos            { return x.Func.Pos() }
-func (x *UnaryExpr) Pos() token.Pos           { return x.OpPos }
-func (x *BinaryExpr) Pos() token.Pos          { return x.X.Pos() }
-func (x *EllipsisExpr) Pos() token.Pos        { return x.Ellipsis }
-func (x *KeyValueExpr) Pos() token.Pos        { return x.Key.Pos() }
-func (x *AwaitExpr) Pos() token.Pos           { return x.Await }
+func (x *UnaryExpr) Pos() token.Pos {
+	if x.Op == token.Question {
+		return x.X.Pos()
+	}
+	return x.OpPos
+}
+func (x *BinaryExpr) Pos() token.Pos   { return x.X.Pos() }
+func (x *EllipsisExpr) Pos() token.Pos { return x.Ellipsis }
+func (x *KeyValueExpr) Pos() token.Pos { return x.Key.Pos() }
+func (x *AwaitExpr) Pos() token.Pos    { return x.Await }
 
 func (s *ExprStmt) End() token.Pos            { return s.X.End() }
 func (x *BadExpr) End() token.Pos             { return x.To }
@@ -112,11 +117,16 @@ func (x *SelectorExpr) End() token.Pos        { return x.Sel.End() }
 func (x *BracketSelectorExpr) End() token.Pos { return x.RBrack + 1 }
 func (x *BindExpr) End() token.Pos            { return x.Sel.End() }
 func (x *CallExpr) End() token.Pos            { return x.RParen + 1 }
-func (x *UnaryExpr) End() token.Pos           { return x.X.End() }
-func (x *BinaryExpr) End() token.Pos          { return x.Y.End() }
-func (x *EllipsisExpr) End() token.Pos        { return x.X.End() }
-func (x *KeyValueExpr) End() token.Pos        { return x.Value.End() }
-func (x *AwaitExpr) End() token.Pos           { return x.X.End() }
+func (x *UnaryExpr) End() token.Pos {
+	if x.Op == token.Question {
+		return x.OpPos + 1
+	}
+	return x.X.End()
+}
+func (x *BinaryExpr) End() token.Pos   { return x.Y.End() }
+func (x *EllipsisExpr) End() token.Pos { return x.X.End() }
+func (x *KeyValueExpr) End() token.Pos { return x.Value.End() }
+func (x *AwaitExpr) End() token.Pos    { return x.X.End() }
 
 func (*ExprStmt) stmtNode()            {}
 func (*BadExpr) exprNode()             {}
@@ -4,6 +4,7 @@ import "github.com/calico32/goose/token"
 
 type (
 	FuncExpr struct {
+		Doc      *CommentGroup
 		Async    token.Pos
 		Memo     token.Pos
 		Func     token.Pos
@@ -11,6 +12,11 @@ type (
 		Name     *Ident
 		Params   *FuncParamList
 
+		// <T> after the receiver and after the name
+		ReceiverTypeParams *TypeParamList
+		TypeParams         *TypeParamList
+		Result             TypeExpr
+
 		Arrow     token.Pos
 		ArrowExpr Expr
 
@@ -31,8 +37,11 @@ type FuncParamList struct {
 }
 
 type FuncParam struct {
+	Colon    token.Pos // named parameters are prefixed with a colon
 	Ellipsis token.Pos
 	Ident    *Ident
+	Pattern  PatternExpr // destructuring pattern, if Ident is nil
+	Type     TypeExpr
 	Value    Expr
 }
 
@@ -86,12 +95,31 @@ func (f *FuncParamList) End() token.Pos {
 func (f *FuncParamList) NumFields() int { return len(f.List) }
 
 func (f *FuncParam) Pos() token.Pos {
+	if f.Colon.IsValid() {
+		return f.Colon
+	}
 	if f.Ellipsis.IsValid() {
 		return f.Ellipsis
 	}
+	if f.Ident == nil {
+		return f.Pattern.Pos()
+	}
 	return f.Ident.Pos()
 }
-func (f *FuncParam) End() token.Pos { return f.Ident.End() }
+func (f *FuncParam) End() token.Pos {
+	if f.Ident == nil {
+		return f.Pattern.End()
+	}
+	return f.Ident.End()
+}
+
+// Bindings returns the identifiers the parameter binds.
+func (f *FuncParam) Bindings() []*Ident {
+	if f.Ident == nil {
+		return Bindings(f.Pattern)
+	}
+	return []*Ident{f.Ident}
+}
 
 func (f *FuncExpr) Flatten() []Node {
 	nodes := make([]Node, 0, len(f.Body))
@@ -4,6 +4,7 @@ import "github.com/calico32/goose/token"
 
 type (
 	GeneratorExpr struct {
+		Doc       *CommentGroup
 		Async     token.Pos
 		Generator token.Pos
 		Receiver  *Ident
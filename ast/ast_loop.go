@@ -7,6 +7,7 @@ type (
 		For      token.Pos
 		Await    token.Pos
 		Var      *Ident
+		Pattern  PatternExpr // destructuring pattern, if Var is nil
 		Iterable Expr
 		Body     []Stmt
 		BlockEnd token.Pos
@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/calico32/goose/token"
+)
+
+type (
+	// macro foo!(x, y) -> x * y
+	MacroStmt struct {
+		Doc    *CommentGroup
+		Macro  token.Pos
+		Name   *Ident
+		Bang   token.Pos
+		Params *MacroParamList
+		Arrow  token.Pos
+		Body   Expr
+
+		// Tokens are the tokens of Body, which invocations substitute their
+		// arguments into.
+		Tokens []*MacroToken
+	}
+
+	// procmacro foo!(tokens) ... end
+	ProcMacroStmt struct {
+		Doc       *CommentGroup
+		ProcMacro token.Pos
+		Name      *Ident
+		Bang      token.Pos
+		Params    *MacroParamList
+		Body      []Stmt
+		BlockEnd  token.Pos
+	}
+
+	// foo!(1, 2)
+	MacroCallExpr struct {
+		Name    *Ident
+		Bang    token.Pos
+		Opening token.Pos
+		Tokens  []*MacroToken
+		Closing token.Pos
+
+		// Expansion is the expression the invocation expands to. It is set by
+		// parser.ExpandMacros.
+		Expansion Expr
+	}
+)
+
+type MacroParamList struct {
+	Opening token.Pos
+	List    []*Ident
+	Closing token.Pos
+}
+
+// MacroToken is a token kept as it was scanned, to be parsed again when a
+// macro is expanded.
+type MacroToken struct {
+	Pos token.Pos
+	Tok token.Token
+	Lit string
+}
+
+func (s *MacroStmt) Pos() token.Pos     { return s.Macro }
+func (s *ProcMacroStmt) Pos() token.Pos { return s.ProcMacro }
+func (x *MacroCallExpr) Pos() token.Pos { return x.Name.Pos() }
+
+func (s *MacroStmt) End() token.Pos     { return s.Body.End() }
+func (s *ProcMacroStmt) End() token.Pos { return s.BlockEnd + 3 }
+func (x *MacroCallExpr) End() token.Pos { return x.Closing + 1 }
+
+func (*MacroStmt) stmtNode()     {}
+func (*ProcMacroStmt) stmtNode() {}
+func (*MacroCallExpr) exprNode() {}
+
+func (s *MacroStmt) Flatten() []Node { return s.Name.Flatten() }
+func (s *ProcMacroStmt) Flatten() []Node {
+	nodes := s.Name.Flatten()
+	for _, stmt := range s.Body {
+		nodes = append(nodes, stmt.Flatten()...)
+	}
+	return nodes
+}
+func (x *MacroCallExpr) Flatten() []Node {
+	nodes := x.Name.Flatten()
+	if x.Expansion != nil {
+		nodes = append(nodes, x.Expansion.Flatten()...)
+	}
+	return nodes
+}
+
+func (l *MacroParamList) Pos() token.Pos { return l.Opening }
+func (l *MacroParamList) End() token.Pos { return l.Closing + 1 }
+
+// Names returns the names of the parameters.
+func (l *MacroParamList) Names() []string {
+	names := make([]string, len(l.List))
+	for i, ident := range l.List {
+		names[i] = ident.Name
+	}
+	return names
+}
+
+func (t *MacroToken) String() string {
+	if t.Lit != "" {
+		return t.Lit
+	}
+	return t.Tok.String()
+}
+
+// TokensString returns tokens as source text. Tokens are separated by a space
+// unless they were adjacent where they were written.
+func TokensString(tokens []*MacroToken) string {
+	var sb strings.Builder
+	for i, t := range tokens {
+		if i > 0 {
+			prev := tokens[i-1]
+			if prev.Pos+token.Pos(len(prev.String())) != t.Pos {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(t.String())
+	}
+	return sb.String()
+}
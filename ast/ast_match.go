@@ -36,11 +36,19 @@ type (
 		X Expr
 	}
 
+	// PatternBinding binds the matched value to a name. Bind is only valid in
+	// match patterns; destructuring patterns bind bare identifiers.
 	PatternBinding struct {
 		Bind  token.Pos
 		Ident *Ident
 	}
 
+	// PatternRest binds the remaining elements of an array pattern.
+	PatternRest struct {
+		Ellipsis token.Pos
+		Ident    *Ident
+	}
+
 	PatternParen struct {
 		LParen token.Pos
 		X      PatternExpr
@@ -66,6 +74,15 @@ type (
 		Closing token.Pos
 	}
 
+	// PatternVariant matches a variant of an enum and its fields, as in
+	// Shape.Circle($r).
+	PatternVariant struct {
+		Variant Expr
+		LParen  token.Pos
+		Args    []PatternExpr
+		RParen  token.Pos
+	}
+
 	PatternComposite struct {
 		Opening token.Pos
 		Fields  []*PatternCompositeField
@@ -83,11 +100,18 @@ func (x *MatchExpr) Pos() token.Pos        { return x.Match }
 func (x *MatchElse) Pos() token.Pos        { return x.Else }
 func (x *MatchPattern) Pos() token.Pos     { return x.Pattern.Pos() }
 func (x *PatternNormal) Pos() token.Pos    { return x.X.Pos() }
-func (x *PatternBinding) Pos() token.Pos   { return x.Bind }
+func (x *PatternBinding) Pos() token.Pos {
+	if x.Bind.IsValid() {
+		return x.Bind
+	}
+	return x.Ident.Pos()
+}
+func (x *PatternRest) Pos() token.Pos      { return x.Ellipsis }
 func (x *PatternParen) Pos() token.Pos     { return x.LParen }
 func (x *PatternTuple) Pos() token.Pos     { return x.Opening }
 func (x *PatternRange) Pos() token.Pos     { return x.Start.Pos() }
 func (x *PatternType) Pos() token.Pos      { return x.Ident.Pos() }
+func (x *PatternVariant) Pos() token.Pos   { return x.Variant.Pos() }
 func (x *PatternComposite) Pos() token.Pos { return x.Opening }
 
 func (x *MatchExpr) End() token.Pos        { return x.BlockEnd + 3 }
@@ -95,10 +119,12 @@ func (x *MatchElse) End() token.Pos        { return x.Expr.End() }
 func (x *MatchPattern) End() token.Pos     { return x.Expr.End() }
 func (x *PatternNormal) End() token.Pos    { return x.X.End() }
 func (x *PatternBinding) End() token.Pos   { return x.Ident.End() }
+func (x *PatternRest) End() token.Pos      { return x.Ident.End() }
 func (x *PatternTuple) End() token.Pos     { return x.Closing + 1 }
 func (x *PatternParen) End() token.Pos     { return x.RParen + 1 }
 func (x *PatternRange) End() token.Pos     { return x.Stop.End() }
 func (x *PatternType) End() token.Pos      { return x.Closing + 1 }
+func (x *PatternVariant) End() token.Pos   { return x.RParen + 1 }
 func (x *PatternComposite) End() token.Pos { return x.Closing + 1 }
 
 func (x *MatchExpr) exprNode() {}
@@ -108,10 +134,12 @@ func (x *MatchPattern) matchArm() {}
 
 func (x *PatternNormal) patternExpr()    {}
 func (x *PatternBinding) patternExpr()   {}
+func (x *PatternRest) patternExpr()      {}
 func (x *PatternParen) patternExpr()     {}
 func (x *PatternTuple) patternExpr()     {}
 func (x *PatternRange) patternExpr()     {}
 func (x *PatternType) patternExpr()      {}
+func (x *PatternVariant) patternExpr()   {}
 func (x *PatternComposite) patternExpr() {}
 
 func (x *MatchExpr) Flatten() []Node {
@@ -126,6 +154,7 @@ func (x *MatchElse) Flatten() []Node      { return x.Expr.Flatten() }
 func (x *MatchPattern) Flatten() []Node   { return append(x.Pattern.Flatten(), x.Expr.Flatten()...) }
 func (x *PatternNormal) Flatten() []Node  { return x.X.Flatten() }
 func (x *PatternBinding) Flatten() []Node { return nil }
+func (x *PatternRest) Flatten() []Node    { return nil }
 func (x *PatternParen) Flatten() []Node   { return x.X.Flatten() }
 func (x *PatternTuple) Flatten() []Node {
 	nodes := make([]Node, 0, len(x.List))
@@ -136,6 +165,13 @@ func (x *PatternTuple) Flatten() []Node {
 }
 func (x *PatternRange) Flatten() []Node { return append(x.Start.Flatten(), x.Stop.Flatten()...) }
 func (x *PatternType) Flatten() []Node  { return x.Ident.Flatten() }
+func (x *PatternVariant) Flatten() []Node {
+	nodes := x.Variant.Flatten()
+	for _, arg := range x.Args {
+		nodes = append(nodes, arg.Flatten()...)
+	}
+	return nodes
+}
 func (x *PatternComposite) Flatten() []Node {
 	nodes := make([]Node, 0, len(x.Fields))
 	for _, field := range x.Fields {
@@ -144,3 +180,54 @@ func (x *PatternComposite) Flatten() []Node {
 	}
 	return nodes
 }
+
+// Bindings returns the identifiers bound by a pattern, in source order. _
+// discards a value, so it is not included.
+func Bindings(pattern PatternExpr) []*Ident {
+	var idents []*Ident
+	bind := func(ident *Ident) {
+		if ident.Name != "_" {
+			idents = append(idents, ident)
+		}
+	}
+	var visit func(PatternExpr)
+	visit = func(pattern PatternExpr) {
+		switch pattern := pattern.(type) {
+		case *PatternBinding:
+			bind(pattern.Ident)
+		case *PatternRest:
+			bind(pattern.Ident)
+		case *PatternParen:
+			visit(pattern.X)
+		case *PatternTuple:
+			for _, elem := range pattern.List {
+				visit(elem)
+			}
+		case *PatternType:
+			visit(&pattern.Binding)
+		case *PatternVariant:
+			for _, arg := range pattern.Args {
+				visit(arg)
+			}
+		case *PatternComposite:
+			for _, field := range pattern.Fields {
+				visit(field.Value)
+			}
+		}
+	}
+	visit(pattern)
+	return idents
+}
+
+// Arity returns the number of elements a tuple pattern destructures before
+// its rest element, and whether it has one. Arrays with exactly n elements
+// match it, or at least n if it has a rest element.
+func (x *PatternTuple) Arity() (n int, rest bool) {
+	for _, elem := range x.List {
+		if _, ok := elem.(*PatternRest); ok {
+			return n, true
+		}
+		n++
+	}
+	return n, false
+}
@@ -9,12 +9,14 @@ type (
 	}
 
 	NativeConst struct {
+		Doc    *CommentGroup
 		Native token.Pos
 		Const  token.Pos
 		Ident  *Ident
 	}
 
 	NativeStruct struct {
+		Doc    *CommentGroup
 		Native token.Pos
 		Struct token.Pos
 		Name   *Ident
@@ -22,16 +24,20 @@ type (
 	}
 
 	NativeFunc struct {
-		Native   token.Pos
-		Async    token.Pos
-		Memo     token.Pos
-		Fn       token.Pos
-		Receiver *Ident
-		Name     *Ident
-		Params   *FuncParamList
+		Doc        *CommentGroup
+		Native     token.Pos
+		Async      token.Pos
+		Memo       token.Pos
+		Fn         token.Pos
+		Receiver   *Ident
+		Name       *Ident
+		TypeParams *TypeParamList
+		Params     *FuncParamList
+		Result     TypeExpr
 	}
 
 	NativeOperator struct {
+		Doc      *CommentGroup
 		Native   token.Pos
 		Async    token.Pos
 		Operator token.Pos
@@ -39,6 +45,15 @@ type (
 		TokPos   token.Pos
 		Tok      token.Token
 		Params   *FuncParamList
+		Result   TypeExpr
+	}
+
+	// native type int
+	NativeType struct {
+		Doc    *CommentGroup
+		Native token.Pos
+		Type   token.Pos
+		Name   *Ident
 	}
 
 	NativeExpr struct {
@@ -51,26 +66,41 @@ func (s *NativeConst) Pos() token.Pos    { return s.Native }
 func (s *NativeStruct) Pos() token.Pos   { return s.Native }
 func (s *NativeFunc) Pos() token.Pos     { return s.Native }
 func (s *NativeOperator) Pos() token.Pos { return s.Native }
+func (s *NativeType) Pos() token.Pos     { return s.Native }
 func (s *NativeExpr) Pos() token.Pos     { return s.Native }
 
-func (s *NativeConst) End() token.Pos    { return s.Ident.End() }
-func (s *NativeStruct) End() token.Pos   { return s.Fields.End() }
-func (s *NativeFunc) End() token.Pos     { return s.Params.End() }
-func (s *NativeOperator) End() token.Pos { return s.Params.End() }
-func (s *NativeExpr) End() token.Pos     { return s.Native + token.Pos(len(s.Id)) }
+func (s *NativeConst) End() token.Pos  { return s.Ident.End() }
+func (s *NativeStruct) End() token.Pos { return s.Fields.End() }
+func (s *NativeFunc) End() token.Pos {
+	if s.Result != nil {
+		return s.Result.End()
+	}
+	return s.Params.End()
+}
+func (s *NativeOperator) End() token.Pos {
+	if s.Result != nil {
+		return s.Result.End()
+	}
+	return s.Params.End()
+}
+func (s *NativeType) End() token.Pos { return s.Name.End() }
+func (s *NativeExpr) End() token.Pos { return s.Native + token.Pos(len(s.Id)) }
 
 func (*NativeConst) stmtNode()    {}
 func (*NativeStruct) stmtNode()   {}
 func (*NativeFunc) stmtNode()     {}
 func (*NativeOperator) stmtNode() {}
+func (*NativeType) stmtNode()     {}
 func (*NativeExpr) exprNode()     {}
 
 func (*NativeConst) nativeStmt()    {}
 func (*NativeStruct) nativeStmt()   {}
 func (*NativeFunc) nativeStmt()     {}
 func (*NativeOperator) nativeStmt() {}
+func (*NativeType) nativeStmt()     {}
 
 func (s *NativeConst) Flatten() []Node  { return nil }
+func (s *NativeType) Flatten() []Node   { return nil }
 func (s *NativeStruct) Flatten() []Node { return nil }
 func (s *NativeFunc) Flatten() []Node {
 	if s.Receiver != nil {
@@ -4,10 +4,12 @@ import "github.com/calico32/goose/token"
 
 type (
 	StructStmt struct {
-		Struct token.Pos
-		Name   *Ident
-		Fields *StructFieldList
-		Init   *StructInit
+		Doc        *CommentGroup
+		Struct     token.Pos
+		Name       *Ident
+		TypeParams *TypeParamList
+		Fields     *StructFieldList
+		Init       *StructInit
 	}
 
 	PropertyExpr struct {
@@ -22,17 +24,21 @@ type (
 	}
 
 	OperatorStmt struct {
-		Async     token.Pos
-		Memo      token.Pos
-		Operator  token.Pos
-		Receiver  *Ident
-		TokPos    token.Pos
-		Tok       token.Token
-		Params    *FuncParamList
-		Arrow     token.Pos
-		ArrowExpr Expr
-		Body      []Stmt
-		BlockEnd  token.Pos
+		Doc      *CommentGroup
+		Async    token.Pos
+		Memo     token.Pos
+		Operator token.Pos
+		Receiver *Ident
+		TokPos   token.Pos
+		Tok      token.Token
+		Params   *FuncParamList
+		Result   TypeExpr
+		Arrow    token.Pos
+
+		ReceiverTypeParams *TypeParamList
+		ArrowExpr          Expr
+		Body               []Stmt
+		BlockEnd           token.Pos
 	}
 )
 
@@ -44,6 +50,7 @@ type StructFieldList struct {
 
 type StructField struct {
 	Ident *Ident
+	Type  TypeExpr
 	Value Expr
 }
 
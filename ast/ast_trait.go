@@ -0,0 +1,97 @@
+package ast
+
+import "github.com/calico32/goose/token"
+
+type (
+	// trait Shape ... end
+	TraitStmt struct {
+		Doc   *CommentGroup
+		Trait token.Pos
+		Name  *Ident
+		// required members are *MethodSpec and *OperatorSpec, default
+		// implementations are *ExprStmt of a named *FuncExpr and *OperatorStmt
+		// without a receiver
+		Body     []Stmt
+		BlockEnd token.Pos
+	}
+
+	// fn area(): float
+	MethodSpec struct {
+		Doc        *CommentGroup
+		Async      token.Pos
+		Memo       token.Pos
+		Fn         token.Pos
+		Name       *Ident
+		TypeParams *TypeParamList
+		Params     *FuncParamList
+		Result     TypeExpr
+	}
+
+	// operator ==(other)
+	OperatorSpec struct {
+		Doc      *CommentGroup
+		Async    token.Pos
+		Operator token.Pos
+		TokPos   token.Pos
+		Tok      token.Token
+		Params   *FuncParamList
+		Result   TypeExpr
+	}
+
+	// impl Shape for Square
+	ImplStmt struct {
+		Impl   token.Pos
+		Trait  *Ident
+		For    token.Pos
+		Struct *Ident
+	}
+)
+
+func (s *TraitStmt) Pos() token.Pos { return s.Trait }
+func (s *MethodSpec) Pos() token.Pos {
+	if s.Async.IsValid() {
+		return s.Async
+	}
+	if s.Memo.IsValid() {
+		return s.Memo
+	}
+	return s.Fn
+}
+func (s *OperatorSpec) Pos() token.Pos {
+	if s.Async.IsValid() {
+		return s.Async
+	}
+	return s.Operator
+}
+func (s *ImplStmt) Pos() token.Pos { return s.Impl }
+
+func (s *TraitStmt) End() token.Pos { return s.BlockEnd + 3 }
+func (s *MethodSpec) End() token.Pos {
+	if s.Result != nil {
+		return s.Result.End()
+	}
+	return s.Params.End()
+}
+func (s *OperatorSpec) End() token.Pos {
+	if s.Result != nil {
+		return s.Result.End()
+	}
+	return s.Params.End()
+}
+func (s *ImplStmt) End() token.Pos { return s.Struct.End() }
+
+func (*TraitStmt) stmtNode()    {}
+func (*MethodSpec) stmtNode()   {}
+func (*OperatorSpec) stmtNode() {}
+func (*ImplStmt) stmtNode()     {}
+
+func (s *TraitStmt) Flatten() []Node {
+	nodes := make([]Node, 0, len(s.Body))
+	for _, stmt := range s.Body {
+		nodes = append(nodes, stmt.Flatten()...)
+	}
+	return nodes
+}
+func (s *MethodSpec) Flatten() []Node   { return s.Params.Flatten() }
+func (s *OperatorSpec) Flatten() []Node { return s.Params.Flatten() }
+func (s *ImplStmt) Flatten() []Node     { return []Node{s.Trait, s.Struct} }
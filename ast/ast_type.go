@@ -0,0 +1,176 @@
+package ast
+
+import "github.com/calico32/goose/token"
+
+// TypeExpr is a type annotation. Annotations are checked by the validator and
+// ignored by the interpreter unless it is asked to enforce them.
+type TypeExpr interface {
+	Node
+	typeNode()
+}
+
+type (
+	// A named type like int or Point, possibly qualified (macro.TokenTree)
+	// and with type arguments (Array<int>).
+	TypeName struct {
+		Qualifier *Ident
+		Name      *Ident
+		Args      *TypeArgList
+	}
+
+	// T[]
+	ArrayType struct {
+		Elem     TypeExpr
+		LBracket token.Pos
+		RBracket token.Pos
+	}
+
+	// int | float
+	UnionType struct {
+		Types []TypeExpr
+	}
+
+	// { x: int, y: int }
+	ObjectType struct {
+		Opening token.Pos
+		Fields  []*ObjectTypeField
+		Closing token.Pos
+	}
+
+	// fn(a: any, b: any) -> int
+	FuncType struct {
+		Func    token.Pos
+		Opening token.Pos
+		Params  []*FuncTypeParam
+		Closing token.Pos
+		Arrow   token.Pos
+		Result  TypeExpr
+	}
+
+	// (int | float)
+	ParenType struct {
+		Opening token.Pos
+		X       TypeExpr
+		Closing token.Pos
+	}
+
+	BadType struct {
+		From, To token.Pos
+	}
+)
+
+type ObjectTypeField struct {
+	Ident *Ident
+	Type  TypeExpr
+}
+
+type FuncTypeParam struct {
+	Ellipsis token.Pos
+	Ident    *Ident // nil if the parameter is not named
+	Type     TypeExpr
+}
+
+// TypeArgList is the type arguments of a named type, like <int> in Array<int>.
+type TypeArgList struct {
+	Opening token.Pos
+	List    []TypeExpr
+	Closing token.Pos
+}
+
+// TypeParamList is the type parameters of a generic declaration, like <T> in
+// fn foo<T>(x: T): T.
+type TypeParamList struct {
+	Opening token.Pos
+	List    []*Ident
+	Closing token.Pos
+}
+
+type (
+	// type Point = { x: int, y: int }
+	TypeStmt struct {
+		Doc        *CommentGroup
+		Type       token.Pos
+		Name       *Ident
+		TypeParams *TypeParamList
+		Assign     token.Pos
+		Value      TypeExpr
+	}
+)
+
+func (x *TypeName) Pos() token.Pos {
+	if x.Qualifier != nil {
+		return x.Qualifier.Pos()
+	}
+	return x.Name.Pos()
+}
+func (x *ArrayType) Pos() token.Pos  { return x.Elem.Pos() }
+func (x *UnionType) Pos() token.Pos  { return x.Types[0].Pos() }
+func (x *ObjectType) Pos() token.Pos { return x.Opening }
+func (x *FuncType) Pos() token.Pos   { return x.Func }
+func (x *ParenType) Pos() token.Pos  { return x.Opening }
+func (x *BadType) Pos() token.Pos    { return x.From }
+func (s *TypeStmt) Pos() token.Pos   { return s.Type }
+
+func (x *TypeName) End() token.Pos {
+	if x.Args != nil {
+		return x.Args.End()
+	}
+	return x.Name.End()
+}
+func (x *ArrayType) End() token.Pos  { return x.RBracket + 1 }
+func (x *UnionType) End() token.Pos  { return x.Types[len(x.Types)-1].End() }
+func (x *ObjectType) End() token.Pos { return x.Closing + 1 }
+func (x *FuncType) End() token.Pos {
+	if x.Result != nil {
+		return x.Result.End()
+	}
+	return x.Closing + 1
+}
+func (x *ParenType) End() token.Pos { return x.Closing + 1 }
+func (x *BadType) End() token.Pos   { return x.To }
+func (s *TypeStmt) End() token.Pos  { return s.Value.End() }
+
+func (*TypeName) typeNode()   {}
+func (*ArrayType) typeNode()  {}
+func (*UnionType) typeNode()  {}
+func (*ObjectType) typeNode() {}
+func (*FuncType) typeNode()   {}
+func (*ParenType) typeNode()  {}
+func (*BadType) typeNode()    {}
+
+func (*TypeStmt) stmtNode() {}
+
+// types hold no values, so there is nothing to flatten
+func (x *TypeName) Flatten() []Node   { return nil }
+func (x *ArrayType) Flatten() []Node  { return nil }
+func (x *UnionType) Flatten() []Node  { return nil }
+func (x *ObjectType) Flatten() []Node { return nil }
+func (x *FuncType) Flatten() []Node   { return nil }
+func (x *ParenType) Flatten() []Node  { return nil }
+func (x *BadType) Flatten() []Node    { return []Node{x} }
+func (s *TypeStmt) Flatten() []Node   { return nil }
+
+func (l *TypeArgList) Pos() token.Pos { return l.Opening }
+func (l *TypeArgList) End() token.Pos { return l.Closing + 1 }
+
+func (l *TypeParamList) Pos() token.Pos { return l.Opening }
+func (l *TypeParamList) End() token.Pos { return l.Closing + 1 }
+
+// Names returns the names of the type parameters, or nil if l is nil.
+func (l *TypeParamList) Names() []string {
+	if l == nil {
+		return nil
+	}
+	names := make([]string, len(l.List))
+	for i, ident := range l.List {
+		names[i] = ident.Name
+	}
+	return names
+}
+
+// TypeString returns t as it is written in source, like Array<int> | null.
+func TypeString(t TypeExpr) string {
+	var p NodePrinter
+	p.Print(t)
+	return p.String()
+}
@@ -46,6 +46,20 @@ func (p *NodePrinter) write(s string) {
 	}
 }
 
+// writeAnnotation writes the type annotation t, if there is one.
+func (p *NodePrinter) writeAnnotation(t TypeExpr) {
+	if t != nil {
+		p.write(": ")
+		p.Print(t)
+	}
+}
+
+func (p *NodePrinter) writeTypeParams(params *TypeParamList) {
+	if params != nil {
+		p.write("<" + strings.Join(params.Names(), ", ") + ">")
+	}
+}
+
 func (p *NodePrinter) String() string {
 	return p.output.String()
 }
@@ -91,6 +105,7 @@ func (p *NodePrinter) Print(node Node) {
 		if n.Receiver != nil {
 			p.write(" ")
 			p.Print(n.Receiver)
+			p.writeTypeParams(n.ReceiverTypeParams)
 			p.write(".")
 			if n.Name != nil {
 				p.Print(n.Name)
@@ -99,6 +114,7 @@ func (p *NodePrinter) Print(node Node) {
 			p.write(" ")
 			p.Print(n.Name)
 		}
+		p.writeTypeParams(n.TypeParams)
 
 		p.write("(")
 		for i, arg := range n.Params.List {
@@ -108,6 +124,7 @@ func (p *NodePrinter) Print(node Node) {
 			p.Print(arg)
 		}
 		p.write(")")
+		p.writeAnnotation(n.Result)
 
 		if n.Arrow.IsValid() {
 			p.write(" -> ")
@@ -120,7 +137,12 @@ func (p *NodePrinter) Print(node Node) {
 		if n.Ellipsis.IsValid() {
 			p.write("...")
 		}
-		p.Print(n.Ident)
+		if n.Ident != nil {
+			p.Print(n.Ident)
+		} else {
+			p.Print(n.Pattern)
+		}
+		p.writeAnnotation(n.Type)
 		if n.Value != nil {
 			p.write(" = ")
 			p.Print(n.Value)
@@ -164,14 +186,24 @@ func (p *NodePrinter) Print(node Node) {
 		p.write("\"")
 	case *LetStmt:
 		p.write("let ")
-		p.Print(n.Ident)
+		if n.Ident != nil {
+			p.Print(n.Ident)
+		} else {
+			p.Print(n.Pattern)
+		}
+		p.writeAnnotation(n.Type)
 		if n.Value != nil {
 			p.write(" = ")
 			p.Print(n.Value)
 		}
 	case *ConstStmt:
 		p.write("const ")
-		p.Print(n.Ident)
+		if n.Ident != nil {
+			p.Print(n.Ident)
+		} else {
+			p.Print(n.Pattern)
+		}
+		p.writeAnnotation(n.Type)
 		p.write(" = ")
 		p.Print(n.Value)
 
@@ -189,7 +221,11 @@ func (p *NodePrinter) Print(node Node) {
 		if n.Await.IsValid() {
 			p.write("await ")
 		}
-		p.Print(n.Var)
+		if n.Var != nil {
+			p.Print(n.Var)
+		} else {
+			p.Print(n.Pattern)
+		}
 		p.write(" in ")
 		p.Print(n.Iterable)
 		p.write(" ")
@@ -414,12 +450,14 @@ func (p *NodePrinter) Print(node Node) {
 	case *StructStmt:
 		p.write("struct ")
 		p.Print(n.Name)
+		p.writeTypeParams(n.TypeParams)
 		p.write("(")
 		for i, field := range n.Fields.List {
 			if i > 0 {
 				p.write(", ")
 			}
 			p.Print(field.Ident)
+			p.writeAnnotation(field.Type)
 			if field.Value != nil {
 				p.write(" = ")
 				p.Print(field.Value)
@@ -435,8 +473,11 @@ func (p *NodePrinter) Print(node Node) {
 			p.write("async ")
 		}
 		p.write("operator ")
-		p.Print(n.Receiver)
-		p.write(" ")
+		if n.Receiver != nil {
+			p.Print(n.Receiver)
+			p.writeTypeParams(n.ReceiverTypeParams)
+			p.write(" ")
+		}
 		p.write(n.Tok.String())
 		p.write("(")
 		for i, param := range n.Params.List {
@@ -447,13 +488,216 @@ func (p *NodePrinter) Print(node Node) {
 				p.write("...")
 			}
 			p.Print(param.Ident)
+			p.writeAnnotation(param.Type)
 			if param.Value != nil {
 				p.write(" = ")
 				p.Print(param.Value)
 			}
 		}
+		p.write(")")
+		p.writeAnnotation(n.Result)
+		p.write(" ")
+		p.writeBlock(n.Body)
+	case *TraitStmt:
+		p.write("trait ")
+		p.Print(n.Name)
+		p.write(" ")
+		p.writeBlock(n.Body)
+	case *MethodSpec:
+		if n.Async.IsValid() {
+			p.write("async ")
+		}
+		p.write("fn ")
+		p.Print(n.Name)
+		p.writeTypeParams(n.TypeParams)
+		p.write("(")
+		for i, param := range n.Params.List {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.Print(param)
+		}
+		p.write(")")
+		p.writeAnnotation(n.Result)
+	case *OperatorSpec:
+		if n.Async.IsValid() {
+			p.write("async ")
+		}
+		p.write("operator ")
+		p.write(n.Tok.String())
+		p.write("(")
+		for i, param := range n.Params.List {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.Print(param)
+		}
+		p.write(")")
+		p.writeAnnotation(n.Result)
+	case *EnumStmt:
+		p.write("enum ")
+		p.Print(n.Name)
+		p.write(" {\n")
+		p.indent++
+		for _, variant := range n.Variants {
+			p.Print(variant.Name)
+			if variant.Fields != nil {
+				p.write("(")
+				for i, field := range variant.Fields.List {
+					if i > 0 {
+						p.write(", ")
+					}
+					p.Print(field.Ident)
+					p.writeAnnotation(field.Type)
+					if field.Value != nil {
+						p.write(" = ")
+						p.Print(field.Value)
+					}
+				}
+				p.write(")")
+			}
+			p.write("\n")
+		}
+		p.indent--
+		p.write("}")
+	case *ImplStmt:
+		p.write("impl ")
+		p.Print(n.Trait)
+		p.write(" for ")
+		p.Print(n.Struct)
+
+	case *TypeStmt:
+		p.write("type ")
+		p.Print(n.Name)
+		p.writeTypeParams(n.TypeParams)
+		p.write(" = ")
+		p.Print(n.Value)
+	case *MacroStmt:
+		p.write("macro ")
+		p.Print(n.Name)
+		p.write("!(")
+		p.write(strings.Join(n.Params.Names(), ", "))
+		p.write(") -> ")
+		p.write(TokensString(n.Tokens))
+	case *ProcMacroStmt:
+		p.write("procmacro ")
+		p.Print(n.Name)
+		p.write("!(")
+		p.write(strings.Join(n.Params.Names(), ", "))
 		p.write(") ")
 		p.writeBlock(n.Body)
+	case *MacroCallExpr:
+		p.Print(n.Name)
+		p.write("!(")
+		p.write(TokensString(n.Tokens))
+		p.write(")")
+	case *TypeName:
+		if n.Qualifier != nil {
+			p.Print(n.Qualifier)
+			p.write(".")
+		}
+		p.Print(n.Name)
+		if n.Args != nil {
+			p.write("<")
+			for i, arg := range n.Args.List {
+				if i > 0 {
+					p.write(", ")
+				}
+				p.Print(arg)
+			}
+			p.write(">")
+		}
+	case *ArrayType:
+		p.Print(n.Elem)
+		p.write("[]")
+	case *UnionType:
+		for i, t := range n.Types {
+			if i > 0 {
+				p.write(" | ")
+			}
+			p.Print(t)
+		}
+	case *ObjectType:
+		p.write("{ ")
+		for i, field := range n.Fields {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.Print(field.Ident)
+			p.writeAnnotation(field.Type)
+		}
+		p.write(" }")
+	case *FuncType:
+		p.write("fn(")
+		for i, param := range n.Params {
+			if i > 0 {
+				p.write(", ")
+			}
+			if param.Ellipsis.IsValid() {
+				p.write("...")
+			}
+			if param.Ident != nil {
+				p.Print(param.Ident)
+				p.write(": ")
+			}
+			p.Print(param.Type)
+		}
+		p.write(")")
+		if n.Result != nil {
+			p.write(" -> ")
+			p.Print(n.Result)
+		}
+	case *ParenType:
+		p.write("(")
+		p.Print(n.X)
+		p.write(")")
+
+	case *PatternNormal:
+		p.Print(n.X)
+	case *PatternBinding:
+		if n.Bind.IsValid() {
+			p.write("$")
+		}
+		p.Print(n.Ident)
+	case *PatternRest:
+		p.write("...")
+		p.Print(n.Ident)
+	case *PatternParen:
+		p.write("(")
+		p.Print(n.X)
+		p.write(")")
+	case *PatternTuple:
+		p.write("[")
+		for i, elem := range n.List {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.Print(elem)
+		}
+		p.write("]")
+	case *PatternVariant:
+		p.Print(n.Variant)
+		p.write("(")
+		for i, arg := range n.Args {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.Print(arg)
+		}
+		p.write(")")
+	case *PatternComposite:
+		p.write("{ ")
+		for i, field := range n.Fields {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.Print(field.Key)
+			if field.Colon.IsValid() {
+				p.write(": ")
+				p.Print(field.Value)
+			}
+		}
+		p.write(" }")
 
 	default:
 		p.write(fmt.Sprintf("<unhandled %T>", node))
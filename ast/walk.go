@@ -0,0 +1,35 @@
+package ast
+
+import "reflect"
+
+// Walk calls visit for every node in the tree rooted at node, parents before
+// their children, including parts of nodes like parameter lists that are not
+// nodes themselves.
+func Walk(node any, visit func(any)) {
+	walk(reflect.ValueOf(node), visit)
+}
+
+func walk(v reflect.Value, visit func(any)) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), visit)
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		visit(v.Interface())
+		walk(v.Elem(), visit)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walk(v.Field(i), visit)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), visit)
+		}
+	}
+}
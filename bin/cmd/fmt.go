@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/calico32/goose/format"
+)
+
+// formatPaths formats the goose files in paths, descending into directories,
+// or standard input if there are none. It returns the exit code.
+func formatPaths(paths []string, write bool, check bool, out io.Writer) int {
+	if len(paths) == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return formatSource("<standard input>", src, false, check, out)
+	}
+
+	exitCode := 0
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (file != path && filepath.Ext(file) != ".goose") {
+				return nil
+			}
+
+			src, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if code := formatSource(file, src, write, check, out); code != 0 {
+				exitCode = code
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func formatSource(name string, src []byte, write bool, check bool, out io.Writer) int {
+	formatted, err := format.Source(name, src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	changed := !bytes.Equal(src, formatted)
+	switch {
+	case check:
+		if changed {
+			fmt.Fprintln(out, name)
+			return 1
+		}
+	case write:
+		if changed {
+			info, err := os.Stat(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			if err := os.WriteFile(name, formatted, info.Mode().Perm()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	default:
+		out.Write(formatted)
+	}
+	return 0
+}
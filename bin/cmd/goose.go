@@ -28,6 +28,7 @@ var outputFile = flag.String("output", "", "Write output to `file`")
 var help = flag.Bool("help", false, "Show help message")
 var version = flag.Bool("version", false, "Show version")
 var jsonOutput = flag.Bool("json", false, "Output JSON instead of text")
+var enforceTypes = flag.Bool("enforce-types", false, "Check type annotations while running")
 
 func main() {
 	flag.Parse()
@@ -48,6 +49,7 @@ func main() {
 		fmt.Println("  build [file]             Compile a goose program")
 		fmt.Println("  scan [file]              Scan a goose program")
 		fmt.Println("  parse [file]             Parse a goose program")
+		fmt.Println("  fmt [-w] [-check] [path] Format goose programs")
 		fmt.Println()
 		fmt.Println("Options:")
 		flag.PrintDefaults()
@@ -57,7 +59,7 @@ func main() {
 	var verb string
 	var args []string
 
-	if flag.NArg() < 2 && flag.Arg(0) != "scan" && flag.Arg(0) != "parse" && flag.Arg(0) != "validate" && flag.Arg(0) != "build" {
+	if flag.NArg() < 2 && flag.Arg(0) != "scan" && flag.Arg(0) != "parse" && flag.Arg(0) != "validate" && flag.Arg(0) != "build" && flag.Arg(0) != "fmt" {
 		verb = "run"
 		args = flag.Args()
 	} else if flag.NArg() < 2 {
@@ -185,6 +187,8 @@ func main() {
 		if err != nil {
 			panic(err)
 		}
+		i.SetArgs(args[1:])
+		i.SetEnforceTypes(*enforceTypes)
 
 		exitCode, err := i.Run()
 		if err != nil {
@@ -272,6 +276,14 @@ func main() {
 
 		outWriter.Sync()
 
+	case "fmt":
+		flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+		write := flags.Bool("w", false, "Write the result to the file instead of the output")
+		check := flags.Bool("check", false, "List files that are not formatted and exit with status 1 if there are any")
+		flags.Parse(args)
+
+		os.Exit(formatPaths(flags.Args(), *write, *check, outWriter))
+
 	default:
 		fmt.Println("Unknown verb:", verb)
 	}
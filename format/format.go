@@ -0,0 +1,381 @@
+// Package format implements the canonical formatting of goose source code.
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+)
+
+// Indent is the text used for one level of indentation.
+const Indent = "  "
+
+// Source formats src, the contents of the module named specifier, in the
+// canonical style. Line breaks and comments are kept, while indentation,
+// blank lines and the spacing between tokens are normalized. src must be
+// free of syntax errors.
+func Source(specifier string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, specifier, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &formatter{
+		base:     fset.File(specifier).Base(),
+		openers:  map[int]bool{},
+		exprElse: map[int]bool{},
+		starts:   map[int]bool{},
+		prefix:   map[int]bool{},
+		postfix:  map[int]bool{},
+		tight:    map[int]bool{},
+		slices:   map[int]bool{},
+		angles:   map[int]bool{},
+		verbatim: map[int]bool{},
+	}
+	ast.Walk(module, f.visit)
+	return f.format(src), nil
+}
+
+// formatter holds what the syntax tree says about the tokens of a module,
+// keyed by their offset, since the tokens are scanned again to keep comments.
+type formatter struct {
+	base int
+
+	openers  map[int]bool // keywords of blocks closed by end
+	exprElse map[int]bool // else of if expressions and match arms
+	starts   map[int]bool // statements, match arms and enum variants
+	prefix   map[int]bool // unary operators, the colons of named parameters and the bangs of macros
+	postfix  map[int]bool // unary operators after their operand and the bangs of macros
+	tight    map[int]bool // ( and [ of calls, parameters and indexes
+	slices   map[int]bool // [ of slices
+	angles   map[int]bool // < and > of type parameters and arguments
+	verbatim map[int]bool // the arguments of macro invocations, which keep their spacing
+}
+
+func (f *formatter) offset(pos token.Pos) int {
+	return int(pos) - f.base
+}
+
+func (f *formatter) visit(node any) {
+	if stmt, ok := node.(ast.Stmt); ok {
+		f.starts[f.offset(stmt.Pos())] = true
+	}
+
+	switch n := node.(type) {
+	case *ast.FuncExpr:
+		if !n.Arrow.IsValid() {
+			f.openers[f.offset(n.Func)] = true
+		}
+	case *ast.OperatorStmt:
+		if !n.Arrow.IsValid() {
+			f.openers[f.offset(n.Operator)] = true
+		}
+		switch n.Tok {
+		case token.Index, token.IndexAssign:
+			// operator Point []=(i, value), with the ] directly after the [
+			f.prefix[f.offset(n.TokPos)+1] = true
+		case token.Colon:
+			// operator Point :(low, high)
+			f.prefix[f.offset(n.TokPos)] = true
+		}
+	case *ast.GeneratorExpr:
+		f.openers[f.offset(n.Generator)] = true
+	case *ast.IfStmt:
+		f.openers[f.offset(n.If)] = true
+	case *ast.ForStmt:
+		f.openers[f.offset(n.For)] = true
+	case *ast.RepeatWhileStmt:
+		f.openers[f.offset(n.Repeat)] = true
+	case *ast.RepeatForeverStmt:
+		f.openers[f.offset(n.Repeat)] = true
+	case *ast.RepeatCountStmt:
+		f.openers[f.offset(n.Repeat)] = true
+	case *ast.TryStmt:
+		f.openers[f.offset(n.Try)] = true
+	case *ast.DoExpr:
+		f.openers[f.offset(n.Do)] = true
+	case *ast.MatchExpr:
+		f.openers[f.offset(n.Match)] = true
+	case *ast.TraitStmt:
+		f.openers[f.offset(n.Trait)] = true
+	case *ast.EnumStmt:
+		f.openers[f.offset(n.Enum)] = true
+	case *ast.StructInit:
+		f.openers[f.offset(n.Init)] = true
+	case *ast.IfExpr:
+		if n.ElsePos.IsValid() {
+			f.exprElse[f.offset(n.ElsePos)] = true
+		}
+	case *ast.MatchElse:
+		f.exprElse[f.offset(n.Else)] = true
+		f.starts[f.offset(n.Else)] = true
+	case *ast.MatchPattern:
+		f.starts[f.offset(n.Pos())] = true
+	case *ast.EnumVariant:
+		f.starts[f.offset(n.Pos())] = true
+	case *ast.PatternVariant:
+		f.tight[f.offset(n.LParen)] = true
+	case *ast.UnaryExpr:
+		if n.Op == token.Question {
+			f.postfix[f.offset(n.OpPos)] = true
+		} else if n.Op != token.Await {
+			f.prefix[f.offset(n.OpPos)] = true
+		}
+	case *ast.CallExpr:
+		f.tight[f.offset(n.LParen)] = true
+	case *ast.FuncParamList:
+		f.tight[f.offset(n.Opening)] = true
+	case *ast.FuncParam:
+		if n.Colon.IsValid() {
+			f.prefix[f.offset(n.Colon)] = true
+		}
+	case *ast.StructFieldList:
+		f.tight[f.offset(n.Opening)] = true
+	case *ast.BracketSelectorExpr:
+		f.tight[f.offset(n.LBrack)] = true
+	case *ast.SliceExpr:
+		f.tight[f.offset(n.LBrack)] = true
+		f.slices[f.offset(n.LBrack)] = true
+	case *ast.TypeParamList:
+		f.angles[f.offset(n.Opening)] = true
+		f.angles[f.offset(n.Closing)] = true
+	case *ast.TypeArgList:
+		f.angles[f.offset(n.Opening)] = true
+		f.angles[f.offset(n.Closing)] = true
+	case *ast.ArrayType:
+		f.tight[f.offset(n.LBracket)] = true
+	case *ast.FuncType:
+		f.tight[f.offset(n.Opening)] = true
+	case *ast.MacroStmt:
+		f.prefix[f.offset(n.Bang)] = true
+		f.postfix[f.offset(n.Bang)] = true
+	case *ast.ProcMacroStmt:
+		f.openers[f.offset(n.ProcMacro)] = true
+		f.prefix[f.offset(n.Bang)] = true
+		f.postfix[f.offset(n.Bang)] = true
+	case *ast.MacroCallExpr:
+		f.prefix[f.offset(n.Bang)] = true
+		f.postfix[f.offset(n.Bang)] = true
+		for _, t := range n.Tokens {
+			f.verbatim[f.offset(t.Pos)] = true
+		}
+		f.verbatim[f.offset(n.Closing)] = true
+	}
+}
+
+type tok struct {
+	offset  int
+	tok     token.Token
+	text    string
+	line    int
+	endLine int // differs from line for block comments
+}
+
+func scan(src []byte) []tok {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	s := scanner.Scanner{}
+	s.Init(file, src, func(token.Position, string) {})
+
+	var tokens []tok
+	for {
+		pos, t, lit := s.Scan()
+		if len(tokens) > 0 && isStringPart(tokens[len(tokens)-1].tok) {
+			// the literals of strings have their escapes interpreted, so
+			// the text is taken from the source, up to the next token
+			last := &tokens[len(tokens)-1]
+			last.text = string(src[last.offset:file.Offset(pos)])
+			if last.tok == token.StringEnd {
+				last.text = strings.TrimRight(last.text, " \t\r\n")
+			}
+		}
+		if t == token.EOF {
+			break
+		}
+		text := lit
+		if text == "" {
+			text = t.String()
+		}
+		if t == token.Comment {
+			text = strings.TrimRight(text, " \t\r")
+		}
+		line := file.Line(pos)
+		tokens = append(tokens, tok{
+			offset:  file.Offset(pos),
+			tok:     t,
+			text:    text,
+			line:    line,
+			endLine: line + strings.Count(text, "\n"),
+		})
+	}
+	return tokens
+}
+
+// splitAngles splits the > that closes type parameters or arguments off the
+// token it was scanned as part of, like the >= in Array<int>= [], the same way
+// the parser does.
+func (f *formatter) splitAngles(tokens []tok) []tok {
+	var split []tok
+	for _, t := range tokens {
+		for f.angles[t.offset] {
+			var rest token.Token
+			switch t.tok {
+			case token.BitShr:
+				rest = token.Gt
+			case token.Gte:
+				rest = token.Assign
+			case token.BitShrAssign:
+				rest = token.Gte
+			}
+			if rest == token.Illegal {
+				break
+			}
+			gt := t
+			gt.tok, gt.text = token.Gt, token.Gt.String()
+			split = append(split, gt)
+			t.offset++
+			t.tok, t.text = rest, rest.String()
+		}
+		split = append(split, t)
+	}
+	return split
+}
+
+func isStringPart(t token.Token) bool {
+	switch t {
+	case token.StringStart, token.StringMid, token.StringInterpIdent, token.StringEnd:
+		return true
+	}
+	return false
+}
+
+// frame is an open block or bracket.
+type frame struct {
+	closer token.Token // End for blocks
+	indent int         // indentation of the line it was opened on
+	slice  bool
+}
+
+var closers = map[token.Token]token.Token{
+	token.LParen:                token.RParen,
+	token.LBracket:              token.RBracket,
+	token.HashLBracket:          token.RBracket,
+	token.LBrace:                token.RBrace,
+	token.StringInterpExprStart: token.StringInterpExprEnd,
+}
+
+func (f *formatter) format(src []byte) []byte {
+	var out bytes.Buffer
+	var stack []frame
+	var prev tok
+	indent := 0
+
+	for i, t := range f.splitAngles(scan(src)) {
+		if i == 0 || t.line > prev.endLine {
+			if i > 0 {
+				out.WriteByte('\n')
+				if t.line > prev.endLine+1 {
+					out.WriteByte('\n')
+				}
+			}
+			indent = f.indent(stack, t)
+			out.WriteString(strings.Repeat(Indent, indent))
+		} else if f.space(stack, prev, t) {
+			out.WriteByte(' ')
+		}
+		out.WriteString(t.text)
+
+		top := token.None
+		if len(stack) > 0 {
+			top = stack[len(stack)-1].closer
+		}
+		switch {
+		case t.tok == top:
+			stack = stack[:len(stack)-1]
+		case f.openers[t.offset] && !(t.tok == token.If && f.blockElse(prev)):
+			// else if continues the block of the first if
+			stack = append(stack, frame{closer: token.End, indent: indent})
+		case closers[t.tok] != 0:
+			stack = append(stack, frame{closer: closers[t.tok], indent: indent, slice: f.slices[t.offset]})
+		}
+		prev = t
+	}
+
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// blockElse reports whether t is the else of an if statement.
+func (f *formatter) blockElse(t tok) bool {
+	return t.tok == token.Else && !f.exprElse[t.offset]
+}
+
+// indent returns the indentation of the line starting with t.
+func (f *formatter) indent(stack []frame, t tok) int {
+	if len(stack) == 0 {
+		if f.starts[t.offset] || t.tok == token.Comment {
+			return 0
+		}
+		return 1
+	}
+
+	top := stack[len(stack)-1]
+	if top.closer != token.End {
+		if t.tok == top.closer {
+			return top.indent
+		}
+		return top.indent + 1
+	}
+
+	switch {
+	case t.tok == token.End, t.tok == token.Catch, t.tok == token.Finally, f.blockElse(t):
+		return top.indent
+	case f.starts[t.offset] || t.tok == token.Comment:
+		return top.indent + 1
+	}
+	// an expression continued from the previous line
+	return top.indent + 2
+}
+
+// space reports whether prev and t, which are on the same line, are
+// separated by a space.
+func (f *formatter) space(stack []frame, prev, t tok) bool {
+	if f.verbatim[t.offset] {
+		return prev.offset+len(prev.text) < t.offset
+	}
+	if f.angles[t.offset] || f.angles[prev.offset] && prev.tok == token.Lt {
+		// Array<int>
+		return false
+	}
+	if f.prefix[prev.offset] {
+		// - -x must not become --x
+		return (prev.tok == token.Sub || prev.tok == token.Add) && t.tok == prev.tok
+	}
+
+	switch prev.tok {
+	case token.LParen, token.LBracket, token.HashLBracket, token.Period, token.Bind, token.MatchBind, token.Ellipsis,
+		token.StringStart, token.StringMid, token.StringInterpIdent, token.StringInterpExprStart:
+		return false
+	case token.LBrace:
+		return t.tok != token.RBrace
+	case token.Colon:
+		return len(stack) == 0 || !stack[len(stack)-1].slice
+	}
+	switch t.tok {
+	case token.Colon:
+		return f.prefix[t.offset]
+	case token.RParen, token.RBracket, token.Comma, token.Semi, token.Period, token.Bind, token.Inc, token.Dec,
+		token.StringMid, token.StringInterpIdent, token.StringInterpExprStart, token.StringInterpExprEnd, token.StringEnd:
+		return false
+	case token.LParen, token.LBracket:
+		return !f.tight[t.offset]
+	}
+	return !f.postfix[t.offset]
+}
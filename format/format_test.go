@@ -0,0 +1,193 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/token"
+)
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"operators",
+			"let x=1+2*-3\nx+=y?\ni++\nlet r = 1  to 10 step 2\nlet n = - -x - !y\n",
+			"let x = 1 + 2 * -3\nx += y?\ni++\nlet r = 1 to 10 step 2\nlet n = - -x - !y\n",
+		},
+		{
+			"brackets",
+			"println( x[1 : 2], a[ 0 ], [1,2], {a:1}, {}, #[ k ] )\nconst nulls = [null;   10]\n",
+			"println(x[1:2], a[0], [1, 2], { a: 1 }, {}, #[k])\nconst nulls = [null; 10]\n",
+		},
+		{
+			"strings",
+			"let s = \"a\\n $x${ a+1 }$y ${ f( 1 ) }\"  // comment   \n",
+			"let s = \"a\\n $x${a + 1}$y ${f(1)}\" // comment\n",
+		},
+		{
+			"blocks",
+			"fn add(a,b,:c,:d=4,...e)\nreturn a+b\nend\nif x\nif y\nz()\nend\nelse if w\n    v()\nelse\nu()\nend\n",
+			"fn add(a, b, :c, :d = 4, ...e)\n  return a + b\nend\nif x\n  if y\n    z()\n  end\nelse if w\n  v()\nelse\n  u()\nend\n",
+		},
+		{
+			"try",
+			"try\nthrow 1\ncatch as e\nprintln(e)\nfinally\ndone()\nend\n",
+			"try\n  throw 1\ncatch as e\n  println(e)\nfinally\n  done()\nend\n",
+		},
+		{
+			"nested functions",
+			"foo(fn(x)\nreturn x\nend, 1)\nlet f = fn(x) -> x*2\n",
+			"foo(fn(x)\n  return x\nend, 1)\nlet f = fn(x) -> x * 2\n",
+		},
+		{
+			"match",
+			"let m = match x\n1 -> \"one\"\n    else -> if x then \"some\" else \"many\"\nend\n",
+			"let m = match x\n  1 -> \"one\"\n  else -> if x then \"some\" else \"many\"\nend\n",
+		},
+		{
+			"continued lines",
+			"let o = {\na: 1,\nb: [\n1,\n],\n}\nlet y = x +\n1\nadd(x, 5)?\n-> add(_?, 10)\n",
+			"let o = {\n  a: 1,\n  b: [\n    1,\n  ],\n}\nlet y = x +\n  1\nadd(x, 5)?\n  -> add(_?, 10)\n",
+		},
+		{
+			"struct",
+			"struct Point(x,y) init\n#sum = #x+#y\nend\nfn Point.len() -> #x\n",
+			"struct Point(x, y) init\n  #sum = #x + #y\nend\nfn Point.len() -> #x\n",
+		},
+		{
+			"types",
+			"type Pair<T>={first:T,second:T}\nlet x:int|null=1\nfn add<T>(a:T,:b:Array<int>=[]):fn(int)->int[]\nend\nstruct Box<T>(value:T[])\n",
+			"type Pair<T> = { first: T, second: T }\nlet x: int | null = 1\nfn add<T>(a: T, :b: Array<int> = []): fn(int) -> int[]\nend\nstruct Box<T>(value: T[])\n",
+		},
+		// macro call arguments are token trees, not expressions, so they are
+		// left as written
+		{
+			"macros",
+			"macro foo ! (x,y)->x*y\nprintln(foo! (2 - 3,  5+4))\nlet macro = sq!( 3 )\n",
+			"macro foo!(x, y) -> x * y\nprintln(foo!(2 - 3, 5+4))\nlet macro = sq!( 3 )\n",
+		},
+		{
+			"procmacros",
+			"procmacro upper ! (tokens)\nreturn macro.eval(tokens)\n  end\nprintln(upper!(a b))\n",
+			"procmacro upper!(tokens)\n  return macro.eval(tokens)\nend\nprintln(upper!(a b))\n",
+		},
+		{
+			"index and slice operators",
+			"operator Grid []= (i, v)\nend\noperator Grid[](i) -> i\noperator Grid : (low, high) -> low\nlet c = a<=>b\n",
+			"operator Grid []=(i, v)\nend\noperator Grid [](i) -> i\noperator Grid :(low, high) -> low\nlet c = a <=> b\n",
+		},
+		{
+			"bitwise not and shifts",
+			"let x = ~ a<<2 | b>>1\n",
+			"let x = ~a << 2 | b >> 1\n",
+		},
+		{
+			"labeled loops",
+			"outer :  for i in x\nfor j in y\nbreak   outer\nend\nend\n",
+			"outer: for i in x\n  for j in y\n    break outer\n  end\nend\n",
+		},
+		{
+			"destructuring",
+			"let [ a,b , ...rest ]=xs\nconst {x,y : yy}=p\nfn f( [a,b] , {c} ) -> a\n",
+			"let [a, b, ...rest] = xs\nconst { x, y: yy } = p\nfn f([a, b], { c }) -> a\n",
+		},
+		{
+			"traits",
+			"trait Shape\n    fn area( ): float\nfn name() -> \"shape\"\n  operator ==( other )\nend\nimpl  Shape for   Square\n",
+			"trait Shape\n  fn area(): float\n  fn name() -> \"shape\"\n  operator ==(other)\nend\nimpl Shape for Square\n",
+		},
+		{
+			"enums",
+			"enum Shape\nCircle( r )\n    Rect(w,h = 1)\n  Empty\nend\nmatch s\nShape.Circle ( $r ) -> r\n  Shape.Rect($w,$h)->w\nend\n",
+			"enum Shape\n  Circle(r)\n  Rect(w, h = 1)\n  Empty\nend\nmatch s\n  Shape.Circle($r) -> r\n  Shape.Rect($w, $h) -> w\nend\n",
+		},
+		{
+			"blank lines and comments",
+			"\n\n// a\nlet x = 1\n\n\n\nrepeat 3 times\n// b\n  /* c\n d */ i++\nend\n\n\n",
+			"// a\nlet x = 1\n\nrepeat 3 times\n  // b\n  /* c\n d */ i++\nend\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Source("test.goose", []byte(test.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Source("test.goose", []byte("let x =")); err == nil {
+		t.Error("expected an error for a module with syntax errors")
+	}
+}
+
+// TestModules formats the examples and the standard library, checking that
+// only whitespace changes and that formatting again changes nothing.
+// unparsed lists the modules that describe syntax the parser does not
+// support yet.
+var unparsed = map[string]string{
+	"builtin_future.goose": "describes planned native operator declarations",
+}
+
+func TestModules(t *testing.T) {
+	t.Parallel()
+
+	files, _ := filepath.Glob("../examples/*.goose")
+	std, _ := filepath.Glob("../lib/std/*/*.goose")
+	for _, file := range append(files, std...) {
+		file := file
+		t.Run(strings.TrimPrefix(file, "../"), func(t *testing.T) {
+			t.Parallel()
+
+			if reason, ok := unparsed[filepath.Base(file)]; ok {
+				t.Skip(reason)
+			}
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted, err := Source(file, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			again, err := Source(file, formatted)
+			if err != nil {
+				t.Fatalf("formatted module does not parse: %s", err)
+			}
+			if string(again) != string(formatted) {
+				t.Error("formatting is not idempotent")
+			}
+
+			before, after := scan(src), scan(formatted)
+			if len(before) != len(after) {
+				t.Fatalf("got %d tokens after formatting, want %d", len(after), len(before))
+			}
+			for i := range before {
+				if before[i].tok != after[i].tok || before[i].text != after[i].text {
+					pos := token.Position{Filename: file, Line: before[i].line}
+					t.Fatalf("%s: got %s %q, want %s %q", pos, after[i].tok, after[i].text, before[i].tok, before[i].text)
+				}
+			}
+		})
+	}
+}
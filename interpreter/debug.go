@@ -23,6 +23,7 @@ func (interp *interp) printTrace(a ...any) {
 }
 
 func push(i *interp, node ast.Node) *interp {
+	i.step()
 	i.lastPos = node.Pos()
 	i.posStack = append(i.posStack, node.Pos())
 	return i
@@ -3,6 +3,7 @@ package interpreter
 import (
 	"fmt"
 	"math/big"
+	"sort"
 
 	. "github.com/calico32/goose/interpreter/lib"
 	"github.com/calico32/goose/lib/types"
@@ -12,9 +13,9 @@ var GlobalDocs = []types.BuiltinDoc{
 	{
 		Name:        "len",
 		Label:       "len(x)",
-		Signature:   "len(x: array | string) -> int",
-		Desc:        "Get the length of an array or string.",
-		Description: "Get the length of an array or string. If the argument is an array, the length is the number of elements in the array. If the argument is a string, the length is the number of characters in the string.",
+		Signature:   "len(x: array | string | bytes) -> int",
+		Desc:        "Get the length of an array, string or bytes.",
+		Description: "Get the length of an array, string or bytes. If the argument is an array, the length is the number of elements in the array. If the argument is a string, the length is the number of characters in the string. If the argument is bytes, the length is the number of bytes.",
 		Examples: []types.CodeSnippet{
 			{
 				Content: `<pre>
@@ -87,6 +88,23 @@ var GlobalDocs = []types.BuiltinDoc{
 			},
 		},
 	},
+	{
+		Name:        "entries",
+		Label:       "entries(obj)",
+		Signature:   "entries(obj: object) -> [string, any][]",
+		Desc:        "Get the properties of an object as key-value pairs.",
+		Description: "Get the properties of an object as an array of `[key, value]` pairs, sorted by key. Only the object's own properties with string keys are included.",
+		Examples: []types.CodeSnippet{
+			{
+				Content: `<pre>
+				const p = { x: 1, y: 2 }
+				for [k, v] in entries(p)
+				  println(k, v) // x 1, then y 2
+				end
+				</pre>`,
+			},
+		},
+	},
 	{
 		Name:        "typeof",
 		Label:       "typeof(value)",
@@ -116,8 +134,10 @@ var Globals = map[string]FuncType{
 			return NewReturn(NewInteger(big.NewInt(int64(len(v.Elements)))))
 		case *String:
 			return NewReturn(NewInteger(big.NewInt(int64(len(v.Value)))))
+		case *Bytes:
+			return NewReturn(NewInteger(big.NewInt(int64(len(v.Value)))))
 		default:
-			ctx.Interp.Throw("len(x): expected an array or string, got %s", ctx.Args[0].Type())
+			ctx.Interp.Throw("len(x): expected an array, string or bytes, got %s", ctx.Args[0].Type())
 			return nil
 		}
 	},
@@ -182,8 +202,28 @@ var Globals = map[string]FuncType{
 			}
 			exitCode = ctx.Args[0].(Numeric).Int()
 		}
-		// TODO: tinygo doesn't let you recover panics, so any exit will cause a crash
-		panic(gooseExit{exitCode})
+		ctx.Interp.Exit(exitCode)
+		return &Return{}
+	},
+	"entries": func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 1 {
+			ctx.Interp.Throw("entries(obj): expected 1 argument")
+		}
+		obj, ok := ctx.Args[0].(*Composite)
+		if !ok {
+			ctx.Interp.Throw("entries(obj): expected an object, got %s", ctx.Args[0].Type())
+		}
+
+		keys := make([]string, 0, len(obj.Properties[PKString]))
+		for key := range obj.Properties[PKString] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make([]Value, len(keys))
+		for idx, key := range keys {
+			entries[idx] = NewArray(NewString(key), obj.Properties[PKString][key])
+		}
+		return NewReturn(NewArray(entries...))
 	},
 	"typeof": func(ctx *FuncContext) *Return {
 		if len(ctx.Args) < 1 {
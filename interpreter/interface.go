@@ -22,6 +22,8 @@ func New(file *ast.Module, fset *token.FileSet, trace bool, stdin io.Reader, std
 		stderr:         stderr,
 		gooseRoot:      os.Getenv("GOOSEROOT"),
 		executionStack: make([]*Module, 0, 10),
+		nativeModules:  make(map[string]*NativeModule),
+		typeAliases:    make(map[*Composite]*typeAlias),
 	}
 
 	if i.gooseRoot == "" {
@@ -62,6 +64,8 @@ func EvalExpr(expr ast.Expr, scope *Scope) (ret Value, err error) {
 		modules:   make(map[string]*Module),
 		global:    NewGlobalScope(GlobalConstants),
 		gooseRoot: os.Getenv("GOOSEROOT"),
+
+		typeAliases: make(map[*Composite]*typeAlias),
 	}
 
 	module := &Module{
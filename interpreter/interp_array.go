@@ -80,6 +80,25 @@ func (i *interp) evalSliceExpr(scope *Scope, expr *ast.SliceExpr) Value {
 
 	x := i.evalExpr(scope, expr.X)
 
+	// missing bounds are null, so that slice operators can pass theirs on to
+	// a built-in slice
+	low, high := Value(NullValue), Value(NullValue)
+	if expr.Low != nil {
+		low = i.evalExpr(scope, expr.Low)
+	}
+	if expr.High != nil {
+		high = i.evalExpr(scope, expr.High)
+	}
+
+	if op := GetOperator(x, token.Colon); op != nil {
+		return op.Executor(&FuncContext{
+			Interp: i,
+			Scope:  scope,
+			This:   x,
+			Args:   []Value{low, high},
+		}).Value
+	}
+
 	var values []Value
 	if a, ok := x.(*Array); ok {
 		values = a.Elements
@@ -88,15 +107,20 @@ func (i *interp) evalSliceExpr(scope *Scope, expr *ast.SliceExpr) Value {
 		for _, r := range a.Value {
 			values = append(values, Wrap(string(r)))
 		}
+	} else if b, ok := x.(*Bytes); ok {
+		values = make([]Value, len(b.Value))
+		for idx, c := range b.Value {
+			values[idx] = Wrap(int(c))
+		}
 	} else {
-		i.Throw("cannot slice non-array and non-string type %s", x.Type())
+		i.Throw("cannot slice non-array, non-string and non-bytes type %s", x.Type())
 	}
 
 	start := int64(0)
 	end := int64(len(values))
 
-	if expr.Low != nil {
-		begin := i.evalExpr(scope, expr.Low)
+	if _, ok := low.(*Null); !ok {
+		begin := low
 
 		if _, ok := begin.(Numeric); !ok {
 			i.Throw("expected numeric type for slice start index, got %s", begin.Type())
@@ -116,8 +140,8 @@ func (i *interp) evalSliceExpr(scope *Scope, expr *ast.SliceExpr) Value {
 		start = idx
 	}
 
-	if expr.High != nil {
-		endVal := i.evalExpr(scope, expr.High)
+	if _, ok := high.(*Null); !ok {
+		endVal := high
 
 		if _, ok := endVal.(Numeric); !ok {
 			i.Throw("expected numeric type for slice end index, got %s", endVal.Type())
@@ -152,6 +176,12 @@ func (i *interp) evalSliceExpr(scope *Scope, expr *ast.SliceExpr) Value {
 
 	if _, ok := x.(*Array); ok {
 		return Wrap(result)
+	} else if _, ok := x.(*Bytes); ok {
+		out := make([]byte, len(result))
+		for idx, v := range result {
+			out[idx] = byte(v.(*Integer).Value.Int64())
+		}
+		return NewBytes(out)
 	} else {
 		var out strings.Builder
 		for _, v := range result {
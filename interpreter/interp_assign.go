@@ -5,11 +5,19 @@ import (
 
 	"github.com/calico32/goose/ast"
 	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
 )
 
 func (i *interp) runLetStmt(scope *Scope, stmt *ast.LetStmt) StmtResult {
 	defer un(trace(i, "let stmt"))
 
+	if stmt.Pattern != nil {
+		value := i.evalExpr(scope, stmt.Value)
+		i.checkType(scope, stmt.Type, nil, value, "destructuring declaration")
+		i.bindPattern(scope, stmt.Pattern, value, false)
+		return &Void{}
+	}
+
 	if stmt.Ident.Name == "_" {
 		i.Throw("cannot declare _")
 	}
@@ -27,6 +35,9 @@ func (i *interp) runLetStmt(scope *Scope, stmt *ast.LetStmt) StmtResult {
 	if value == nil {
 		value = NullValue
 	}
+	if stmt.Value != nil {
+		i.checkType(scope, stmt.Type, nil, value, "declaration of "+stmt.Ident.Name)
+	}
 
 	scope.Set(stmt.Ident.Name, &Variable{
 		Constant: false,
@@ -42,6 +53,13 @@ func (i *interp) runLetStmt(scope *Scope, stmt *ast.LetStmt) StmtResult {
 func (i *interp) runConstStmt(scope *Scope, stmt *ast.ConstStmt) StmtResult {
 	defer un(trace(i, "const stmt"))
 
+	if stmt.Pattern != nil {
+		value := i.evalExpr(scope, stmt.Value)
+		i.checkType(scope, stmt.Type, nil, value, "destructuring declaration")
+		i.bindPattern(scope, stmt.Pattern, value, true)
+		return &Void{}
+	}
+
 	if stmt.Ident.Name == "_" {
 		i.Throw("cannot declare _")
 	}
@@ -51,6 +69,7 @@ func (i *interp) runConstStmt(scope *Scope, stmt *ast.ConstStmt) StmtResult {
 	}
 
 	value := i.evalExpr(scope, stmt.Value)
+	i.checkType(scope, stmt.Type, nil, value, "declaration of "+stmt.Ident.Name)
 
 	scope.Set(stmt.Ident.Name, &Variable{
 		Constant: true,
@@ -161,30 +180,22 @@ func (i *interp) runAssignStmt(scope *Scope, stmt *ast.AssignStmt) StmtResult {
 		}
 		sel := i.evalExpr(scope, lhs.Sel)
 
-		if _, ok := existing.(*Array); ok {
-			if _, ok := sel.(*Integer); !ok {
-				i.Throw("cannot index array with type %s", sel.Type())
+		rhs := i.evalExpr(scope, stmt.Rhs)
+		if stmt.Tok != token.Assign {
+			selected := i.index(scope, existing, sel)
+			op := GetOperator(selected, stmt.Tok)
+			if op == nil {
+				i.Throw("operator %s not defined for type %s", stmt.Tok, existing.Type())
 			}
+			rhs = op.Executor(&FuncContext{
+				Interp: i,
+				Scope:  scope,
+				This:   selected,
+				Args:   []Value{rhs},
+			}).Value
 		}
 
-		if _, ok := sel.(PropertyKey); !ok {
-			i.Throw("cannot index with type %s", sel.Type())
-		}
-
-		selected := GetProperty(existing, sel.(PropertyKey))
-		op := GetOperator(selected, stmt.Tok)
-		if op == nil {
-			i.Throw("operator %s not defined for type %s", stmt.Tok, existing.Type())
-		}
-		rhs := i.evalExpr(scope, stmt.Rhs)
-		newValue := op.Executor(&FuncContext{
-			Interp: i,
-			Scope:  scope,
-			This:   selected,
-			Args:   []Value{rhs},
-		})
-
-		SetProperty(existing, sel.(PropertyKey), newValue.Value)
+		i.setIndex(scope, existing, sel, rhs)
 	}
 
 	return &Void{}
@@ -256,18 +267,7 @@ func (i *interp) runIncDecStmt(scope *Scope, stmt *ast.IncDecStmt) StmtResult {
 			}
 		}
 		sel := i.evalExpr(scope, lhs.Sel)
-
-		if _, ok := obj.(*Array); ok {
-			if _, ok := sel.(*Integer); !ok {
-				i.Throw("cannot index array with type %s", sel.Type())
-			}
-		}
-
-		if _, ok := sel.(PropertyKey); !ok {
-			i.Throw("cannot index with type %s", sel.Type())
-		}
-
-		existing := GetProperty(obj, sel.(PropertyKey))
+		existing := i.index(scope, obj, sel)
 
 		if _, ok := existing.(Numeric); !ok {
 			i.Throw("cannot increment non-numeric value %s", existing)
@@ -284,7 +284,7 @@ func (i *interp) runIncDecStmt(scope *Scope, stmt *ast.IncDecStmt) StmtResult {
 			Args:   []Value{Wrap(1)},
 		})
 
-		SetProperty(obj, sel.(PropertyKey), newValue.Value)
+		i.setIndex(scope, obj, sel, newValue.Value)
 	}
 	return &Void{}
 }
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+func (i *interp) runEnumStmt(scope *Scope, stmt *ast.EnumStmt) StmtResult {
+	defer un(trace(i, "enum stmt"))
+
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		i.Throw("cannot redefine variable %s", stmt.Name.Name)
+	}
+
+	enum := NewEnum(stmt.Name.Name)
+
+	for _, variant := range stmt.Variants {
+		if _, ok := enum.Properties[PKString][variant.Name.Name]; ok {
+			i.Throw("duplicate variant %s", variant.Name.Name)
+		}
+
+		var fields []*ast.StructField
+		if variant.Fields != nil {
+			fields = variant.Fields.List
+		}
+
+		// validate fields
+		fieldNames := make([]string, len(fields))
+		seen := map[string]bool{}
+		for idx, field := range fields {
+			if seen[field.Ident.Name] {
+				i.Throw("duplicate field %s", field.Ident.Name)
+			}
+			seen[field.Ident.Name] = true
+			fieldNames[idx] = field.Ident.Name
+		}
+
+		kind := AddVariant(enum, variant.Name.Name, fieldNames)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var fieldDefaults []Value
+		for _, field := range fields {
+			var v Value
+			if field.Value != nil {
+				v = i.evalExpr(scope, field.Value)
+			} else {
+				v = NullValue
+			}
+			fieldDefaults = append(fieldDefaults, v.Clone())
+		}
+
+		closure := scope.Fork(ScopeOwnerClosure)
+
+		enum.Properties[PKString][variant.Name.Name] = &Func{
+			NewableProto: kind.Proto,
+			Executor: func(ctx *FuncContext) *Return {
+				obj := &Variant{Kind: kind, Fields: make([]Value, len(fields))}
+				for idx, field := range fields {
+					var v Value
+					if idx < len(ctx.Args) {
+						v = ctx.Args[idx].Clone()
+					} else {
+						v = fieldDefaults[idx].Clone()
+					}
+					i.checkType(closure, field.Type, nil, v, "field "+field.Ident.Name)
+					obj.Fields[idx] = v
+				}
+				return NewReturn(obj)
+			},
+		}
+	}
+
+	enum.Freeze()
+
+	scope.Set(stmt.Name.Name, &Variable{
+		Constant: true,
+		Value:    enum,
+	})
+
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: enum,
+	}
+}
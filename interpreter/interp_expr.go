@@ -59,6 +59,8 @@ func (i *interp) evalExpr(scope *Scope, expr ast.Expr) Value {
 		return i.evalRangeExpr(scope, expr)
 	case *ast.MatchExpr:
 		return i.evalMatchExpr(scope, expr)
+	case *ast.MacroCallExpr:
+		return i.evalMacroCallExpr(scope, expr)
 	default:
 		if badExpr, ok := expr.(*ast.BadExpr); ok {
 			i.Throw("unexpected bad expression %#v", badExpr)
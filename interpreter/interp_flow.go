@@ -46,7 +46,8 @@ func (i *interp) evalDoExpr(scope *Scope, expr *ast.DoExpr) (ret Value) {
 	result := i.runStmts(newScope, expr.Body)
 	switch result := result.(type) {
 	case *Break, *Continue:
-		i.Throw("break/continue not allowed in do expression") // TODO: support
+		// carry the branch to the enclosing loop
+		panic(&branch{result})
 	case *Return:
 		i.Throw("return not allowed in do expression") // TODO: support
 	case *LoneValue:
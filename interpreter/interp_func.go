@@ -20,10 +20,12 @@ func (i *interp) evalFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
 	// validate parameters
 	paramNames := map[string]bool{}
 	for _, param := range expr.Params.List {
-		if paramNames[param.Ident.Name] {
-			i.Throw("duplicate parameter %s", param.Ident.Name)
+		for _, ident := range param.Bindings() {
+			if paramNames[ident.Name] {
+				i.Throw("duplicate parameter %s", ident.Name)
+			}
+			paramNames[ident.Name] = true
 		}
-		paramNames[param.Ident.Name] = true
 	}
 
 	var memoCache map[string]*Return
@@ -43,6 +45,7 @@ func (i *interp) evalFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
 	}
 
 	closure := scope.Fork(ScopeOwnerClosure)
+	generics := typeParams(expr.ReceiverTypeParams, expr.TypeParams)
 
 	// TODO: async
 
@@ -70,19 +73,24 @@ func (i *interp) evalFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
 			}()
 		}
 
-		// set parameters in scope
+		// set parameters in scope; named parameters can't be passed by
+		// position, so they take their default values
+		positional := 0
 		for idx, param := range expr.Params.List {
 			var v Value
-			if idx < len(ctx.Args) {
-				v = ctx.Args[idx].Clone()
+			if !param.Colon.IsValid() && positional < len(ctx.Args) {
+				v = ctx.Args[positional].Clone()
+				positional++
 			} else {
 				v = paramDefaults[idx].Clone()
 			}
+			what := fmt.Sprintf("argument %d", idx+1)
+			if param.Ident != nil {
+				what = "parameter " + param.Ident.Name
+			}
+			i.checkType(closure, param.Type, generics, v, what)
 
-			funcScope.Set(param.Ident.Name, &Variable{
-				Constant: false,
-				Value:    v,
-			})
+			i.bindParam(funcScope, param, v)
 		}
 
 		// TODO: better this
@@ -91,6 +99,16 @@ func (i *interp) evalFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
 			Value:    ctx.This,
 		})
 
+		if expr.Result != nil {
+			defer func() {
+				if ret != nil {
+					i.checkType(closure, expr.Result, generics, ret.Value, "return value")
+				}
+			}()
+		}
+
+		defer i.fence("cannot branch from function")
+
 		if expr.Arrow.IsValid() {
 			result := i.evalExpr(funcScope, expr.ArrowExpr)
 			return NewReturn(&result)
@@ -216,3 +234,17 @@ func (i *interp) runReturnStmt(scope *Scope, stmt *ast.ReturnStmt) StmtResult {
 
 	return NewReturn(&ret)
 }
+
+// bindParam declares a parameter in the scope of a call, destructuring the
+// argument if the parameter is a pattern.
+func (i *interp) bindParam(scope *Scope, param *ast.FuncParam, v Value) {
+	if param.Pattern != nil {
+		i.bindPattern(scope, param.Pattern, v, false)
+		return
+	}
+
+	scope.Set(param.Ident.Name, &Variable{
+		Constant: false,
+		Value:    v,
+	})
+}
@@ -17,10 +17,12 @@ func (i *interp) evalGeneratorExpr(scope *Scope, expr *ast.GeneratorExpr) Value
 	// validate parameters
 	paramNames := map[string]bool{}
 	for _, param := range expr.Params.List {
-		if paramNames[param.Ident.Name] {
-			i.Throw("duplicate parameter %s", param.Ident.Name)
+		for _, ident := range param.Bindings() {
+			if paramNames[ident.Name] {
+				i.Throw("duplicate parameter %s", ident.Name)
+			}
+			paramNames[ident.Name] = true
 		}
-		paramNames[param.Ident.Name] = true
 	}
 
 	var paramDefaults []Value
@@ -40,22 +42,23 @@ func (i *interp) evalGeneratorExpr(scope *Scope, expr *ast.GeneratorExpr) Value
 				channel <- &GeneratorError{Error: err.(error)}
 			}
 		}()
+		defer i.fence("cannot branch from generator")
 
 		genScope := scope.Fork(ScopeOwnerGenerator)
 
-		// set parameters in scope
+		// set parameters in scope; named parameters can't be passed by
+		// position, so they take their default values
+		positional := 0
 		for idx, param := range expr.Params.List {
 			var v Value
-			if idx < len(ctx.Args) {
-				v = ctx.Args[idx].Clone()
+			if !param.Colon.IsValid() && positional < len(ctx.Args) {
+				v = ctx.Args[positional].Clone()
+				positional++
 			} else {
 				v = paramDefaults[idx].Clone()
 			}
 
-			genScope.Set(param.Ident.Name, &Variable{
-				Constant: false,
-				Value:    v,
-			})
+			i.bindParam(genScope, param, v)
 		}
 
 		// TODO: better this
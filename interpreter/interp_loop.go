@@ -55,7 +55,7 @@ func (i *interp) evalRangeExpr(scope *Scope, expr *ast.RangeExpr) Value {
 
 }
 
-func (i *interp) runRepeatCountStmt(scope *Scope, stmt *ast.RepeatCountStmt) StmtResult {
+func (i *interp) runRepeatCountStmt(scope *Scope, stmt *ast.RepeatCountStmt, label string) StmtResult {
 	defer un(trace(i, "repeat count stmt"))
 	count := int64(0)
 	totalCount := i.evalExpr(scope, stmt.Count)
@@ -66,14 +66,9 @@ func (i *interp) runRepeatCountStmt(scope *Scope, stmt *ast.RepeatCountStmt) Stm
 
 	for count < totalCount.(Numeric).Int64() {
 		repeatScope := scope.Fork(ScopeOwnerRepeat)
-		result := i.runStmts(repeatScope, stmt.Body)
-		switch result := result.(type) {
-		case *Return:
+		result, ok := loopResult(i.runLoopBody(repeatScope, stmt.Body), label)
+		if !ok {
 			return result
-		case *Break:
-			return &Void{}
-		case *Continue:
-			// continue
 		}
 		count++
 	}
@@ -83,11 +78,16 @@ func (i *interp) runRepeatCountStmt(scope *Scope, stmt *ast.RepeatCountStmt) Stm
 
 func (i *interp) runBranchStmt(_ *Scope, stmt *ast.BranchStmt) StmtResult {
 	defer un(trace(i, "branch stmt"))
+	label := ""
+	if stmt.Label != nil {
+		label = stmt.Label.Name
+	}
+
 	switch stmt.Tok {
 	case token.Break:
-		return &Break{}
+		return &Break{Label: label}
 	case token.Continue:
-		return &Continue{}
+		return &Continue{Label: label}
 	default:
 		i.Throw("unexpected branch type %v", stmt.Tok)
 	}
@@ -95,7 +95,7 @@ func (i *interp) runBranchStmt(_ *Scope, stmt *ast.BranchStmt) StmtResult {
 	return nil
 }
 
-func (i *interp) runRepeatWhileStmt(scope *Scope, stmt *ast.RepeatWhileStmt) StmtResult {
+func (i *interp) runRepeatWhileStmt(scope *Scope, stmt *ast.RepeatWhileStmt, label string) StmtResult {
 	defer un(trace(i, "repeat while stmt"))
 	for {
 		cond := i.evalExpr(scope, stmt.Cond)
@@ -104,63 +104,124 @@ func (i *interp) runRepeatWhileStmt(scope *Scope, stmt *ast.RepeatWhileStmt) Stm
 		}
 
 		repeatScope := scope.Fork(ScopeOwnerRepeat)
-		result := i.runStmts(repeatScope, stmt.Body)
-		switch result.(type) {
-		case *Return:
+		result, ok := loopResult(i.runLoopBody(repeatScope, stmt.Body), label)
+		if !ok {
 			return result
-		case *Break:
-			return &Void{}
-		case *Continue:
-			// continue
 		}
 	}
 
 	return &Void{}
 }
 
-func (i *interp) runRepeatForeverStmt(scope *Scope, stmt *ast.RepeatForeverStmt) StmtResult {
+func (i *interp) runRepeatForeverStmt(scope *Scope, stmt *ast.RepeatForeverStmt, label string) StmtResult {
 	defer un(trace(i, "repeat forever stmt"))
 	for {
 		repeatScope := scope.Fork(ScopeOwnerRepeat)
-		result := i.runStmts(repeatScope, stmt.Body)
-		switch result.(type) {
-		case *Return:
+		result, ok := loopResult(i.runLoopBody(repeatScope, stmt.Body), label)
+		if !ok {
 			return result
-		case *Break:
-			return &Void{}
-		case *Continue:
-			// continue
 		}
 	}
 }
 
-func (interp *interp) runForStmt(scope *Scope, stmt *ast.ForStmt) StmtResult {
+func (interp *interp) runForStmt(scope *Scope, stmt *ast.ForStmt, label string) StmtResult {
 	defer un(trace(interp, "for stmt"))
 
 	iterable := interp.spawnIterator(interp.evalExpr(scope, stmt.Iterable))
 
-	name := stmt.Var.Name
 	for iterVal := range iterable.channel {
 		forScope := scope.Fork(ScopeOwnerFor)
-		forScope.Set(name, &Variable{
-			Constant: false,
-			Value:    iterVal,
-		})
-
-		result := interp.runStmts(forScope, stmt.Body)
-		switch result.(type) {
-		case *Return:
+		if stmt.Pattern != nil {
+			interp.bindPattern(forScope, stmt.Pattern, iterVal, false)
+		} else {
+			forScope.Set(stmt.Var.Name, &Variable{
+				Constant: false,
+				Value:    iterVal,
+			})
+		}
+
+		result, ok := loopResult(interp.runLoopBody(forScope, stmt.Body), label)
+		if !ok {
 			return result
-		case *Break:
-			return &Void{}
-		case *Continue:
-			// continue
 		}
 	}
 
 	return &Void{}
 }
 
+func (i *interp) runLabeledStmt(scope *Scope, stmt *ast.LabeledStmt) StmtResult {
+	defer un(trace(i, "labeled stmt"))
+
+	label := stmt.Label.Name
+	switch loop := stmt.Stmt.(type) {
+	case *ast.RepeatCountStmt:
+		return i.runRepeatCountStmt(scope, loop, label)
+	case *ast.RepeatWhileStmt:
+		return i.runRepeatWhileStmt(scope, loop, label)
+	case *ast.RepeatForeverStmt:
+		return i.runRepeatForeverStmt(scope, loop, label)
+	case *ast.ForStmt:
+		return i.runForStmt(scope, loop, label)
+	default:
+		i.Throw("label %s must be attached to a loop", label)
+		return nil
+	}
+}
+
+// loopResult decides what a loop labeled label does with the result of one
+// iteration. It returns false along with the loop's result when the loop
+// should stop; breaks and continues aimed at other labels are passed on.
+func loopResult(result StmtResult, label string) (StmtResult, bool) {
+	switch r := result.(type) {
+	case *Return:
+		return r, false
+	case *Break:
+		if r.Label == "" || r.Label == label {
+			return &Void{}, false
+		}
+		return r, false
+	case *Continue:
+		if r.Label == "" || r.Label == label {
+			return nil, true
+		}
+		return r, false
+	}
+	return nil, true
+}
+
+// branch carries a break or continue out of a do expression, which can only
+// produce a value, to the loop around it.
+type branch struct {
+	result StmtResult
+}
+
+// runLoopBody runs one iteration of a loop, catching branches raised inside
+// do expressions.
+func (i *interp) runLoopBody(scope *Scope, body []ast.Stmt) (result StmtResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(*branch)
+			if !ok {
+				panic(r)
+			}
+			result = b.result
+		}
+	}()
+
+	return i.runStmts(scope, body)
+}
+
+// fence turns a branch escaping from a do expression into an error. It is
+// deferred wherever a break or continue cannot reach an enclosing loop.
+func (i *interp) fence(format string, args ...any) {
+	if r := recover(); r != nil {
+		if _, ok := r.(*branch); ok {
+			i.Throw(format, args...)
+		}
+		panic(r)
+	}
+}
+
 type iterator struct {
 	channel chan Value
 }
@@ -177,6 +238,10 @@ func (interp *interp) spawnIterator(iterable Value) *iterator {
 			for _, elem := range iterable.Elements {
 				ch <- elem
 			}
+		case *Bytes:
+			for _, b := range iterable.Value {
+				ch <- Wrap(int(b))
+			}
 		case *IntRange:
 			// optimized for int64 ranges
 			if iterable.Start.IsInt64() && iterable.Stop.IsInt64() && iterable.Step.IsInt64() {
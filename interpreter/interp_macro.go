@@ -0,0 +1,133 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+	std_macro "github.com/calico32/goose/lib/std/macro"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+func (i *interp) evalMacroCallExpr(scope *Scope, expr *ast.MacroCallExpr) Value {
+	defer un(trace(i, "macro call expr"))
+
+	if expr.Expansion == nil {
+		i.Throw("macro %s! was not expanded", expr.Name.Name)
+	}
+
+	return i.evalExpr(scope, expr.Expansion)
+}
+
+// maxSandboxSteps limits how many statements and expressions a procedural
+// macro may evaluate, so that one that loops forever fails instead of hanging.
+const maxSandboxSteps = 1_000_000
+
+// sandboxModules are the std modules procedural macros may import.
+var sandboxModules = map[string]bool{
+	"collections": true,
+	"json":        true,
+	"language":    true,
+	"macro":       true,
+	"math":        true,
+}
+
+// SandboxAllows reports whether procedural macros may import specifier.
+func SandboxAllows(specifier string) bool {
+	name, ok := strings.CutPrefix(specifier, "std:")
+	return ok && sandboxModules[strings.Split(name, "/")[0]]
+}
+
+// step counts an evaluation step of a sandboxed interpreter, and stops it once
+// it has taken too many.
+func (i *interp) step() {
+	if !i.sandbox {
+		return
+	}
+	i.steps++
+	if i.steps > maxSandboxSteps {
+		i.Throw("procmacro exceeded its step limit")
+	}
+}
+
+// ProcMacroRunner returns a parser.ProcMacroFunc that runs procedural macros in
+// a fresh, sandboxed interpreter. The body of the procmacro runs with its
+// parameters bound to arrays of token trees and std:macro bound to macro, and
+// must return a string or token trees. Procmacros can only import the std
+// modules in sandboxModules, and are stopped after maxSandboxSteps steps.
+// Output is written to stdout and stderr, which may be nil to discard it.
+func ProcMacroRunner(fset *token.FileSet, stdout io.Writer, stderr io.Writer) parser.ProcMacroFunc {
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	return func(def *ast.ProcMacroStmt, args [][]*ast.MacroToken) (src string, err error) {
+		i := &interp{
+			fset:           fset,
+			modules:        make(map[string]*Module),
+			global:         NewGlobalScope(GlobalConstants),
+			stdin:          strings.NewReader(""),
+			stdout:         stdout,
+			stderr:         stderr,
+			executionStack: make([]*Module, 0, 10),
+			nativeModules:  make(map[string]*NativeModule),
+			typeAliases:    make(map[*Composite]*typeAlias),
+			sandbox:        true,
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				switch r := r.(type) {
+				case GooseExit:
+					err = fmt.Errorf("procmacro exited with code %d", r.Code)
+				case error:
+					err = r
+				default:
+					err = fmt.Errorf("%v", r)
+				}
+			}
+		}()
+
+		i.defineGlobals()
+		i.runBuiltins()
+
+		module := &Module{
+			Module:  &ast.Module{Specifier: "macro:" + def.Name.Name + "!", Scheme: "macro"},
+			Scope:   i.global.Fork(ScopeOwnerModule),
+			Exports: make(map[string]*Variable),
+		}
+		module.Scope.SetModule(module)
+		i.modules[module.Specifier] = module
+		i.executionStack = append(i.executionStack, module)
+
+		_, std := i.loadModule("std:macro", module.Scope)
+		exports := NewComposite()
+		for name, value := range std.Exports {
+			SetProperty(exports, NewString(name), value.Value)
+		}
+		exports.Frozen = true
+		module.Scope.Set("macro", &Variable{Value: exports, Constant: true})
+
+		// the body runs at the top level of the module, so that it can import
+		// modules
+		for n, param := range def.Params.List {
+			module.Scope.Set(param.Name, &Variable{Value: std_macro.TokenTrees(fset, args[n])})
+		}
+
+		result, ok := i.runStmts(module.Scope, def.Body).(*Return)
+		if !ok || result.Value == nil {
+			return "", fmt.Errorf("procmacro %s! did not return a value", def.Name.Name)
+		}
+		src, ok = std_macro.Source(result.Value)
+		if !ok {
+			return "", fmt.Errorf("procmacro %s! returned %s, expected a string or token trees", def.Name.Name, result.Value.Type())
+		}
+		return src, nil
+	}
+}
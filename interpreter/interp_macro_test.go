@@ -0,0 +1,26 @@
+package interpreter
+
+import "testing"
+
+// TestMacroErrorPositions checks that macros which fail to expand are
+// reported at their invocations.
+func TestMacroErrorPositions(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want string
+	}{
+		{
+			"macro sq!(x) -> x * x\n\nprintln(sq!(1, 2))\n",
+			"/test/main.goose:3:9: macro sq! takes 1 argument, got 2",
+		},
+		{
+			"procmacro boom!(a, b)\n  return missing\nend\n\nprintln(boom!(1, 2))\n",
+			"/test/main.goose:5:9: in expansion of boom!: /test/main.goose:2:10: Goose error: missing is not defined",
+		},
+	} {
+		_, err := run(t, test.src, nil)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("%q: got error %v, want %s", test.src, err, test.want)
+		}
+	}
+}
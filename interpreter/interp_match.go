@@ -61,6 +61,27 @@ func (i *interp) matchPattern(scope *Scope, pattern ast.PatternExpr, x Value) (b
 			})
 			return IsTruthy(ret.Value), scope
 		}
+	case *ast.PatternVariant:
+		y := i.evalExpr(scope, pattern.Variant)
+		constructor, ok := y.(*Func)
+		if !ok || constructor.NewableProto == nil {
+			i.Throw("%s is not an enum variant", PrintExpr(pattern.Variant))
+		}
+		variant, ok := x.(*Variant)
+		if !ok || variant.Kind.Proto != constructor.NewableProto {
+			return false, scope
+		}
+		if len(pattern.Args) != len(variant.Fields) {
+			i.Throw("variant %s has %d fields, got %d", variant.Kind.Name, len(variant.Fields), len(pattern.Args))
+		}
+		for idx, arg := range pattern.Args {
+			var matched bool
+			matched, scope = i.matchPattern(scope, arg, variant.Fields[idx])
+			if !matched {
+				return false, scope
+			}
+		}
+		return true, scope
 	case *ast.PatternTuple, *ast.PatternComposite, *ast.PatternParen, *ast.PatternType:
 		i.Throw("pattern type %T not implemented", pattern)
 	default:
@@ -69,3 +90,66 @@ func (i *interp) matchPattern(scope *Scope, pattern ast.PatternExpr, x Value) (b
 
 	return false, nil
 }
+
+// bindPattern destructures x into the names of a let, const, for or parameter
+// pattern, declaring them in scope. Missing fields bind null and _ discards
+// the value it matches. Arrays must have as many elements as the pattern, or
+// at least as many if it has a rest element.
+func (i *interp) bindPattern(scope *Scope, pattern ast.PatternExpr, x Value, constant bool) {
+	switch pattern := pattern.(type) {
+	case *ast.PatternBinding:
+		i.bindName(scope, pattern.Ident, x, constant)
+	case *ast.PatternParen:
+		i.bindPattern(scope, pattern.X, x, constant)
+	case *ast.PatternTuple:
+		arr, ok := x.(*Array)
+		if !ok {
+			i.Throw("cannot destructure %s as an array", x.Type())
+		}
+		if n, rest := pattern.Arity(); len(arr.Elements) < n || !rest && len(arr.Elements) > n {
+			if rest {
+				i.Throw("cannot destructure an array of length %d into a pattern of length at least %d", len(arr.Elements), n)
+			}
+			i.Throw("cannot destructure an array of length %d into a pattern of length %d", len(arr.Elements), n)
+		}
+		for idx, elem := range pattern.List {
+			if rest, ok := elem.(*ast.PatternRest); ok {
+				elements := append([]Value{}, arr.Elements[idx:]...)
+				i.bindName(scope, rest.Ident, NewArray(elements...), constant)
+				break
+			}
+			i.bindPattern(scope, elem, arr.Elements[idx], constant)
+		}
+	case *ast.PatternComposite:
+		if _, ok := x.(*Null); ok {
+			i.Throw("cannot destructure null")
+		}
+		for _, field := range pattern.Fields {
+			var key Value
+			if ident, ok := field.Key.(*ast.PatternNormal).X.(*ast.Ident); ok {
+				key = NewString(ident.Name)
+			} else {
+				key = i.evalExpr(scope, field.Key.(*ast.PatternNormal).X)
+			}
+			if _, ok := key.(PropertyKey); !ok {
+				i.Throw("cannot use %s as property key", key.Type())
+			}
+			i.bindPattern(scope, field.Value, GetProperty(x, key.(PropertyKey)), constant)
+		}
+	default:
+		i.Throw("cannot destructure with pattern %T", pattern)
+	}
+}
+
+func (i *interp) bindName(scope *Scope, ident *ast.Ident, x Value, constant bool) {
+	if ident.Name == "_" {
+		return
+	}
+	if scope.IsDefinedInCurrentScope(ident.Name) {
+		i.Throw("cannot redefine variable %s", ident.Name)
+	}
+	scope.Set(ident.Name, &Variable{
+		Constant: constant,
+		Value:    x,
+	})
+}
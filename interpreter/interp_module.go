@@ -232,6 +232,10 @@ func (i *interp) loadModule(specifier string, scope *Scope) (string, *Module) {
 		}
 	}
 
+	if i.sandbox && !SandboxAllows(scheme+":"+name) {
+		i.Throw("procmacros cannot import %s", scheme+":"+name)
+	}
+
 	if module, ok := i.modules[specifier]; ok {
 		return specifier, module
 	}
@@ -246,11 +250,38 @@ func (i *interp) loadModule(specifier string, scope *Scope) (string, *Module) {
 	case "std":
 		module = i.loadStdModule(scheme + ":" + name)
 	default:
-		i.Throw("unknown import scheme %s", scheme)
+		native := i.lookupNativeModule(scheme + ":" + name)
+		if native == nil {
+			i.Throw("unknown import scheme %s (no native module %s is registered)", scheme, scheme+":"+name)
+		}
+		module = i.loadNativeModule(native)
 	}
 	return scheme + ":" + name, module
 }
 
+func (i *interp) loadNativeModule(native *NativeModule) *Module {
+	if module, ok := i.modules[native.Specifier]; ok {
+		return module
+	}
+
+	file, err := parser.ParseFile(i.fset, native.Specifier, native.Source, nil)
+	if err != nil {
+		i.Throw(err.Error())
+	}
+
+	module := &Module{
+		Module:  file,
+		Exports: make(map[string]*Variable),
+		Scope:   i.global.Fork(ScopeOwnerModule),
+	}
+
+	module.Scope.SetModule(module)
+	i.modules[native.Specifier] = module
+	i.runModule(module)
+
+	return module
+}
+
 func (i *interp) loadFileModule(specifier string, dir string) *Module {
 	if module, ok := i.modules[specifier]; ok {
 		return module
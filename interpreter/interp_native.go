@@ -2,14 +2,19 @@ package interpreter
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/calico32/goose/ast"
 	. "github.com/calico32/goose/interpreter/lib"
 
 	std_fs "github.com/calico32/goose/lib/std/fs"
+	std_http "github.com/calico32/goose/lib/std/http"
 	std_json "github.com/calico32/goose/lib/std/json"
 	std_language "github.com/calico32/goose/lib/std/language"
+	std_macro "github.com/calico32/goose/lib/std/macro"
 	std_math "github.com/calico32/goose/lib/std/math"
+	std_os "github.com/calico32/goose/lib/std/os"
 	std_platform "github.com/calico32/goose/lib/std/platform"
 	std_random "github.com/calico32/goose/lib/std/random"
 	std_readline "github.com/calico32/goose/lib/std/readline"
@@ -17,7 +22,7 @@ import (
 
 func (i *interp) evalNativeExpr(scope *Scope, expr *ast.NativeExpr) Value {
 	module := scope.Module()
-	if moduleNatives, ok := Natives[module.Specifier]; ok {
+	if moduleNatives, ok := i.nativesFor(module.Specifier); ok {
 		if value, ok := moduleNatives[expr.Id]; ok {
 			return value
 		} else {
@@ -39,19 +44,22 @@ func (i *interp) runNativeStmt(scope *Scope, stmt ast.NativeStmt) StmtResult {
 		name = "S/" + stmt.Name.Name
 	case *ast.NativeFunc:
 		if stmt.Receiver != nil {
-			name = "F/" + stmt.Receiver.Name + "." + name
+			name = "F/" + stmt.Receiver.Name + "." + stmt.Name.Name
 		} else {
 			name = "F/" + stmt.Name.Name
 		}
 	case *ast.NativeOperator:
 		name = "O/" + stmt.Receiver.Name + "." + stmt.Tok.String()
+	case *ast.NativeType:
+		// native types only exist for the validator
+		return &Void{}
 	default:
 		i.Throw(fmt.Sprintf("invalid native stmt type %T", stmt))
 	}
 
 	specifier := scope.Module().Specifier
 
-	if moduleNatives, ok := Natives[specifier]; ok {
+	if moduleNatives, ok := i.nativesFor(specifier); ok {
 		if value, ok := moduleNatives[name]; ok {
 			if fn, ok := stmt.(*ast.NativeFunc); ok && fn.Receiver != nil {
 				// find proto
@@ -76,6 +84,7 @@ func (i *interp) runNativeStmt(scope *Scope, stmt ast.NativeStmt) StmtResult {
 				}
 
 				proto.Properties[PKString][fn.Name.Name] = value
+				return &Void{}
 			}
 
 			scope.Set(name[2:], &Variable{
@@ -97,9 +106,109 @@ var Natives = map[string]map[string]Value{
 	"std:language/builtin.goose": std_language.Builtin,
 
 	"std:fs/index.goose":       std_fs.Index,
+	"std:http/index.goose":     std_http.Index,
 	"std:json/index.goose":     std_json.Index,
+	"std:macro/index.goose":    std_macro.Index,
 	"std:math/index.goose":     std_math.Index,
+	"std:os/index.goose":       std_os.Index,
 	"std:platform/index.goose": std_platform.Index,
 	"std:random/index.goose":   std_random.Index,
 	"std:readline/index.goose": std_readline.Index,
 }
+
+// NativeModule is a module implemented by the host program. Source is the
+// Goose source of the module, which declares its exports (usually with
+// `export native` declarations), and Natives holds the values those
+// declarations refer to, keyed like Natives (e.g. "F/name", "C/name").
+type NativeModule struct {
+	Specifier string
+	Source    string
+	Natives   map[string]Value
+}
+
+var nativeModulesMu sync.RWMutex
+var nativeModules = map[string]*NativeModule{}
+
+var reservedSchemes = map[string]bool{
+	"file": true,
+	"pkg":  true,
+	"std":  true,
+}
+
+func newNativeModule(specifier string, source string, natives map[string]Value) (*NativeModule, error) {
+	colon := strings.Index(specifier, ":")
+	if colon <= 0 || colon == len(specifier)-1 {
+		return nil, fmt.Errorf("invalid native module specifier %q: expected scheme:name", specifier)
+	}
+	if scheme := specifier[:colon]; reservedSchemes[scheme] {
+		return nil, fmt.Errorf("invalid native module specifier %q: scheme %s is reserved", specifier, scheme)
+	}
+	if natives == nil {
+		natives = map[string]Value{}
+	}
+	return &NativeModule{
+		Specifier: specifier,
+		Source:    source,
+		Natives:   natives,
+	}, nil
+}
+
+// RegisterNativeModule makes a native module available to every interpreter
+// and validator, e.g. RegisterNativeModule("host:billing", src, natives) for
+// `import "host:billing"`. The file, pkg and std schemes are reserved.
+func RegisterNativeModule(specifier string, source string, natives map[string]Value) error {
+	module, err := newNativeModule(specifier, source, natives)
+	if err != nil {
+		return err
+	}
+
+	nativeModulesMu.Lock()
+	defer nativeModulesMu.Unlock()
+	if _, ok := nativeModules[specifier]; ok {
+		return fmt.Errorf("native module %s is already registered", specifier)
+	}
+	nativeModules[specifier] = module
+	return nil
+}
+
+// LookupNativeModule returns the globally registered native module with the
+// given specifier, or nil.
+func LookupNativeModule(specifier string) *NativeModule {
+	nativeModulesMu.RLock()
+	defer nativeModulesMu.RUnlock()
+	return nativeModules[specifier]
+}
+
+// RegisterNativeModule makes a native module available to this interpreter
+// only. Modules registered here take precedence over global registrations.
+// The validator and language server only see global registrations, so they
+// report imports of modules registered here as unknown; register modules that
+// should be checked with the package-level RegisterNativeModule.
+func (i *interp) RegisterNativeModule(specifier string, source string, natives map[string]Value) error {
+	module, err := newNativeModule(specifier, source, natives)
+	if err != nil {
+		return err
+	}
+	if _, ok := i.nativeModules[specifier]; ok {
+		return fmt.Errorf("native module %s is already registered", specifier)
+	}
+	i.nativeModules[specifier] = module
+	return nil
+}
+
+func (i *interp) lookupNativeModule(specifier string) *NativeModule {
+	if module, ok := i.nativeModules[specifier]; ok {
+		return module
+	}
+	return LookupNativeModule(specifier)
+}
+
+func (i *interp) nativesFor(specifier string) (map[string]Value, bool) {
+	if natives, ok := Natives[specifier]; ok {
+		return natives, true
+	}
+	if module := i.lookupNativeModule(specifier); module != nil {
+		return module.Natives, true
+	}
+	return nil, false
+}
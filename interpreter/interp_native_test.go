@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+const greetSource = "export native fn greet(name)\n"
+
+// greeter returns natives for greetSource whose greet returns prefix followed
+// by its argument.
+func greeter(prefix string) map[string]Value {
+	return map[string]Value{
+		"F/greet": &Func{Executor: func(ctx *FuncContext) *Return {
+			return NewReturn(prefix + ctx.Args[0].(*String).Value)
+		}},
+	}
+}
+
+func TestRegisterNativeModule(t *testing.T) {
+	if err := RegisterNativeModule("test:global", greetSource, greeter("global ")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterNativeModule("test:global", greetSource, nil); err == nil {
+		t.Errorf("registering test:global twice: expected an error")
+	}
+	if module := LookupNativeModule("test:global"); module == nil || module.Source != greetSource {
+		t.Errorf("LookupNativeModule(test:global) = %v", module)
+	}
+	if module := LookupNativeModule("test:missing"); module != nil {
+		t.Errorf("LookupNativeModule(test:missing) = %v, want nil", module)
+	}
+
+	for _, specifier := range []string{"std:greet", "file:greet", "pkg:greet", "greet", ":greet", "test:"} {
+		if err := RegisterNativeModule(specifier, greetSource, nil); err == nil {
+			t.Errorf("RegisterNativeModule(%q): expected an error", specifier)
+		}
+	}
+
+	out, err := run(t, "import \"test:global\"\nprintln(global.greet(\"goose\"))\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "global goose\n" {
+		t.Errorf("got %q, want %q", out, "global goose\n")
+	}
+}
+
+func TestInterpNativeModule(t *testing.T) {
+	if err := RegisterNativeModule("test:shadowed", greetSource, greeter("global ")); err != nil {
+		t.Fatal(err)
+	}
+
+	src := "import \"test:shadowed\"\nimport \"test:local\"\nprintln(shadowed.greet(\"a\"))\nprintln(local.greet(\"b\"))\n"
+	out, err := run(t, src, func(i *interp) {
+		if err := i.RegisterNativeModule("test:shadowed", greetSource, greeter("local ")); err != nil {
+			t.Fatal(err)
+		}
+		if err := i.RegisterNativeModule("test:local", greetSource, greeter("local ")); err != nil {
+			t.Fatal(err)
+		}
+		if err := i.RegisterNativeModule("test:local", greetSource, nil); err == nil {
+			t.Errorf("registering test:local twice: expected an error")
+		}
+		if err := i.RegisterNativeModule("std:local", greetSource, nil); err == nil {
+			t.Errorf("registering std:local: expected an error")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// modules registered on the interpreter take precedence
+	if out != "local a\nlocal b\n" {
+		t.Errorf("got %q, want %q", out, "local a\nlocal b\n")
+	}
+
+	// and are not visible to other interpreters
+	if LookupNativeModule("test:local") != nil {
+		t.Errorf("test:local was registered globally")
+	}
+	_, err = run(t, "import \"test:local\"\n", nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown import scheme test") {
+		t.Errorf("importing an unregistered module: got %v", err)
+	}
+}
+
+func TestUnknownNativeSymbol(t *testing.T) {
+	if err := RegisterNativeModule("test:partial", greetSource+"export native fn missing()\n", greeter("")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := run(t, "import \"test:partial\"\n", nil)
+	if err == nil || !strings.Contains(err.Error(), "native symbol F/missing not found in module test:partial") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package interpreter
 import (
 	"github.com/calico32/goose/ast"
 	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
 )
 
 func (i *interp) evalSelectorExpr(scope *Scope, expr *ast.SelectorExpr) Value {
@@ -37,9 +38,50 @@ func (i *interp) evalBracketSelectorExpr(scope *Scope, expr *ast.BracketSelector
 
 	sel := i.evalExpr(scope, expr.Sel)
 
+	return i.index(scope, x, sel)
+}
+
+// index returns x[sel], calling the [] operator of x if it has one.
+func (i *interp) index(scope *Scope, x Value, sel Value) Value {
+	if op := GetOperator(x, token.Index); op != nil {
+		return op.Executor(&FuncContext{
+			Interp: i,
+			Scope:  scope,
+			This:   x,
+			Args:   []Value{sel},
+		}).Value
+	}
+
 	if _, ok := sel.(PropertyKey); !ok {
 		i.Throw("cannot use %s as property key", sel.Type())
 	}
 
 	return GetProperty(x, sel.(PropertyKey))
 }
+
+// setIndex sets x[sel] to value, calling the []= operator of x if it has one.
+func (i *interp) setIndex(scope *Scope, x Value, sel Value, value Value) {
+	if op := GetOperator(x, token.IndexAssign); op != nil {
+		op.Executor(&FuncContext{
+			Interp: i,
+			Scope:  scope,
+			This:   x,
+			Args:   []Value{sel, value},
+		})
+		return
+	}
+
+	if _, ok := x.(*Array); ok {
+		if _, ok := sel.(*Integer); !ok {
+			i.Throw("cannot index array with type %s", sel.Type())
+		}
+	}
+
+	if _, ok := sel.(PropertyKey); !ok {
+		i.Throw("cannot index with type %s", sel.Type())
+	}
+
+	if err := SetProperty(x, sel.(PropertyKey), value); err != nil {
+		i.Throw(err.Error())
+	}
+}
@@ -9,15 +9,19 @@ func (i *interp) runStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 	defer un(trace(i, "stmt"))
 	defer pop(push(i, stmt))
 
+	i.runQueued()
+
 	switch stmt := stmt.(type) {
 	case *ast.RepeatCountStmt:
-		return i.runRepeatCountStmt(scope, stmt)
+		return i.runRepeatCountStmt(scope, stmt, "")
 	case *ast.RepeatWhileStmt:
-		return i.runRepeatWhileStmt(scope, stmt)
+		return i.runRepeatWhileStmt(scope, stmt, "")
 	case *ast.RepeatForeverStmt:
-		return i.runRepeatForeverStmt(scope, stmt)
+		return i.runRepeatForeverStmt(scope, stmt, "")
 	case *ast.ForStmt:
-		return i.runForStmt(scope, stmt)
+		return i.runForStmt(scope, stmt, "")
+	case *ast.LabeledStmt:
+		return i.runLabeledStmt(scope, stmt)
 	case *ast.IfStmt:
 		return i.runIfStmt(scope, stmt)
 	case *ast.ReturnStmt:
@@ -38,6 +42,12 @@ func (i *interp) runStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 		return i.runStructStmt(scope, stmt)
 	case *ast.OperatorStmt:
 		return i.runOperatorStmt(scope, stmt)
+	case *ast.TraitStmt:
+		return i.runTraitStmt(scope, stmt)
+	case *ast.ImplStmt:
+		return i.runImplStmt(scope, stmt)
+	case *ast.EnumStmt:
+		return i.runEnumStmt(scope, stmt)
 	case *ast.ExportDeclStmt:
 		return i.runExportDeclStmt(scope, stmt)
 	case *ast.ExportListStmt:
@@ -50,6 +60,11 @@ func (i *interp) runStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 		return i.runNativeStmt(scope, stmt)
 	case *ast.SymbolStmt:
 		return i.runSymbolStmt(scope, stmt)
+	case *ast.TypeStmt:
+		return i.runTypeStmt(scope, stmt)
+	case *ast.MacroStmt, *ast.ProcMacroStmt:
+		// macros are expanded before the module runs
+		return &Void{}
 	default:
 		i.Throw("unexpected statement type %T", stmt)
 		return nil
@@ -57,6 +72,8 @@ func (i *interp) runStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 }
 
 func (i *interp) runStmts(scope *Scope, body []ast.Stmt) StmtResult {
+	i.step()
+
 	var last StmtResult
 	for _, stmt := range body {
 		result := i.runStmt(scope, stmt)
@@ -39,6 +39,7 @@ func (i *interp) runStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResult {
 	proto.Name = stmt.Name.Name
 
 	closure := scope.Fork(ScopeOwnerClosure)
+	generics := typeParams(stmt.TypeParams)
 
 	var executor FuncType = func(ctx *FuncContext) *Return {
 		// create new scope
@@ -60,6 +61,7 @@ func (i *interp) runStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResult {
 			} else {
 				v = fieldDefaults[idx].Clone()
 			}
+			i.checkType(closure, param.Type, generics, v, "field "+param.Ident.Name)
 
 			if stmt.Init != nil {
 				newScope.Set(param.Ident.Name, &Variable{
@@ -85,6 +87,8 @@ func (i *interp) runStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResult {
 				Value:    obj,
 			})
 
+			defer i.fence("cannot return or branch from struct initializer")
+
 			result := i.runStmts(newScope, stmt.Init.Body)
 			switch result.(type) {
 			case *Return, *Break, *Continue:
@@ -130,13 +134,23 @@ func (i *interp) runOperatorStmt(scope *Scope, stmt *ast.OperatorStmt) StmtResul
 		i.Throw("operator %s already defined for %s", stmt.Tok, stmt.Receiver.Name)
 	}
 
+	proto.Operators[stmt.Tok] = i.newOperator(scope, stmt)
+
+	return &Void{}
+}
+
+// newOperator creates the operator function declared by stmt, closing over
+// scope.
+func (i *interp) newOperator(scope *Scope, stmt *ast.OperatorStmt) *OperatorFunc {
 	// validate parameters
 	paramNames := map[string]bool{}
 	for _, param := range stmt.Params.List {
-		if paramNames[param.Ident.Name] {
-			i.Throw("duplicate parameter %s", param.Ident.Name)
+		for _, ident := range param.Bindings() {
+			if paramNames[ident.Name] {
+				i.Throw("duplicate parameter %s", ident.Name)
+			}
+			paramNames[ident.Name] = true
 		}
-		paramNames[param.Ident.Name] = true
 	}
 
 	var memoCache map[string]*Return
@@ -180,10 +194,7 @@ func (i *interp) runOperatorStmt(scope *Scope, stmt *ast.OperatorStmt) StmtResul
 				v = NullValue
 			}
 
-			opScope.Set(param.Ident.Name, &Variable{
-				Constant: false,
-				Value:    v,
-			})
+			i.bindParam(opScope, param, v)
 		}
 
 		// set this
@@ -193,6 +204,13 @@ func (i *interp) runOperatorStmt(scope *Scope, stmt *ast.OperatorStmt) StmtResul
 		})
 
 		// run operator
+		defer i.fence("cannot branch from operator")
+
+		if stmt.Arrow.IsValid() {
+			result := i.evalExpr(opScope, stmt.ArrowExpr)
+			return NewReturn(&result)
+		}
+
 		result := i.runStmts(opScope, stmt.Body)
 		switch result := result.(type) {
 		case *Return:
@@ -204,12 +222,8 @@ func (i *interp) runOperatorStmt(scope *Scope, stmt *ast.OperatorStmt) StmtResul
 		return NewReturn(NullValue)
 	}
 
-	value := &OperatorFunc{
+	return &OperatorFunc{
 		Builtin:  false,
 		Executor: executor,
 	}
-
-	proto.Operators[stmt.Tok] = value
-
-	return &Void{}
 }
@@ -0,0 +1,75 @@
+package interpreter
+
+import (
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+func (i *interp) runTraitStmt(scope *Scope, stmt *ast.TraitStmt) StmtResult {
+	defer un(trace(i, "trait stmt"))
+
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		i.Throw("cannot redefine variable %s", stmt.Name.Name)
+	}
+
+	trait := NewTrait(stmt.Name.Name)
+	methods := trait.Defaults.Properties[PKString]
+
+	for _, member := range stmt.Body {
+		switch member := member.(type) {
+		case *ast.ExprStmt:
+			fn := member.X.(*ast.FuncExpr)
+			if _, ok := methods[fn.Name.Name]; ok {
+				i.Throw("duplicate receiver function %s", fn.Name.Name)
+			}
+
+			// each default gets its own scope so that it is only reachable
+			// through the structs implementing the trait
+			methods[fn.Name.Name] = i.evalFuncExpr(scope.Fork(ScopeOwnerClosure), fn)
+		case *ast.OperatorStmt:
+			if _, ok := trait.Defaults.Operators[member.Tok]; ok {
+				i.Throw("operator %s already defined for %s", member.Tok, stmt.Name.Name)
+			}
+
+			trait.Defaults.Operators[member.Tok] = i.newOperator(scope, member)
+		}
+	}
+
+	scope.Set(stmt.Name.Name, &Variable{
+		Constant: true,
+		Value:    trait,
+	})
+
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: trait,
+	}
+}
+
+func (i *interp) runImplStmt(scope *Scope, stmt *ast.ImplStmt) StmtResult {
+	defer un(trace(i, "impl stmt"))
+
+	traitVar := scope.Get(stmt.Trait.Name)
+	if traitVar == nil {
+		i.Throw("unknown trait %s", stmt.Trait.Name)
+	}
+
+	trait, ok := traitVar.Value.(*Trait)
+	if !ok {
+		i.Throw("%s is not a trait", stmt.Trait.Name)
+	}
+
+	constructor := scope.Get(stmt.Struct.Name)
+	if constructor == nil {
+		i.Throw("unknown type %s", stmt.Struct.Name)
+	}
+
+	receiver, ok := constructor.Value.(*Func)
+	if !ok || receiver.NewableProto == nil {
+		i.Throw("cannot implement trait %s for %s", stmt.Trait.Name, stmt.Struct.Name)
+	}
+
+	trait.Implement(receiver.NewableProto)
+
+	return &Void{}
+}
@@ -0,0 +1,175 @@
+package interpreter
+
+import (
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+// SetEnforceTypes makes the interpreter check the type annotations of
+// variables, parameters and return values as they are assigned, instead of
+// ignoring them.
+func (i *interp) SetEnforceTypes(enforce bool) { i.enforceTypes = enforce }
+
+// typeAlias is the type a type statement names. Type statements declare a
+// constant holding a placeholder value, so that they can be exported and
+// imported like other declarations.
+type typeAlias struct {
+	stmt  *ast.TypeStmt
+	scope *Scope
+}
+
+func (i *interp) runTypeStmt(scope *Scope, stmt *ast.TypeStmt) StmtResult {
+	defer un(trace(i, "type stmt"))
+
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		i.Throw("cannot redefine variable %s", stmt.Name.Name)
+	}
+
+	value := NewComposite()
+	value.Name = stmt.Name.Name
+	value.Freeze()
+	i.typeAliases[value] = &typeAlias{stmt: stmt, scope: scope}
+
+	scope.Set(stmt.Name.Name, &Variable{
+		Constant: true,
+		Value:    value,
+	})
+
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: value,
+	}
+}
+
+// checkType throws if types are enforced and value does not match the
+// annotation t. generics are the type parameters in effect, which match any
+// value.
+func (i *interp) checkType(scope *Scope, t ast.TypeExpr, generics map[string]bool, value Value, what string) {
+	if !i.enforceTypes || t == nil {
+		return
+	}
+	if !i.matchesType(scope, t, generics, value) {
+		i.Throw("cannot use %s as %s in %s", value.Type(), ast.TypeString(t), what)
+	}
+}
+
+func (i *interp) matchesType(scope *Scope, t ast.TypeExpr, generics map[string]bool, value Value) bool {
+	switch t := t.(type) {
+	case *ast.TypeName:
+		return i.matchesTypeName(scope, t, generics, value)
+	case *ast.ArrayType:
+		array, ok := value.(*Array)
+		if !ok {
+			return false
+		}
+		for _, elem := range array.Elements {
+			if !i.matchesType(scope, t.Elem, generics, elem) {
+				return false
+			}
+		}
+		return true
+	case *ast.UnionType:
+		for _, t := range t.Types {
+			if i.matchesType(scope, t, generics, value) {
+				return true
+			}
+		}
+		return false
+	case *ast.ObjectType:
+		if _, ok := value.(*Composite); !ok {
+			return false
+		}
+		for _, field := range t.Fields {
+			if !i.matchesType(scope, field.Type, generics, GetProperty(value, NewString(field.Ident.Name))) {
+				return false
+			}
+		}
+		return true
+	case *ast.FuncType:
+		_, ok := value.(*Func)
+		return ok
+	case *ast.ParenType:
+		return i.matchesType(scope, t.X, generics, value)
+	}
+	return true
+}
+
+func (i *interp) matchesTypeName(scope *Scope, t *ast.TypeName, generics map[string]bool, value Value) bool {
+	if t.Qualifier != nil || generics[t.Name.Name] {
+		return true
+	}
+
+	switch t.Name.Name {
+	case "any":
+		return true
+	case "never":
+		return false
+	case "null", "void":
+		return value == NullValue
+	case "int":
+		_, ok := value.(*Integer)
+		return ok
+	case "float":
+		_, ok := value.(*Float)
+		return ok
+	case "string":
+		_, ok := value.(*String)
+		return ok
+	case "bool":
+		_, ok := value.(*Bool)
+		return ok
+	case "Array":
+		if t.Args != nil && len(t.Args.List) == 1 {
+			return i.matchesType(scope, &ast.ArrayType{Elem: t.Args.List[0]}, generics, value)
+		}
+		_, ok := value.(*Array)
+		return ok
+	case "Function":
+		_, ok := value.(*Func)
+		return ok
+	}
+
+	variable := scope.Get(t.Name.Name)
+	if variable == nil {
+		i.Throw("unknown type %s", t.Name.Name)
+	}
+	switch decl := variable.Value.(type) {
+	case *Composite:
+		if alias, ok := i.typeAliases[decl]; ok {
+			return i.matchesType(alias.scope, alias.stmt.Value, typeParams(alias.stmt.TypeParams), value)
+		}
+		if decl.Proto != nil && decl.Proto.Proto == VariantPrototype {
+			// variants of an enum have its shared prototype in their chain
+			for proto := value.Prototype(); proto != nil; proto = proto.Proto {
+				if proto == decl.Proto {
+					return true
+				}
+			}
+			return false
+		}
+	case *Func:
+		if decl.NewableProto != nil {
+			// instances of a struct have its prototype in their chain
+			for proto := value.Prototype(); proto != nil; proto = proto.Proto {
+				if proto == decl.NewableProto {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	i.Throw("%s is not a type", t.Name.Name)
+	return false
+}
+
+// typeParams returns the type parameters of a generic declaration, which match
+// any value.
+func typeParams(lists ...*ast.TypeParamList) map[string]bool {
+	params := map[string]bool{}
+	for _, list := range lists {
+		for _, name := range list.Names() {
+			params[name] = true
+		}
+	}
+	return params
+}
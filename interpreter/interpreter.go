@@ -3,9 +3,12 @@ package interpreter
 import (
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/calico32/goose/ast"
 	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/parser"
 	"github.com/calico32/goose/token"
 )
 
@@ -19,12 +22,28 @@ type interp struct {
 	stdout         io.Writer
 	stderr         io.Writer
 	gooseRoot      string
+	args           []string
+	nativeModules  map[string]*NativeModule
+	enforceTypes   bool
+	typeAliases    map[*Composite]*typeAlias
 
 	// internal state
 	trace    bool
 	indent   int
 	lastPos  token.Pos
 	posStack []token.Pos
+
+	// sandbox is set for interpreters running procedural macros, which may
+	// only import some modules and run for a limited number of steps
+	sandbox bool
+	steps   int
+
+	// functions queued by Enqueue, run between statements or while a native
+	// is in Block, which wake wakes
+	queueMu sync.Mutex
+	queue   []func()
+	queued  atomic.Bool
+	wake    chan struct{}
 }
 
 type CallFrame struct {
@@ -47,6 +66,76 @@ func (i *interp) Stdin() io.Reader            { return i.stdin }
 func (i *interp) Stdout() io.Writer           { return i.stdout }
 func (i *interp) Stderr() io.Writer           { return i.stderr }
 func (i *interp) GooseRoot() string           { return i.gooseRoot }
+func (i *interp) Args() []string              { return i.args }
+
+// SetArgs sets the arguments passed to the program, exposed as std:os.args.
+func (i *interp) SetArgs(args []string) { i.args = args }
+
+func (i *interp) Exit(code int) {
+	// TODO: tinygo doesn't let you recover panics, so any exit will cause a crash
+	panic(GooseExit{Code: code})
+}
+
+func (i *interp) Enqueue(fn func()) {
+	i.queueMu.Lock()
+	i.queue = append(i.queue, fn)
+	wake := i.wake
+	i.queueMu.Unlock()
+	i.queued.Store(true)
+
+	if wake != nil {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (i *interp) Block(wait func()) {
+	done := make(chan struct{})
+	wake := make(chan struct{}, 1)
+
+	i.queueMu.Lock()
+	outer := i.wake
+	i.wake = wake
+	i.queueMu.Unlock()
+	defer func() {
+		i.queueMu.Lock()
+		i.wake = outer
+		i.queueMu.Unlock()
+	}()
+
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	for {
+		i.runQueued()
+		select {
+		case <-done:
+			return
+		case <-wake:
+		}
+	}
+}
+
+// runQueued runs the functions queued by Enqueue. Exits and exceptions from
+// them unwind the interpreter like those of the statement about to run.
+func (i *interp) runQueued() {
+	if !i.queued.Load() {
+		return
+	}
+	i.queueMu.Lock()
+	queue := i.queue
+	i.queue = nil
+	i.queued.Store(false)
+	i.queueMu.Unlock()
+
+	for _, fn := range queue {
+		fn()
+	}
+}
 
 func (i *interp) CurrentModule() *Module {
 	if len(i.executionStack) == 0 {
@@ -64,28 +153,29 @@ func (i *interp) CurrentModule() *Module {
 
 const PANIC int = 128
 
-type gooseExit struct{ code int }
-
 func (i *interp) Run() (exitCode int, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			if exit, ok := r.(gooseExit); ok {
-				exitCode = int(exit.code)
+			if exit, ok := r.(GooseExit); ok {
+				exitCode = exit.Code
 			} else {
 				panic(r)
 			}
 		}
 	}()
+	i.defineGlobals()
+	i.runBuiltins()
+	i.runModule(i.CurrentModule())
+	return 0, nil
+}
+
+func (i *interp) defineGlobals() {
 	for k, v := range Globals {
 		i.global.Set(k, &Variable{
 			Constant: true,
 			Value:    &Func{Executor: v},
 		})
 	}
-
-	i.runBuiltins()
-	i.runModule(i.CurrentModule())
-	return 0, nil
 }
 
 func (i *interp) runModule(module *Module) {
@@ -98,6 +188,13 @@ func (i *interp) runModule(module *Module) {
 		i.stderr = os.Stderr
 	}
 
+	if err := parser.ExpandMacros(i.fset, module.Module, ProcMacroRunner(i.fset, i.stdout, i.stderr)); err != nil {
+		// the errors are already positioned at the invocations
+		panic(err)
+	}
+
+	defer i.fence("cannot branch from top-level")
+
 	for _, stmt := range module.Stmts {
 		result := i.runStmt(module.Scope, stmt)
 
@@ -0,0 +1,228 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+// run runs src as the module /test/main.goose and returns what it printed, or
+// the error it was stopped by. setup, if not nil, is called before the module
+// runs.
+func run(t *testing.T, src string, setup func(i *interp)) (stdout string, err error) {
+	t.Helper()
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "/test/main.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	i, err := New(f, fset, false, nil, &out, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup != nil {
+		setup(i)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			stdout = out.String()
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	i.Run()
+	return out.String(), nil
+}
+
+func TestDecimalBounds(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want string
+	}{
+		{`decimal.parse("1e999999999")`, "failed to parse decimal"},
+		{`decimal.parse("1", 99999999999)`, "precision must be at most 100000"},
+		{`decimal.parse("1", 18446744073709551616)`, "precision must be at most 100000"},
+		{`decimal.from(1).withPrecision(100001)`, "precision must be at most 100000"},
+		{`decimal.from(1).round(100001)`, "precision must be at most 100000"},
+	} {
+		_, err := run(t, test.src+"\n", nil)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %s", test.src, err, test.want)
+		}
+	}
+
+	if out, err := run(t, "println(decimal.tryParse(\"1e999999999\"))\nprintln(decimal.parse(\"1e5\", 100000).round(2))\n", nil); err != nil || out != "null\n100000.00\n" {
+		t.Errorf("got %q, %v", out, err)
+	}
+}
+
+func TestDestructuringArity(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want string // the error, if any
+	}{
+		{"const [a, b] = [1, 2]\nprintln(a, b)\n", ""},
+		{"const [a, ...b] = [1]\nprintln(a, b)\n", ""},
+		{"const [c, d] = [5]\n", "cannot destructure an array of length 1 into a pattern of length 2"},
+		{"const [c] = [5, 6]\n", "cannot destructure an array of length 2 into a pattern of length 1"},
+		{"const [c, [d, e]] = [1, [2]]\n", "cannot destructure an array of length 1 into a pattern of length 2"},
+		{"const [c, d, ...e] = [1]\n", "cannot destructure an array of length 1 into a pattern of length at least 2"},
+		{"let [a, b] = [5]\n", "cannot destructure an array of length 1 into a pattern of length 2"},
+		{"let [a, ...b] = [1, 2, 3]\nprintln(a, b)\n", ""},
+		{"for [a, b] in [[1, 2], [3]]\n  println(a, b)\nend\n", "cannot destructure an array of length 1 into a pattern of length 2"},
+		{"fn f([a, b]) -> a + b\nprintln(f([1, 2]))\nf([1, 2, 3])\n", "cannot destructure an array of length 3 into a pattern of length 2"},
+	} {
+		_, err := run(t, test.src, nil)
+		if test.want == "" && err != nil {
+			t.Errorf("%q: unexpected error %v", test.src, err)
+		} else if test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)) {
+			t.Errorf("%q: got error %v, want %s", test.src, err, test.want)
+		}
+	}
+
+	out, err := run(t, "for [k, v] in entries({ b: 2, a: 1 })\n  println(k, v)\nend\n", nil)
+	if err != nil || out != "a 1\nb 2\n" {
+		t.Errorf("entries: got %q, %v", out, err)
+	}
+}
+
+func TestIsEnum(t *testing.T) {
+	src := `enum Shape
+  Circle(r)
+  Square(side)
+end
+
+enum E
+  A
+  B
+end
+
+enum F
+  A
+end
+
+println(Shape.Circle(2) is Shape, Shape.Circle(2) !is Shape)
+println(E.A is E, E.A !is E)
+println(Shape.Circle(2) is Shape.Circle, Shape.Circle(2) is Shape.Square)
+println(E.A is F, F.A !is E, Shape.Circle(2) is E)
+println(1 is int, 1 !is int, {} is Shape)
+`
+	want := "true false\ntrue false\ntrue false\nfalse true false\ntrue false false\n"
+	if out, err := run(t, src, nil); err != nil || out != want {
+		t.Errorf("got %q, %v, want %q", out, err, want)
+	}
+}
+
+func TestMemoVariants(t *testing.T) {
+	src := `enum Shape
+  Circle(r)
+  Pair(a, b)
+end
+
+let calls = 0
+memo fn m(x)
+  calls++
+  return calls
+end
+
+println(m(Shape.Circle(1)), m(Shape.Circle("1")), m(Shape.Circle(1)), m(Shape.Circle("1")))
+println(m(Shape.Pair("a:b", "c")), m(Shape.Pair("a", "b:c")), m(Shape.Pair("a:b", "c")))
+`
+	want := "1 2 1 2\n3 4 3\n"
+	if out, err := run(t, src, nil); err != nil || out != want {
+		t.Errorf("got %q, %v, want %q", out, err, want)
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	src := `struct Vec(items)
+
+operator Vec [](key) -> #items[key]
+operator Vec []=(key, value)
+  #items[key] = value * 10
+end
+operator Vec :(low, high) -> Vec(#items[low:high])
+
+struct Money(cents)
+
+operator Money <=>(other) -> #cents - other.cents
+operator Money ==(other) -> #cents == other.cents
+
+let v = Vec([1, 2, 3, 4])
+v[1] = 5
+println(v[0], v[1])
+println(v[1:3].items, v[2:].items, v[:1].items)
+
+let a = Money(1)
+let b = Money(2)
+println(a < b, a <= b, a > b, a >= b, a <= Money(1), a >= Money(1))
+println(a == Money(1), a != Money(1), a != b)
+let sorted = [Money(3), Money(1), Money(2)].sort()
+println(sorted[0].cents, sorted[1].cents, sorted[2].cents)
+`
+	want := "1 50\n[50, 3] [3, 4] [1]\ntrue true false false true true\ntrue false true\n1 2 3\n"
+	if out, err := run(t, src, nil); err != nil || out != want {
+		t.Errorf("got %q, %v, want %q", out, err, want)
+	}
+}
+
+func TestSignalWhileBlocked(t *testing.T) {
+	// keep the signals sent below from stopping the test before the program
+	// handles them
+	caught := make(chan os.Signal, 1)
+	signal.Notify(caught, syscall.SIGTERM)
+	defer signal.Stop(caught)
+
+	src := `import "std:os"
+import "std:http"
+
+os.signal(os.SIGTERM, fn(sig)
+  println("handled " + sig)
+  exit(3)
+end)
+http.serve("127.0.0.1:0", fn(req) -> "")
+`
+	type result struct {
+		stdout string
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		stdout, err := run(t, src, nil)
+		done <- result{stdout, err}
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case r := <-done:
+			if r.err != nil {
+				t.Fatal(r.err)
+			}
+			if r.stdout != "handled SIGTERM\n" {
+				t.Errorf("got %q, want the handler's output", r.stdout)
+			}
+			return
+		case <-timeout:
+			t.Fatal("the handler didn't run while http.serve was blocked")
+		case <-time.After(20 * time.Millisecond):
+			syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		}
+	}
+}
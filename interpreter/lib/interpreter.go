@@ -15,10 +15,27 @@ type Interpreter interface {
 	ExecutionStack() []*Module
 	Global() *Scope
 	GooseRoot() string
+	Args() []string
 
 	CurrentModule() *Module
 
 	Run() (exitCode int, err error)
 
 	Throw(format string, args ...interface{})
+	Exit(code int)
+
+	// Enqueue schedules fn to run on the interpreter's goroutine before the
+	// next statement. It is safe to call from any goroutine, so natives use it
+	// to run Goose code in response to outside events.
+	Enqueue(fn func())
+	// Block calls wait, which blocks outside the interpreter, on another
+	// goroutine and runs the functions queued with Enqueue as they arrive
+	// until it returns. Natives that wait for outside events use it so that
+	// those functions don't wait for them.
+	Block(wait func())
 }
+
+// GooseExit is the panic value used to unwind the interpreter when a program
+// exits. Natives that run on other goroutines should recover it and exit the
+// process themselves.
+type GooseExit struct{ Code int }
@@ -81,18 +81,23 @@ var ArrayPrototype = &Composite{
 				return NewReturn(values)
 			}},
 			"sort": &Func{Executor: func(ctx *FuncContext) *Return {
+				array := ctx.This.(*Array).Elements
+				c := make([]Value, len(array))
+				copy(c, array)
+
 				if len(ctx.Args) < 1 {
-					ctx.Interp.Throw("sort(func): expected at least 1 argument")
+					// without a comparator, elements are compared with <=>
+					sort.SliceStable(c, func(i, j int) bool {
+						return Compare(ctx.Interp, ctx.Scope, c[i], c[j]) < 0
+					})
+					return NewReturn(c)
 				}
 
-				array := ctx.This.(*Array).Elements
 				comparator := ctx.Args[0]
 				if _, ok := comparator.(*Func); !ok {
 					ctx.Interp.Throw("sort(func): expected func to be a function")
 				}
 
-				c := make([]Value, len(array))
-				copy(c, array)
 				sort.Slice(c, func(i, j int) bool {
 					a := c[i]
 					b := c[j]
@@ -0,0 +1,171 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/calico32/goose/token"
+)
+
+// MaxBytes is the largest number of bytes that functions allocating them,
+// like bytes.alloc, accept.
+const MaxBytes = 1 << 32
+
+var BytesPrototype = &Composite{
+	Proto:  Object,
+	Frozen: true,
+	Properties: Properties{
+		PKString: {
+			"toString": &Func{Executor: func(ctx *FuncContext) *Return {
+				b := ctx.This.(*Bytes).Value
+				parts := make([]string, len(b))
+				for i, c := range b {
+					parts[i] = fmt.Sprintf("%02x", c)
+				}
+				return NewReturn("<bytes " + strings.Join(parts, " ") + ">")
+			}},
+			"slice": &Func{Executor: func(ctx *FuncContext) *Return {
+				if len(ctx.Args) != 1 && len(ctx.Args) != 2 {
+					ctx.Interp.Throw("slice(x, y): expected 2 arguments")
+				}
+				if _, ok := ctx.Args[0].(Numeric); !ok {
+					ctx.Interp.Throw("slice(x, y): expected integer as first argument")
+				}
+				b := ctx.This.(*Bytes).Value
+				x := ctx.Args[0].(Numeric).Int()
+				y := len(b)
+				if len(ctx.Args) == 2 {
+					if _, ok := ctx.Args[1].(Numeric); !ok {
+						ctx.Interp.Throw("slice(x, y): expected integer as second argument")
+					}
+					y = ctx.Args[1].(Numeric).Int()
+				}
+				if x < 0 {
+					x = len(b) + x
+				}
+				if y < 0 {
+					y = len(b) + y
+				}
+				if x < 0 || y > len(b) || x > y {
+					ctx.Interp.Throw("slice(x, y): index out of bounds")
+				}
+				return NewReturn(NewBytes(append([]byte{}, b[x:y]...)))
+			}},
+			"concat": &Func{Executor: func(ctx *FuncContext) *Return {
+				out := append([]byte{}, ctx.This.(*Bytes).Value...)
+				for _, arg := range ctx.Args {
+					other, ok := arg.(*Bytes)
+					if !ok {
+						ctx.Interp.Throw("concat(...b): expected bytes, got %s", arg.Type())
+					}
+					out = append(out, other.Value...)
+				}
+				return NewReturn(NewBytes(out))
+			}},
+			"toHex": &Func{Executor: func(ctx *FuncContext) *Return {
+				return NewReturn(hex.EncodeToString(ctx.This.(*Bytes).Value))
+			}},
+			"toBase64": &Func{Executor: func(ctx *FuncContext) *Return {
+				return NewReturn(base64.StdEncoding.EncodeToString(ctx.This.(*Bytes).Value))
+			}},
+			"toArray": &Func{Executor: func(ctx *FuncContext) *Return {
+				b := ctx.This.(*Bytes).Value
+				out := make([]Value, len(b))
+				for i, c := range b {
+					out[i] = NewInteger(big.NewInt(int64(c)))
+				}
+				return NewReturn(out)
+			}},
+			"decode": &Func{Executor: func(ctx *FuncContext) *Return {
+				encoding := "utf-8"
+				if len(ctx.Args) > 0 {
+					if _, ok := ctx.Args[0].(*String); !ok {
+						ctx.Interp.Throw("decode(encoding): expected string as first argument")
+					}
+					encoding = ctx.Args[0].(*String).Value
+				}
+
+				s, err := DecodeBytes(ctx.This.(*Bytes).Value, encoding)
+				if err != nil {
+					ctx.Interp.Throw("decode(encoding): %s", err.Error())
+				}
+				return NewReturn(s)
+			}},
+		},
+	},
+	Operators: Operators{
+		token.Eq: OpFunc(func(c *OpContext[*Bytes, Value]) Value {
+			if b, ok := c.Other.(*Bytes); ok {
+				return BoolFrom[bytes.Equal(c.This.Value, b.Value)]
+			}
+			return FalseValue
+		}),
+		token.Add: OpFunc(func(c *OpContext[*Bytes, Value]) Value {
+			if b, ok := c.Other.(*Bytes); ok {
+				out := make([]byte, 0, len(c.This.Value)+len(b.Value))
+				out = append(out, c.This.Value...)
+				return NewBytes(append(out, b.Value...))
+			}
+			c.Interp.Throw(operatorNotDefined(c.This.Type(), token.Add, c.Other.Type()))
+			return nil
+		}),
+	},
+}
+
+// EncodeString converts a string to bytes using the named encoding: utf-8,
+// latin1, ascii, hex or base64.
+func EncodeString(s string, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "utf-8", "utf8":
+		return []byte(s), nil
+	case "latin1", "iso-8859-1", "ascii":
+		limit := rune(255)
+		if strings.ToLower(encoding) == "ascii" {
+			limit = 127
+		}
+		out := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > limit {
+				return nil, fmt.Errorf("character %q cannot be encoded as %s", r, encoding)
+			}
+			out = append(out, byte(r))
+		}
+		return out, nil
+	case "hex":
+		return hex.DecodeString(s)
+	case "base64":
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return nil, fmt.Errorf("unknown encoding %s", encoding)
+}
+
+// DecodeBytes converts bytes to a string using the named encoding. It is the
+// inverse of EncodeString.
+func DecodeBytes(b []byte, encoding string) (string, error) {
+	switch strings.ToLower(encoding) {
+	case "utf-8", "utf8":
+		if !utf8.Valid(b) {
+			return "", fmt.Errorf("bytes are not valid utf-8")
+		}
+		return string(b), nil
+	case "latin1", "iso-8859-1", "ascii":
+		var sb strings.Builder
+		for _, c := range b {
+			if c > 127 && strings.ToLower(encoding) == "ascii" {
+				return "", fmt.Errorf("byte %#02x is not valid ascii", c)
+			}
+			sb.WriteRune(rune(c))
+		}
+		return sb.String(), nil
+	case "hex":
+		return hex.EncodeToString(b), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+	return "", fmt.Errorf("unknown encoding %s", encoding)
+}
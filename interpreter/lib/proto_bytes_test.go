@@ -0,0 +1,128 @@
+package lib_test
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+)
+
+func method(this Value, name string, args ...Value) Value {
+	return GetProperty(this, NewString(name)).(*Func).Executor(&FuncContext{This: this, Args: args}).Value
+}
+
+func operator(this Value, tok token.Token, other Value) Value {
+	return GetOperator(this, tok).Executor(&FuncContext{This: this, Args: []Value{other}}).Value
+}
+
+func integer(n int64) *Integer { return NewInteger(big.NewInt(n)) }
+
+func TestBytesPrototype(t *testing.T) {
+	t.Parallel()
+
+	b := NewBytes([]byte("goose"))
+
+	for _, test := range []struct {
+		name string
+		got  Value
+		want string
+	}{
+		{"toString", method(b, "toString"), "<bytes 67 6f 6f 73 65>"},
+		{"toHex", method(b, "toHex"), "676f6f7365"},
+		{"toBase64", method(b, "toBase64"), "Z29vc2U="},
+		{"decode", method(b, "decode"), "goose"},
+		{"decode hex", method(b, "decode", NewString("hex")), "676f6f7365"},
+		{"decode latin1", method(NewBytes([]byte{0xe9}), "decode", NewString("latin1")), "é"},
+		{"slice", method(b, "slice", integer(1), integer(3)), "oo"},
+		{"slice from", method(b, "slice", integer(3)), "se"},
+		{"slice negative", method(b, "slice", integer(-2)), "se"},
+		{"concat", method(b, "concat", NewBytes([]byte("!")), NewBytes([]byte("?"))), "goose!?"},
+		{"+", operator(b, token.Add, NewBytes([]byte("s"))), "gooses"},
+	} {
+		var got string
+		switch v := test.got.(type) {
+		case *String:
+			got = v.Value
+		case *Bytes:
+			got = string(v.Value)
+		default:
+			t.Errorf("%s: got %s, want string or bytes", test.name, v.Type())
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if got := string(b.Value); got != "goose" {
+		t.Errorf("methods modified the receiver: %q", got)
+	}
+
+	for _, test := range []struct {
+		other Value
+		want  bool
+	}{
+		{NewBytes([]byte("goose")), true},
+		{NewBytes([]byte("geese")), false},
+		{NewBytes(nil), false},
+		{NewString("goose"), false},
+	} {
+		if got := operator(b, token.Eq, test.other); got != BoolFrom[test.want] {
+			t.Errorf("bytes == %s: got %v, want %v", test.other, got.Unwrap(), test.want)
+		}
+	}
+}
+
+func TestBytesIndex(t *testing.T) {
+	t.Parallel()
+
+	b := NewBytes([]byte{1, 2, 3})
+
+	for _, test := range []struct {
+		index int64
+		want  Value
+	}{
+		{0, integer(1)},
+		{2, integer(3)},
+		{3, NullValue},
+		{-1, NullValue},
+	} {
+		got := GetProperty(b, integer(test.index))
+		if got.Type() != test.want.Type() || got.Hash() != test.want.Hash() {
+			t.Errorf("b[%d]: got %s, want %s", test.index, got.Hash(), test.want.Hash())
+		}
+	}
+
+	if err := SetProperty(b, integer(1), integer(255)); err != nil {
+		t.Errorf("b[1] = 255: %s", err)
+	}
+	if b.Value[1] != 255 {
+		t.Errorf("b[1] = 255: got %d", b.Value[1])
+	}
+
+	for _, test := range []struct {
+		name  string
+		key   PropertyKey
+		value Value
+	}{
+		{"out of range", integer(3), integer(0)},
+		{"negative index", integer(-1), integer(0)},
+		{"string key", NewString("x"), integer(0)},
+		{"too large", integer(0), integer(256)},
+		{"negative value", integer(0), integer(-1)},
+		{"non-integer", integer(0), NewString("a")},
+	} {
+		if err := SetProperty(b, test.key, test.value); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	b.Freeze()
+	if err := SetProperty(b, integer(0), integer(0)); err == nil {
+		t.Errorf("frozen: expected an error")
+	}
+	if got := string(b.Value); got != "\x01\xff\x03" {
+		t.Errorf("failed assignments modified the bytes: %q", got)
+	}
+}
@@ -0,0 +1,455 @@
+package lib
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/calico32/goose/token"
+)
+
+// Rounding is the way a decimal is rounded when digits are dropped.
+type Rounding int
+
+const (
+	RoundHalfEven Rounding = iota // to the nearest neighbor, ties to the even one
+	RoundHalfUp                   // to the nearest neighbor, ties away from zero
+	RoundHalfDown                 // to the nearest neighbor, ties towards zero
+	RoundUp                       // away from zero
+	RoundDown                     // towards zero
+	RoundCeiling                  // towards positive infinity
+	RoundFloor                    // towards negative infinity
+)
+
+var roundingNames = [...]string{
+	RoundHalfEven: "half-even",
+	RoundHalfUp:   "half-up",
+	RoundHalfDown: "half-down",
+	RoundUp:       "up",
+	RoundDown:     "down",
+	RoundCeiling:  "ceiling",
+	RoundFloor:    "floor",
+}
+
+func (r Rounding) String() string { return roundingNames[r] }
+
+// ParseRounding returns the rounding mode called name.
+func ParseRounding(name string) (Rounding, bool) {
+	for r, n := range roundingNames {
+		if n == name {
+			return Rounding(r), true
+		}
+	}
+	return 0, false
+}
+
+// DefaultDecimalPlaces is the number of digits after the decimal point that
+// quotients are rounded to when neither operand has a precision.
+const DefaultDecimalPlaces = 16
+
+// MaxDecimalPlaces bounds the exponents ParseDecimal accepts and the
+// precision decimals can be given, so that neither makes a decimal with more
+// digits than can be computed in reasonable time.
+const MaxDecimalPlaces = 100000
+
+// NewDecimal returns the decimal value * 10^-scale, without a precision.
+func NewDecimal(value *big.Int, scale int) *Decimal {
+	return &Decimal{Value: value, Scale: scale, Precision: -1}
+}
+
+// DecimalFromInt returns i as a decimal.
+func DecimalFromInt(i *big.Int) *Decimal {
+	return NewDecimal(new(big.Int).Set(i), 0)
+}
+
+// DecimalFromFloat returns the decimal with the shortest representation that
+// converts back to f. ok is false if f is NaN or infinite.
+func DecimalFromFloat(f float64) (d *Decimal, ok bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// ParseDecimal parses a decimal number like "-12.50" or "1.5e3". The scale of
+// the result is the number of digits after the decimal point. ok is false if
+// s is not a decimal or its exponent is larger than MaxDecimalPlaces.
+func ParseDecimal(s string) (d *Decimal, ok bool) {
+	s = strings.TrimSpace(s)
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > MaxDecimalPlaces || e < -MaxDecimalPlaces {
+			return nil, false
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	sign := ""
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		sign, s = s[:1], s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return nil, false
+	}
+
+	value, _ := new(big.Int).SetString(sign+whole+frac, 10)
+	d = NewDecimal(value, len(frac)-exp)
+	if d.Scale < 0 {
+		d = d.rescale(0)
+	}
+	return d, true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundQuo returns num / den rounded to an integer with mode.
+func roundQuo(num, den *big.Int, mode Rounding) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// the sign of the exact quotient, and how the remainder compares to half
+	// of the divisor
+	sign := num.Sign() * den.Sign()
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	cmp := half.CmpAbs(den)
+
+	away := false
+	switch mode {
+	case RoundHalfEven:
+		away = cmp > 0 || cmp == 0 && q.Bit(0) == 1
+	case RoundHalfUp:
+		away = cmp >= 0
+	case RoundHalfDown:
+		away = cmp > 0
+	case RoundUp:
+		away = true
+	case RoundCeiling:
+		away = sign > 0
+	case RoundFloor:
+		away = sign < 0
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
+
+// rescale returns d with scale digits after the decimal point, which must be
+// at least its current scale.
+func (d *Decimal) rescale(scale int) *Decimal {
+	value := new(big.Int).Mul(d.Value, pow10(scale-d.Scale))
+	return &Decimal{Value: value, Scale: scale, Precision: d.Precision, Rounding: d.Rounding}
+}
+
+// Round returns d rounded to places digits after the decimal point with mode.
+// The result has exactly places digits after the decimal point.
+func (d *Decimal) Round(places int, mode Rounding) *Decimal {
+	if places >= d.Scale {
+		return d.rescale(places)
+	}
+	value := roundQuo(d.Value, pow10(d.Scale-places), mode)
+	return &Decimal{Value: value, Scale: places, Precision: d.Precision, Rounding: d.Rounding}
+}
+
+// Normalize returns d without trailing zeros after the decimal point.
+func (d *Decimal) Normalize() *Decimal {
+	return d.trim(0)
+}
+
+// trim removes trailing zeros after the decimal point from d, keeping at
+// least min digits.
+func (d *Decimal) trim(min int) *Decimal {
+	value, scale := new(big.Int).Set(d.Value), d.Scale
+	ten, r := big.NewInt(10), new(big.Int)
+	for scale > min {
+		q, _ := new(big.Int).QuoRem(value, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		value, scale = q, scale-1
+	}
+	return &Decimal{Value: value, Scale: scale, Precision: d.Precision, Rounding: d.Rounding}
+}
+
+// Cmp compares d and e, returning -1, 0 or 1.
+func (d *Decimal) Cmp(e *Decimal) int {
+	x, y := align(d, e)
+	return x.Cmp(y)
+}
+
+// String returns d in plain notation, with all digits after the decimal
+// point.
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.Value).Text(10)
+	if d.Scale > 0 {
+		if len(digits) <= d.Scale {
+			digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.Scale] + "." + digits[len(digits)-d.Scale:]
+	}
+	if d.Value.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// align returns the unscaled values of d and e at the same scale.
+func align(d, e *Decimal) (*big.Int, *big.Int) {
+	switch {
+	case d.Scale < e.Scale:
+		return d.rescale(e.Scale).Value, e.Value
+	case d.Scale > e.Scale:
+		return d.Value, e.rescale(d.Scale).Value
+	}
+	return d.Value, e.Value
+}
+
+func maxScale(x, y *Decimal) int {
+	if x.Scale > y.Scale {
+		return x.Scale
+	}
+	return y.Scale
+}
+
+// toDecimal converts the integers and decimals that can be mixed with
+// decimals to a decimal.
+func toDecimal(v Value) (*Decimal, bool) {
+	switch v := v.(type) {
+	case *Decimal:
+		return v, true
+	case *Integer:
+		return DecimalFromInt(v.Value), true
+	case *SizedInt:
+		return DecimalFromInt(v.Value), true
+	}
+	return nil, false
+}
+
+// decimalArith applies the arithmetic operator tok to x and y. The result is
+// rounded to the precision of x, or of y if x has none.
+func decimalArith(interp Interpreter, tok token.Token, x, y *Decimal) Value {
+	ctx := x
+	if x.Precision < 0 && y.Precision >= 0 {
+		ctx = y
+	}
+
+	var res *Decimal
+	switch tok {
+	case token.Add, token.Sub:
+		a, b := align(x, y)
+		value := new(big.Int)
+		if tok == token.Add {
+			value.Add(a, b)
+		} else {
+			value.Sub(a, b)
+		}
+		res = NewDecimal(value, maxScale(x, y))
+	case token.Mul:
+		res = NewDecimal(new(big.Int).Mul(x.Value, y.Value), x.Scale+y.Scale)
+	case token.Quo:
+		if y.Value.Sign() == 0 {
+			interp.Throw("division by zero")
+		}
+		places := ctx.Precision
+		if places < 0 {
+			places = DefaultDecimalPlaces
+		}
+		// x / y = (x.Value / y.Value) * 10^(y.Scale - x.Scale)
+		num, den := new(big.Int).Set(x.Value), new(big.Int).Set(y.Value)
+		if shift := places + y.Scale - x.Scale; shift >= 0 {
+			num.Mul(num, pow10(shift))
+		} else {
+			den.Mul(den, pow10(-shift))
+		}
+		res = NewDecimal(roundQuo(num, den, ctx.Rounding), places)
+		if ctx.Precision < 0 {
+			res = res.trim(maxScale(x, y))
+		}
+	case token.Rem:
+		if y.Value.Sign() == 0 {
+			interp.Throw("division by zero")
+		}
+		a, b := align(x, y)
+		res = NewDecimal(new(big.Int).Rem(a, b), maxScale(x, y))
+	case token.Pow:
+		exp := y.Normalize()
+		if exp.Scale != 0 || exp.Value.Sign() < 0 {
+			return &Float{math.Pow(x.Float64(), y.Float64())}
+		}
+		n := int(exp.Value.Int64())
+		res = NewDecimal(new(big.Int).Exp(x.Value, exp.Value, nil), x.Scale*n)
+	default:
+		interp.Throw(operatorNotDefined(x.Type(), tok, y.Type()))
+	}
+
+	res.Precision, res.Rounding = ctx.Precision, ctx.Rounding
+	if res.Precision >= 0 {
+		res = res.Round(res.Precision, res.Rounding)
+	}
+	return res
+}
+
+// mixDecimal applies tok to a decimal and another number. Integers are
+// converted to decimals, and the decimal to a float if the other is a float.
+func mixDecimal(c *OpContext[*Decimal, Value], tok token.Token) Value {
+	if f, ok := c.Other.(*Float); ok {
+		return promoted(c.Interp, c.Scope, tok, &Float{c.This.Float64()}, f)
+	}
+	other, ok := toDecimal(c.Other)
+	if !ok {
+		c.Interp.Throw(operatorNotDefined(c.This.Type(), tok, c.Other.Type()))
+	}
+	return decimalArith(c.Interp, tok, c.This, other)
+}
+
+// promoted applies the operator tok of this, which has been converted to the
+// wider type of the operands, to this and other.
+func promoted(interp Interpreter, scope *Scope, tok token.Token, this Value, other Value) Value {
+	return this.Prototype().Operators[tok].Executor(&FuncContext{
+		Interp: interp,
+		Scope:  scope,
+		This:   this,
+		Args:   []Value{other},
+	}).Value
+}
+
+// DecimalArgs reads the optional precision and rounding mode arguments of the
+// decimal function name. The precision is -1 and the rounding mode that of d
+// if they are missing.
+func DecimalArgs(ctx *FuncContext, args []Value, name string, d *Decimal) (precision int, rounding Rounding) {
+	precision, rounding = -1, d.Rounding
+	if len(args) > 0 {
+		if _, isNull := args[0].(*Null); !isNull {
+			n, ok := args[0].(*Integer)
+			if !ok || n.Value.Sign() < 0 {
+				ctx.Interp.Throw("%s: expected a non-negative integer as the precision", name)
+			}
+			if !n.Value.IsInt64() || n.Value.Int64() > MaxDecimalPlaces {
+				ctx.Interp.Throw("%s: precision must be at most %d", name, MaxDecimalPlaces)
+			}
+			precision = int(n.Value.Int64())
+		}
+	}
+	if len(args) > 1 {
+		s, ok := args[1].(*String)
+		if !ok {
+			ctx.Interp.Throw("%s: expected a string as the rounding mode", name)
+		}
+		if rounding, ok = ParseRounding(s.Value); !ok {
+			ctx.Interp.Throw("%s: unknown rounding mode %q", name, s.Value)
+		}
+	}
+	return precision, rounding
+}
+
+// WithPrecision returns d with a precision and rounding mode. d is rounded to
+// the precision unless it is -1.
+func (d *Decimal) WithPrecision(precision int, rounding Rounding) *Decimal {
+	res := &Decimal{Value: d.Value, Scale: d.Scale, Precision: precision, Rounding: rounding}
+	if precision >= 0 {
+		res = res.Round(precision, rounding)
+	}
+	return res
+}
+
+var DecimalPrototype = &Composite{
+	Proto:  Object,
+	Frozen: true,
+	Properties: Properties{
+		PKString: {
+			"toString": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					return NewReturn(NewString(ctx.This.(*Decimal).String()))
+				},
+			},
+			"round": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					d := ctx.This.(*Decimal)
+					places, rounding := DecimalArgs(ctx, ctx.Args, "round(places, rounding)", d)
+					if places < 0 {
+						places = 0
+					}
+					return NewReturn(d.Round(places, rounding))
+				},
+			},
+			"withPrecision": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					if len(ctx.Args) < 1 {
+						ctx.Interp.Throw("withPrecision(places, rounding): expected at least 1 argument")
+					}
+					d := ctx.This.(*Decimal)
+					precision, rounding := DecimalArgs(ctx, ctx.Args, "withPrecision(places, rounding)", d)
+					return NewReturn(d.WithPrecision(precision, rounding))
+				},
+			},
+			"toInt": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					return NewReturn(NewInteger(ctx.This.(*Decimal).BigInt()))
+				},
+			},
+			"toFloat": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					return NewReturn(ctx.This.(*Decimal).Float64())
+				},
+			},
+		},
+	},
+	Operators: Operators{
+		token.Eq: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			if other, ok := toDecimal(c.Other); ok {
+				return BoolFrom[c.This.Cmp(other) == 0]
+			}
+			return FalseValue
+		}),
+		token.LogNot: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			return BoolFrom[c.This.Value.Sign() == 0]
+		}),
+		token.Add: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			switch o := c.Other.(type) {
+			case nil:
+				return c.This
+			case *String:
+				return NewString(c.This.String() + o.Value)
+			}
+			return mixDecimal(c, token.Add)
+		}),
+		token.Sub: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			if c.Other == nil {
+				d := c.This
+				return &Decimal{Value: new(big.Int).Neg(d.Value), Scale: d.Scale, Precision: d.Precision, Rounding: d.Rounding}
+			}
+			return mixDecimal(c, token.Sub)
+		}),
+		token.Gt: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			if f, ok := c.Other.(*Float); ok {
+				return BoolFrom[c.This.Float64() > f.Value]
+			}
+			other, ok := toDecimal(c.Other)
+			if !ok {
+				c.Interp.Throw(operatorNotDefined(c.This.Type(), token.Gt, c.Other.Type()))
+			}
+			return BoolFrom[c.This.Cmp(other) > 0]
+		}),
+		token.Mul: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			return mixDecimal(c, token.Mul)
+		}),
+		token.Quo: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			return mixDecimal(c, token.Quo)
+		}),
+		token.Rem: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			return mixDecimal(c, token.Rem)
+		}),
+		token.Pow: OpFunc(func(c *OpContext[*Decimal, Value]) Value {
+			return mixDecimal(c, token.Pow)
+		}),
+	},
+}
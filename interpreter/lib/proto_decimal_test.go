@@ -0,0 +1,75 @@
+package lib_test
+
+import (
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		src  string
+		want string
+	}{
+		{"0", "0"},
+		{"12.50", "12.50"},
+		{"-0.05", "-0.05"},
+		{"+3", "3"},
+		{".5", "0.5"},
+		{"1.5e3", "1500"},
+		{"1.5e-3", "0.0015"},
+		{" 7 ", "7"},
+		{"1e3", "1000"},
+	} {
+		d, ok := ParseDecimal(test.src)
+		if !ok {
+			t.Errorf("%q: failed to parse", test.src)
+			continue
+		}
+		if d.String() != test.want {
+			t.Errorf("%q: got %s, want %s", test.src, d, test.want)
+		}
+	}
+
+	// exponents beyond MaxDecimalPlaces would take too long to apply
+	for _, src := range []string{"", "-", ".", "1.2.3", "1e", "0x10", "1,5", "1e999999999", "1e-999999999", "1e100001"} {
+		if _, ok := ParseDecimal(src); ok {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	t.Parallel()
+
+	modes := []Rounding{RoundHalfEven, RoundHalfUp, RoundHalfDown, RoundUp, RoundDown, RoundCeiling, RoundFloor}
+	for _, test := range []struct {
+		src  string
+		want [7]string // in the order of modes
+	}{
+		{"2.5", [7]string{"2", "3", "2", "3", "2", "3", "2"}},
+		{"3.5", [7]string{"4", "4", "3", "4", "3", "4", "3"}},
+		{"-2.5", [7]string{"-2", "-3", "-2", "-3", "-2", "-2", "-3"}},
+		{"2.51", [7]string{"3", "3", "3", "3", "2", "3", "2"}},
+		{"-2.49", [7]string{"-2", "-2", "-2", "-3", "-2", "-2", "-3"}},
+		{"7", [7]string{"7", "7", "7", "7", "7", "7", "7"}},
+	} {
+		d, _ := ParseDecimal(test.src)
+		for i, mode := range modes {
+			if got := d.Round(0, mode).String(); got != test.want[i] {
+				t.Errorf("%s rounded %s: got %s, want %s", test.src, mode, got, test.want[i])
+			}
+		}
+	}
+
+	d, _ := ParseDecimal("1.5")
+	if got := d.Round(3, RoundHalfEven).String(); got != "1.500" {
+		t.Errorf("1.5 rounded to 3 places: got %s, want 1.500", got)
+	}
+	d, _ = ParseDecimal("1.2300")
+	if got := d.Normalize().String(); got != "1.23" {
+		t.Errorf("1.2300 normalized: got %s, want 1.23", got)
+	}
+}
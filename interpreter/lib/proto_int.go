@@ -28,8 +28,13 @@ var IntegerPrototype = &Composite{
 	},
 	Operators: Operators{
 		token.Eq: OpFunc(func(c *OpContext[*Integer, Value]) Value {
-			if i, ok := c.Other.(*Integer); ok {
-				return BoolFrom[c.This.Value.Cmp(i.Value) == 0]
+			switch o := c.Other.(type) {
+			case *Integer:
+				return BoolFrom[c.This.Value.Cmp(o.Value) == 0]
+			case *SizedInt:
+				return BoolFrom[c.This.Value.Cmp(o.Value) == 0]
+			case *Decimal:
+				return BoolFrom[DecimalFromInt(c.This.Value).Cmp(o) == 0]
 			}
 			return FalseValue
 		}),
@@ -45,6 +50,10 @@ var IntegerPrototype = &Composite{
 				res := new(big.Int)
 				res.Add(c.This.Value, o.Value)
 				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.Add, o.Kind, o.Overflow, c.This.Value, o.Value)
+			case *Decimal:
+				return promoted(c.Interp, c.Scope, token.Add, DecimalFromInt(c.This.Value), o)
 			case *Float:
 				f, _ := c.This.Value.Float64()
 				return &Float{f + o.Value}
@@ -59,6 +68,10 @@ var IntegerPrototype = &Composite{
 			switch o := c.Other.(type) {
 			case *Integer:
 				return BoolFrom[c.This.Value.Cmp(o.Value) > 0]
+			case *SizedInt:
+				return BoolFrom[c.This.Value.Cmp(o.Value) > 0]
+			case *Decimal:
+				return BoolFrom[DecimalFromInt(c.This.Value).Cmp(o) > 0]
 			case *Float:
 				f, _ := c.This.Value.Float64()
 				return BoolFrom[f > o.Value]
@@ -78,6 +91,10 @@ var IntegerPrototype = &Composite{
 				res := new(big.Int)
 				res.Sub(c.This.Value, o.Value)
 				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.Sub, o.Kind, o.Overflow, c.This.Value, o.Value)
+			case *Decimal:
+				return promoted(c.Interp, c.Scope, token.Sub, DecimalFromInt(c.This.Value), o)
 			case *Float:
 				f, _ := c.This.Value.Float64()
 				return &Float{f - o.Value}
@@ -92,6 +109,10 @@ var IntegerPrototype = &Composite{
 				res := new(big.Int)
 				res.Mul(c.This.Value, o.Value)
 				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.Mul, o.Kind, o.Overflow, c.This.Value, o.Value)
+			case *Decimal:
+				return promoted(c.Interp, c.Scope, token.Mul, DecimalFromInt(c.This.Value), o)
 			case *Float:
 				f, _ := c.This.Value.Float64()
 				return &Float{f * o.Value}
@@ -109,6 +130,10 @@ var IntegerPrototype = &Composite{
 				res := new(big.Int)
 				res.Quo(c.This.Value, o.Value)
 				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.Quo, o.Kind, o.Overflow, c.This.Value, o.Value)
+			case *Decimal:
+				return promoted(c.Interp, c.Scope, token.Quo, DecimalFromInt(c.This.Value), o)
 			case *Float:
 				if o.Value == 0 {
 					c.Interp.Throw("division by zero")
@@ -120,19 +145,59 @@ var IntegerPrototype = &Composite{
 				return nil
 			}
 		}),
-		token.BitAnd: OpFunc(func(c *OpContext[*Integer, *Integer]) Value {
-			res := new(big.Int)
-			res.And(c.This.Value, c.Other.Value)
-			return &Integer{res}
+		token.BitAnd: OpFunc(func(c *OpContext[*Integer, Value]) Value {
+			switch o := c.Other.(type) {
+			case *Integer:
+				res := new(big.Int)
+				res.And(c.This.Value, o.Value)
+				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.BitAnd, o.Kind, o.Overflow, c.This.Value, o.Value)
+			default:
+				c.Interp.Throw(operatorNotDefined(c.This.Type(), token.BitAnd, o.Type()))
+				return nil
+			}
+		}),
+		token.BitOr: OpFunc(func(c *OpContext[*Integer, Value]) Value {
+			switch o := c.Other.(type) {
+			case *Integer:
+				res := new(big.Int)
+				res.Or(c.This.Value, o.Value)
+				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.BitOr, o.Kind, o.Overflow, c.This.Value, o.Value)
+			default:
+				c.Interp.Throw(operatorNotDefined(c.This.Type(), token.BitOr, o.Type()))
+				return nil
+			}
 		}),
-		token.BitOr: OpFunc(func(c *OpContext[*Integer, *Integer]) Value {
+		token.BitXor: OpFunc(func(c *OpContext[*Integer, Value]) Value {
+			switch o := c.Other.(type) {
+			case *Integer:
+				res := new(big.Int)
+				res.Xor(c.This.Value, o.Value)
+				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.BitXor, o.Kind, o.Overflow, c.This.Value, o.Value)
+			default:
+				c.Interp.Throw(operatorNotDefined(c.This.Type(), token.BitXor, o.Type()))
+				return nil
+			}
+		}),
+		token.BitShl: OpFunc(func(c *OpContext[*Integer, *Integer]) Value {
+			if c.Other.Value.Sign() < 0 || !c.Other.Value.IsInt64() {
+				c.Interp.Throw("invalid shift count %s", c.Other.Value.Text(10))
+			}
 			res := new(big.Int)
-			res.Or(c.This.Value, c.Other.Value)
+			res.Lsh(c.This.Value, uint(c.Other.Value.Int64()))
 			return &Integer{res}
 		}),
-		token.BitXor: OpFunc(func(c *OpContext[*Integer, *Integer]) Value {
+		token.BitShr: OpFunc(func(c *OpContext[*Integer, *Integer]) Value {
+			if c.Other.Value.Sign() < 0 || !c.Other.Value.IsInt64() {
+				c.Interp.Throw("invalid shift count %s", c.Other.Value.Text(10))
+			}
 			res := new(big.Int)
-			res.Xor(c.This.Value, c.Other.Value)
+			res.Rsh(c.This.Value, uint(c.Other.Value.Int64()))
 			return &Integer{res}
 		}),
 		token.BitNot: OpFunc(func(c *OpContext[*Integer, *Integer]) Value {
@@ -149,6 +214,10 @@ var IntegerPrototype = &Composite{
 				res := new(big.Int)
 				res.Rem(c.This.Value, o.Value)
 				return &Integer{res}
+			case *SizedInt:
+				return sizedArith(c.Interp, token.Rem, o.Kind, o.Overflow, c.This.Value, o.Value)
+			case *Decimal:
+				return promoted(c.Interp, c.Scope, token.Rem, DecimalFromInt(c.This.Value), o)
 			case *Float:
 				f, _ := c.This.Value.Float64()
 				return &Float{math.Mod(f, o.Value)}
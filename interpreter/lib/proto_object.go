@@ -116,58 +116,64 @@ var Object = &Composite{
 				return c.This
 			}
 		}),
-		token.Is: OpFunc(func(c *OpContext[Value, Value]) Value {
-			p1 := c.This.Prototype()
-			p2 := c.Other
+	},
+}
 
-			// for primitives, check against their builtin singletons
-			if singleton, ok := BuiltinSingletons[p1]; ok && p2 == singleton {
-				return TrueValue
-			}
+func init() {
+	// added here, since isInstance refers to VariantPrototype, which refers
+	// back to Object
+	Object.Operators[token.Is] = OpFunc(func(c *OpContext[Value, Value]) Value {
+		return BoolFrom[isInstance(c.This, c.Other)]
+	})
+	Object.Operators[token.IsNot] = OpFunc(func(c *OpContext[Value, Value]) Value {
+		return BoolFrom[!isInstance(c.This, c.Other)]
+	})
+}
 
-			for p2 != nil {
-				if p1 == p2 {
-					return TrueValue
-				}
-				switch x := p2.(type) {
-				case *Func:
-					p2 = x.NewableProto
-				case *Composite:
-					if x == nil {
-						return FalseValue
-					}
-					p2 = x.Prototype()
-				default:
-					p2 = p2.Prototype()
-				}
+// isInstance reports whether x is an instance of typ: a trait it implements, a
+// constructor or enum it was created by, or a value whose prototype chain
+// includes the prototype of x.
+func isInstance(x Value, typ Value) bool {
+	if trait, ok := typ.(*Trait); ok {
+		return trait.ImplementedBy(x)
+	}
 
+	p1 := x.Prototype()
+	p2 := typ
+
+	// for primitives, check against their builtin singletons
+	if singleton, ok := BuiltinSingletons[p1]; ok && p2 == singleton {
+		return true
+	}
+
+	// an enum's prototype is shared by its variants
+	if enum, ok := typ.(*Composite); ok && enum != nil && enum.Proto != nil && enum.Proto.Proto == VariantPrototype {
+		for p := p1; p != nil; p = p.Proto {
+			if p == enum.Proto {
+				return true
 			}
+		}
+		return false
+	}
 
-			return FalseValue
-		}),
-		token.IsNot: OpFunc(func(c *OpContext[Value, Value]) Value {
-			p1 := c.This.Prototype()
-			p2 := c.Other
-			for p2 != nil {
-				if p1 == p2 {
-					return FalseValue
-				}
-				switch x := p2.(type) {
-				case *Func:
-					p2 = x.NewableProto
-				case *Composite:
-					if x == nil {
-						return TrueValue
-					}
-					p2 = x.Prototype()
-				default:
-					p2 = p2.Prototype()
-				}
+	for p2 != nil {
+		if p1 == p2 {
+			return true
+		}
+		switch x := p2.(type) {
+		case *Func:
+			p2 = x.NewableProto
+		case *Composite:
+			if x == nil {
+				return false
 			}
+			p2 = x.Prototype()
+		default:
+			p2 = p2.Prototype()
+		}
+	}
 
-			return TrueValue
-		}),
-	},
+	return false
 }
 
 func operatorNotDefined(this string, op token.Token, other string) string {
@@ -0,0 +1,352 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/calico32/goose/token"
+)
+
+// IntKind is the size and signedness of a sized integer.
+type IntKind int
+
+const (
+	Int8 IntKind = iota
+	Int16
+	Int32
+	Int64
+	Uint8
+	Uint16
+	Uint32
+	Uint64
+	NumIntKinds
+)
+
+var intKindNames = [...]string{
+	Int8:   "i8",
+	Int16:  "i16",
+	Int32:  "i32",
+	Int64:  "i64",
+	Uint8:  "u8",
+	Uint16: "u16",
+	Uint32: "u32",
+	Uint64: "u64",
+}
+
+var intKindTypeNames = [...]string{
+	Int8:   "Int8",
+	Int16:  "Int16",
+	Int32:  "Int32",
+	Int64:  "Int64",
+	Uint8:  "Uint8",
+	Uint16: "Uint16",
+	Uint32: "Uint32",
+	Uint64: "Uint64",
+}
+
+// String returns the name of the builtin for k, like "u8".
+func (k IntKind) String() string { return intKindNames[k] }
+
+// TypeName returns the type name of values of kind k, like "Uint8".
+func (k IntKind) TypeName() string { return intKindTypeNames[k] }
+
+func (k IntKind) Signed() bool { return k < Uint8 }
+
+func (k IntKind) Bits() uint { return 8 << (k % 4) }
+
+// Min returns the smallest value of kind k.
+func (k IntKind) Min() *big.Int {
+	if !k.Signed() {
+		return new(big.Int)
+	}
+	return new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), k.Bits()-1))
+}
+
+// Max returns the largest value of kind k.
+func (k IntKind) Max() *big.Int {
+	bits := k.Bits()
+	if k.Signed() {
+		bits--
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), bits)
+	return max.Sub(max, big.NewInt(1))
+}
+
+// Overflow is what happens when the result of an operation on a sized integer
+// does not fit in its kind.
+type Overflow int
+
+const (
+	OverflowWrap     Overflow = iota // wrap around in two's complement
+	OverflowSaturate                 // clamp to the smallest or largest value
+	OverflowChecked                  // throw an error
+)
+
+var overflowNames = [...]string{
+	OverflowWrap:     "wrap",
+	OverflowSaturate: "saturate",
+	OverflowChecked:  "checked",
+}
+
+func (o Overflow) String() string { return overflowNames[o] }
+
+// NewSizedInt converts v to kind k, handling values out of its range with o.
+func NewSizedInt(v *big.Int, k IntKind, o Overflow) (*SizedInt, error) {
+	min, max := k.Min(), k.Max()
+	if v.Cmp(min) >= 0 && v.Cmp(max) <= 0 {
+		return &SizedInt{Value: v, Kind: k, Overflow: o}, nil
+	}
+
+	switch o {
+	case OverflowSaturate:
+		if v.Sign() < 0 {
+			return &SizedInt{Value: min, Kind: k, Overflow: o}, nil
+		}
+		return &SizedInt{Value: max, Kind: k, Overflow: o}, nil
+	case OverflowChecked:
+		return nil, fmt.Errorf("integer overflow: %s does not fit in %s", v.Text(10), k)
+	}
+	return &SizedInt{Value: wrap(v, k), Kind: k, Overflow: o}, nil
+}
+
+// wrap returns v modulo 2^bits of k, in the range of k.
+func wrap(v *big.Int, k IntKind) *big.Int {
+	mod := new(big.Int).Lsh(big.NewInt(1), k.Bits())
+	res := new(big.Int).Mod(v, mod)
+	if k.Signed() && res.Cmp(k.Max()) > 0 {
+		res.Sub(res, mod)
+	}
+	return res
+}
+
+// SizedIntFrom converts an integer, sized integer, decimal or float to kind k.
+// Fractions are truncated towards zero.
+func SizedIntFrom(v Value, k IntKind, o Overflow) (*SizedInt, error) {
+	var i *big.Int
+	switch v := v.(type) {
+	case *Integer:
+		i = v.Value
+	case *SizedInt:
+		i = v.Value
+	case *Decimal:
+		i = v.BigInt()
+	case *Float:
+		if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+			return nil, fmt.Errorf("cannot convert %s to %s", v.Hash(), k)
+		}
+		i, _ = big.NewFloat(math.Trunc(v.Value)).Int(nil)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to %s", v.Type(), k)
+	}
+	return NewSizedInt(i, k, o)
+}
+
+// maxShift bounds shift counts and exponents, as any larger shift of a sized
+// integer produces the same result and any larger power overflows.
+const maxShift = 128
+
+// sizedArith applies tok to x and y and converts the result to kind k with o.
+// Bitwise operators and shifts always wrap.
+func sizedArith(interp Interpreter, tok token.Token, k IntKind, o Overflow, x, y *big.Int) Value {
+	res := new(big.Int)
+	switch tok {
+	case token.Add:
+		res.Add(x, y)
+	case token.Sub:
+		res.Sub(x, y)
+	case token.Mul:
+		res.Mul(x, y)
+	case token.Quo, token.Rem:
+		if y.Sign() == 0 {
+			interp.Throw("division by zero")
+		}
+		if tok == token.Quo {
+			res.Quo(x, y)
+		} else {
+			res.Rem(x, y)
+		}
+	case token.Pow:
+		if y.Sign() < 0 {
+			interp.Throw("negative exponent %s for %s", y.Text(10), k)
+		}
+		if o == OverflowWrap {
+			res.Exp(x, y, new(big.Int).Lsh(big.NewInt(1), k.Bits()))
+			break
+		}
+		if x.CmpAbs(big.NewInt(1)) > 0 && y.Cmp(big.NewInt(maxShift)) > 0 {
+			// keep the sign of the result, which overflows either way
+			y = big.NewInt(maxShift + int64(y.Bit(0)))
+		}
+		res.Exp(x, y, nil)
+	case token.BitAnd, token.BitOr, token.BitXor, token.BitShl, token.BitShr:
+		switch tok {
+		case token.BitAnd:
+			res.And(x, y)
+		case token.BitOr:
+			res.Or(x, y)
+		case token.BitXor:
+			res.Xor(x, y)
+		default:
+			if y.Sign() < 0 {
+				interp.Throw("negative shift count %s", y.Text(10))
+			}
+			n := uint(maxShift)
+			if y.IsUint64() && y.Uint64() < maxShift {
+				n = uint(y.Uint64())
+			}
+			if tok == token.BitShl {
+				res.Lsh(x, n)
+			} else {
+				res.Rsh(x, n)
+			}
+		}
+		return &SizedInt{Value: wrap(res, k), Kind: k, Overflow: o}
+	default:
+		interp.Throw(operatorNotDefined(k.TypeName(), tok, "Integer"))
+	}
+
+	i, err := NewSizedInt(res, k, o)
+	if err != nil {
+		interp.Throw(err.Error())
+	}
+	return i
+}
+
+// mixSized applies tok to a sized integer and another number. Integers are
+// converted to the kind of the sized integer, which is converted to a decimal
+// or float if the other is one.
+func mixSized(c *OpContext[*SizedInt, Value], tok token.Token) Value {
+	switch o := c.Other.(type) {
+	case *Integer:
+		return sizedArith(c.Interp, tok, c.This.Kind, c.This.Overflow, c.This.Value, o.Value)
+	case *SizedInt:
+		if o.Kind != c.This.Kind {
+			c.Interp.Throw("mismatched integer types %s and %s", c.This.Kind, o.Kind)
+		}
+		return sizedArith(c.Interp, tok, c.This.Kind, c.This.Overflow, c.This.Value, o.Value)
+	case *Decimal:
+		if DecimalPrototype.Operators[tok] != nil {
+			return promoted(c.Interp, c.Scope, tok, DecimalFromInt(c.This.Value), o)
+		}
+	case *Float:
+		if FloatPrototype.Operators[tok] != nil {
+			return promoted(c.Interp, c.Scope, tok, &Float{c.This.Float64()}, o)
+		}
+	}
+	c.Interp.Throw(operatorNotDefined(c.This.Type(), tok, c.Other.Type()))
+	return nil
+}
+
+// SizedIntPrototypes are the prototypes of each kind of sized integer, which
+// share their properties and operators.
+var SizedIntPrototypes [NumIntKinds]*Composite
+
+func init() {
+	for k := range SizedIntPrototypes {
+		SizedIntPrototypes[k] = &Composite{
+			Proto:      Object,
+			Frozen:     true,
+			Properties: sizedIntProperties,
+			Operators:  sizedIntOperators,
+		}
+	}
+}
+
+var sizedIntProperties = Properties{
+	PKString: {
+		"toString": &Func{
+			Executor: func(ctx *FuncContext) *Return {
+				base := 10
+				if len(ctx.Args) >= 1 {
+					base = int(ctx.Args[0].(*Integer).Value.Int64())
+					if base < 2 || base > 62 {
+						ctx.Interp.Throw("base must be between 2 and 62")
+					}
+				}
+				return NewReturn(NewString(ctx.This.(*SizedInt).Value.Text(base)))
+			},
+		},
+		"toInt": &Func{
+			Executor: func(ctx *FuncContext) *Return {
+				return NewReturn(NewInteger(ctx.This.(*SizedInt).Value))
+			},
+		},
+	},
+}
+
+var sizedIntOperators = Operators{
+	token.Eq: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		switch o := c.Other.(type) {
+		case *Integer:
+			return BoolFrom[c.This.Value.Cmp(o.Value) == 0]
+		case *SizedInt:
+			return BoolFrom[c.This.Value.Cmp(o.Value) == 0]
+		case *Decimal:
+			return BoolFrom[DecimalFromInt(c.This.Value).Cmp(o) == 0]
+		}
+		return FalseValue
+	}),
+	token.LogNot: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return BoolFrom[c.This.Value.Sign() == 0]
+	}),
+	token.Add: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		switch o := c.Other.(type) {
+		case nil:
+			return c.This
+		case *String:
+			return NewString(c.This.Value.Text(10) + o.Value)
+		}
+		return mixSized(c, token.Add)
+	}),
+	token.Sub: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		if c.Other == nil {
+			return sizedArith(c.Interp, token.Sub, c.This.Kind, c.This.Overflow, new(big.Int), c.This.Value)
+		}
+		return mixSized(c, token.Sub)
+	}),
+	token.Gt: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		switch o := c.Other.(type) {
+		case *Integer:
+			return BoolFrom[c.This.Value.Cmp(o.Value) > 0]
+		case *SizedInt:
+			return BoolFrom[c.This.Value.Cmp(o.Value) > 0]
+		case *Decimal:
+			return BoolFrom[DecimalFromInt(c.This.Value).Cmp(o) > 0]
+		case *Float:
+			return BoolFrom[c.This.Float64() > o.Value]
+		}
+		c.Interp.Throw(operatorNotDefined(c.This.Type(), token.Gt, c.Other.Type()))
+		return nil
+	}),
+	token.Mul: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.Mul)
+	}),
+	token.Quo: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.Quo)
+	}),
+	token.Rem: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.Rem)
+	}),
+	token.Pow: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.Pow)
+	}),
+	token.BitAnd: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.BitAnd)
+	}),
+	token.BitOr: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.BitOr)
+	}),
+	token.BitXor: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.BitXor)
+	}),
+	token.BitShl: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.BitShl)
+	}),
+	token.BitShr: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return mixSized(c, token.BitShr)
+	}),
+	token.BitNot: OpFunc(func(c *OpContext[*SizedInt, Value]) Value {
+		return &SizedInt{Value: wrap(new(big.Int).Not(c.This.Value), c.This.Kind), Kind: c.This.Kind, Overflow: c.This.Overflow}
+	}),
+}
@@ -0,0 +1,49 @@
+package lib_test
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+func TestNewSizedInt(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		value    int64
+		kind     IntKind
+		overflow Overflow
+		want     string // empty if the conversion fails
+	}{
+		{200, Uint8, OverflowWrap, "200"},
+		{256, Uint8, OverflowWrap, "0"},
+		{-1, Uint8, OverflowWrap, "255"},
+		{128, Int8, OverflowWrap, "-128"},
+		{-129, Int8, OverflowWrap, "127"},
+		{1 << 40, Int32, OverflowWrap, "0"},
+		{300, Uint8, OverflowSaturate, "255"},
+		{-300, Int8, OverflowSaturate, "-128"},
+		{-1, Uint16, OverflowSaturate, "0"},
+		{255, Uint8, OverflowChecked, "255"},
+		{256, Uint8, OverflowChecked, ""},
+		{-1, Uint32, OverflowChecked, ""},
+	} {
+		i, err := NewSizedInt(big.NewInt(test.value), test.kind, test.overflow)
+		switch {
+		case test.want == "" && err == nil:
+			t.Errorf("%s.%s(%d): expected an error, got %s", test.kind, test.overflow, test.value, i.Value)
+		case test.want != "" && err != nil:
+			t.Errorf("%s.%s(%d): %s", test.kind, test.overflow, test.value, err)
+		case test.want != "" && i.Value.String() != test.want:
+			t.Errorf("%s.%s(%d): got %s, want %s", test.kind, test.overflow, test.value, i.Value, test.want)
+		}
+	}
+
+	if got := Uint64.Max().String(); got != "18446744073709551615" {
+		t.Errorf("u64.max: got %s", got)
+	}
+	if got := Int16.Min().String(); got != "-32768" {
+		t.Errorf("i16.min: got %s", got)
+	}
+}
@@ -83,6 +83,20 @@ var StringPrototype = &Composite{
 				}
 				return NewReturn(NewString(ctx.This.(*String).Value[x:y]))
 			}},
+			"toBytes": &Func{Executor: func(ctx *FuncContext) *Return {
+				encoding := "utf-8"
+				if len(ctx.Args) > 0 {
+					if _, ok := ctx.Args[0].(*String); !ok {
+						ctx.Interp.Throw("toBytes(encoding): expected string as first argument")
+					}
+					encoding = ctx.Args[0].(*String).Value
+				}
+				b, err := EncodeString(ctx.This.(*String).Value, encoding)
+				if err != nil {
+					ctx.Interp.Throw("toBytes(encoding): %s", err.Error())
+				}
+				return NewReturn(NewBytes(b))
+			}},
 			"trim": &Func{Executor: func(ctx *FuncContext) *Return {
 				return NewReturn(NewString(strings.TrimSpace(ctx.This.(*String).Value)))
 			}},
@@ -0,0 +1,52 @@
+package lib
+
+var TraitPrototype = &Composite{
+	Proto:  Object,
+	Frozen: true,
+	Properties: Properties{
+		PKString: {
+			"toString": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					return NewReturn(NewString("<trait " + ctx.This.(*Trait).Name + ">"))
+				},
+			},
+		},
+	},
+}
+
+func NewTrait(name string) *Trait {
+	defaults := NewComposite()
+	defaults.Name = name
+	return &Trait{
+		Name:     name,
+		Defaults: defaults,
+		Impls:    make(map[*Composite]bool),
+	}
+}
+
+// Implement records proto as an implementation of t and links the trait's
+// default receivers and operators into its prototype chain, below anything
+// proto defines itself.
+func (t *Trait) Implement(proto *Composite) {
+	if t.Impls[proto] {
+		return
+	}
+	t.Impls[proto] = true
+	proto.Proto = &Composite{
+		Name:       t.Name,
+		Proto:      proto.Proto,
+		Properties: t.Defaults.Properties,
+		Operators:  t.Defaults.Operators,
+		Frozen:     true,
+	}
+}
+
+// ImplementedBy reports whether any prototype of v implements t.
+func (t *Trait) ImplementedBy(v Value) bool {
+	for proto := v.Prototype(); proto != nil; proto = proto.Proto {
+		if t.Impls[proto] {
+			return true
+		}
+	}
+	return false
+}
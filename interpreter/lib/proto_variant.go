@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"strings"
+
+	"github.com/calico32/goose/token"
+)
+
+// VariantKind describes one variant of an enum.
+type VariantKind struct {
+	Enum   string
+	Name   string
+	Fields []string
+	Proto  *Composite
+}
+
+var VariantPrototype = &Composite{
+	Proto:  Object,
+	Frozen: true,
+	Properties: Properties{
+		PKString: {
+			"toString": &Func{
+				Executor: func(ctx *FuncContext) *Return {
+					v, ok := ctx.This.(*Variant)
+					if !ok {
+						return NewReturn(NewString("<enum " + ctx.This.Prototype().Name + ">"))
+					}
+
+					var sb strings.Builder
+					sb.WriteString(v.Kind.Enum + "." + v.Kind.Name)
+					if len(v.Kind.Fields) > 0 {
+						sb.WriteString("(")
+						for idx, field := range v.Fields {
+							if idx > 0 {
+								sb.WriteString(", ")
+							}
+							sb.WriteString(ToString(ctx.Interp, ctx.Scope, field))
+						}
+						sb.WriteString(")")
+					}
+					return NewReturn(NewString(sb.String()))
+				},
+			},
+		},
+	},
+	Operators: Operators{
+		token.Eq: OpFunc(func(c *OpContext[Value, Value]) Value {
+			v1, ok1 := c.This.(*Variant)
+			v2, ok2 := c.Other.(*Variant)
+			if !ok1 || !ok2 {
+				return BoolFrom[c.This == c.Other]
+			}
+			if v1.Kind != v2.Kind {
+				return FalseValue
+			}
+			for idx, field := range v1.Fields {
+				eq := GetOperator(field, token.Eq)
+				if eq == nil {
+					return FalseValue
+				}
+				ret := eq.Executor(&FuncContext{
+					Interp: c.Interp,
+					Scope:  c.Scope,
+					This:   field,
+					Args:   []Value{v2.Fields[idx]},
+				})
+				if !IsTruthy(ret.Value) {
+					return FalseValue
+				}
+			}
+			return TrueValue
+		}),
+	},
+}
+
+// NewEnum creates the composite an enum declaration binds its name to. Its
+// prototype is shared by every variant of the enum; variants are added with
+// AddVariant.
+func NewEnum(name string) *Composite {
+	enum := NewComposite()
+	enum.Name = name
+	enum.Proto = &Composite{
+		Name:   name,
+		Proto:  VariantPrototype,
+		Frozen: true,
+	}
+	return enum
+}
+
+// AddVariant creates a variant of enum with the given field names. Unit
+// variants are stored on the enum as values; the constructor for variants with
+// fields must be set by the caller using the returned kind's prototype.
+func AddVariant(enum *Composite, name string, fields []string) *VariantKind {
+	kind := &VariantKind{
+		Enum:   enum.Name,
+		Name:   name,
+		Fields: fields,
+		Proto: &Composite{
+			Name:   enum.Name,
+			Proto:  enum.Proto,
+			Frozen: true,
+		},
+	}
+	if len(fields) == 0 {
+		enum.Properties[PKString][name] = &Variant{Kind: kind}
+	}
+	return kind
+}
@@ -7,8 +7,8 @@ type (
 
 	Return   struct{ Value Value }
 	Yield    struct{ Value Value }
-	Break    struct{}
-	Continue struct{}
+	Break    struct{ Label string }
+	Continue struct{ Label string }
 	Void     struct{}
 	Export   struct {
 		Value Value
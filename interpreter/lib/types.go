@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 	"unsafe"
 
 	"github.com/calico32/goose/token"
@@ -73,7 +74,18 @@ type (
 	Null    struct{}
 	Integer struct{ Value *big.Int }
 	Float   struct{ Value float64 }
-	Symbol  struct {
+	Decimal struct {
+		Value     *big.Int // unscaled value
+		Scale     int      // digits after the decimal point
+		Precision int      // digits after the decimal point results are rounded to, or -1
+		Rounding  Rounding
+	}
+	SizedInt struct {
+		Value    *big.Int
+		Kind     IntKind
+		Overflow Overflow
+	}
+	Symbol struct {
 		Name string
 		Id   int64
 	}
@@ -82,6 +94,10 @@ type (
 	String struct {
 		Value string
 	}
+	Bytes struct {
+		Value  []byte
+		Frozen bool
+	}
 	Array struct {
 		Elements []Value
 		Frozen   bool
@@ -101,6 +117,15 @@ type (
 		NewableProto *Composite
 		Frozen       bool
 	}
+	Trait struct {
+		Name     string
+		Defaults *Composite // default receiver functions and operators
+		Impls    map[*Composite]bool
+	}
+	Variant struct {
+		Kind   *VariantKind
+		Fields []Value
+	}
 	Generator struct {
 		Async   bool
 		Channel chan GeneratorMessage
@@ -119,26 +144,32 @@ type (
 )
 
 func NewString(s string) *String      { return &String{Value: s} }
+func NewBytes(b []byte) *Bytes        { return &Bytes{Value: b} }
 func NewInteger(i *big.Int) *Integer  { return &Integer{Value: i} }
 func NewFloat(f float64) *Float       { return &Float{Value: f} }
 func NewArray(values ...Value) *Array { return &Array{Elements: values} }
 
 type ValueType interface {
-	string | float64 | bool | []any | []Value |
+	string | float64 | bool | []any | []Value | []byte |
 		[]string | []int | []int64 | []float64 | []bool |
-		Null | Bool | String | Func | Integer | Float | Array | Composite | Generator | IntRange | FloatRange |
-		*Null | *Bool | *String | *Func | *Integer | *Float | *Array | *Composite | *Generator | *IntRange | *FloatRange | *Value
+		Null | Bool | String | Bytes | Func | Integer | Float | Decimal | SizedInt | Array | Composite | Trait | Variant | Generator | IntRange | FloatRange |
+		*Null | *Bool | *String | *Bytes | *Func | *Integer | *Float | *Decimal | *SizedInt | *Array | *Composite | *Trait | *Variant | *Generator | *IntRange | *FloatRange | *Value
 }
 
 func (*Null) gooseValue()       {}
 func (*Integer) gooseValue()    {}
 func (*Float) gooseValue()      {}
+func (*Decimal) gooseValue()    {}
+func (*SizedInt) gooseValue()   {}
 func (*Symbol) gooseValue()     {}
 func (*Bool) gooseValue()       {}
 func (*String) gooseValue()     {}
+func (*Bytes) gooseValue()      {}
 func (*Array) gooseValue()      {}
 func (*Composite) gooseValue()  {}
 func (*Func) gooseValue()       {}
+func (*Trait) gooseValue()      {}
+func (*Variant) gooseValue()    {}
 func (*Generator) gooseValue()  {}
 func (*IntRange) gooseValue()   {}
 func (*FloatRange) gooseValue() {}
@@ -146,23 +177,37 @@ func (*FloatRange) gooseValue() {}
 func (*Null) Type() string       { return "Null" }
 func (*Integer) Type() string    { return "Integer" }
 func (*Float) Type() string      { return "Float" }
+func (*Decimal) Type() string    { return "Decimal" }
+func (i *SizedInt) Type() string { return i.Kind.TypeName() }
 func (*Symbol) Type() string     { return "Symbol" }
 func (*Bool) Type() string       { return "Bool" }
 func (*String) Type() string     { return "String" }
+func (*Bytes) Type() string      { return "Bytes" }
 func (*Array) Type() string      { return "Array" }
 func (*Composite) Type() string  { return "Composite" }
 func (*Func) Type() string       { return "Func" }
+func (*Trait) Type() string      { return "Trait" }
+func (*Variant) Type() string    { return "Variant" }
 func (*Generator) Type() string  { return "Generator" }
 func (*IntRange) Type() string   { return "IntRange" }
 func (*FloatRange) Type() string { return "FloatRange" }
 
-func (n *Null) Unwrap() any    { return nil }
-func (i *Integer) Unwrap() any { return i.Value }
-func (f *Float) Unwrap() any   { return f.Value }
-func (s *Symbol) Unwrap() any  { return s.Name }
-func (b *Bool) Unwrap() any    { return b.Value }
-func (s *String) Unwrap() any  { return s.Value }
-func (a *Array) Unwrap() any   { return a.Elements }
+func (n *Null) Unwrap() any     { return nil }
+func (i *Integer) Unwrap() any  { return i.Value }
+func (f *Float) Unwrap() any    { return f.Value }
+func (d *Decimal) Unwrap() any  { return d.String() }
+func (i *SizedInt) Unwrap() any { return i.Value }
+func (s *Symbol) Unwrap() any   { return s.Name }
+func (b *Bool) Unwrap() any     { return b.Value }
+func (s *String) Unwrap() any   { return s.Value }
+func (b *Bytes) Unwrap() any    { return b.Value }
+func (a *Array) Unwrap() any {
+	result := make([]any, len(a.Elements))
+	for i, value := range a.Elements {
+		result[i] = value.Unwrap()
+	}
+	return result
+}
 func (c *Composite) Unwrap() any {
 	result := make(map[string]any)
 	for kind := PropertyKeyKind(0); kind < NumPKKinds; kind++ {
@@ -177,17 +222,28 @@ func (c *Composite) Unwrap() any {
 	return result
 }
 func (f *Func) Unwrap() any       { return f.Executor }
+func (t *Trait) Unwrap() any      { return t }
 func (g *Generator) Unwrap() any  { return g }
 func (r *IntRange) Unwrap() any   { return r }
 func (r *FloatRange) Unwrap() any { return r }
+func (v *Variant) Unwrap() any {
+	result := make(map[string]any)
+	for idx, field := range v.Kind.Fields {
+		result[field] = v.Fields[idx].Unwrap()
+	}
+	return result
+}
 
-func (n *Null) Clone() Value    { return n }
-func (i *Integer) Clone() Value { return i }
-func (f *Float) Clone() Value   { return f }
-func (s *Symbol) Clone() Value  { return s }
-func (b *Bool) Clone() Value    { return b }
-func (s *String) Clone() Value  { return s }
-func (a *Array) Clone() Value   { return a }
+func (n *Null) Clone() Value     { return n }
+func (i *Integer) Clone() Value  { return i }
+func (f *Float) Clone() Value    { return f }
+func (d *Decimal) Clone() Value  { return d }
+func (i *SizedInt) Clone() Value { return i }
+func (s *Symbol) Clone() Value   { return s }
+func (b *Bool) Clone() Value     { return b }
+func (s *String) Clone() Value   { return s }
+func (b *Bytes) Clone() Value    { return b }
+func (a *Array) Clone() Value    { return a }
 func (c *Composite) Clone() Value {
 	return &Composite{
 		Proto:      c.Proto,
@@ -196,7 +252,9 @@ func (c *Composite) Clone() Value {
 		Frozen:     c.Frozen,
 	}
 }
-func (f *Func) Clone() Value { return f }
+func (f *Func) Clone() Value    { return f }
+func (t *Trait) Clone() Value   { return t }
+func (v *Variant) Clone() Value { return v }
 func (g *Generator) Clone() Value {
 	return &Generator{
 		Async:   g.Async,
@@ -218,12 +276,17 @@ func (r *FloatRange) Clone() Value {
 	}
 }
 
-func (n *Null) Freeze()    {}
-func (i *Integer) Freeze() {}
-func (f *Float) Freeze()   {}
-func (s *Symbol) Freeze()  {}
-func (b *Bool) Freeze()    {}
-func (s *String) Freeze()  {}
+func (n *Null) Freeze()     {}
+func (i *Integer) Freeze()  {}
+func (f *Float) Freeze()    {}
+func (d *Decimal) Freeze()  {}
+func (i *SizedInt) Freeze() {}
+func (s *Symbol) Freeze()   {}
+func (b *Bool) Freeze()     {}
+func (s *String) Freeze()   {}
+func (b *Bytes) Freeze() {
+	b.Frozen = true
+}
 func (a *Array) Freeze() {
 	a.Frozen = true
 }
@@ -236,15 +299,22 @@ func (f *Func) Freeze() {
 func (g *Generator) Freeze() {
 	g.Frozen = true
 }
+func (t *Trait) Freeze()      {}
+func (v *Variant) Freeze()    {}
 func (r *IntRange) Freeze()   {}
 func (r *FloatRange) Freeze() {}
 
-func (n *Null) Unfreeze()    {}
-func (i *Integer) Unfreeze() {}
-func (f *Float) Unfreeze()   {}
-func (s *Symbol) Unfreeze()  {}
-func (b *Bool) Unfreeze()    {}
-func (s *String) Unfreeze()  {}
+func (n *Null) Unfreeze()     {}
+func (i *Integer) Unfreeze()  {}
+func (f *Float) Unfreeze()    {}
+func (d *Decimal) Unfreeze()  {}
+func (i *SizedInt) Unfreeze() {}
+func (s *Symbol) Unfreeze()   {}
+func (b *Bool) Unfreeze()     {}
+func (s *String) Unfreeze()   {}
+func (b *Bytes) Unfreeze() {
+	b.Frozen = false
+}
 func (a *Array) Unfreeze() {
 	a.Frozen = false
 }
@@ -257,11 +327,15 @@ func (f *Func) Unfreeze() {
 func (g *Generator) Unfreeze() {
 	g.Frozen = false
 }
+func (t *Trait) Unfreeze()      {}
+func (v *Variant) Unfreeze()    {}
 func (r *IntRange) Unfreeze()   {}
 func (r *FloatRange) Unfreeze() {}
 
-func (*Integer) numeric() {}
-func (*Float) numeric()   {}
+func (*Integer) numeric()  {}
+func (*Float) numeric()    {}
+func (*Decimal) numeric()  {}
+func (*SizedInt) numeric() {}
 
 func (i *Integer) Int() int         { return int(i.Value.Int64()) }
 func (i *Integer) Int64() int64     { return i.Value.Int64() }
@@ -270,10 +344,24 @@ func (i *Integer) Float64() float64 {
 	f, _ := i.Value.Float64()
 	return f
 }
-func (f *Float) Int() int         { return int(f.Value) }
-func (f *Float) Int64() int64     { return int64(f.Value) }
-func (f *Float) BigInt() *big.Int { return big.NewInt(int64(f.Value)) }
-func (f *Float) Float64() float64 { return f.Value }
+func (f *Float) Int() int           { return int(f.Value) }
+func (f *Float) Int64() int64       { return int64(f.Value) }
+func (f *Float) BigInt() *big.Int   { return big.NewInt(int64(f.Value)) }
+func (f *Float) Float64() float64   { return f.Value }
+func (d *Decimal) Int() int         { return int(d.BigInt().Int64()) }
+func (d *Decimal) Int64() int64     { return d.BigInt().Int64() }
+func (d *Decimal) BigInt() *big.Int { return d.Round(0, RoundDown).Value }
+func (d *Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+func (i *SizedInt) Int() int         { return int(i.Value.Int64()) }
+func (i *SizedInt) Int64() int64     { return i.Value.Int64() }
+func (i *SizedInt) BigInt() *big.Int { return i.Value }
+func (i *SizedInt) Float64() float64 {
+	f, _ := i.Value.Float64()
+	return f
+}
 
 func (*String) kind() PropertyKeyKind  { return PKString }
 func (*Integer) kind() PropertyKeyKind { return PKInteger }
@@ -286,26 +374,49 @@ func (s *Symbol) CanonicalValue() string  { return "@" + s.Name + "#" + strconv.
 func (n *Null) Prototype() *Composite       { return NullPrototype }
 func (i *Integer) Prototype() *Composite    { return IntegerPrototype }
 func (f *Float) Prototype() *Composite      { return FloatPrototype }
+func (d *Decimal) Prototype() *Composite    { return DecimalPrototype }
+func (i *SizedInt) Prototype() *Composite   { return SizedIntPrototypes[i.Kind] }
 func (s *Symbol) Prototype() *Composite     { return SymbolPrototype }
 func (b *Bool) Prototype() *Composite       { return BoolPrototype }
 func (s *String) Prototype() *Composite     { return StringPrototype }
+func (b *Bytes) Prototype() *Composite      { return BytesPrototype }
 func (a *Array) Prototype() *Composite      { return ArrayPrototype }
 func (c *Composite) Prototype() *Composite  { return c.Proto }
 func (f *Func) Prototype() *Composite       { return FuncPrototype }
+func (t *Trait) Prototype() *Composite      { return TraitPrototype }
+func (v *Variant) Prototype() *Composite    { return v.Kind.Proto }
 func (g *Generator) Prototype() *Composite  { return Object }
 func (r *IntRange) Prototype() *Composite   { return RangePrototype }
 func (r *FloatRange) Prototype() *Composite { return RangePrototype }
 
+// Hash of bytes is a copy of their current contents, unlike arrays, which hash
+// by identity. Bytes modified after being hashed (e.g. as the argument of a
+// memoized function) no longer match the old hash.
+func (b *Bytes) Hash() string     { return string(b.Value) }
 func (n *Null) Hash() string      { return "null" }
 func (i *Integer) Hash() string   { return i.Value.Text(10) }
 func (f *Float) Hash() string     { return strconv.FormatFloat(f.Value, 'f', -1, 64) }
+func (d *Decimal) Hash() string   { return d.Normalize().String() }
+func (i *SizedInt) Hash() string  { return i.Value.Text(10) }
 func (s *Symbol) Hash() string    { return strconv.FormatInt(s.Id, 10) }
 func (b *Bool) Hash() string      { return strconv.FormatBool(b.Value) }
 func (s *String) Hash() string    { return s.Value }
 func (a *Array) Hash() string     { return strconv.FormatUint(uint64(uintptr(unsafe.Pointer(a))), 10) }
 func (c *Composite) Hash() string { return strconv.FormatUint(uint64(uintptr(unsafe.Pointer(c))), 10) }
 func (f *Func) Hash() string      { return strconv.FormatUint(uint64(uintptr(unsafe.Pointer(f))), 10) }
+func (t *Trait) Hash() string     { return strconv.FormatUint(uint64(uintptr(unsafe.Pointer(t))), 10) }
 func (g *Generator) Hash() string { return strconv.FormatUint(uint64(uintptr(unsafe.Pointer(g))), 10) }
+func (v *Variant) Hash() string {
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatUint(uint64(uintptr(unsafe.Pointer(v.Kind))), 10))
+	for _, field := range v.Fields {
+		// fields of different types can hash alike, and their hashes can
+		// contain the separator
+		hash := field.Hash()
+		fmt.Fprintf(&sb, ":%s:%d:%s", field.Type(), len(hash), hash)
+	}
+	return sb.String()
+}
 func (r *IntRange) Hash() string {
 	return fmt.Sprintf("%s:%s:%s", r.Start.Text(10), r.Stop.Text(10), r.Step.Text(10))
 }
@@ -319,6 +430,26 @@ func (r *FloatRange) Contains(f *Float) bool {
 }
 
 func GetProperty(v Value, key PropertyKey) Value {
+	if b, ok := v.(*Bytes); ok {
+		if index, ok := key.(*Integer); ok {
+			if index.Value.Cmp(big.NewInt(0)) == -1 || index.Value.Cmp(big.NewInt(int64(len(b.Value)))) >= 0 {
+				return NullValue
+			}
+
+			return NewInteger(big.NewInt(int64(b.Value[index.Value.Int64()])))
+		}
+	}
+
+	if variant, ok := v.(*Variant); ok {
+		if name, ok := key.(*String); ok {
+			for idx, field := range variant.Kind.Fields {
+				if field == name.Value {
+					return variant.Fields[idx]
+				}
+			}
+		}
+	}
+
 	array, ok1 := v.(*Array)
 	index, ok2 := key.(*Integer)
 	if ok1 && ok2 {
@@ -349,6 +480,28 @@ func GetProperty(v Value, key PropertyKey) Value {
 }
 
 func SetProperty(v Value, key PropertyKey, val Value) error {
+	if b, ok := v.(*Bytes); ok {
+		index, ok := key.(*Integer)
+		if !ok {
+			return fmt.Errorf("cannot use %s as an index", key.Type())
+		}
+
+		if index.Value.Cmp(big.NewInt(0)) == -1 || index.Value.Cmp(big.NewInt(int64(len(b.Value)))) >= 0 {
+			return fmt.Errorf("index %s out of range", index.Value.Text(10))
+		}
+
+		if b.Frozen {
+			return fmt.Errorf("cannot modify frozen bytes")
+		}
+
+		n, ok := val.(*Integer)
+		if !ok || !n.Value.IsInt64() || n.Value.Int64() < 0 || n.Value.Int64() > 255 {
+			return fmt.Errorf("byte value must be an integer between 0 and 255")
+		}
+
+		b.Value[index.Value.Int64()] = byte(n.Value.Int64())
+		return nil
+	}
 	if a, ok := v.(*Array); ok {
 		index, ok := key.(*Integer)
 		if !ok {
@@ -375,50 +528,68 @@ func SetProperty(v Value, key PropertyKey, val Value) error {
 	return nil
 }
 
-func GetOperator(v Value, tok token.Token) *OperatorFunc {
-	var derivedOperators = map[token.Token]FuncType{
-		token.Lt: func(ctx *FuncContext) *Return {
-			gt := GetOperator(ctx.Args[0], token.Gt).Executor(ctx)
-			eq := GetOperator(ctx.Args[0], token.Eq).Executor(ctx)
-			return NewReturn(!gt.Value.(*Bool).Value && !eq.Value.(*Bool).Value)
-		},
-		token.Lte: func(ctx *FuncContext) *Return {
-			gt := GetOperator(ctx.Args[0], token.Gt).Executor(ctx)
-			return NewReturn(!gt.Value.(*Bool).Value)
-		},
-		token.Gte: func(ctx *FuncContext) *Return {
-			gt := GetOperator(ctx.Args[0], token.Gt).Executor(ctx)
-			eq := GetOperator(ctx.Args[0], token.Eq).Executor(ctx)
-			return NewReturn(gt.Value.(*Bool).Value || eq.Value.(*Bool).Value)
-		},
-		token.Neq: func(ctx *FuncContext) *Return {
-			eq := GetOperator(ctx.Args[0], token.Eq).Executor(ctx)
-			return NewReturn(!eq.Value.(*Bool).Value)
-		},
-		token.Assign: func(ctx *FuncContext) *Return {
+// derivedOperators are the operators that every value has unless its prototype
+// chain defines them, implemented in terms of other operators. They are set in
+// init, since they call GetOperator.
+var derivedOperators map[token.Token]*OperatorFunc
+
+func init() {
+	derivedOperators = map[token.Token]*OperatorFunc{
+		token.Lt: {Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			gt := callOperator(ctx, token.Gt)
+			eq := callOperator(ctx, token.Eq)
+			return NewReturn(!IsTruthy(gt) && !IsTruthy(eq))
+		}},
+		token.Lte: {Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			gt := callOperator(ctx, token.Gt)
+			return NewReturn(!IsTruthy(gt))
+		}},
+		token.Gte: {Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			gt := callOperator(ctx, token.Gt)
+			eq := callOperator(ctx, token.Eq)
+			return NewReturn(IsTruthy(gt) || IsTruthy(eq))
+		}},
+		token.Neq: {Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			eq := callOperator(ctx, token.Eq)
+			return NewReturn(!IsTruthy(eq))
+		}},
+		token.Spaceship: {Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			if IsTruthy(callOperator(ctx, token.Gt)) {
+				return &Return{Wrap(1)}
+			}
+			if IsTruthy(callOperator(ctx, token.Eq)) {
+				return &Return{Wrap(0)}
+			}
+			return &Return{Wrap(-1)}
+		}},
+		token.Assign: {Builtin: true, Executor: func(ctx *FuncContext) *Return {
 			return &Return{ctx.Args[0]}
-		},
-	}
-
-	var tokenAliases = map[token.Token]token.Token{
-		token.AddAssign:     token.Add,
-		token.Inc:           token.Add,
-		token.SubAssign:     token.Sub,
-		token.Dec:           token.Sub,
-		token.MulAssign:     token.Mul,
-		token.QuoAssign:     token.Quo,
-		token.PowAssign:     token.Pow,
-		token.RemAssign:     token.Rem,
-		token.LogAndAssign:  token.LogAnd,
-		token.LogOrAssign:   token.LogOr,
-		token.LogNullAssign: token.LogNull,
-		token.BitAndAssign:  token.BitAnd,
-		token.BitOrAssign:   token.BitOr,
-		token.BitXorAssign:  token.BitXor,
-		token.BitShlAssign:  token.BitShl,
-		token.BitShrAssign:  token.BitShr,
+		}},
 	}
+}
 
+// tokenAliases maps assignment and increment operators to the operators they
+// apply.
+var tokenAliases = map[token.Token]token.Token{
+	token.AddAssign:     token.Add,
+	token.Inc:           token.Add,
+	token.SubAssign:     token.Sub,
+	token.Dec:           token.Sub,
+	token.MulAssign:     token.Mul,
+	token.QuoAssign:     token.Quo,
+	token.PowAssign:     token.Pow,
+	token.RemAssign:     token.Rem,
+	token.LogAndAssign:  token.LogAnd,
+	token.LogOrAssign:   token.LogOr,
+	token.LogNullAssign: token.LogNull,
+	token.BitAndAssign:  token.BitAnd,
+	token.BitOrAssign:   token.BitOr,
+	token.BitXorAssign:  token.BitXor,
+	token.BitShlAssign:  token.BitShl,
+	token.BitShrAssign:  token.BitShr,
+}
+
+func GetOperator(v Value, tok token.Token) *OperatorFunc {
 	var op *OperatorFunc
 	proto := v.Prototype()
 	for proto != nil {
@@ -428,6 +599,11 @@ func GetOperator(v Value, tok token.Token) *OperatorFunc {
 			} else {
 				op = proto.Operators[tok]
 			}
+			if op == nil {
+				// operators derived from ones defined by the same prototype
+				// take precedence over those inherited from its prototypes
+				op = proto.derivedOperator(tok)
+			}
 		}
 		if op != nil {
 			break
@@ -441,14 +617,71 @@ func GetOperator(v Value, tok token.Token) *OperatorFunc {
 	}
 
 	if op == nil {
-		if op, ok := derivedOperators[tok]; ok {
-			return &OperatorFunc{Builtin: true, Executor: op}
-		}
+		return derivedOperators[tok]
 	}
 
 	return op
 }
 
+// derivedOperator returns an operator for tok that is derived from another
+// operator of c: comparisons from <=> and != from ==.
+func (c *Composite) derivedOperator(tok token.Token) *OperatorFunc {
+	switch tok {
+	case token.Lt, token.Lte, token.Gt, token.Gte:
+		if c.Operators[token.Spaceship] == nil {
+			return nil
+		}
+		return &OperatorFunc{Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			cmp := Compare(ctx.Interp, ctx.Scope, ctx.This, ctx.Args[0])
+			switch tok {
+			case token.Lt:
+				return NewReturn(cmp < 0)
+			case token.Lte:
+				return NewReturn(cmp <= 0)
+			case token.Gt:
+				return NewReturn(cmp > 0)
+			default:
+				return NewReturn(cmp >= 0)
+			}
+		}}
+	case token.Neq:
+		if c.Operators[token.Eq] == nil {
+			return nil
+		}
+		return &OperatorFunc{Builtin: true, Executor: func(ctx *FuncContext) *Return {
+			return NewReturn(!IsTruthy(callOperator(ctx, token.Eq)))
+		}}
+	}
+	return nil
+}
+
+// callOperator calls the operator tok of ctx.This with the arguments in ctx.
+func callOperator(ctx *FuncContext, tok token.Token) Value {
+	op := GetOperator(ctx.This, tok)
+	if op == nil {
+		ctx.Interp.Throw("operator %s not defined for type %s", tok, ctx.This.Type())
+	}
+	return op.Executor(ctx).Value
+}
+
+// Compare compares a and b with the <=> operator of a, returning a negative
+// number, zero or a positive number if a is less than, equal to or greater
+// than b.
+func Compare(interp Interpreter, scope *Scope, a, b Value) int {
+	ret := callOperator(&FuncContext{Interp: interp, Scope: scope, This: a, Args: []Value{b}}, token.Spaceship)
+	n, ok := ret.(Numeric)
+	if !ok {
+		interp.Throw("operator <=> must return a number, got %s", ret.Type())
+	}
+	switch f := n.Float64(); {
+	case f < 0:
+		return -1
+	case f > 0:
+		return 1
+	}
+	return 0
+}
+
 func IsTruthy(v Value) bool {
 	switch v := v.(type) {
 	case *Null:
@@ -459,14 +692,24 @@ func IsTruthy(v Value) bool {
 		return v.Value.Cmp(big.NewInt(0)) != 0
 	case *Float:
 		return v.Value != 0
+	case *Decimal:
+		return v.Value.Sign() != 0
+	case *SizedInt:
+		return v.Value.Sign() != 0
 	case *String:
 		return v.Value != ""
+	case *Bytes:
+		return len(v.Value) != 0
 	case *Array:
 		return len(v.Elements) != 0
 	case *Composite:
 		return len(v.Properties) != 0
 	case *Func:
 		return true
+	case *Trait:
+		return true
+	case *Variant:
+		return true
 	case *Generator:
 		return true
 	}
@@ -40,8 +40,16 @@ func Wrap(value any) Value {
 		return &String{string(value)}
 	case string:
 		return &String{value}
+	case []byte:
+		return &Bytes{Value: value}
 	case []Value:
 		return &Array{Elements: value}
+	case []any:
+		vals := make([]Value, len(value))
+		for i, v := range value {
+			vals[i] = Wrap(v)
+		}
+		return &Array{Elements: vals}
 	case []int:
 		vals := make([]Value, len(value))
 		for i, v := range value {
@@ -29,17 +29,38 @@ var Index = map[string]Value{
 
 		return NewReturn(string(f))
 	}},
+	"F/readBytes": &Func{Executor: func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 1 {
+			ctx.Interp.Throw("std:fs.readBytes(file): expected 1 argument")
+		}
+		file := ToString(ctx.Interp, ctx.Scope, ctx.Args[0])
+		if file == "" {
+			ctx.Interp.Throw("std:fs.readBytes(file): expected string")
+		}
+
+		f, err := os.ReadFile(file)
+		if err != nil {
+			ctx.Interp.Throw(err.Error())
+		}
+
+		return NewReturn(NewBytes(f))
+	}},
 	"F/writeFile": &Func{Executor: func(ctx *FuncContext) *Return {
 		if len(ctx.Args) < 2 {
 			ctx.Interp.Throw("std:fs.writeFile(file): expected 2 arguments")
 		}
 		file := ToString(ctx.Interp, ctx.Scope, ctx.Args[0])
-		content := ToString(ctx.Interp, ctx.Scope, ctx.Args[1])
+		var content []byte
+		if b, ok := ctx.Args[1].(*Bytes); ok {
+			content = b.Value
+		} else {
+			content = []byte(ToString(ctx.Interp, ctx.Scope, ctx.Args[1]))
+		}
 		if file == "" {
 			ctx.Interp.Throw("std:fs.writeFile(file): expected string")
 		}
 
-		err := os.WriteFile(file, []byte(content), 0644)
+		err := os.WriteFile(file, content, 0644)
 		if err != nil {
 			ctx.Interp.Throw(err.Error())
 		}
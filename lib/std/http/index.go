@@ -0,0 +1,277 @@
+package std_http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib/types"
+)
+
+var Doc = types.StdlibDoc{
+	Name:        "http",
+	Description: "Make HTTP requests and serve HTTP responses.",
+}
+
+var Index = map[string]Value{
+	"F/request": &Func{Executor: func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 2 {
+			ctx.Interp.Throw("std:http.request(method, url, options): expected at least 2 arguments")
+		}
+		method := stringArg(ctx, "request(method, url, options)", 0)
+		url := stringArg(ctx, "request(method, url, options)", 1)
+		options := optionalArg(ctx, 2)
+		return NewReturn(doRequest(ctx, "request(method, url, options)", strings.ToUpper(method), url, GetProperty(options, NewString("body")), options))
+	}},
+	"F/get":    clientMethod(http.MethodGet, false),
+	"F/head":   clientMethod(http.MethodHead, false),
+	"F/delete": clientMethod(http.MethodDelete, false),
+	"F/post":   clientMethod(http.MethodPost, true),
+	"F/put":    clientMethod(http.MethodPut, true),
+	"F/patch":  clientMethod(http.MethodPatch, true),
+
+	"F/serve": &Func{Executor: func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 2 {
+			ctx.Interp.Throw("std:http.serve(port, handler): expected 2 arguments")
+		}
+
+		var addr string
+		switch port := ctx.Args[0].(type) {
+		case Numeric:
+			addr = fmt.Sprintf(":%d", port.Int())
+		case *String:
+			addr = port.Value
+		default:
+			ctx.Interp.Throw("std:http.serve(port, handler): expected port to be an integer or address string")
+		}
+
+		handler := Handler(ctx.Interp, ctx.Scope, ctx.Args[1])
+		var err error
+		ctx.Interp.Block(func() {
+			err = http.ListenAndServe(addr, handler)
+		})
+		if err != nil {
+			ctx.Interp.Throw("std:http.serve(port, handler): " + err.Error())
+		}
+		return &Return{}
+	}},
+}
+
+// clientMethod creates a request function for a fixed method. Methods with a
+// body take it as their second argument, before options.
+func clientMethod(method string, hasBody bool) *Func {
+	name := strings.ToLower(method)
+	signature := name + "(url, options)"
+	if hasBody {
+		signature = name + "(url, body, options)"
+	}
+
+	return &Func{Executor: func(ctx *FuncContext) *Return {
+		url := stringArg(ctx, signature, 0)
+		var body, options Value = NullValue, NullValue
+		if hasBody {
+			body = optionalArg(ctx, 1)
+			options = optionalArg(ctx, 2)
+		} else {
+			options = optionalArg(ctx, 1)
+		}
+		return NewReturn(doRequest(ctx, signature, method, url, body, options))
+	}}
+}
+
+func stringArg(ctx *FuncContext, signature string, index int) string {
+	if len(ctx.Args) <= index {
+		ctx.Interp.Throw("std:http.%s: expected at least %d argument(s)", signature, index+1)
+	}
+	s, ok := ctx.Args[index].(*String)
+	if !ok {
+		ctx.Interp.Throw("std:http.%s: expected string", signature)
+	}
+	return s.Value
+}
+
+func optionalArg(ctx *FuncContext, index int) Value {
+	if len(ctx.Args) <= index {
+		return NullValue
+	}
+	return ctx.Args[index]
+}
+
+// encodeBody converts a Goose value into a request or response body. Strings
+// and bytes are sent as-is; any other non-null value is encoded as JSON.
+func encodeBody(value Value) (body []byte, contentType string, err error) {
+	switch value := value.(type) {
+	case *Null:
+		return nil, "", nil
+	case *String:
+		return []byte(value.Value), "text/plain; charset=utf-8", nil
+	case *Bytes:
+		return value.Value, "application/octet-stream", nil
+	default:
+		body, err = json.Marshal(value.Unwrap())
+		return body, "application/json", err
+	}
+}
+
+func headersToComposite(header http.Header) *Composite {
+	headers := NewComposite()
+	for name, values := range header {
+		SetProperty(headers, NewString(name), NewString(strings.Join(values, ", ")))
+	}
+	return headers
+}
+
+func compositeToHeaders(i Interpreter, scope *Scope, value Value, header http.Header) {
+	headers, ok := value.(*Composite)
+	if !ok {
+		return
+	}
+	for name, v := range headers.Properties[PKString] {
+		header.Set(name, ToString(i, scope, v))
+	}
+}
+
+func doRequest(ctx *FuncContext, signature string, method string, url string, body Value, options Value) *Composite {
+	data, contentType, err := encodeBody(body)
+	if err != nil {
+		ctx.Interp.Throw("std:http.%s: %s", signature, err.Error())
+	}
+
+	var reader io.Reader
+	if data != nil {
+		reader = strings.NewReader(string(data))
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		ctx.Interp.Throw("std:http.%s: %s", signature, err.Error())
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	compositeToHeaders(ctx.Interp, ctx.Scope, GetProperty(options, NewString("headers")), req.Header)
+
+	client := &http.Client{}
+	if timeout, ok := GetProperty(options, NewString("timeout")).(Numeric); ok {
+		client.Timeout = time.Duration(timeout.Float64() * float64(time.Millisecond))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		ctx.Interp.Throw("std:http.%s: %s", signature, err.Error())
+	}
+
+	stream := IsTruthy(GetProperty(options, NewString("stream")))
+	return newResponse(ctx, resp, stream)
+}
+
+// response holds the state of a response body, which is either read eagerly
+// or streamed on demand.
+type response struct {
+	resp   *http.Response
+	reader *bufio.Reader
+	body   []byte
+	done   bool
+}
+
+func (r *response) readAll() ([]byte, error) {
+	if r.done {
+		return r.body, nil
+	}
+	rest, err := io.ReadAll(r.reader)
+	r.body = append(r.body, rest...)
+	r.done = true
+	r.resp.Body.Close()
+	return r.body, err
+}
+
+func newResponse(ctx *FuncContext, resp *http.Response, stream bool) *Composite {
+	r := &response{resp: resp, reader: bufio.NewReader(resp.Body)}
+
+	res := NewComposite()
+	res.Name = "Response"
+	SetProperty(res, NewString("status"), Wrap(resp.StatusCode))
+	SetProperty(res, NewString("statusText"), NewString(http.StatusText(resp.StatusCode)))
+	SetProperty(res, NewString("ok"), Wrap(resp.StatusCode >= 200 && resp.StatusCode < 300))
+	SetProperty(res, NewString("url"), NewString(resp.Request.URL.String()))
+	SetProperty(res, NewString("headers"), headersToComposite(resp.Header))
+
+	if !stream {
+		body, err := r.readAll()
+		if err != nil {
+			ctx.Interp.Throw("std:http: error reading response body: " + err.Error())
+		}
+		SetProperty(res, NewString("body"), NewBytes(body))
+	}
+
+	text := func(ctx *FuncContext, signature string, try bool) *Return {
+		body, err := r.readAll()
+		if err != nil {
+			ctx.Interp.Throw("std:http.Response.%s: %s", signature, err.Error())
+		}
+		if !utf8.Valid(body) {
+			if try {
+				return NewReturn(NullValue)
+			}
+			ctx.Interp.Throw("std:http.Response.%s: body is not valid unicode", signature)
+		}
+		return NewReturn(string(body))
+	}
+
+	decode := func(ctx *FuncContext, signature string, try bool) *Return {
+		body, err := r.readAll()
+		if err != nil {
+			ctx.Interp.Throw("std:http.Response.%s: %s", signature, err.Error())
+		}
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			if try {
+				return NewReturn(NullValue)
+			}
+			ctx.Interp.Throw("std:http.Response.%s: %s", signature, err.Error())
+		}
+		return &Return{Value: Wrap(v)}
+	}
+
+	SetProperty(res, NewString("text"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return text(ctx, "text()", false)
+	}})
+	SetProperty(res, NewString("tryText"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return text(ctx, "tryText()", true)
+	}})
+	SetProperty(res, NewString("json"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return decode(ctx, "json()", false)
+	}})
+	SetProperty(res, NewString("tryJson"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return decode(ctx, "tryJson()", true)
+	}})
+	SetProperty(res, NewString("readLine"), &Func{Executor: func(ctx *FuncContext) *Return {
+		if r.done {
+			return NewReturn(NullValue)
+		}
+		line, err := r.reader.ReadString('\n')
+		if err == io.EOF {
+			r.done = true
+			r.resp.Body.Close()
+			if line == "" {
+				return NewReturn(NullValue)
+			}
+		} else if err != nil {
+			ctx.Interp.Throw("std:http.Response.readLine(): " + err.Error())
+		}
+		return NewReturn(strings.TrimSuffix(line, "\n"))
+	}})
+	SetProperty(res, NewString("close"), &Func{Executor: func(ctx *FuncContext) *Return {
+		r.done = true
+		r.resp.Body.Close()
+		return &Return{}
+	}})
+
+	return res
+}
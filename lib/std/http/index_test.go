@@ -0,0 +1,147 @@
+package std_http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib/std/internal/stdtest"
+)
+
+func call(t *testing.T, name string, args ...Value) Value {
+	t.Helper()
+	ret := Index["F/"+name].(*Func).Executor(&FuncContext{Interp: stdtest.Interp{}, Args: args})
+	return ret.Value
+}
+
+func property(v Value, name string) Value {
+	return GetProperty(v, NewString(name))
+}
+
+func composite(props map[string]Value) *Composite {
+	c := NewComposite()
+	for k, v := range props {
+		SetProperty(c, NewString(k), v)
+	}
+	return c
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	res := call(t, "post", NewString(server.URL), NewArray(Wrap(1), Wrap(2)), composite(map[string]Value{
+		"timeout": Wrap(1000),
+	}))
+
+	if status := property(res, "status").(*Integer).Value.Int64(); status != http.StatusCreated {
+		t.Errorf("status = %d, want %d", status, http.StatusCreated)
+	}
+	headers := property(res, "headers")
+	if method := property(headers, "X-Method").(*String).Value; method != "POST" {
+		t.Errorf("X-Method = %q, want POST", method)
+	}
+	if ct := property(headers, "Content-Type").(*String).Value; ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if body := string(property(res, "body").(*Bytes).Value); body != "[1,2]" {
+		t.Errorf("body = %q, want [1,2]", body)
+	}
+
+	decoded := property(res, "json").(*Func).Executor(&FuncContext{Interp: stdtest.Interp{}}).Value
+	if arr, ok := decoded.(*Array); !ok || len(arr.Elements) != 2 {
+		t.Errorf("json() = %#v, want array of length 2", decoded)
+	}
+}
+
+func TestClientStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "one\ntwo\n")
+	}))
+	defer server.Close()
+
+	res := call(t, "get", NewString(server.URL), composite(map[string]Value{"stream": TrueValue}))
+	readLine := property(res, "readLine").(*Func)
+	for _, want := range []string{"one", "two"} {
+		line := readLine.Executor(&FuncContext{Interp: stdtest.Interp{}}).Value
+		if s, ok := line.(*String); !ok || s.Value != want {
+			t.Errorf("readLine() = %#v, want %q", line, want)
+		}
+	}
+	if line := readLine.Executor(&FuncContext{Interp: stdtest.Interp{}}).Value; line != NullValue {
+		t.Errorf("readLine() at EOF = %#v, want null", line)
+	}
+}
+
+func TestServer(t *testing.T) {
+	routes := composite(map[string]Value{
+		"GET /users/:id": &Func{Executor: func(ctx *FuncContext) *Return {
+			id := property(property(ctx.Args[0], "params"), "id")
+			return NewReturn(composite(map[string]Value{"id": id}))
+		}},
+		"GET /users/me": &Func{Executor: func(ctx *FuncContext) *Return {
+			return NewReturn("me")
+		}},
+		"HEAD /users/me": &Func{Executor: func(ctx *FuncContext) *Return {
+			return &Return{Value: NullValue}
+		}},
+		"/files/:name": &Func{Executor: func(ctx *FuncContext) *Return {
+			return NewReturn("file")
+		}},
+		"/:dir/readme": &Func{Executor: func(ctx *FuncContext) *Return {
+			return NewReturn("readme")
+		}},
+		"POST /echo": &Func{Executor: func(ctx *FuncContext) *Return {
+			return NewReturn(composite(map[string]Value{
+				"status":  Wrap(202),
+				"headers": composite(map[string]Value{"X-Echo": TrueValue}),
+				"body":    property(ctx.Args[0], "body"),
+			}))
+		}},
+	})
+
+	server := httptest.NewServer(Handler(stdtest.Interp{}, nil, routes))
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/users/42", http.StatusOK, `{"id":"42"}`},
+		{"GET", "/users/me", http.StatusOK, "me"},
+		{"HEAD", "/users/42", http.StatusOK, ""},
+		{"HEAD", "/users/me", http.StatusNoContent, ""},
+		{"GET", "/files/readme", http.StatusOK, "readme"},
+		{"POST", "/echo", http.StatusAccepted, "hello"},
+		{"GET", "/echo", http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+		{"GET", "/missing", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, server.URL+test.path, strings.NewReader("hello"))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.status {
+			t.Errorf("%s %s: status = %d, want %d", test.method, test.path, resp.StatusCode, test.status)
+		}
+		if string(body) != test.body {
+			t.Errorf("%s %s: body = %q, want %q", test.method, test.path, body, test.body)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package std_http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+// route is a single entry in a routing table. Patterns are written as
+// "METHOD /path/:param" or "/path/:param"; a trailing "*" matches the rest of
+// the path and binds it to the "*" param. GET routes also answer HEAD
+// requests unless there is a HEAD route for the same path.
+type route struct {
+	pattern  string
+	method   string
+	segments []string
+	handler  *Func
+}
+
+func (r *route) match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	params := map[string]string{}
+	for i, segment := range r.segments {
+		if segment == "*" {
+			params["*"] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = parts[i]
+		} else if segment != parts[i] {
+			return nil, false
+		}
+	}
+	return params, len(parts) == len(r.segments)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+func parseRoutes(i Interpreter, routes *Composite) []*route {
+	table := []*route{}
+	head := map[string]bool{}
+	for pattern, value := range routes.Properties[PKString] {
+		handler, ok := value.(*Func)
+		if !ok {
+			i.Throw("std:http.serve(port, handler): handler for route %q is not a function", pattern)
+		}
+
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			method, path = "", pattern
+		}
+
+		r := &route{
+			pattern:  pattern,
+			method:   strings.ToUpper(method),
+			segments: splitPath(path),
+			handler:  handler,
+		}
+		if r.method == http.MethodHead {
+			head[strings.Join(r.segments, "/")] = true
+		}
+		table = append(table, r)
+	}
+
+	for _, r := range table {
+		if r.method == http.MethodGet && !head[strings.Join(r.segments, "/")] {
+			table = append(table, &route{
+				pattern:  http.MethodHead + r.pattern[len(r.method):],
+				method:   http.MethodHead,
+				segments: r.segments,
+				handler:  r.handler,
+			})
+		}
+	}
+
+	// try the most specific routes first, and break ties by pattern so that
+	// the order doesn't depend on map iteration
+	sort.Slice(table, func(a, b int) bool {
+		sa, sb := specificity(table[a]), specificity(table[b])
+		if sa != sb {
+			return sa > sb
+		}
+		return table[a].pattern < table[b].pattern
+	})
+	return table
+}
+
+func specificity(r *route) int {
+	score := 0
+	for _, segment := range r.segments {
+		switch {
+		case segment == "*":
+			score -= 1000
+		case strings.HasPrefix(segment, ":"):
+			score += 1
+		default:
+			score += 2
+		}
+	}
+	if r.method != "" {
+		score++
+	}
+	return score
+}
+
+// Handler returns an http.Handler that dispatches requests to handler, which
+// is either a function called for every request or a composite mapping route
+// patterns to functions. Handlers run on the interpreter's goroutine, through
+// Enqueue.
+func Handler(i Interpreter, scope *Scope, handler Value) http.Handler {
+	var table []*route
+	switch handler := handler.(type) {
+	case *Func:
+		table = []*route{{segments: []string{"*"}, handler: handler}}
+	case *Composite:
+		table = parseRoutes(i, handler)
+	default:
+		i.Throw("std:http.serve(port, handler): expected handler to be a function or composite of routes")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var fn *Func
+		var params map[string]string
+		methodMismatch := false
+		for _, r := range table {
+			p, ok := r.match(req.URL.Path)
+			if !ok {
+				continue
+			}
+			if r.method != "" && r.method != req.Method {
+				methodMismatch = true
+				continue
+			}
+			fn, params = r.handler, p
+			break
+		}
+
+		if fn == nil {
+			if methodMismatch {
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			} else {
+				http.NotFound(w, req)
+			}
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		done := make(chan struct{})
+		i.Enqueue(func() {
+			defer close(done)
+			defer func() {
+				if r := recover(); r != nil {
+					if _, ok := r.(GooseExit); ok {
+						// exit the program from the interpreter's goroutine
+						panic(r)
+					}
+					fmt.Fprintln(i.Stderr(), r)
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			ret := fn.Executor(&FuncContext{
+				Interp: i,
+				Scope:  scope,
+				This:   Wrap(nil),
+				Args:   []Value{newRequest(req, body, params)},
+			})
+
+			var result Value = NullValue
+			if ret != nil && ret.Value != nil {
+				result = ret.Value
+			}
+			writeResponse(i, scope, w, result)
+		})
+		<-done
+	})
+}
+
+func newRequest(req *http.Request, body []byte, params map[string]string) *Composite {
+	r := NewComposite()
+	r.Name = "Request"
+
+	query := NewComposite()
+	for name, values := range req.URL.Query() {
+		SetProperty(query, NewString(name), NewString(values[0]))
+	}
+
+	p := NewComposite()
+	for name, value := range params {
+		SetProperty(p, NewString(name), NewString(value))
+	}
+
+	SetProperty(r, NewString("method"), NewString(req.Method))
+	SetProperty(r, NewString("path"), NewString(req.URL.Path))
+	SetProperty(r, NewString("url"), NewString(req.URL.RequestURI()))
+	SetProperty(r, NewString("query"), query)
+	SetProperty(r, NewString("params"), p)
+	SetProperty(r, NewString("headers"), headersToComposite(req.Header))
+	SetProperty(r, NewString("body"), NewBytes(body))
+	SetProperty(r, NewString("json"), &Func{Executor: func(ctx *FuncContext) *Return {
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			ctx.Interp.Throw("std:http.Request.json(): " + err.Error())
+		}
+		return &Return{Value: Wrap(v)}
+	}})
+
+	return r
+}
+
+// writeResponse writes a handler's return value. A composite with an integer
+// status property is treated as a response with status, headers and body;
+// anything else is used as the body with status 200 (204 for null).
+func writeResponse(i Interpreter, scope *Scope, w http.ResponseWriter, result Value) {
+	status := http.StatusOK
+	body := result
+
+	if c, ok := result.(*Composite); ok {
+		if s, ok := GetProperty(c, NewString("status")).(*Integer); ok {
+			status = int(s.Value.Int64())
+			body = GetProperty(c, NewString("body"))
+			compositeToHeaders(i, scope, GetProperty(c, NewString("headers")), w.Header())
+		}
+	}
+
+	data, contentType, err := encodeBody(body)
+	if err != nil {
+		i.Throw("std:http.serve(port, handler): cannot encode response: " + err.Error())
+	}
+
+	if data == nil && status == http.StatusOK {
+		status = http.StatusNoContent
+	}
+	if contentType != "" && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(status)
+	w.Write(data)
+}
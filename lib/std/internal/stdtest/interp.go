@@ -0,0 +1,48 @@
+// Package stdtest holds helpers shared by the tests of the standard library.
+package stdtest
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+)
+
+// Interp stands in for the interpreter when calling natives directly. Output
+// is discarded and Throw panics with an error.
+//
+// Functions passed to Enqueue run immediately, unless Queue is set: then they
+// are sent to it, and the test runs them when it receives them, standing in
+// for the interpreter's statement loop.
+type Interp struct {
+	Argv  []string
+	Queue chan func()
+}
+
+func (Interp) Stdin() io.Reader               { return os.Stdin }
+func (Interp) Stdout() io.Writer              { return io.Discard }
+func (Interp) Stderr() io.Writer              { return io.Discard }
+func (Interp) Modules() map[string]*Module    { return nil }
+func (Interp) Fset() *token.FileSet           { return token.NewFileSet() }
+func (Interp) ExecutionStack() []*Module      { return nil }
+func (Interp) Global() *Scope                 { return nil }
+func (Interp) GooseRoot() string              { return "" }
+func (i Interp) Args() []string               { return i.Argv }
+func (Interp) CurrentModule() *Module         { return nil }
+func (Interp) Run() (exitCode int, err error) { return 0, nil }
+func (Interp) Exit(code int)                  { panic(GooseExit{Code: code}) }
+func (Interp) Throw(format string, args ...interface{}) {
+	panic(fmt.Errorf(format, args...))
+}
+
+func (Interp) Block(wait func()) { wait() }
+
+func (i Interp) Enqueue(fn func()) {
+	if i.Queue == nil {
+		fn()
+		return
+	}
+	i.Queue <- fn
+}
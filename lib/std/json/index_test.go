@@ -0,0 +1,28 @@
+package std_json
+
+import (
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+)
+
+func TestEncode(t *testing.T) {
+	nested := NewComposite()
+	SetProperty(nested, NewString("a"), NewArray(Wrap(1), NewArray(Wrap(2), NewString("x")), NullValue))
+
+	tests := []struct {
+		value Value
+		want  string
+	}{
+		{NewArray(), "[]"},
+		{NewArray(Wrap(1), TrueValue, NewString("s")), `[1,true,"s"]`},
+		{nested, `{"a":[1,[2,"x"],null]}`},
+	}
+
+	for _, test := range tests {
+		ret := Index["F/encode"].(*Func).Executor(&FuncContext{Args: []Value{test.value}})
+		if got := ret.Value.(*String).Value; got != test.want {
+			t.Errorf("encode() = %s, want %s", got, test.want)
+		}
+	}
+}
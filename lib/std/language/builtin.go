@@ -1,6 +1,8 @@
 package std_language
 
 import (
+	"fmt"
+	"math"
 	"math/big"
 	"strconv"
 	"strings"
@@ -24,6 +26,20 @@ var Builtins = []types.BuiltinDoc{
 		Desc:        "Try to parse an integer from a string.",
 		Description: "Try to parse an integer from a string. The base can be specified as a second argument. If no base is provided, base 10 is used. If the string is not a valid integer, `null` is returned.",
 	},
+	{
+		Name:        "int.max",
+		Label:       "int.max",
+		Signature:   "int.max: int",
+		Desc:        "The largest 64-bit signed integer.",
+		Description: "The largest 64-bit signed integer, 9223372036854775807. Integers are arbitrary-precision, so values can be larger than this; use sized integers like `i64` to stay within 64 bits.",
+	},
+	{
+		Name:        "int.min",
+		Label:       "int.min",
+		Signature:   "int.min: int",
+		Desc:        "The smallest 64-bit signed integer.",
+		Description: "The smallest 64-bit signed integer, -9223372036854775808. Integers are arbitrary-precision, so values can be smaller than this; use sized integers like `i64` to stay within 64 bits.",
+	},
 	{
 		Name:        "float.parse",
 		Label:       "float.parse(str)",
@@ -38,6 +54,41 @@ var Builtins = []types.BuiltinDoc{
 		Desc:        "Try to parse a float from a string.",
 		Description: "Try to parse a float from a string. If the string is not a valid float, `null` is returned.",
 	},
+	{
+		Name:        "float.infinity",
+		Label:       "float.infinity",
+		Signature:   "float.infinity: float",
+		Desc:        "Positive infinity.",
+		Description: "Positive infinity. Negate it for negative infinity.",
+	},
+	{
+		Name:        "float.nan",
+		Label:       "float.nan",
+		Signature:   "float.nan: float",
+		Desc:        "Not a number.",
+		Description: "Not a number. NaN is not equal to anything, including itself.",
+	},
+	{
+		Name:        "decimal.parse",
+		Label:       "decimal.parse(str)",
+		Signature:   "decimal.parse(s: str, precision?: int, rounding?: str) -> decimal",
+		Desc:        "Parse a decimal from a string.",
+		Description: "Parse a decimal from a string like `\"12.50\"` or `\"1.5e3\"`. Decimals keep every digit they are written with, so `decimal.parse(\"0.1\") + decimal.parse(\"0.2\")` is exactly 0.3.\n\nIf a precision is given, the results of arithmetic on the decimal are rounded to that many digits after the decimal point, using the rounding mode: `\"half-even\"` (the default), `\"half-up\"`, `\"half-down\"`, `\"up\"`, `\"down\"`, `\"ceiling\"` or `\"floor\"`. Without a precision, quotients are rounded to 16 digits. If the string is not a valid decimal, an error is thrown (use `decimal.tryParse` to avoid this).",
+	},
+	{
+		Name:        "decimal.tryParse",
+		Label:       "decimal.tryParse(str)",
+		Signature:   "decimal.tryParse(s: str, precision?: int, rounding?: str) -> decimal | null",
+		Desc:        "Try to parse a decimal from a string.",
+		Description: "Try to parse a decimal from a string, like `decimal.parse`. If the string is not a valid decimal, `null` is returned.",
+	},
+	{
+		Name:        "decimal.from",
+		Label:       "decimal.from(value)",
+		Signature:   "decimal.from(value: int | float | decimal, precision?: int, rounding?: str) -> decimal",
+		Desc:        "Convert a number to a decimal.",
+		Description: "Convert a number to a decimal, with a precision and rounding mode like `decimal.parse`. Floats are converted to the decimal with the fewest digits that converts back to the same float, so `decimal.from(0.1)` is 0.1.\n\nIntegers mixed with decimals in arithmetic are converted to decimals, while decimals mixed with floats are converted to floats.",
+	},
 	{
 		Name:        "bool.parse",
 		Label:       "bool.parse(str)",
@@ -45,13 +96,37 @@ var Builtins = []types.BuiltinDoc{
 		Desc:        "Parse a boolean from a string.",
 		Description: `Parse a boolean from a string. Valid values are "1", "t", "T", "TRUE", "true", "True", "0", "f", "F", "FALSE", "false", and "False". If the string is not a valid boolean, an error is thrown.`,
 	},
+	{
+		Name:        "bytes.from",
+		Label:       "bytes.from(value)",
+		Signature:   "bytes.from(value: string | int[] | bytes, encoding?: string) -> bytes",
+		Desc:        "Create bytes from a string or an array of integers.",
+		Description: `Create bytes from a string or an array of integers. Strings are encoded with the given encoding ("utf-8", "latin1", "ascii", "hex" or "base64"), defaulting to "utf-8". Array elements must be integers between 0 and 255.`,
+	},
+	{
+		Name:        "bytes.alloc",
+		Label:       "bytes.alloc(n)",
+		Signature:   "bytes.alloc(n: int) -> bytes",
+		Desc:        "Create n zeroed bytes.",
+		Description: "Create n zeroed bytes.",
+	},
 }
 
 var Builtin = map[string]Value{
-	"O/int":    IntegerBuiltin,
-	"O/float":  FloatBuiltin,
-	"O/string": StringBuiltin,
-	"O/bool":   BoolBuiltin,
+	"O/int":     IntegerBuiltin,
+	"O/float":   FloatBuiltin,
+	"O/string":  StringBuiltin,
+	"O/bool":    BoolBuiltin,
+	"O/bytes":   BytesBuiltin,
+	"O/decimal": DecimalBuiltin,
+	"O/i8":      SizedIntBuiltins[Int8],
+	"O/i16":     SizedIntBuiltins[Int16],
+	"O/i32":     SizedIntBuiltins[Int32],
+	"O/i64":     SizedIntBuiltins[Int64],
+	"O/u8":      SizedIntBuiltins[Uint8],
+	"O/u16":     SizedIntBuiltins[Uint16],
+	"O/u32":     SizedIntBuiltins[Uint32],
+	"O/u64":     SizedIntBuiltins[Uint64],
 }
 
 func init() {
@@ -60,6 +135,15 @@ func init() {
 	BuiltinSingletons[FloatPrototype] = FloatBuiltin
 	BuiltinSingletons[StringPrototype] = StringBuiltin
 	BuiltinSingletons[BoolPrototype] = BoolBuiltin
+	BuiltinSingletons[BytesPrototype] = BytesBuiltin
+	BuiltinSingletons[DecimalPrototype] = DecimalBuiltin
+	for k, builtin := range SizedIntBuiltins {
+		BuiltinSingletons[SizedIntPrototypes[k]] = builtin
+	}
+
+	for k := IntKind(0); k < NumIntKinds; k++ {
+		Builtins = append(Builtins, sizedIntDocs(k)...)
+	}
 }
 
 var IntegerBuiltin = &Composite{
@@ -68,6 +152,8 @@ var IntegerBuiltin = &Composite{
 	Frozen: true,
 	Properties: Properties{
 		PKString: {
+			"max": NewInteger(big.NewInt(math.MaxInt64)),
+			"min": NewInteger(big.NewInt(math.MinInt64)),
 			"parse": &Func{Executor: func(ctx *FuncContext) *Return {
 				if len(ctx.Args) < 1 {
 					ctx.Interp.Throw("int.parse(s): expected at least 1 argument")
@@ -135,6 +221,8 @@ var FloatBuiltin = &Composite{
 	Frozen: true,
 	Properties: Properties{
 		PKString: {
+			"infinity": NewFloat(math.Inf(1)),
+			"nan":      NewFloat(math.NaN()),
 			"parse": &Func{Executor: func(ctx *FuncContext) *Return {
 				if len(ctx.Args) < 1 {
 					ctx.Interp.Throw("float.parse(s): expected at least 1 argument")
@@ -174,6 +262,154 @@ var FloatBuiltin = &Composite{
 	},
 }
 
+var DecimalBuiltin = &Composite{
+	Name:   "decimal",
+	Proto:  nil,
+	Frozen: true,
+	Properties: Properties{
+		PKString: {
+			"parse": &Func{Executor: func(ctx *FuncContext) *Return {
+				if len(ctx.Args) < 1 {
+					ctx.Interp.Throw("decimal.parse(s): expected at least 1 argument")
+					return &Return{}
+				}
+
+				str, ok := ctx.Args[0].(*String)
+				if !ok {
+					ctx.Interp.Throw("decimal.parse(s): expected string")
+					return &Return{}
+				}
+				d, ok := ParseDecimal(str.Value)
+				if !ok {
+					ctx.Interp.Throw("decimal.parse(s): failed to parse decimal")
+					return &Return{}
+				}
+				precision, rounding := DecimalArgs(ctx, ctx.Args[1:], "decimal.parse(s, precision, rounding)", d)
+				return NewReturn(d.WithPrecision(precision, rounding))
+			}},
+			"tryParse": &Func{Executor: func(ctx *FuncContext) *Return {
+				if len(ctx.Args) < 1 {
+					return ReturnNull
+				}
+
+				str, ok := ctx.Args[0].(*String)
+				if !ok {
+					return ReturnNull
+				}
+				d, ok := ParseDecimal(str.Value)
+				if !ok {
+					return ReturnNull
+				}
+				precision, rounding := DecimalArgs(ctx, ctx.Args[1:], "decimal.tryParse(s, precision, rounding)", d)
+				return NewReturn(d.WithPrecision(precision, rounding))
+			}},
+			"from": &Func{Executor: func(ctx *FuncContext) *Return {
+				if len(ctx.Args) < 1 {
+					ctx.Interp.Throw("decimal.from(value): expected at least 1 argument")
+					return &Return{}
+				}
+
+				var d *Decimal
+				switch value := ctx.Args[0].(type) {
+				case *Decimal:
+					d = value
+				case *Integer:
+					d = DecimalFromInt(value.Value)
+				case *SizedInt:
+					d = DecimalFromInt(value.Value)
+				case *Float:
+					var ok bool
+					if d, ok = DecimalFromFloat(value.Value); !ok {
+						ctx.Interp.Throw("decimal.from(value): cannot convert %s to decimal", value.Hash())
+						return &Return{}
+					}
+				default:
+					ctx.Interp.Throw("decimal.from(value): expected int, float or decimal")
+					return &Return{}
+				}
+				precision, rounding := DecimalArgs(ctx, ctx.Args[1:], "decimal.from(value, precision, rounding)", d)
+				if len(ctx.Args) < 2 {
+					// keep the precision of a decimal
+					precision = d.Precision
+				}
+				return NewReturn(d.WithPrecision(precision, rounding))
+			}},
+		},
+	},
+}
+
+// SizedIntBuiltins are the builtins of each kind of sized integer, like u8.
+var SizedIntBuiltins = func() (builtins [NumIntKinds]*Composite) {
+	for k := range builtins {
+		builtins[k] = sizedIntBuiltin(IntKind(k))
+	}
+	return builtins
+}()
+
+func sizedIntBuiltin(k IntKind) *Composite {
+	convert := func(o Overflow) *Func {
+		return &Func{Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) < 1 {
+				ctx.Interp.Throw("%s.%s(value): expected 1 argument", k, o)
+				return &Return{}
+			}
+			i, err := SizedIntFrom(ctx.Args[0], k, o)
+			if err != nil {
+				ctx.Interp.Throw("%s.%s(value): %s", k, o, err)
+				return &Return{}
+			}
+			return NewReturn(i)
+		}}
+	}
+
+	return &Composite{
+		Name:   k.String(),
+		Proto:  nil,
+		Frozen: true,
+		Properties: Properties{
+			PKString: {
+				"min":      NewInteger(k.Min()),
+				"max":      NewInteger(k.Max()),
+				"bits":     NewInteger(big.NewInt(int64(k.Bits()))),
+				"wrap":     convert(OverflowWrap),
+				"saturate": convert(OverflowSaturate),
+				"checked":  convert(OverflowChecked),
+			},
+		},
+	}
+}
+
+func sizedIntDocs(k IntKind) []types.BuiltinDoc {
+	kind := "signed"
+	if !k.Signed() {
+		kind = "unsigned"
+	}
+	arithmetic := "\n\nArithmetic on the result converts integers to " + k.String() + ", and "
+	return []types.BuiltinDoc{
+		{
+			Name:        k.String() + ".wrap",
+			Label:       k.String() + ".wrap(value)",
+			Signature:   k.String() + ".wrap(value: int | float | decimal | " + k.String() + ") -> " + k.String(),
+			Desc:        fmt.Sprintf("Convert a number to a %d-bit %s integer that wraps around on overflow.", k.Bits(), kind),
+			Description: fmt.Sprintf("Convert a number to a %d-bit %s integer, truncating fractions towards zero and wrapping around in two's complement if it is out of range.", k.Bits(), kind) + arithmetic + "wraps around when a result is out of range. Bitwise operators and shifts always wrap around.",
+		},
+		{
+			Name:        k.String() + ".saturate",
+			Label:       k.String() + ".saturate(value)",
+			Signature:   k.String() + ".saturate(value: int | float | decimal | " + k.String() + ") -> " + k.String(),
+			Desc:        fmt.Sprintf("Convert a number to a %d-bit %s integer that saturates on overflow.", k.Bits(), kind),
+			Description: fmt.Sprintf("Convert a number to a %d-bit %s integer, truncating fractions towards zero and clamping it to `%s.min` or `%s.max` if it is out of range.", k.Bits(), kind, k, k) + arithmetic + "clamps results that are out of range. Bitwise operators and shifts always wrap around.",
+		},
+		{
+			Name:        k.String() + ".checked",
+			Label:       k.String() + ".checked(value)",
+			Signature:   k.String() + ".checked(value: int | float | decimal | " + k.String() + ") -> " + k.String(),
+			Desc:        fmt.Sprintf("Convert a number to a %d-bit %s integer that throws on overflow.", k.Bits(), kind),
+			Description: fmt.Sprintf("Convert a number to a %d-bit %s integer, truncating fractions towards zero and throwing an error if it is out of range.", k.Bits(), kind) + arithmetic + "throws an error when a result is out of range. Bitwise operators and shifts always wrap around.",
+		},
+	}
+}
+
 var StringBuiltin = &Composite{
 	Name:       "string",
 	Proto:      nil,
@@ -209,3 +445,65 @@ var BoolBuiltin = &Composite{
 		},
 	},
 }
+
+var BytesBuiltin = &Composite{
+	Name:   "bytes",
+	Proto:  nil,
+	Frozen: true,
+	Properties: Properties{
+		PKString: {
+			"from": &Func{Executor: func(ctx *FuncContext) *Return {
+				if len(ctx.Args) < 1 {
+					ctx.Interp.Throw("bytes.from(value): expected at least 1 argument")
+					return &Return{}
+				}
+
+				switch value := ctx.Args[0].(type) {
+				case *String:
+					encoding := "utf-8"
+					if len(ctx.Args) > 1 {
+						if e, ok := ctx.Args[1].(*String); ok {
+							encoding = e.Value
+						} else {
+							ctx.Interp.Throw("bytes.from(s, encoding): expected string")
+						}
+					}
+					b, err := EncodeString(value.Value, encoding)
+					if err != nil {
+						ctx.Interp.Throw("bytes.from(s, encoding): " + err.Error())
+					}
+					return NewReturn(NewBytes(b))
+				case *Array:
+					b := make([]byte, len(value.Elements))
+					for i, el := range value.Elements {
+						n, ok := el.(*Integer)
+						if !ok || !n.Value.IsInt64() || n.Value.Int64() < 0 || n.Value.Int64() > 255 {
+							ctx.Interp.Throw("bytes.from(array): element %d is not an integer between 0 and 255", i)
+						}
+						b[i] = byte(n.Value.Int64())
+					}
+					return NewReturn(NewBytes(b))
+				case *Bytes:
+					return NewReturn(NewBytes(append([]byte{}, value.Value...)))
+				default:
+					ctx.Interp.Throw("bytes.from(value): expected string, array or bytes")
+					return &Return{}
+				}
+			}},
+			"alloc": &Func{Executor: func(ctx *FuncContext) *Return {
+				if len(ctx.Args) < 1 {
+					ctx.Interp.Throw("bytes.alloc(n): expected 1 argument")
+					return &Return{}
+				}
+				n, ok := ctx.Args[0].(*Integer)
+				if !ok || n.Value.Sign() < 0 {
+					ctx.Interp.Throw("bytes.alloc(n): expected non-negative integer")
+				}
+				if !n.Value.IsInt64() || n.Value.Int64() > MaxBytes {
+					ctx.Interp.Throw("bytes.alloc(n): size %s is too large", n.Value.Text(10))
+				}
+				return NewReturn(NewBytes(make([]byte, n.Value.Int64())))
+			}},
+		},
+	},
+}
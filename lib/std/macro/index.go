@@ -0,0 +1,359 @@
+package std_macro
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib/types"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+)
+
+var Doc = types.StdlibDoc{
+	Name:        "macro",
+	Description: "Token trees for writing procedural macros.",
+}
+
+// TokenPrototype is the prototype of single tokens in a token tree.
+var TokenPrototype = &Composite{
+	Name:       "Token",
+	Proto:      Object,
+	Properties: Properties{PKString: {}},
+	Operators:  Operators{},
+}
+
+// GroupPrototype is the prototype of the token trees between matching
+// brackets.
+var GroupPrototype = &Composite{
+	Name:       "Group",
+	Proto:      Object,
+	Properties: Properties{PKString: {}},
+	Operators:  Operators{},
+}
+
+func init() {
+	toString := &Func{Executor: func(ctx *FuncContext) *Return {
+		return NewReturn(NewString(sourceOf(ctx, ctx.This)))
+	}}
+	TokenPrototype.Properties[PKString]["toString"] = toString
+	GroupPrototype.Properties[PKString]["toString"] = toString
+}
+
+var closers = map[string]string{
+	"(":  ")",
+	"[":  "]",
+	"{":  "}",
+	"#[": "]",
+}
+
+var Index = map[string]Value{
+	"S/Token": &Func{
+		NewableProto: TokenPrototype,
+		Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) < 2 {
+				ctx.Interp.Throw("Token(kind, literal): expected at least 2 arguments")
+			}
+			kind, ok1 := ctx.Args[0].(*String)
+			literal, ok2 := ctx.Args[1].(*String)
+			if !ok1 || !ok2 {
+				ctx.Interp.Throw("Token(kind, literal): expected strings")
+			}
+			return &Return{Value: newToken(kind.Value, literal.Value, position(ctx.Args[2:]))}
+		},
+	},
+	"S/Group": &Func{
+		NewableProto: GroupPrototype,
+		Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) < 2 {
+				ctx.Interp.Throw("Group(delimiter, tokens): expected at least 2 arguments")
+			}
+			delimiter, ok := ctx.Args[0].(*String)
+			if !ok || closers[delimiter.Value] == "" {
+				ctx.Interp.Throw("Group(delimiter, tokens): expected one of \"(\", \"[\", \"{\" or \"#[\" as the delimiter")
+			}
+			tokens, ok := ctx.Args[1].(*Array)
+			if !ok {
+				ctx.Interp.Throw("Group(delimiter, tokens): expected an array of token trees")
+			}
+			return &Return{Value: newGroup(delimiter.Value, tokens.Elements, position(ctx.Args[2:]))}
+		},
+	},
+	"F/eval": &Func{Executor: func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 1 {
+			ctx.Interp.Throw("macro.eval(tree): expected at least 1 argument")
+		}
+		tree := ctx.Args[0]
+		if group, ok := tree.(*Composite); ok && group.Proto == GroupPrototype {
+			// the contents of a group, without its delimiters
+			tree = group.Properties[PKString]["tokens"]
+		}
+		return NewReturn(NewString(sourceOf(ctx, tree)))
+	}},
+	"F/tokenize": &Func{Executor: func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 1 {
+			ctx.Interp.Throw("macro.tokenize(source): expected at least 1 argument")
+		}
+		src, ok := ctx.Args[0].(*String)
+		if !ok {
+			ctx.Interp.Throw("macro.tokenize(source): expected string")
+		}
+		tokens, err := Tokenize(src.Value)
+		if err != nil {
+			ctx.Interp.Throw("macro.tokenize(source): %s", err)
+		}
+		return NewReturn(tokens)
+	}},
+}
+
+// Tokenize scans src into token trees.
+func Tokenize(src string) (*Array, error) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("macro:tokenize", -1, len(src))
+
+	var errors scanner.ErrorList
+	var s scanner.Scanner
+	s.Init(file, []byte(src), errors.Add)
+
+	var tokens []*ast.MacroToken
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.Comment {
+			tokens = append(tokens, &ast.MacroToken{Pos: pos, Tok: tok, Lit: lit})
+		}
+	}
+	if err := errors.Err(); err != nil {
+		return nil, err
+	}
+	return TokenTrees(fset, tokens), nil
+}
+
+// TokenTrees converts tokens into token trees: a Group for the tokens between
+// each pair of matching brackets, and a Token for everything else. The parts
+// of a string literal become a single Token.
+func TokenTrees(fset *token.FileSet, tokens []*ast.MacroToken) *Array {
+	var trees []Value
+	for {
+		var inner []Value
+		inner, tokens = tokenTrees(fset, tokens)
+		trees = append(trees, inner...)
+		if len(tokens) == 0 {
+			return NewArray(trees...)
+		}
+		// an unmatched closing bracket
+		trees = append(trees, newToken("operator", tokens[0].String(), fset.Position(tokens[0].Pos)))
+		tokens = tokens[1:]
+	}
+}
+
+// tokenTrees converts tokens up to the first unmatched closing bracket, and
+// returns the tokens from that bracket on.
+func tokenTrees(fset *token.FileSet, tokens []*ast.MacroToken) ([]Value, []*ast.MacroToken) {
+	var trees []Value
+	for len(tokens) > 0 {
+		t := tokens[0]
+		pos := fset.Position(t.Pos)
+		switch t.Tok {
+		case token.LParen, token.LBracket, token.LBrace, token.HashLBracket:
+			inner, rest := tokenTrees(fset, tokens[1:])
+			if len(rest) > 0 {
+				rest = rest[1:]
+			}
+			trees = append(trees, newGroup(t.String(), inner, pos))
+			tokens = rest
+			continue
+		case token.RParen, token.RBracket, token.RBrace:
+			return trees, tokens
+		case token.StringStart:
+			n := stringLength(tokens)
+			trees = append(trees, newToken("string", ast.TokensString(tokens[:n]), pos))
+			tokens = tokens[n:]
+			continue
+		}
+		trees = append(trees, newToken(kindOf(t.Tok), t.String(), pos))
+		tokens = tokens[1:]
+	}
+	return trees, nil
+}
+
+// stringLength returns the number of tokens in the string literal at the
+// start of tokens, including the strings nested in its interpolations.
+func stringLength(tokens []*ast.MacroToken) int {
+	depth := 0
+	for i, t := range tokens {
+		switch t.Tok {
+		case token.StringStart:
+			depth++
+		case token.StringEnd:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
+
+func kindOf(tok token.Token) string {
+	switch {
+	case tok == token.Ident:
+		return "ident"
+	case tok == token.Int:
+		return "int"
+	case tok == token.Float:
+		return "float"
+	case tok.IsKeyword():
+		return "keyword"
+	default:
+		return "operator"
+	}
+}
+
+func position(args []Value) token.Position {
+	var pos token.Position
+	if len(args) > 0 {
+		if line, ok := args[0].(*Integer); ok {
+			pos.Line = int(line.Int64())
+		}
+	}
+	if len(args) > 1 {
+		if column, ok := args[1].(*Integer); ok {
+			pos.Column = int(column.Int64())
+		}
+	}
+	return pos
+}
+
+func newToken(kind, literal string, pos token.Position) *Composite {
+	return &Composite{
+		Proto: TokenPrototype,
+		Properties: Properties{PKString: {
+			"kind":    NewString(kind),
+			"literal": NewString(literal),
+			"line":    NewInteger(big.NewInt(int64(pos.Line))),
+			"column":  NewInteger(big.NewInt(int64(pos.Column))),
+		}},
+		Operators: Operators{},
+	}
+}
+
+func newGroup(delimiter string, tokens []Value, pos token.Position) *Composite {
+	return &Composite{
+		Proto: GroupPrototype,
+		Properties: Properties{PKString: {
+			"kind":      NewString("group"),
+			"delimiter": NewString(delimiter),
+			"tokens":    NewArray(tokens...),
+			"line":      NewInteger(big.NewInt(int64(pos.Line))),
+			"column":    NewInteger(big.NewInt(int64(pos.Column))),
+		}},
+		Operators: Operators{},
+	}
+}
+
+func sourceOf(ctx *FuncContext, v Value) string {
+	src, ok := Source(v)
+	if !ok {
+		ctx.Interp.Throw("macro.eval(tree): expected a token tree, an array of token trees or a string")
+	}
+	return src
+}
+
+// Source returns the source text of a token tree, an array of token trees or
+// a string, which is used as is. Tokens are separated by a space unless they
+// were adjacent where they were written. ok is false if v is none of these.
+func Source(v Value) (src string, ok bool) {
+	var sb strings.Builder
+	ok = writeSource(&sb, v)
+	return sb.String(), ok
+}
+
+func writeSource(sb *strings.Builder, v Value) bool {
+	switch v := v.(type) {
+	case *String:
+		sb.WriteString(v.Value)
+		return true
+	case *Array:
+		return writeTrees(sb, v.Elements)
+	case *Composite:
+		props := v.Properties[PKString]
+		switch v.Proto {
+		case TokenPrototype:
+			literal, ok := props["literal"].(*String)
+			if !ok {
+				return false
+			}
+			sb.WriteString(literal.Value)
+			return true
+		case GroupPrototype:
+			delimiter, ok1 := props["delimiter"].(*String)
+			tokens, ok2 := props["tokens"].(*Array)
+			if !ok1 || !ok2 || closers[delimiter.Value] == "" {
+				return false
+			}
+			// the closing bracket is spaced like the opening one, whose
+			// position is the only one that is known
+			space := ""
+			line, _, _ := treePosition(v)
+			if line > 0 && len(tokens.Elements) > 0 && !adjacent(v, tokens.Elements[0]) {
+				space = " "
+			}
+			sb.WriteString(delimiter.Value + space)
+			if !writeTrees(sb, tokens.Elements) {
+				return false
+			}
+			sb.WriteString(space + closers[delimiter.Value])
+			return true
+		}
+	}
+	return false
+}
+
+func writeTrees(sb *strings.Builder, trees []Value) bool {
+	for i, tree := range trees {
+		if i > 0 && (isGroup(trees[i-1]) || !adjacent(trees[i-1], tree)) {
+			sb.WriteByte(' ')
+		}
+		if !writeSource(sb, tree) {
+			return false
+		}
+	}
+	return true
+}
+
+func isGroup(v Value) bool {
+	c, ok := v.(*Composite)
+	return ok && c.Proto == GroupPrototype
+}
+
+// adjacent reports whether b was written directly after a, which is a token
+// or the opening bracket of a group.
+func adjacent(a, b Value) bool {
+	aLine, aColumn, aText := treePosition(a)
+	bLine, bColumn, _ := treePosition(b)
+	return aLine > 0 && aLine == bLine && aColumn+len(aText) == bColumn
+}
+
+// treePosition returns the position of a token tree, and the text of the
+// token or the opening bracket of the group.
+func treePosition(v Value) (line, column int, text string) {
+	c, ok := v.(*Composite)
+	if !ok || (c.Proto != TokenPrototype && c.Proto != GroupPrototype) {
+		return 0, 0, ""
+	}
+	props := c.Properties[PKString]
+	l, ok1 := props["line"].(*Integer)
+	col, ok2 := props["column"].(*Integer)
+	t, ok3 := props["literal"].(*String)
+	if c.Proto == GroupPrototype {
+		t, ok3 = props["delimiter"].(*String)
+	}
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, ""
+	}
+	return int(l.Int64()), int(col.Int64()), t.Value
+}
@@ -0,0 +1,66 @@
+package std_macro
+
+import (
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+)
+
+func TestTokenize(t *testing.T) {
+	trees, err := Tokenize(`SELECT f(a, [b]) FROM "t${x}" WHERE n >= 1.5 end`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, tree := range trees.Elements {
+		kinds = append(kinds, tree.(*Composite).Properties[PKString]["kind"].(*String).Value)
+	}
+	want := []string{"ident", "ident", "group", "ident", "string", "ident", "ident", "operator", "float", "keyword"}
+	if len(kinds) != len(want) {
+		t.Fatalf("got kinds %q, want %q", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("tree %d has kind %s, want %s", i, kinds[i], want[i])
+		}
+	}
+
+	group := trees.Elements[2].(*Composite).Properties[PKString]
+	if n := len(group["tokens"].(*Array).Elements); n != 3 {
+		t.Errorf("group has %d trees, want 3", n)
+	}
+	if column := group["column"].(*Integer).Int64(); column != 9 {
+		t.Errorf("group at column %d, want 9", column)
+	}
+}
+
+func TestSource(t *testing.T) {
+	for _, src := range []string{
+		`SELECT f(a, [b]) FROM "t${x}" WHERE n >= 1.5`,
+		`#[1, 2] { a: "b" }`,
+	} {
+		trees, err := Tokenize(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := Source(trees); !ok || got != src {
+			t.Errorf("Source(Tokenize(%q)) = %q, %t", src, got, ok)
+		}
+	}
+
+	// created tokens have no position, so they are separated by spaces
+	trees := NewArray(
+		newToken("ident", "a", token.Position{}),
+		newGroup("(", []Value{newToken("int", "1", token.Position{})}, token.Position{}),
+		NewString("+ b"),
+	)
+	if got, ok := Source(trees); !ok || got != "a (1) + b" {
+		t.Errorf("Source(trees) = %q, %t", got, ok)
+	}
+
+	if _, ok := Source(NewArray(NewFloat(1))); ok {
+		t.Errorf("Source accepted a float")
+	}
+}
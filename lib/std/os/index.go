@@ -0,0 +1,318 @@
+package std_os
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib/types"
+)
+
+var Doc = types.StdlibDoc{
+	Name:        "os",
+	Description: "Interact with the operating system: arguments, environment, signals and subprocesses.",
+}
+
+var signals = map[string]os.Signal{
+	"SIGINT":  os.Interrupt,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+var signalMu sync.Mutex
+var signalChannels = map[string]chan os.Signal{}
+
+var Index = map[string]Value{
+	"C/SIGINT":  NewString("SIGINT"),
+	"C/SIGTERM": NewString("SIGTERM"),
+
+	"F/argv": &Func{Executor: func(ctx *FuncContext) *Return {
+		return NewReturn(ctx.Interp.Args())
+	}},
+	"F/getpid": &Func{Executor: func(ctx *FuncContext) *Return {
+		return &Return{Value: Wrap(os.Getpid())}
+	}},
+	"F/hostname": &Func{Executor: func(ctx *FuncContext) *Return {
+		name, err := os.Hostname()
+		if err != nil {
+			ctx.Interp.Throw("std:os.hostname(): " + err.Error())
+		}
+		return NewReturn(name)
+	}},
+	"F/exit": &Func{Executor: func(ctx *FuncContext) *Return {
+		code := 0
+		if len(ctx.Args) > 0 {
+			n, ok := ctx.Args[0].(Numeric)
+			if !ok {
+				ctx.Interp.Throw("std:os.exit(code): expected integer")
+			}
+			code = n.Int()
+		}
+		ctx.Interp.Exit(code)
+		return &Return{}
+	}},
+
+	"F/getenv": &Func{Executor: func(ctx *FuncContext) *Return {
+		name := stringArg(ctx, "getenv(name)", 0)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return NewReturn(NullValue)
+		}
+		return NewReturn(value)
+	}},
+	"F/setenv": &Func{Executor: func(ctx *FuncContext) *Return {
+		name := stringArg(ctx, "setenv(name, value)", 0)
+		if len(ctx.Args) < 2 {
+			ctx.Interp.Throw("std:os.setenv(name, value): expected 2 arguments")
+		}
+		value := ToString(ctx.Interp, ctx.Scope, ctx.Args[1])
+		if err := os.Setenv(name, value); err != nil {
+			ctx.Interp.Throw("std:os.setenv(name, value): " + err.Error())
+		}
+		return &Return{}
+	}},
+	"F/unsetenv": &Func{Executor: func(ctx *FuncContext) *Return {
+		name := stringArg(ctx, "unsetenv(name)", 0)
+		if err := os.Unsetenv(name); err != nil {
+			ctx.Interp.Throw("std:os.unsetenv(name): " + err.Error())
+		}
+		return &Return{}
+	}},
+	"F/env": &Func{Executor: func(ctx *FuncContext) *Return {
+		env := NewComposite()
+		for _, kv := range os.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			SetProperty(env, NewString(name), NewString(value))
+		}
+		return NewReturn(env)
+	}},
+
+	"F/cwd": &Func{Executor: func(ctx *FuncContext) *Return {
+		dir, err := os.Getwd()
+		if err != nil {
+			ctx.Interp.Throw("std:os.cwd(): " + err.Error())
+		}
+		return NewReturn(dir)
+	}},
+	"F/chdir": &Func{Executor: func(ctx *FuncContext) *Return {
+		dir := stringArg(ctx, "chdir(dir)", 0)
+		if err := os.Chdir(dir); err != nil {
+			ctx.Interp.Throw("std:os.chdir(dir): " + err.Error())
+		}
+		return &Return{}
+	}},
+
+	"F/signal": &Func{Executor: func(ctx *FuncContext) *Return {
+		name := stringArg(ctx, "signal(sig, handler)", 0)
+		sig, ok := signals[name]
+		if !ok {
+			ctx.Interp.Throw("std:os.signal(sig, handler): unknown signal %s", name)
+		}
+
+		var handler *Func
+		if len(ctx.Args) > 1 {
+			switch h := ctx.Args[1].(type) {
+			case *Func:
+				handler = h
+			case *Null:
+			default:
+				ctx.Interp.Throw("std:os.signal(sig, handler): expected handler to be a function or null")
+			}
+		}
+
+		signalMu.Lock()
+		defer signalMu.Unlock()
+
+		if ch, ok := signalChannels[name]; ok {
+			signal.Stop(ch)
+			close(ch)
+			delete(signalChannels, name)
+		}
+
+		if handler == nil {
+			return &Return{}
+		}
+
+		ch := make(chan os.Signal, 1)
+		signalChannels[name] = ch
+		signal.Notify(ch, sig)
+
+		// handlers run on the interpreter's goroutine, between statements
+		interp := ctx.Interp
+		scope := ctx.Scope
+		go func() {
+			for range ch {
+				interp.Enqueue(func() {
+					handler.Executor(&FuncContext{
+						Interp: interp,
+						Scope:  scope,
+						This:   Wrap(nil),
+						Args:   []Value{NewString(name)},
+					})
+				})
+			}
+		}()
+
+		return &Return{}
+	}},
+
+	"F/exec": &Func{Executor: func(ctx *FuncContext) *Return {
+		cmd := command(ctx, "exec(cmd, args, input)")
+		if len(ctx.Args) > 2 {
+			if _, ok := ctx.Args[2].(*Null); !ok {
+				cmd.Stdin = strings.NewReader(ToString(ctx.Interp, ctx.Scope, ctx.Args[2]))
+			}
+		}
+
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		code := exitCode(ctx, "exec(cmd, args, input)", cmd.Run())
+
+		result := NewComposite()
+		SetProperty(result, NewString("code"), Wrap(code))
+		SetProperty(result, NewString("stdout"), NewString(stdout.String()))
+		SetProperty(result, NewString("stderr"), NewString(stderr.String()))
+		return NewReturn(result)
+	}},
+	"F/spawn": &Func{Executor: func(ctx *FuncContext) *Return {
+		cmd := command(ctx, "spawn(cmd, args)")
+
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			ctx.Interp.Throw("std:os.spawn(cmd, args): " + err.Error())
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			ctx.Interp.Throw("std:os.spawn(cmd, args): " + err.Error())
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			ctx.Interp.Throw("std:os.spawn(cmd, args): " + err.Error())
+		}
+
+		if err := cmd.Start(); err != nil {
+			ctx.Interp.Throw("std:os.spawn(cmd, args): " + err.Error())
+		}
+
+		return NewReturn(newProcess(cmd, stdin, bufio.NewReader(stdout), bufio.NewReader(stderr)))
+	}},
+}
+
+func stringArg(ctx *FuncContext, signature string, index int) string {
+	if len(ctx.Args) <= index {
+		ctx.Interp.Throw("std:os.%s: expected at least %d argument(s)", signature, index+1)
+	}
+	s, ok := ctx.Args[index].(*String)
+	if !ok {
+		ctx.Interp.Throw("std:os.%s: expected string", signature)
+	}
+	return s.Value
+}
+
+// command builds an *exec.Cmd from a command name and an optional array of
+// arguments. The child inherits the environment and working directory.
+func command(ctx *FuncContext, signature string) *exec.Cmd {
+	name := stringArg(ctx, signature, 0)
+
+	var args []string
+	if len(ctx.Args) > 1 {
+		switch a := ctx.Args[1].(type) {
+		case *Array:
+			for _, arg := range a.Elements {
+				args = append(args, ToString(ctx.Interp, ctx.Scope, arg))
+			}
+		case *Null:
+		default:
+			ctx.Interp.Throw("std:os.%s: expected args to be an array", signature)
+		}
+	}
+
+	return exec.Command(name, args...)
+}
+
+// exitCode converts the result of waiting on a command into an exit code,
+// throwing if the command could not be run at all.
+func exitCode(ctx *FuncContext, signature string, err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	ctx.Interp.Throw("std:os.%s: %s", signature, err.Error())
+	return -1
+}
+
+func newProcess(cmd *exec.Cmd, stdin io.WriteCloser, stdout *bufio.Reader, stderr *bufio.Reader) *Composite {
+	process := NewComposite()
+	process.Name = "Process"
+
+	readLine := func(ctx *FuncContext, r *bufio.Reader, signature string) *Return {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			ctx.Interp.Throw("std:os.Process.%s: %s", signature, err.Error())
+		}
+		if line == "" && err == io.EOF {
+			return NewReturn(NullValue)
+		}
+		return NewReturn(strings.TrimSuffix(line, "\n"))
+	}
+
+	readAll := func(ctx *FuncContext, r *bufio.Reader, signature string) *Return {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			ctx.Interp.Throw("std:os.Process.%s: %s", signature, err.Error())
+		}
+		return NewReturn(string(data))
+	}
+
+	SetProperty(process, NewString("pid"), Wrap(cmd.Process.Pid))
+	SetProperty(process, NewString("write"), &Func{Executor: func(ctx *FuncContext) *Return {
+		if len(ctx.Args) < 1 {
+			ctx.Interp.Throw("std:os.Process.write(data): expected 1 argument")
+		}
+		_, err := io.WriteString(stdin, ToString(ctx.Interp, ctx.Scope, ctx.Args[0]))
+		if err != nil {
+			ctx.Interp.Throw("std:os.Process.write(data): " + err.Error())
+		}
+		return &Return{}
+	}})
+	SetProperty(process, NewString("closeStdin"), &Func{Executor: func(ctx *FuncContext) *Return {
+		stdin.Close()
+		return &Return{}
+	}})
+	SetProperty(process, NewString("readLine"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return readLine(ctx, stdout, "readLine()")
+	}})
+	SetProperty(process, NewString("readErrLine"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return readLine(ctx, stderr, "readErrLine()")
+	}})
+	SetProperty(process, NewString("read"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return readAll(ctx, stdout, "read()")
+	}})
+	SetProperty(process, NewString("readErr"), &Func{Executor: func(ctx *FuncContext) *Return {
+		return readAll(ctx, stderr, "readErr()")
+	}})
+	SetProperty(process, NewString("kill"), &Func{Executor: func(ctx *FuncContext) *Return {
+		if err := cmd.Process.Kill(); err != nil {
+			ctx.Interp.Throw("std:os.Process.kill(): " + err.Error())
+		}
+		return &Return{}
+	}})
+	SetProperty(process, NewString("wait"), &Func{Executor: func(ctx *FuncContext) *Return {
+		stdin.Close()
+		return &Return{Value: Wrap(exitCode(ctx, "Process.wait()", cmd.Wait()))}
+	}})
+
+	return process
+}
@@ -0,0 +1,135 @@
+package std_os
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib/std/internal/stdtest"
+)
+
+func call(t *testing.T, interp Interpreter, name string, args ...Value) Value {
+	t.Helper()
+	ret := Index["F/"+name].(*Func).Executor(&FuncContext{Interp: interp, Args: args})
+	return ret.Value
+}
+
+func property(v Value, name string) Value {
+	return GetProperty(v, NewString(name))
+}
+
+func TestArgv(t *testing.T) {
+	interp := stdtest.Interp{Argv: []string{"a", "b c"}}
+	got := call(t, interp, "argv").Unwrap()
+	if fmt.Sprint(got) != "[a b c]" {
+		t.Errorf("argv() = %v, want [a b c]", got)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	interp := stdtest.Interp{}
+	name := NewString("GOOSE_OS_TEST")
+	t.Setenv("GOOSE_OS_TEST", "")
+	os.Unsetenv("GOOSE_OS_TEST")
+
+	if got := call(t, interp, "getenv", name); got != NullValue {
+		t.Errorf("getenv of an unset variable = %v, want null", got.Unwrap())
+	}
+	call(t, interp, "setenv", name, NewString("1"))
+	if got := call(t, interp, "getenv", name).Unwrap(); got != "1" {
+		t.Errorf("getenv after setenv = %v, want 1", got)
+	}
+	if got := property(call(t, interp, "env"), "GOOSE_OS_TEST").Unwrap(); got != "1" {
+		t.Errorf("env().GOOSE_OS_TEST = %v, want 1", got)
+	}
+	call(t, interp, "unsetenv", name)
+	if got := call(t, interp, "getenv", name); got != NullValue {
+		t.Errorf("getenv after unsetenv = %v, want null", got.Unwrap())
+	}
+}
+
+func TestExec(t *testing.T) {
+	interp := stdtest.Interp{}
+	sh := NewString("sh")
+
+	result := call(t, interp, "exec", sh, NewArray(NewString("-c"), NewString("cat; echo oops >&2; exit 3")), NewString("input"))
+	if got := property(result, "code").Unwrap(); fmt.Sprint(got) != "3" {
+		t.Errorf("code = %v, want 3", got)
+	}
+	if got := property(result, "stdout").Unwrap(); got != "input" {
+		t.Errorf("stdout = %q, want %q", got, "input")
+	}
+	if got := property(result, "stderr").Unwrap(); got != "oops\n" {
+		t.Errorf("stderr = %q, want %q", got, "oops\n")
+	}
+
+	result = call(t, interp, "exec", sh, NewArray(NewString("-c"), NewString("true")))
+	if got := property(result, "code").Unwrap(); fmt.Sprint(got) != "0" {
+		t.Errorf("code = %v, want 0", got)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("exec of a missing command did not throw")
+			}
+		}()
+		call(t, interp, "exec", NewString("goose-os-test-missing-command"))
+	}()
+}
+
+func TestSpawn(t *testing.T) {
+	interp := stdtest.Interp{}
+	process := call(t, interp, "spawn", NewString("sh"), NewArray(NewString("-c"), NewString("read line; echo \"got $line\"; echo done >&2; exit 2")))
+
+	method := func(name string, args ...Value) Value {
+		return property(process, name).(*Func).Executor(&FuncContext{Interp: interp, Args: args}).Value
+	}
+
+	method("write", NewString("hello\n"))
+	if got := method("readLine").Unwrap(); got != "got hello" {
+		t.Errorf("readLine() = %v, want %q", got, "got hello")
+	}
+	if got := method("readErrLine").Unwrap(); got != "done" {
+		t.Errorf("readErrLine() = %v, want %q", got, "done")
+	}
+	if got := method("readLine"); got != NullValue {
+		t.Errorf("readLine() at end of output = %v, want null", got.Unwrap())
+	}
+	if got := method("wait").Unwrap(); fmt.Sprint(got) != "2" {
+		t.Errorf("wait() = %v, want 2", got)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	interp := stdtest.Interp{Queue: make(chan func(), 1)}
+
+	var got []string
+	handler := &Func{Executor: func(ctx *FuncContext) *Return {
+		got = append(got, ctx.Args[0].Unwrap().(string))
+		return &Return{}
+	}}
+	call(t, interp, "signal", NewString("SIGTERM"), handler)
+	defer call(t, interp, "signal", NewString("SIGTERM"), NullValue)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// the handler is queued rather than run on the signal goroutine
+	select {
+	case fn := <-interp.Queue:
+		if len(got) != 0 {
+			t.Fatalf("handler ran before it was dequeued")
+		}
+		fn()
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal handler was not queued")
+	}
+	if len(got) != 1 || got[0] != "SIGTERM" {
+		t.Errorf("handler called with %v, want [SIGTERM]", got)
+	}
+}
@@ -1,6 +1,8 @@
 package std_random
 
 import (
+	"fmt"
+	"math"
 	"math/big"
 	"math/rand"
 
@@ -14,58 +16,278 @@ var Doc = types.StdlibDoc{
 	Description: "A library for generating random numbers.",
 }
 
+var global = newLockedSource(randomSeed())
+
+// RandomPrototype is the prototype of generators created with Random(seed).
+var RandomPrototype = &Composite{
+	Name:       "Random",
+	Proto:      Object,
+	Properties: Properties{PKString: {}},
+	Operators:  Operators{},
+}
+
 var Index = map[string]Value{
-	"F/choice": &Func{Executor: func(ctx *FuncContext) *Return {
-		if len(ctx.Args) != 1 {
-			ctx.Interp.Throw("choice() expects exactly 1 argument")
-		}
-		if _, ok := ctx.Args[0].(*Array); !ok {
-			ctx.Interp.Throw("choice() expects an array as its argument")
-		}
-		els := ctx.Args[0].(*Array).Elements
-		return &Return{Value: els[rand.Intn(len(els))]}
-	}},
-	"F/int": &Func{Executor: func(ctx *FuncContext) *Return {
-		if len(ctx.Args) != 2 {
-			ctx.Interp.Throw("int() expects exactly 2 arguments")
-		}
-		if _, ok := ctx.Args[0].(*Integer); !ok {
-			ctx.Interp.Throw("int() expects an integer as its first argument")
-		}
-		if _, ok := ctx.Args[1].(*Integer); !ok {
-			ctx.Interp.Throw("int() expects an integer as its second argument")
-		}
-		min := ctx.Args[0].(*Integer).Value
-		max := ctx.Args[1].(*Integer).Value
-		if min.Cmp(max) > 0 {
-			ctx.Interp.Throw("int() expects the first argument to be less than or equal to the second argument")
-		}
-		d := new(big.Int)
-		d.Sub(max, min)
-		v := rand.Int63n(d.Int64()) + min.Int64()
-		return &Return{Value: &Integer{Value: big.NewInt(v)}}
-	}},
-	"F/sample": &Func{Executor: func(ctx *FuncContext) *Return {
-		if len(ctx.Args) != 2 {
-			ctx.Interp.Throw("sample() expects exactly 2 arguments")
-		}
-		if _, ok := ctx.Args[0].(*Array); !ok {
-			ctx.Interp.Throw("sample() expects an array as its first argument")
-		}
-		if _, ok := ctx.Args[1].(*Integer); !ok {
-			ctx.Interp.Throw("sample() expects an integer as its second argument")
-		}
-		els := ctx.Args[0].(*Array).Elements
-		n := ctx.Args[1].(*Integer).Value
-		if n.Int64() > int64(len(els)) {
-			ctx.Interp.Throw("sample() expects the second argument to be less than or equal to the length of the first argument")
+	"S/Random": &Func{
+		NewableProto: RandomPrototype,
+		Executor: func(ctx *FuncContext) *Return {
+			seed := randomSeed()
+			if len(ctx.Args) > 0 && ctx.Args[0] != NullValue {
+				seed = seedArg(ctx, "Random")
+			}
+			return &Return{Value: newRandom(rand.New(rand.NewSource(seed)), true)}
+		},
+	},
+	"C/secure": newRandom(rand.New(cryptoSource{}), false),
+}
+
+func init() {
+	for name, fn := range functions(rand.New(global), true) {
+		Index["F/"+name] = fn
+	}
+}
+
+// newRandom creates a Random instance whose methods draw from r.
+func newRandom(r *rand.Rand, seedable bool) *Composite {
+	obj := &Composite{
+		Proto:      RandomPrototype,
+		Properties: Properties{PKString: {}},
+		Operators:  Operators{},
+	}
+	for name, fn := range functions(r, seedable) {
+		obj.Properties[PKString][name] = fn
+	}
+	return obj
+}
+
+// functions returns the generator functions backed by r. They are shared by
+// the package-level functions, Random instances and secure.
+func functions(r *rand.Rand, seedable bool) map[string]*Func {
+	return map[string]*Func{
+		"seed": {Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) != 1 {
+				ctx.Interp.Throw("seed() expects exactly 1 argument")
+			}
+			seed := seedArg(ctx, "seed")
+			if !seedable {
+				ctx.Interp.Throw("seed() cannot seed a secure generator")
+			}
+			r.Seed(seed)
+			return &Return{}
+		}},
+		"choice": {Executor: func(ctx *FuncContext) *Return {
+			els := arrayArg(ctx, "choice", 1)
+			if len(els) == 0 {
+				ctx.Interp.Throw("choice() expects a non-empty array")
+			}
+			return &Return{Value: els[r.Intn(len(els))]}
+		}},
+		"weightedChoice": {Executor: func(ctx *FuncContext) *Return {
+			els := arrayArg(ctx, "weightedChoice", 2)
+			if _, ok := ctx.Args[1].(*Array); !ok {
+				ctx.Interp.Throw("weightedChoice() expects an array of weights as its second argument")
+			}
+			weights := ctx.Args[1].(*Array).Elements
+			if len(weights) != len(els) {
+				ctx.Interp.Throw("weightedChoice() expects one weight per element")
+			}
+			total := 0.0
+			for _, w := range weights {
+				n, ok := w.(Numeric)
+				if !ok || n.Float64() < 0 {
+					ctx.Interp.Throw("weightedChoice() expects weights to be non-negative numbers")
+				}
+				total += n.Float64()
+			}
+			if total == 0 {
+				ctx.Interp.Throw("weightedChoice() expects at least one positive weight")
+			}
+			x := r.Float64() * total
+			for i, w := range weights {
+				x -= w.(Numeric).Float64()
+				if x < 0 {
+					return &Return{Value: els[i]}
+				}
+			}
+			// rounding error; pick the last element with a positive weight
+			for i := len(weights) - 1; i >= 0; i-- {
+				if weights[i].(Numeric).Float64() > 0 {
+					return &Return{Value: els[i]}
+				}
+			}
+			panic("unreachable")
+		}},
+		"int": {Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) != 2 {
+				ctx.Interp.Throw("int() expects exactly 2 arguments")
+			}
+			if _, ok := ctx.Args[0].(*Integer); !ok {
+				ctx.Interp.Throw("int() expects an integer as its first argument")
+			}
+			if _, ok := ctx.Args[1].(*Integer); !ok {
+				ctx.Interp.Throw("int() expects an integer as its second argument")
+			}
+			min := ctx.Args[0].(*Integer).Value
+			max := ctx.Args[1].(*Integer).Value
+			if min.Cmp(max) >= 0 {
+				ctx.Interp.Throw("int() expects the first argument to be less than the second argument")
+			}
+			d := new(big.Int)
+			d.Sub(max, min)
+			v := new(big.Int).Rand(r, d)
+			return &Return{Value: &Integer{Value: v.Add(v, min)}}
+		}},
+		"float": {Executor: func(ctx *FuncContext) *Return {
+			return &Return{Value: &Float{Value: floatRange(ctx, r, "float")}}
+		}},
+		"uniform": {Executor: func(ctx *FuncContext) *Return {
+			return &Return{Value: &Float{Value: floatRange(ctx, r, "uniform")}}
+		}},
+		"random": {Executor: func(ctx *FuncContext) *Return {
+			return &Return{Value: &Float{Value: r.Float64()}}
+		}},
+		"bool": {Executor: func(ctx *FuncContext) *Return {
+			p := numberArg(ctx, "bool", 0, 0.5)
+			if p < 0 || p > 1 {
+				ctx.Interp.Throw("bool() expects a probability between 0 and 1")
+			}
+			return &Return{Value: BoolFrom[r.Float64() < p]}
+		}},
+		"normal": {Executor: func(ctx *FuncContext) *Return {
+			mean := numberArg(ctx, "normal", 0, 0)
+			stddev := numberArg(ctx, "normal", 1, 1)
+			if stddev < 0 {
+				ctx.Interp.Throw("normal() expects a non-negative standard deviation")
+			}
+			return &Return{Value: &Float{Value: r.NormFloat64()*stddev + mean}}
+		}},
+		"exponential": {Executor: func(ctx *FuncContext) *Return {
+			rate := numberArg(ctx, "exponential", 0, 1)
+			if rate <= 0 {
+				ctx.Interp.Throw("exponential() expects a positive rate")
+			}
+			return &Return{Value: &Float{Value: r.ExpFloat64() / rate}}
+		}},
+		"shuffle": {Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) != 1 && len(ctx.Args) != 2 {
+				ctx.Interp.Throw("shuffle() expects 1 or 2 arguments")
+			}
+			els := arrayArg(ctx, "shuffle", len(ctx.Args))
+			n := len(els)
+			if len(ctx.Args) == 2 {
+				n = countArg(ctx, "shuffle", len(els))
+			}
+			return &Return{Value: &Array{Elements: shuffle(r, els, n)}}
+		}},
+		"sample": {Executor: func(ctx *FuncContext) *Return {
+			els := arrayArg(ctx, "sample", 2)
+			n := countArg(ctx, "sample", len(els))
+			return &Return{Value: &Array{Elements: shuffle(r, els, n)}}
+		}},
+		"bytes": {Executor: func(ctx *FuncContext) *Return {
+			if len(ctx.Args) != 1 {
+				ctx.Interp.Throw("bytes() expects exactly 1 argument")
+			}
+			n, ok := ctx.Args[0].(*Integer)
+			if !ok || n.Value.Sign() < 0 {
+				ctx.Interp.Throw("bytes() expects a non-negative integer as its argument")
+			}
+			if !n.Value.IsInt64() || n.Value.Int64() > MaxBytes {
+				ctx.Interp.Throw("bytes(): size %s is too large", n.Value.Text(10))
+			}
+			b := make([]byte, n.Value.Int64())
+			fill(r, b)
+			return &Return{Value: NewBytes(b)}
+		}},
+		"uuid": {Executor: func(ctx *FuncContext) *Return {
+			var b [16]byte
+			fill(r, b[:])
+			b[6] = b[6]&0x0f | 0x40 // version 4
+			b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+			s := fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+			return &Return{Value: NewString(s)}
+		}},
+	}
+}
+
+// arrayArg checks that exactly count arguments were passed and that the first
+// one is an array, returning its elements.
+func arrayArg(ctx *FuncContext, name string, count int) []Value {
+	if len(ctx.Args) != count {
+		ctx.Interp.Throw("%s() expects exactly %d argument(s)", name, count)
+	}
+	if _, ok := ctx.Args[0].(*Array); !ok {
+		ctx.Interp.Throw("%s() expects an array as its first argument", name)
+	}
+	return ctx.Args[0].(*Array).Elements
+}
+
+// seedArg returns the first argument as a seed, which must be an integer that
+// fits in 64 bits.
+func seedArg(ctx *FuncContext, name string) int64 {
+	n, ok := ctx.Args[0].(*Integer)
+	if !ok {
+		ctx.Interp.Throw("%s() expects an integer seed", name)
+	}
+	if !n.Value.IsInt64() {
+		ctx.Interp.Throw("%s() expects a seed between %d and %d", name, math.MinInt64, math.MaxInt64)
+	}
+	return n.Value.Int64()
+}
+
+// countArg returns the second argument as a count between 0 and max.
+func countArg(ctx *FuncContext, name string, max int) int {
+	if _, ok := ctx.Args[1].(*Integer); !ok {
+		ctx.Interp.Throw("%s() expects an integer as its second argument", name)
+	}
+	n := ctx.Args[1].(*Integer).Value
+	if n.Sign() < 0 || n.Cmp(big.NewInt(int64(max))) > 0 {
+		ctx.Interp.Throw("%s() expects the second argument to be between 0 and the length of the first argument", name)
+	}
+	return int(n.Int64())
+}
+
+// numberArg returns the argument at index as a float, or def if it is missing
+// or null.
+func numberArg(ctx *FuncContext, name string, index int, def float64) float64 {
+	if len(ctx.Args) <= index || ctx.Args[index] == NullValue {
+		return def
+	}
+	n, ok := ctx.Args[index].(Numeric)
+	if !ok {
+		ctx.Interp.Throw("%s() expects a number as argument %d", name, index+1)
+	}
+	return n.Float64()
+}
+
+// floatRange returns a float in [min, max), defaulting to [0, 1).
+func floatRange(ctx *FuncContext, r *rand.Rand, name string) float64 {
+	if len(ctx.Args) != 0 && len(ctx.Args) != 2 {
+		ctx.Interp.Throw("%s() expects 0 or 2 arguments", name)
+	}
+	min := numberArg(ctx, name, 0, 0)
+	max := numberArg(ctx, name, 1, 1)
+	if min >= max || math.IsInf(max-min, 0) {
+		ctx.Interp.Throw("%s() expects the first argument to be less than the second argument", name)
+	}
+	return min + r.Float64()*(max-min)
+}
+
+// shuffle returns n elements of els in random order, leaving els untouched.
+func shuffle(r *rand.Rand, els []Value, n int) []Value {
+	out := make([]Value, len(els))
+	copy(out, els)
+	r.Shuffle(len(out), func(i, j int) {
+		out[i], out[j] = out[j], out[i]
+	})
+	return out[:n]
+}
+
+func fill(r *rand.Rand, b []byte) {
+	for i := 0; i < len(b); i += 8 {
+		v := r.Uint64()
+		for j := i; j < i+8 && j < len(b); j++ {
+			b[j] = byte(v)
+			v >>= 8
 		}
-		rand.Shuffle(len(els), func(i, j int) {
-			els[i], els[j] = els[j], els[i]
-		})
-		return &Return{Value: &Array{Elements: els[:n.Int64()]}}
-	}},
-	"F/uniform": &Func{Executor: func(ctx *FuncContext) *Return {
-		return &Return{Value: &Float{Value: rand.Float64()}}
-	}},
+	}
 }
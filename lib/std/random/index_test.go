@@ -0,0 +1,130 @@
+package std_random
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib/std/internal/stdtest"
+)
+
+func integer(n int64) *Integer { return NewInteger(big.NewInt(n)) }
+
+func array(values ...int64) *Array {
+	a := NewArray()
+	for _, v := range values {
+		a.Elements = append(a.Elements, integer(v))
+	}
+	return a
+}
+
+// seeded returns a Random created with the given seed.
+func seeded(t *testing.T, seed Value) *Composite {
+	t.Helper()
+	ret := Index["S/Random"].(*Func).Executor(&FuncContext{Interp: stdtest.Interp{}, Args: []Value{seed}})
+	return ret.Value.(*Composite)
+}
+
+func call(r *Composite, name string, args ...Value) Value {
+	fn := r.Properties[PKString][name].(*Func)
+	return fn.Executor(&FuncContext{Interp: stdtest.Interp{}, This: r, Args: args}).Value
+}
+
+func format(v Value) string {
+	switch v := v.(type) {
+	case *Float:
+		return fmt.Sprintf("%.6f", v.Value)
+	case *Array:
+		parts := make([]string, len(v.Elements))
+		for i, el := range v.Elements {
+			parts[i] = format(el)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	default:
+		return fmt.Sprint(v.Unwrap())
+	}
+}
+
+// TestSeededReplay pins the values drawn from a seeded generator, so that
+// programs using Random(seed) keep producing the same output.
+func TestSeededReplay(t *testing.T) {
+	r := seeded(t, integer(42))
+
+	for _, test := range []struct {
+		name string
+		args []Value
+		want string
+	}{
+		{"int", []Value{integer(0), integer(100)}, "99"},
+		{"int", []Value{integer(-5), integer(5)}, "3"},
+		{"float", []Value{integer(0), integer(10)}, "0.438185"},
+		{"random", nil, "0.383193"},
+		{"shuffle", []Value{array(1, 2, 3, 4, 5)}, "[1 3 4 2 5]"},
+		{"sample", []Value{array(1, 2, 3, 4, 5), integer(2)}, "[3 5]"},
+		{"normal", []Value{integer(10), integer(2)}, "8.701933"},
+		{"exponential", []Value{integer(2)}, "0.789291"},
+		{"weightedChoice", []Value{array(1, 2, 3), array(1, 0, 3)}, "3"},
+		{"choice", []Value{array(1, 2, 3)}, "3"},
+	} {
+		if got := format(call(r, test.name, test.args...)); got != test.want {
+			t.Errorf("%s%s: got %s, want %s", test.name, format(NewArray(test.args...)), got, test.want)
+		}
+	}
+
+	// reseeding replays the same sequence
+	a := format(call(seeded(t, integer(7)), "int", integer(0), integer(1000000)))
+	b := seeded(t, integer(1))
+	call(b, "seed", integer(7))
+	if got := format(call(b, "int", integer(0), integer(1000000))); got != a {
+		t.Errorf("after seed(7): got %s, want %s", got, a)
+	}
+}
+
+func TestSeedRange(t *testing.T) {
+	huge, _ := new(big.Int).SetString("9223372036854775808", 10) // 2^63
+
+	for _, seed := range []Value{NewInteger(huge), NewInteger(new(big.Int).Neg(new(big.Int).Add(huge, big.NewInt(1)))), NewString("1")} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Random(%s): expected an error", format(seed))
+				}
+			}()
+			seeded(t, seed)
+		}()
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("seed(%s): expected an error", format(seed))
+				}
+			}()
+			call(seeded(t, integer(1)), "seed", seed)
+		}()
+	}
+
+	// the extremes of int64 are accepted
+	seeded(t, NewInteger(new(big.Int).Sub(huge, big.NewInt(1))))
+	seeded(t, NewInteger(new(big.Int).Neg(huge)))
+}
+
+func TestBytesSize(t *testing.T) {
+	r := seeded(t, integer(1))
+	if got := call(r, "bytes", integer(16)).(*Bytes); len(got.Value) != 16 {
+		t.Errorf("bytes(16): got %d bytes", len(got.Value))
+	}
+
+	huge, _ := new(big.Int).SetString("9223372036854775808", 10) // 2^63
+	for _, n := range []Value{integer(-1), integer(MaxBytes + 1), NewInteger(huge)} {
+		func() {
+			defer func() {
+				// not a runtime panic from allocating
+				if err, ok := recover().(error); !ok || !strings.HasPrefix(err.Error(), "bytes()") {
+					t.Errorf("bytes(%s): got %v, want an error", format(n), err)
+				}
+			}()
+			call(r, "bytes", n)
+		}()
+	}
+}
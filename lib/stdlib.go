@@ -7,8 +7,11 @@ import (
 	std_collections "github.com/calico32/goose/lib/std/collections"
 	std_crypto "github.com/calico32/goose/lib/std/crypto"
 	std_fs "github.com/calico32/goose/lib/std/fs"
+	std_http "github.com/calico32/goose/lib/std/http"
 	std_json "github.com/calico32/goose/lib/std/json"
+	std_macro "github.com/calico32/goose/lib/std/macro"
 	std_math "github.com/calico32/goose/lib/std/math"
+	std_os "github.com/calico32/goose/lib/std/os"
 	std_platform "github.com/calico32/goose/lib/std/platform"
 	std_random "github.com/calico32/goose/lib/std/random"
 	std_readline "github.com/calico32/goose/lib/std/readline"
@@ -24,9 +27,12 @@ var StdlibDocs = []types.StdlibDoc{
 	std_collections.Doc,
 	std_crypto.Doc,
 	std_fs.Doc,
+	std_http.Doc,
 	std_json.Doc,
 	// std_language.Doc,
+	std_macro.Doc,
 	std_math.Doc,
+	std_os.Doc,
 	std_platform.Doc,
 	std_random.Doc,
 	std_readline.Doc,
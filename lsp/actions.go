@@ -0,0 +1,813 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/lib"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+)
+
+// codeActions computes the code actions of a checked document, whose text is
+// still the text it was checked with.
+type codeActions struct {
+	ls     *LanguageServer
+	v      *validator.Validator
+	fset   *token.FileSet
+	uri    DocumentURI
+	module *ast.Module
+	src    []byte
+	base   int
+
+	siblings map[string][]string // names exported by the files next to the document
+}
+
+// diagnostic converts a diagnostic of the validator to an LSP diagnostic.
+func (ls *LanguageServer) diagnostic(fset *token.FileSet, d *validator.Diagnostic) Diagnostic {
+	return Diagnostic{
+		Message:  d.Message,
+		Severity: d.Severity,
+		Range:    ls.Range(fset, d.Node),
+		Source:   "goose",
+	}
+}
+
+// CodeAction returns quick fixes for the problems in the requested range and
+// the refactors that apply to it.
+func (ls *LanguageServer) CodeAction(ctx context.Context, params *CodeActionParams) (result []CodeAction, err error) {
+	ls.logger.Sugar().Debugf("CodeAction: %s", params.TextDocument.URI.Filename())
+	documentUri := params.TextDocument.URI
+	v, ok := ls.validators[documentUri]
+	if !ok {
+		return []CodeAction{}, nil
+	}
+	sourceMu, ok := ls.sourceFiles[documentUri]
+	if !ok {
+		return []CodeAction{}, nil
+	}
+	// edits are computed from the checked text, so they can't be offered
+	// until the document has been checked again
+	src := ls.snapshots[documentUri]
+	stale := !bytes.Equal(sourceMu.Lock().Bytes(), src)
+	sourceMu.Unlock()
+	fset := v.Fset()
+	file := fset.File("file:" + documentUri.Filename())
+	if stale || file == nil || file.Size() != len(src) {
+		return []CodeAction{}, nil
+	}
+
+	moduleMu := ls.modules[documentUri]
+	module := moduleMu.Lock()
+	defer moduleMu.Unlock()
+
+	a := &codeActions{ls: ls, v: v, fset: fset, uri: documentUri, module: module, src: src, base: file.Base()}
+	start := ls.Pos(fset, documentUri, params.Range.Start)
+	end := ls.Pos(fset, documentUri, params.Range.End)
+
+	result = []CodeAction{}
+	for _, d := range v.Diagnostics() {
+		if d.Problem == validator.ProblemNone || fset.Position(d.Node.Pos()).Filename != file.Specifier() {
+			continue
+		}
+		if d.Node.End() < start || d.Node.Pos() > end {
+			continue
+		}
+		for _, action := range a.quickFixes(d) {
+			action.Kind = QuickFix
+			action.Diagnostics = []Diagnostic{ls.diagnostic(fset, d)}
+			result = append(result, action)
+		}
+	}
+	if action, ok := a.arrowFunction(start); ok {
+		result = append(result, action)
+	}
+	if action, ok := a.extractFunction(start, end); ok {
+		result = append(result, action)
+	}
+
+	if len(params.Context.Only) == 0 {
+		return result, nil
+	}
+	filtered := []CodeAction{}
+	for _, action := range result {
+		for _, kind := range params.Context.Only {
+			if action.Kind == kind || strings.HasPrefix(string(action.Kind), string(kind)+".") {
+				filtered = append(filtered, action)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+func (a *codeActions) offset(pos token.Pos) int { return int(pos) - a.base }
+func (a *codeActions) pos(offset int) token.Pos { return token.Pos(a.base + offset) }
+
+// edit returns an edit of the document that replaces the text from start to
+// end.
+func (a *codeActions) edit(start token.Pos, end token.Pos, text string) TextEdit {
+	return TextEdit{
+		Range: Range{
+			Start: a.ls.position(a.fset.Position(start)),
+			End:   a.ls.position(a.fset.Position(end)),
+		},
+		NewText: text,
+	}
+}
+
+// action returns a code action that makes edits to the document.
+func (a *codeActions) action(title string, edits ...TextEdit) CodeAction {
+	return CodeAction{
+		Title: title,
+		Edit:  &WorkspaceEdit{Changes: map[DocumentURI][]TextEdit{a.uri: edits}},
+	}
+}
+
+// freeName returns name, or name followed by the smallest number from 2 that
+// makes it, if it is not defined at pos or declared anywhere in the module.
+func (a *codeActions) freeName(name string, pos token.Pos) string {
+	declared := map[string]bool{}
+	for _, decl := range a.v.Declarations() {
+		declared[decl.Name] = true
+	}
+	scope := a.v.ScopeAt(pos)
+	candidate := name
+	for i := 2; scope.IsDefined(candidate) || declared[candidate]; i++ {
+		candidate = fmt.Sprint(name, i)
+	}
+	return candidate
+}
+
+func (a *codeActions) quickFixes(d *validator.Diagnostic) []CodeAction {
+	switch d.Problem {
+	case validator.ProblemAlreadyDefined:
+		return a.aliasImport(d.Node)
+	case validator.ProblemUnexported:
+		return a.exportName(d.Node, d.Related)
+	case validator.ProblemUndefinedExport:
+		return a.removeShowField(d.Node)
+	case validator.ProblemDuplicateParameter:
+		return a.removeParameter(d.Node)
+	case validator.ProblemUndefined:
+		if ident, ok := d.Node.(*ast.Ident); ok {
+			return a.importName(ident.Name)
+		}
+	}
+	return nil
+}
+
+// aliasImport renames the name bound by an import that is already defined.
+// node is the shown name or the import spec that binds it.
+func (a *codeActions) aliasImport(node ast.Node) []CodeAction {
+	alias := func(name string) string { return a.freeName(name, node.Pos()) }
+	switch node := node.(type) {
+	case *ast.ModuleSpecPlain:
+		name, err := ast.ModuleName(strings.TrimPrefix(node.Specifier, scheme(node.Specifier)))
+		if err != nil {
+			return nil
+		}
+		name = alias(name)
+		return []CodeAction{a.action("Import as "+name, a.edit(node.End(), node.End(), " as "+name))}
+	case *ast.ModuleSpecAs:
+		name := alias(node.Alias.Name)
+		return []CodeAction{a.action("Import as "+name, a.edit(node.Alias.Pos(), node.Alias.End(), name))}
+	case *ast.Ident:
+		var actions []CodeAction
+		ast.Walk(a.module, func(n any) {
+			switch field := n.(type) {
+			case *ast.ShowFieldIdent:
+				if field.Ident == node {
+					name := alias(node.Name)
+					actions = append(actions, a.action("Import as "+name, a.edit(node.End(), node.End(), " as "+name)))
+				}
+			case *ast.ShowFieldAs:
+				if field.Alias == node {
+					name := alias(node.Name)
+					actions = append(actions, a.action("Import as "+name, a.edit(node.Pos(), node.End(), name)))
+				}
+			}
+		})
+		return actions
+	}
+	return nil
+}
+
+// scheme returns the scheme of a module specifier with its colon, like
+// "std:", or "" if it has none.
+func scheme(specifier string) string {
+	if i := strings.Index(specifier, ":"); i >= 0 {
+		return specifier[:i+1]
+	}
+	return ""
+}
+
+// shownName returns the name a show field imports from its module.
+func shownName(field ast.Node) string {
+	switch field := field.(type) {
+	case *ast.ShowFieldIdent:
+		return field.Ident.Name
+	case *ast.ShowFieldAs:
+		return field.Ident.Name
+	}
+	return ""
+}
+
+// exportName adds a name that is shown by an import but not exported by its
+// module to the export list of that module.
+func (a *codeActions) exportName(field ast.Node, decl *validator.Declaration) []CodeAction {
+	name := shownName(field)
+	if name == "" || decl == nil || decl.Module == nil || decl.Module.Module == nil {
+		return nil
+	}
+	file := a.fset.File(a.fset.Position(decl.Decl.Pos()).Filename)
+	if file == nil {
+		return nil
+	}
+	u, ok := a.ls.documentURI(file.Specifier())
+	if !ok || len(a.ls.source(file.Specifier())) != file.Size() {
+		return nil
+	}
+
+	var edit TextEdit
+	var list *ast.ExportList
+	for _, stmt := range decl.Module.Stmts {
+		if stmt, ok := stmt.(*ast.ExportListStmt); ok {
+			list = stmt.List
+		}
+	}
+	switch {
+	case list != nil && len(list.Fields) > 0:
+		end := list.Fields[len(list.Fields)-1].End()
+		edit = a.edit(end, end, ", "+name)
+	case list != nil:
+		edit = a.edit(list.LBrace+1, list.LBrace+1, " "+name+" ")
+	default:
+		end := token.Pos(file.Base() + file.Size())
+		text := "export { " + name + " }\n"
+		if src := a.ls.source(file.Specifier()); len(src) > 0 && src[len(src)-1] != '\n' {
+			text = "\n" + text
+		}
+		edit = a.edit(end, end, text)
+	}
+	return []CodeAction{{
+		Title: fmt.Sprintf("Export %s from %s", name, filepath.Base(u.Filename())),
+		Edit:  &WorkspaceEdit{Changes: map[DocumentURI][]TextEdit{u: {edit}}},
+	}}
+}
+
+// removeShowField removes a name that its module doesn't export from the
+// show list of an import, unless it is the only name in the list.
+func (a *codeActions) removeShowField(field ast.Node) []CodeAction {
+	var actions []CodeAction
+	ast.Walk(a.module, func(n any) {
+		show, ok := n.(*ast.Show)
+		if !ok || len(show.Fields) < 2 {
+			return
+		}
+		for i, f := range show.Fields {
+			if ast.Node(f) != field {
+				continue
+			}
+			// remove the comma on one side of the field
+			var start, end token.Pos
+			if i == 0 {
+				start, end = field.Pos(), show.Fields[1].Pos()
+			} else {
+				start, end = show.Fields[i-1].End(), field.End()
+			}
+			actions = append(actions, a.action(fmt.Sprintf("Remove %s from import", shownName(field)), a.edit(start, end, "")))
+		}
+	})
+	return actions
+}
+
+// removeParameter removes a parameter that has the name of an earlier one.
+func (a *codeActions) removeParameter(ident ast.Node) []CodeAction {
+	paramEnd := func(param *ast.FuncParam) token.Pos {
+		if param.Value != nil {
+			return param.Value.End()
+		}
+		return param.End()
+	}
+	var actions []CodeAction
+	ast.Walk(a.module, func(n any) {
+		params, ok := n.(*ast.FuncParamList)
+		if !ok {
+			return
+		}
+		for i, param := range params.List {
+			if i > 0 && ast.Node(param.Ident) == ident {
+				edit := a.edit(paramEnd(params.List[i-1]), paramEnd(param), "")
+				actions = append(actions, a.action("Remove duplicate parameter "+param.Ident.Name, edit))
+			}
+		}
+	})
+	return actions
+}
+
+var (
+	stdExportsOnce sync.Once
+	stdExportsMap  map[string][]string
+)
+
+// stdExports returns the names exported by each module of the standard
+// library.
+func stdExports() map[string][]string {
+	stdExportsOnce.Do(func() {
+		stdExportsMap = map[string][]string{}
+		entries, _ := lib.Stdlib.ReadDir("std")
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".goose")
+			if name == "language" {
+				continue
+			}
+			file := path.Join("std", entry.Name())
+			if entry.IsDir() {
+				file = path.Join(file, "index.goose")
+			}
+			src, err := lib.Stdlib.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			stdExportsMap[name] = exportedNames(src)
+		}
+	})
+	return stdExportsMap
+}
+
+// exportedNames returns the names exported by the module with source src, or
+// nil if it doesn't parse.
+func exportedNames(src []byte) []string {
+	module, err := parser.ParseFile(token.NewFileSet(), "", src, nil)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, stmt := range module.Stmts {
+		switch stmt := stmt.(type) {
+		case *ast.ExportDeclStmt:
+			switch decl := stmt.Stmt.(type) {
+			case *ast.ConstStmt:
+				if decl.Ident != nil {
+					names = append(names, decl.Ident.Name)
+				}
+			case *ast.LetStmt:
+				if decl.Ident != nil {
+					names = append(names, decl.Ident.Name)
+				}
+			case *ast.StructStmt:
+				names = append(names, decl.Name.Name)
+			case *ast.TraitStmt:
+				names = append(names, decl.Name.Name)
+			case *ast.EnumStmt:
+				names = append(names, decl.Name.Name)
+			case *ast.NativeConst:
+				names = append(names, decl.Ident.Name)
+			case *ast.NativeStruct:
+				names = append(names, decl.Name.Name)
+			case *ast.NativeFunc:
+				if decl.Receiver == nil {
+					names = append(names, decl.Name.Name)
+				}
+			case *ast.ExprStmt:
+				switch fn := decl.X.(type) {
+				case *ast.FuncExpr:
+					if fn.Name != nil && fn.Receiver == nil {
+						names = append(names, fn.Name.Name)
+					}
+				case *ast.GeneratorExpr:
+					if fn.Name != nil && fn.Receiver == nil {
+						names = append(names, fn.Name.Name)
+					}
+				}
+			}
+		case *ast.ExportListStmt:
+			for _, field := range stmt.List.Fields {
+				switch field := field.(type) {
+				case *ast.ExportFieldIdent:
+					names = append(names, field.Ident.Name)
+				case *ast.ExportFieldAs:
+					names = append(names, field.Alias.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// importName imports an undefined name from the standard library or a file
+// next to the document: either a module named name, or a module that
+// exports it.
+func (a *codeActions) importName(name string) []CodeAction {
+	type candidate struct {
+		specifier string
+		show      bool
+	}
+	var candidates []candidate
+
+	exports := stdExports()
+	modules := make([]string, 0, len(exports))
+	for module := range exports {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		if module == name {
+			candidates = append(candidates, candidate{"std:" + module, false})
+		}
+	}
+	for _, module := range modules {
+		if contains(exports[module], name) {
+			candidates = append(candidates, candidate{"std:" + module, true})
+		}
+	}
+
+	if a.siblings == nil {
+		a.siblings = map[string][]string{}
+		dir := filepath.Dir(a.uri.Filename())
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			file := entry.Name()
+			if !entry.IsDir() && strings.HasSuffix(file, ".goose") && filepath.Join(dir, file) != a.uri.Filename() {
+				a.siblings[file] = exportedNames(a.ls.source("file:" + filepath.Join(dir, file)))
+			}
+		}
+	}
+	files := make([]string, 0, len(a.siblings))
+	for file := range a.siblings {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if strings.TrimSuffix(file, ".goose") == name {
+			candidates = append(candidates, candidate{"./" + file, false})
+		}
+		if contains(a.siblings[file], name) {
+			candidates = append(candidates, candidate{"./" + file, true})
+		}
+	}
+
+	actions := []CodeAction{}
+	for _, c := range candidates {
+		if c.show {
+			actions = append(actions, a.action(fmt.Sprintf("Import %s from %q", name, c.specifier), a.importEdit(c.specifier, name)))
+		} else {
+			actions = append(actions, a.action(fmt.Sprintf("Import %q", c.specifier), a.importEdit(c.specifier, "")))
+		}
+	}
+	return actions
+}
+
+// importEdit returns an edit that imports the module specifier, showing name
+// from it if name is not empty. Names are added to an existing show list of
+// the module if there is one; new imports go after the last import.
+func (a *codeActions) importEdit(specifier string, name string) TextEdit {
+	dir := filepath.Dir(a.uri.Filename())
+	var last *ast.ImportStmt
+	for _, stmt := range a.module.Stmts {
+		stmt, ok := stmt.(*ast.ImportStmt)
+		if !ok {
+			continue
+		}
+		last = stmt
+		spec, ok := stmt.Spec.(*ast.ModuleSpecShow)
+		if name == "" || !ok || spec.Show == nil || spec.Show.Ellipsis.IsValid() {
+			continue
+		}
+		if moduleName(spec.Specifier, dir) != moduleName(specifier, dir) {
+			continue
+		}
+		if n := len(spec.Show.Fields); n > 0 {
+			end := spec.Show.Fields[n-1].End()
+			return a.edit(end, end, ", "+name)
+		}
+		return a.edit(spec.Show.LBrace+1, spec.Show.LBrace+1, " "+name+" ")
+	}
+
+	text := fmt.Sprintf("import %q", specifier)
+	if name != "" {
+		text += " show { " + name + " }"
+	}
+	if last != nil {
+		return a.edit(last.End(), last.End(), "\n"+text)
+	}
+	return a.edit(a.pos(0), a.pos(0), text+"\n\n")
+}
+
+// arrowFunction converts the innermost function around pos whose body only
+// returns a value to the arrow form, fn f(x) -> value.
+func (a *codeActions) arrowFunction(pos token.Pos) (CodeAction, bool) {
+	var fn *ast.FuncExpr
+	ast.Walk(a.module, func(n any) {
+		if f, ok := n.(*ast.FuncExpr); ok && f.Pos() <= pos && pos <= f.End() {
+			fn = f
+		}
+	})
+	if fn == nil || fn.Arrow.IsValid() || !fn.BlockEnd.IsValid() || fn.Params == nil || !fn.Params.End().IsValid() || len(fn.Body) != 1 {
+		return CodeAction{}, false
+	}
+	ret, ok := fn.Body[0].(*ast.ReturnStmt)
+	if !ok || ret.Result == nil {
+		return CodeAction{}, false
+	}
+	end := a.offset(fn.BlockEnd)
+	if !bytes.HasPrefix(a.src[end:], []byte("end")) {
+		return CodeAction{}, false
+	}
+	// comments around the return would be lost
+	blank := func(from token.Pos, to token.Pos) bool {
+		return len(bytes.TrimSpace(a.src[a.offset(from):a.offset(to)])) == 0
+	}
+	if !blank(fn.Params.End(), ret.Pos()) || !blank(ret.Result.End(), fn.BlockEnd) {
+		return CodeAction{}, false
+	}
+
+	head := string(a.src[a.offset(fn.Pos()):a.offset(fn.Params.End())])
+	result := string(a.src[a.offset(ret.Result.Pos()):a.offset(ret.Result.End())])
+	edit := a.edit(fn.Pos(), a.pos(end+len("end")), head+" -> "+result)
+	action := a.action("Convert to arrow function", edit)
+	action.Kind = RefactorRewrite
+	return action, true
+}
+
+// extractFunction moves the selected statements, or the selected
+// expression, into a new function declared before the top-level statement
+// they are in, and replaces them with a call of it. Local variables from
+// outside the selection become parameters. Selections that return, break,
+// use this, assign to outside locals or declare names used after them can't
+// be extracted.
+func (a *codeActions) extractFunction(start token.Pos, end token.Pos) (CodeAction, bool) {
+	from, to := a.offset(start), a.offset(end)
+	if from < 0 || to > len(a.src) || from > to {
+		return CodeAction{}, false
+	}
+	for from < to && isSpace(a.src[from]) {
+		from++
+	}
+	for to > from && isSpace(a.src[to-1]) {
+		to--
+	}
+	if from == to {
+		return CodeAction{}, false
+	}
+	start, end = a.pos(from), a.pos(to)
+	text := string(a.src[from:to])
+	selected, ok := a.selection(start, end, text)
+	if !ok {
+		return CodeAction{}, false
+	}
+	_, isExpr := selected[0].(ast.Expr)
+
+	// the top-level statement the selection is in
+	var top ast.Stmt
+	topEnd := len(a.src)
+	for i, stmt := range a.module.Stmts {
+		if stmt.Pos() <= start {
+			top = stmt
+			if i+1 < len(a.module.Stmts) {
+				topEnd = a.offset(a.module.Stmts[i+1].Pos())
+			}
+		}
+	}
+	if top == nil {
+		return CodeAction{}, false
+	}
+	inSelection := func(pos token.Pos) bool { return start <= pos && pos < end }
+	resolved := a.v.Resolved()
+	local := func(ident *ast.Ident) bool {
+		decl, ok := resolved[ident]
+		if !ok || decl.Ident == nil || inSelection(decl.Ident.Pos()) {
+			return false
+		}
+		switch decl.Kind {
+		case validator.DeclVariable, validator.DeclConstant, validator.DeclParameter, validator.DeclFunction:
+		default:
+			return false
+		}
+		offset := a.offset(decl.Ident.Pos())
+		return a.offset(top.Pos()) <= offset && offset < topEnd
+	}
+
+	var params []string
+	var nested []ast.Node // functions and loops inside the selection
+	within := func(pos token.Pos, types ...func(ast.Node) bool) bool {
+		for _, n := range nested {
+			for _, t := range types {
+				if t(n) && n.Pos() <= pos && pos < n.End() {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	isFunc := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncExpr, *ast.GeneratorExpr:
+			return true
+		}
+		return false
+	}
+	isLoop := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RepeatWhileStmt, *ast.RepeatForeverStmt, *ast.RepeatCountStmt:
+			return true
+		}
+		return false
+	}
+	selectors := map[*ast.Ident]bool{}
+	ok = true
+	for _, node := range selected {
+		ast.Walk(node, func(n any) {
+			switch n := n.(type) {
+			case *ast.FuncExpr, *ast.GeneratorExpr, *ast.ForStmt, *ast.RepeatWhileStmt, *ast.RepeatForeverStmt, *ast.RepeatCountStmt:
+				nested = append(nested, n.(ast.Node))
+			case *ast.ReturnStmt, *ast.YieldStmt, *ast.AwaitExpr:
+				if !within(n.(ast.Node).Pos(), isFunc) {
+					ok = false
+				}
+			case *ast.BranchStmt:
+				if !within(n.Pos(), isFunc, isLoop) {
+					ok = false
+				}
+			case *ast.PropertyExpr, *ast.BracketPropertyExpr:
+				ok = false
+			case *ast.AssignStmt:
+				if ident, isIdent := n.Lhs.(*ast.Ident); isIdent && local(ident) {
+					ok = false
+				}
+			case *ast.IncDecStmt:
+				if ident, isIdent := n.X.(*ast.Ident); isIdent && local(ident) {
+					ok = false
+				}
+			case *ast.SelectorExpr:
+				selectors[n.Sel] = true
+			case *ast.Ident:
+				if n.Name == "this" {
+					ok = false
+				}
+				if !selectors[n] && local(n) && !contains(params, n.Name) {
+					params = append(params, n.Name)
+				}
+			}
+		})
+	}
+	if !ok {
+		return CodeAction{}, false
+	}
+	// names declared in the selection are local to the new function
+	for _, decl := range a.v.Declarations() {
+		if decl.Ident == nil || !inSelection(decl.Ident.Pos()) {
+			continue
+		}
+		for _, ref := range a.v.References(decl) {
+			if ref.Pos() >= end && a.fset.Position(ref.Pos()).Filename == a.fset.Position(start).Filename {
+				return CodeAction{}, false
+			}
+		}
+	}
+
+	name := a.freeName("extracted", start)
+	call := name + "(" + strings.Join(params, ", ") + ")"
+	var fn string
+	if isExpr {
+		fn = fmt.Sprintf("fn %s(%s) -> %s\n\n", name, strings.Join(params, ", "), text)
+	} else {
+		// the lines of the body lose the indentation of the selection
+		lineStart := bytes.LastIndexByte(a.src[:from], '\n') + 1
+		indent := string(a.src[lineStart:from])
+		if strings.TrimSpace(indent) != "" {
+			indent = ""
+		}
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			line = strings.TrimPrefix(line, indent)
+			if i == 0 {
+				line = strings.TrimLeft(line, " \t")
+			}
+			if strings.TrimSpace(line) != "" {
+				line = "  " + line
+			}
+			lines[i] = line
+		}
+		fn = fmt.Sprintf("fn %s(%s)\n%s\nend\n\n", name, strings.Join(params, ", "), strings.Join(lines, "\n"))
+	}
+
+	var edits []TextEdit
+	if top.Pos() == start {
+		edits = []TextEdit{a.edit(start, end, fn+call)}
+	} else {
+		at := a.offset(top.Pos())
+		// keep the comments above the statement with it
+		for at > 0 {
+			prev := bytes.LastIndexByte(a.src[:at-1], '\n') + 1
+			if !bytes.HasPrefix(bytes.TrimLeft(a.src[prev:at], " \t"), []byte("//")) {
+				break
+			}
+			at = prev
+		}
+		edits = []TextEdit{a.edit(a.pos(at), a.pos(at), fn), a.edit(start, end, call)}
+	}
+	action := a.action("Extract function", edits...)
+	action.Kind = RefactorExtract
+	return action, true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// selection returns the expression that spans exactly from start to end, or
+// the complete statements of one block that do, or false if the selection is
+// neither. text is the selected text.
+func (a *codeActions) selection(start token.Pos, end token.Pos, text string) ([]ast.Node, bool) {
+	// the selection must make sense on its own
+	parsed, err := parser.ParseFile(token.NewFileSet(), "", []byte(text), nil)
+	if err != nil || len(parsed.Stmts) == 0 {
+		return nil, false
+	}
+
+	var expr ast.Expr
+	var stmts []ast.Stmt
+	ast.Walk(a.module, func(n any) {
+		if e, ok := n.(ast.Expr); ok && expr == nil && e.Pos() == start && e.End() == end {
+			expr = e
+		}
+		if stmt, ok := n.(ast.Stmt); ok && start <= stmt.Pos() && stmt.Pos() < end {
+			// only the outermost statements
+			for _, s := range stmts {
+				if s.Pos() <= stmt.Pos() && stmt.Pos() < s.End() {
+					return
+				}
+			}
+			stmts = append(stmts, stmt)
+		}
+	})
+	if expr != nil {
+		if _, ok := parsed.Stmts[0].(*ast.ExprStmt); ok && len(parsed.Stmts) == 1 {
+			return []ast.Node{expr}, true
+		}
+	}
+
+	// the statements of the selection start where the parsed ones do and
+	// end where the next statement, or the end of the block, begins
+	if len(stmts) != len(parsed.Stmts) || stmts[0].Pos() != start {
+		return nil, false
+	}
+	for i, stmt := range stmts {
+		if int(parsed.Stmts[i].Pos())-1 != a.offset(stmt.Pos())-a.offset(start) {
+			return nil, false
+		}
+	}
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(a.src))
+	s := scanner.Scanner{}
+	s.Init(file, a.src, func(token.Position, string) {})
+	for {
+		pos, t, _ := s.Scan()
+		if t != token.EOF && (t == token.Comment || file.Offset(pos) < a.offset(end)) {
+			continue
+		}
+		switch t {
+		case token.EOF, token.End, token.Else, token.Catch, token.Finally:
+		default:
+			next := a.pos(file.Offset(pos))
+			starts := false
+			ast.Walk(a.module, func(n any) {
+				if stmt, ok := n.(ast.Stmt); ok && stmt.Pos() == next {
+					starts = true
+				}
+			})
+			if !starts {
+				return nil, false
+			}
+		}
+		break
+	}
+
+	nodes := make([]ast.Node, len(stmts))
+	for i, stmt := range stmts {
+		nodes[i] = stmt
+	}
+	return nodes, true
+}
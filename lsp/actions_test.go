@@ -0,0 +1,206 @@
+package lsp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "go.lsp.dev/protocol"
+)
+
+// at returns the empty range at a position.
+func at(line uint32, character uint32) Range {
+	return Range{Start: Position{Line: line, Character: character}, End: Position{Line: line, Character: character}}
+}
+
+func TestCodeActions(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"lib.goose": "export fn double(x) -> x * 2\nfn secret() -> 42\n",
+		"shadow.goose": `let math = 1
+import "std:math"
+`,
+		"main.goose": `import "./lib.goose" show { double, secret, missing }
+
+fn f(a, b, a = 2)
+  return a + b
+end
+
+println(sqrt(double(f(1, 2))))
+`,
+		"extract.goose": `let scale = 10
+
+fn area(w, h)
+  let base = w * h
+  let total = base * scale
+  println(total)
+  return (w + 1) * h
+end
+
+// the loop
+repeat 3 times
+  println(scale)
+end
+`,
+	}
+	dir := writeWorkspace(t, files)
+
+	tests := []struct {
+		file  string
+		rng   Range
+		title string
+		want  map[string]string // the files after the edit
+	}{
+		{"main.goose", at(0, 37), "Export secret from lib.goose", map[string]string{
+			"lib.goose": "export fn double(x) -> x * 2\nfn secret() -> 42\nexport { secret }\n",
+		}},
+		{"main.goose", at(0, 45), "Remove missing from import", map[string]string{
+			"main.goose": `import "./lib.goose" show { double, secret }
+
+fn f(a, b, a = 2)
+  return a + b
+end
+
+println(sqrt(double(f(1, 2))))
+`,
+		}},
+		{"main.goose", at(2, 11), "Remove duplicate parameter a", map[string]string{
+			"main.goose": `import "./lib.goose" show { double, secret, missing }
+
+fn f(a, b)
+  return a + b
+end
+
+println(sqrt(double(f(1, 2))))
+`,
+		}},
+		{"main.goose", at(6, 9), `Import sqrt from "std:math"`, map[string]string{
+			"main.goose": `import "./lib.goose" show { double, secret, missing }
+import "std:math" show { sqrt }
+
+fn f(a, b, a = 2)
+  return a + b
+end
+
+println(sqrt(double(f(1, 2))))
+`,
+		}},
+		{"main.goose", at(3, 4), "Convert to arrow function", map[string]string{
+			"main.goose": `import "./lib.goose" show { double, secret, missing }
+
+fn f(a, b, a = 2) -> a + b
+
+println(sqrt(double(f(1, 2))))
+`,
+		}},
+		{"shadow.goose", at(1, 10), "Import as math2", map[string]string{
+			"shadow.goose": `let math = 1
+import "std:math" as math2
+`,
+		}},
+		// statements, with a local from outside as a parameter
+		{"extract.goose", Range{Start: Position{Line: 4, Character: 2}, End: Position{Line: 6, Character: 0}}, "Extract function", map[string]string{
+			"extract.goose": `let scale = 10
+
+fn extracted(base)
+  let total = base * scale
+  println(total)
+end
+
+fn area(w, h)
+  let base = w * h
+  extracted(base)
+  return (w + 1) * h
+end
+
+// the loop
+repeat 3 times
+  println(scale)
+end
+`,
+		}},
+		// an expression
+		{"extract.goose", Range{Start: Position{Line: 6, Character: 9}, End: Position{Line: 6, Character: 20}}, "Extract function", map[string]string{
+			"extract.goose": `let scale = 10
+
+fn extracted(w, h) -> (w + 1) * h
+
+fn area(w, h)
+  let base = w * h
+  let total = base * scale
+  println(total)
+  return extracted(w, h)
+end
+
+// the loop
+repeat 3 times
+  println(scale)
+end
+`,
+		}},
+		// a top-level statement, which the function is declared before
+		{"extract.goose", Range{Start: Position{Line: 10, Character: 0}, End: Position{Line: 13, Character: 0}}, "Extract function", map[string]string{
+			"extract.goose": `let scale = 10
+
+fn area(w, h)
+  let base = w * h
+  let total = base * scale
+  println(total)
+  return (w + 1) * h
+end
+
+// the loop
+fn extracted()
+  repeat 3 times
+    println(scale)
+  end
+end
+
+extracted()
+`,
+		}},
+		// base is used after the selection, and return leaves the function
+		{"extract.goose", Range{Start: Position{Line: 3, Character: 2}, End: Position{Line: 4, Character: 29}}, "", nil},
+		{"extract.goose", Range{Start: Position{Line: 6, Character: 2}, End: Position{Line: 6, Character: 20}}, "", nil},
+		// part of a statement
+		{"extract.goose", Range{Start: Position{Line: 4, Character: 2}, End: Position{Line: 4, Character: 20}}, "", nil},
+	}
+	for _, test := range tests {
+		ls, u := openServer(t, filepath.Join(dir, test.file))
+		actions, err := ls.CodeAction(context.Background(), &CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: u},
+			Range:        test.rng,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		var action *CodeAction
+		for i := range actions {
+			titles = append(titles, actions[i].Title)
+			if actions[i].Title == test.title {
+				action = &actions[i]
+			}
+		}
+		if test.title == "" {
+			for _, title := range titles {
+				if title == "Extract function" {
+					t.Errorf("%s %v: got %q, want no extraction", test.file, test.rng, title)
+				}
+			}
+			continue
+		}
+		if action == nil {
+			t.Errorf("%s %v: got actions %q, want %q", test.file, test.rng, titles, test.title)
+			continue
+		}
+		for name, want := range test.want {
+			buffer := NewTextBuffer(files[name])
+			applyEdits(t, buffer, action.Edit.Changes[DocumentURI("file://"+filepath.Join(dir, name))], PositionEncodingUTF16)
+			if got := buffer.String(); got != want {
+				t.Errorf("%s: %s: got\n%s\nwant\n%s", test.file, test.title, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/calico32/goose/token"
+	. "go.lsp.dev/protocol"
+)
+
+// PositionEncoding is the unit that Position.Character is counted in.
+type PositionEncoding string
+
+const (
+	PositionEncodingUTF8  PositionEncoding = "utf-8"
+	PositionEncodingUTF16 PositionEncoding = "utf-16"
+	PositionEncodingUTF32 PositionEncoding = "utf-32"
+)
+
+// negotiateEncoding picks the first encoding offered by the client that the
+// server supports. Clients that don't offer any only understand UTF-16.
+func negotiateEncoding(offered []string) PositionEncoding {
+	for _, encoding := range offered {
+		switch PositionEncoding(encoding) {
+		case PositionEncodingUTF8, PositionEncodingUTF16, PositionEncodingUTF32:
+			return PositionEncoding(encoding)
+		}
+	}
+	return PositionEncodingUTF16
+}
+
+// encodedLen returns the length of s in the units of encoding.
+func encodedLen(s string, encoding PositionEncoding) uint32 {
+	switch encoding {
+	case PositionEncodingUTF8:
+		return uint32(len(s))
+	case PositionEncodingUTF32:
+		return uint32(utf8.RuneCountInString(s))
+	}
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return uint32(n)
+}
+
+// byteOffset returns the byte offset in line of the character, counted in the
+// units of encoding. Characters past the end of the line are clamped to it,
+// and characters inside a multi-unit rune are rounded down to its start.
+func byteOffset(line string, character uint32, encoding PositionEncoding) int {
+	if encoding == PositionEncodingUTF8 {
+		if int(character) >= len(line) {
+			return len(line)
+		}
+		for character > 0 && !utf8.RuneStart(line[character]) {
+			character--
+		}
+		return int(character)
+	}
+	units := uint32(0)
+	for i, r := range line {
+		width := uint32(utf16.RuneLen(r))
+		if encoding == PositionEncodingUTF32 {
+			width = 1
+		}
+		if units+width > character {
+			return i
+		}
+		units += width
+	}
+	return len(line)
+}
+
+// lspPosition converts a position in src to an LSP position.
+func lspPosition(src []byte, position token.Position, encoding PositionEncoding) Position {
+	line := uint32(position.Line) - 1
+	column := position.Column - 1
+	start := position.Offset - column
+	if encoding == PositionEncodingUTF8 || start < 0 || position.Offset > len(src) {
+		return Position{Line: line, Character: uint32(column)}
+	}
+	return Position{Line: line, Character: encodedLen(string(src[start:position.Offset]), encoding)}
+}
+
+// TextBuffer holds the text of an open document in a rope, so that edits and
+// conversions between offsets and positions take time logarithmic in the size
+// of the document rather than linear. Lines end with "\n", "\r\n" or "\r", as
+// the LSP specification allows.
+type TextBuffer struct {
+	rope *rope
+	text []byte // the whole text, cached until the next edit
+}
+
+func NewTextBuffer(text string) *TextBuffer {
+	return &TextBuffer{rope: newRope(text)}
+}
+
+// trimTerminator returns line without its line terminator.
+func trimTerminator(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
+
+// Bytes returns the text of the buffer. The result must not be modified.
+func (b *TextBuffer) Bytes() []byte {
+	if b.text == nil {
+		b.text = []byte(b.slice(0, b.Len()))
+	}
+	return b.text
+}
+
+func (b *TextBuffer) String() string {
+	return string(b.Bytes())
+}
+
+// Len returns the length of the text in bytes.
+func (b *TextBuffer) Len() int {
+	return b.rope.len()
+}
+
+// slice returns the text from start to end.
+func (b *TextBuffer) slice(start, end int) string {
+	var sb strings.Builder
+	sb.Grow(end - start)
+	b.rope.slice(&sb, start, end)
+	return sb.String()
+}
+
+// LineCount returns the number of lines in the buffer. A buffer always has at
+// least one (possibly empty) line.
+func (b *TextBuffer) LineCount() int {
+	return b.rope.lineBreaks() + 1
+}
+
+// lineStart returns the offset of the start of line i, which must be less
+// than the number of lines.
+func (b *TextBuffer) lineStart(i int) int {
+	if i == 0 {
+		return 0
+	}
+	return b.rope.lineStart(i)
+}
+
+// Line returns the text of line i, without its line terminator.
+func (b *TextBuffer) Line(i int) string {
+	if i < 0 || i >= b.LineCount() {
+		return ""
+	}
+	end := b.Len()
+	if i+1 < b.LineCount() {
+		end = b.lineStart(i + 1)
+	}
+	return trimTerminator(b.slice(b.lineStart(i), end))
+}
+
+// Offset returns the byte offset of position, clamping positions outside of
+// the buffer to the end of their line or of the buffer.
+func (b *TextBuffer) Offset(position Position, encoding PositionEncoding) int {
+	line := int(position.Line)
+	if line >= b.LineCount() {
+		return b.Len()
+	}
+	return b.lineStart(line) + byteOffset(b.Line(line), position.Character, encoding)
+}
+
+// Position returns the position of a byte offset. Offsets inside a line
+// terminator are clamped to the end of its line.
+func (b *TextBuffer) Position(offset int, encoding PositionEncoding) Position {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > b.Len() {
+		offset = b.Len()
+	}
+	line := b.rope.breaksBefore(offset)
+	inCRLF := offset > 0 && offset < b.Len() && b.rope.byteAt(offset-1) == '\r' && b.rope.byteAt(offset) == '\n'
+	if inCRLF {
+		// the "\r" before offset was counted as a line break
+		line--
+	}
+	before := b.slice(b.lineStart(line), offset)
+	return Position{Line: uint32(line), Character: encodedLen(trimTerminator(before), encoding)}
+}
+
+// Replace replaces the text in rng with text.
+func (b *TextBuffer) Replace(rng Range, text string, encoding PositionEncoding) error {
+	if CmpPositions(rng.Start, rng.End) > 0 {
+		return fmt.Errorf("invalid range: start %d:%d is after end %d:%d", rng.Start.Line, rng.Start.Character, rng.End.Line, rng.End.Character)
+	}
+
+	start := b.Offset(rng.Start, encoding)
+	end := b.Offset(rng.End, encoding)
+	before, rest := b.rope.split(start)
+	_, after := rest.split(end - start)
+	b.rope = join(join(before, newRope(text)), after)
+	b.text = nil
+	return nil
+}
+
+// SetText replaces the whole contents of the buffer.
+func (b *TextBuffer) SetText(text string) {
+	b.rope = newRope(text)
+	b.text = nil
+}
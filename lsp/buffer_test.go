@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	. "go.lsp.dev/protocol"
+	"go.uber.org/zap"
+)
+
+// positionOf computes the LSP position of a byte offset in text the slow way.
+func positionOf(text string, offset int, encoding PositionEncoding) Position {
+	line, start := 0, 0
+	for i := 0; i < offset; i++ {
+		if text[i] == '\n' || text[i] == '\r' && (i+1 == len(text) || text[i+1] != '\n') {
+			line++
+			start = i + 1
+		}
+	}
+	before := text[start:offset]
+	var character int
+	switch encoding {
+	case PositionEncodingUTF8:
+		character = len(before)
+	case PositionEncodingUTF16:
+		character = len(utf16.Encode([]rune(before)))
+	case PositionEncodingUTF32:
+		character = len([]rune(before))
+	}
+	return Position{Line: uint32(line), Character: uint32(character)}
+}
+
+// runeOffset returns a random offset in text that is on a rune boundary and
+// not inside a "\r\n" line terminator.
+func runeOffset(r *rand.Rand, text string) int {
+	offset := r.Intn(len(text) + 1)
+	for offset < len(text) && (!utf8.RuneStart(text[offset]) || offset > 0 && text[offset-1] == '\r' && text[offset] == '\n') {
+		offset++
+	}
+	return offset
+}
+
+// long is longer than a rope leaf, so that edits with it split and rebalance
+// the tree.
+var long = strings.Repeat("let d² = x\r\n\rend\n", 200)
+
+var fragments = []string{"", "a", "let x = 1", "\n", "\n\n", "\r\n", "\r", "é", "d²", "😀", "\t", "end\n", "end\r\n", "🦆 goose", long}
+
+func FuzzTextBuffer(f *testing.F) {
+	f.Add("", int64(0))
+	f.Add("let x = 1\nprintln(x)\n", int64(1))
+	f.Add("let d² = x ** 2\n// 😀 emoji\nconst é = \"é\"", int64(2))
+	f.Add("\n\n\n", int64(3))
+	f.Add("let x = 1\r\nprintln(x)\r\n", int64(4))
+	f.Add("a\rb\r\nc\n\r\r\n", int64(5))
+	f.Add(long+long, int64(6))
+
+	f.Fuzz(func(t *testing.T, text string, seed int64) {
+		if !utf8.ValidString(text) {
+			t.Skip()
+		}
+
+		for _, encoding := range []PositionEncoding{PositionEncodingUTF8, PositionEncodingUTF16, PositionEncodingUTF32} {
+			r := rand.New(rand.NewSource(seed))
+			expected := text
+			buffer := NewTextBuffer(text)
+
+			for i := 0; i < 20; i++ {
+				start := runeOffset(r, expected)
+				end := runeOffset(r, expected)
+				if start > end {
+					start, end = end, start
+				}
+				insert := fragments[r.Intn(len(fragments))] + fragments[r.Intn(len(fragments))]
+
+				rng := Range{Start: positionOf(expected, start, encoding), End: positionOf(expected, end, encoding)}
+				if got := buffer.Offset(rng.Start, encoding); got != start {
+					t.Fatalf("%s: Offset(%v) = %d, want %d in %q", encoding, rng.Start, got, start, expected)
+				}
+				if got := buffer.Position(end, encoding); got != rng.End {
+					t.Fatalf("%s: Position(%d) = %v, want %v in %q", encoding, end, got, rng.End, expected)
+				}
+
+				if err := buffer.Replace(rng, insert, encoding); err != nil {
+					t.Fatalf("%s: Replace(%v, %q): %s", encoding, rng, insert, err)
+				}
+				expected = expected[:start] + insert + expected[end:]
+
+				if buffer.String() != expected {
+					t.Fatalf("%s: after replacing %v with %q, got %q, want %q", encoding, rng, insert, buffer.String(), expected)
+				}
+				checkRope(t, buffer.rope)
+				lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(expected, "\r\n", "\n"), "\r", "\n"), "\n")
+				if buffer.LineCount() != len(lines) {
+					t.Fatalf("%s: got %d lines, want %d in %q", encoding, buffer.LineCount(), len(lines), expected)
+				}
+				if n := r.Intn(len(lines)); buffer.Line(n) != lines[n] {
+					t.Fatalf("%s: Line(%d) = %q, want %q", encoding, n, buffer.Line(n), lines[n])
+				}
+			}
+		}
+	})
+}
+
+// checkRope checks that the lengths and line breaks recorded in r match its
+// text and that it is balanced.
+func checkRope(t *testing.T, r *rope) string {
+	t.Helper()
+	if r == nil {
+		return ""
+	}
+
+	var text string
+	if r.leaf() {
+		if r.text == "" || r.height != 1 {
+			t.Fatalf("leaf %q has height %d", r.text, r.height)
+		}
+		text = r.text
+	} else {
+		if d := r.left.depth() - r.right.depth(); d < -1 || d > 1 || r.height != r.left.depth()+1 && r.height != r.right.depth()+1 {
+			t.Fatalf("unbalanced node: heights %d and %d under %d", r.left.depth(), r.right.depth(), r.height)
+		}
+		text = checkRope(t, r.left) + checkRope(t, r.right)
+	}
+	if r.length != len(text) || r.breaks != countBreaks(text) || r.startsLF != (text[0] == '\n') || r.endsCR != (text[len(text)-1] == '\r') {
+		t.Fatalf("node of %q records length %d and %d breaks", text, r.length, r.breaks)
+	}
+	return text
+}
+
+func TestTextBufferClamp(t *testing.T) {
+	t.Parallel()
+
+	buffer := NewTextBuffer("d² = 1\nx")
+
+	tests := []struct {
+		position Position
+		encoding PositionEncoding
+		offset   int
+	}{
+		{Position{Line: 0, Character: 2}, PositionEncodingUTF16, 3},
+		{Position{Line: 0, Character: 2}, PositionEncodingUTF8, 1}, // inside ², rounded down
+		{Position{Line: 0, Character: 3}, PositionEncodingUTF8, 3},
+		{Position{Line: 0, Character: 100}, PositionEncodingUTF16, 7},
+		{Position{Line: 1, Character: 100}, PositionEncodingUTF16, 9},
+		{Position{Line: 5, Character: 0}, PositionEncodingUTF16, 9},
+	}
+
+	for _, test := range tests {
+		if got := buffer.Offset(test.position, test.encoding); got != test.offset {
+			t.Errorf("%s: Offset(%v) = %d, want %d", test.encoding, test.position, got, test.offset)
+		}
+	}
+
+	crlf := NewTextBuffer("a\r\nb\rc\n")
+	for i, want := range []string{"a", "b", "c", ""} {
+		if got := crlf.Line(i); got != want {
+			t.Errorf("Line(%d) = %q, want %q", i, got, want)
+		}
+	}
+	if got := crlf.Offset(Position{Line: 1, Character: 5}, PositionEncodingUTF16); got != 4 {
+		t.Errorf("Offset past the end of a CRLF line = %d, want 4", got)
+	}
+	if got := crlf.Position(2, PositionEncodingUTF16); got != (Position{Line: 0, Character: 1}) {
+		t.Errorf("Position inside a CRLF terminator = %v, want 0:1", got)
+	}
+	// "\n" after a lone "\r" joins it into a single line terminator
+	if err := crlf.Replace(Range{Start: Position{Line: 2}, End: Position{Line: 2}}, "\n", PositionEncodingUTF16); err != nil {
+		t.Fatal(err)
+	}
+	if got := crlf.LineCount(); got != 4 || crlf.String() != "a\r\nb\r\nc\n" {
+		t.Errorf("got %d lines in %q, want 4 in %q", got, crlf.String(), "a\r\nb\r\nc\n")
+	}
+
+	if err := buffer.Replace(Range{Start: Position{Line: 1}, End: Position{Line: 0}}, "", PositionEncodingUTF16); err == nil {
+		t.Errorf("expected an error for a range that ends before it starts")
+	}
+}
+
+func TestDidChangeRollback(t *testing.T) {
+	t.Parallel()
+
+	u := DocumentURI("file:///main.goose")
+	ls := &LanguageServer{
+		logger:      zap.NewNop(),
+		encoding:    PositionEncodingUTF16,
+		sourceFiles: map[DocumentURI]*Mutexed[*TextBuffer]{u: {v: NewTextBuffer("let x = 1\n")}},
+	}
+	changes := []contentChange{
+		{Range: &Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 9}}, Text: "2"},
+		{Range: &Range{Start: Position{Line: 1}, End: Position{Line: 0}}, Text: ""},
+	}
+	if err := ls.didChange(context.Background(), u, changes); err == nil {
+		t.Fatalf("expected an error for a range that ends before it starts")
+	}
+	if got := ls.sourceFiles[u].v.String(); got != "let x = 1\n" {
+		t.Errorf("text after a failed batch = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,314 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+)
+
+// callSite is a call of a named function in a checked module.
+type callSite struct {
+	callee *ast.Ident             // the name the function is called by
+	caller *validator.Declaration // the declared function the call is in, or nil at the top level
+	file   string                 // the file of the module the call is in
+}
+
+// callData identifies the declaration of a call hierarchy item when the
+// client sends it back. Items for the top level of a module have an offset
+// of -1.
+type callData struct {
+	File   string `json:"file"`
+	Offset int    `json:"offset"`
+}
+
+// callable returns the declared function, generator or struct that decl
+// names, or nil if it can't be called.
+func callable(decl *validator.Declaration) ast.Node {
+	node := decl.Decl
+	switch n := node.(type) {
+	case *ast.LetStmt:
+		node = n.Value
+	case *ast.ConstStmt:
+		node = n.Value
+	}
+	switch node.(type) {
+	case *ast.FuncExpr, *ast.GeneratorExpr, *ast.StructStmt:
+		return node
+	}
+	return nil
+}
+
+// callSites returns every call of a named function in the modules checked
+// by v, along with the declared function each call is in.
+func callSites(v *validator.Validator) []callSite {
+	type function struct {
+		body ast.Node
+		decl *validator.Declaration
+	}
+	var functions []function
+	for _, decl := range v.Declarations() {
+		switch node := callable(decl).(type) {
+		case *ast.FuncExpr, *ast.GeneratorExpr:
+			functions = append(functions, function{node, decl})
+		case *ast.StructStmt:
+			if node.Init != nil {
+				functions = append(functions, function{node.Init, decl})
+			}
+		}
+	}
+
+	fset := v.Fset()
+	sites := []callSite{}
+	for _, module := range v.Modules() {
+		if module == nil || module.Module == nil {
+			continue
+		}
+		ast.Walk(module.Module, func(node any) {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return
+			}
+			site := callSite{file: fset.Position(call.LParen).Filename}
+			switch fn := call.Func.(type) {
+			case *ast.Ident:
+				site.callee = fn
+			case *ast.SelectorExpr:
+				site.callee = fn.Sel
+			default:
+				return
+			}
+
+			// the innermost function that contains the call
+			var inner ast.Node
+			for _, f := range functions {
+				if f.body.Pos() <= call.LParen && call.LParen < f.body.End() && (inner == nil || f.body.Pos() > inner.Pos()) {
+					inner, site.caller = f.body, f.decl
+				}
+			}
+			sites = append(sites, site)
+		})
+	}
+	return sites
+}
+
+// callItem returns the call hierarchy item of a declared function, or false
+// if it isn't declared in a file.
+func (ls *LanguageServer) callItem(fset *token.FileSet, decl *validator.Declaration) (CallHierarchyItem, bool) {
+	node := callable(decl)
+	if node == nil || decl.Ident == nil {
+		return CallHierarchyItem{}, false
+	}
+	file := fset.Position(decl.Decl.Pos()).Filename
+	u, ok := ls.documentURI(file)
+	if !ok {
+		return CallHierarchyItem{}, false
+	}
+
+	name := decl.Name
+	kind := SymbolKindFunction
+	switch node := node.(type) {
+	case *ast.FuncExpr:
+		if node.Receiver != nil {
+			name = node.Receiver.Name + "." + name
+			kind = SymbolKindMethod
+		}
+	case *ast.GeneratorExpr:
+		if node.Receiver != nil {
+			name = node.Receiver.Name + "." + name
+			kind = SymbolKindMethod
+		}
+	case *ast.StructStmt:
+		kind = SymbolKindStruct
+	}
+
+	key := keyOf(fset, decl)
+	return CallHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		Detail:         signature(fset, ls.source(file), decl),
+		URI:            u,
+		Range:          ls.Range(fset, decl.Decl),
+		SelectionRange: ls.Range(fset, decl.Ident),
+		Data:           callData{File: key.file, Offset: key.offset},
+	}, true
+}
+
+// moduleItem returns the call hierarchy item of the top level of the module
+// in file, which makes the calls outside of any function.
+func (ls *LanguageServer) moduleItem(file string) (CallHierarchyItem, bool) {
+	u, ok := ls.documentURI(file)
+	if !ok {
+		return CallHierarchyItem{}, false
+	}
+	return CallHierarchyItem{
+		Name: filepath.Base(u.Filename()),
+		Kind: SymbolKindFile,
+		URI:  u,
+		Data: callData{File: strings.TrimPrefix(file, "file:"), Offset: -1},
+	}, true
+}
+
+// itemKey returns the key of the declaration of a call hierarchy item sent by
+// the client.
+func itemKey(item CallHierarchyItem) (declKey, bool) {
+	raw, err := json.Marshal(item.Data)
+	if err != nil {
+		return declKey{}, false
+	}
+	var data callData
+	if err := json.Unmarshal(raw, &data); err != nil || data.File == "" {
+		return declKey{}, false
+	}
+	return declKey{file: data.File, offset: data.Offset}, true
+}
+
+// callerKey returns the key of the function a call is in, or the key of the
+// top level of its module.
+func callerKey(fset *token.FileSet, site callSite) declKey {
+	if site.caller != nil {
+		return keyOf(fset, site.caller)
+	}
+	return declKey{file: strings.TrimPrefix(site.file, "file:"), offset: -1}
+}
+
+// siteKey identifies a call across validators by the position of its callee.
+func siteKey(fset *token.FileSet, site callSite) declKey {
+	pos := fset.Position(site.callee.Pos())
+	return declKey{file: strings.TrimPrefix(pos.Filename, "file:"), offset: pos.Offset}
+}
+
+// sortRanges orders ranges by their start.
+func sortRanges(ranges []Range) {
+	sort.Slice(ranges, func(a, b int) bool {
+		return CmpPositions(ranges[a].Start, ranges[b].Start) < 0
+	})
+}
+
+func (ls *LanguageServer) PrepareCallHierarchy(ctx context.Context, params *CallHierarchyPrepareParams) (result []CallHierarchyItem, err error) {
+	ls.logger.Sugar().Debugf("PrepareCallHierarchy: %s", params.TextDocument.URI.Filename())
+	v, _, decl := ls.declarationAt(params.TextDocument.URI, params.Position)
+	if decl == nil {
+		return nil, nil
+	}
+	item, ok := ls.callItem(v.Fset(), decl)
+	if !ok {
+		return nil, nil
+	}
+	return []CallHierarchyItem{item}, nil
+}
+
+// IncomingCalls finds the calls of a function in every checked document and
+// the modules they import, grouped by the function they are made from.
+func (ls *LanguageServer) IncomingCalls(ctx context.Context, params *CallHierarchyIncomingCallsParams) (result []CallHierarchyIncomingCall, err error) {
+	ls.logger.Sugar().Debugf("IncomingCalls: %s", params.Item.Name)
+	key, ok := itemKey(params.Item)
+	if !ok {
+		return nil, nil
+	}
+
+	calls := map[declKey]*CallHierarchyIncomingCall{}
+	seen := map[declKey]bool{}
+	for _, v := range ls.validators {
+		fset := v.Fset()
+		resolved := v.Resolved()
+		for _, site := range callSites(v) {
+			decl, ok := resolved[site.callee]
+			if !ok || keyOf(fset, decl) != key {
+				continue
+			}
+			if seen[siteKey(fset, site)] {
+				continue
+			}
+			seen[siteKey(fset, site)] = true
+
+			caller := callerKey(fset, site)
+			call, ok := calls[caller]
+			if !ok {
+				var from CallHierarchyItem
+				if site.caller != nil {
+					from, ok = ls.callItem(fset, site.caller)
+				} else {
+					from, ok = ls.moduleItem(site.file)
+				}
+				if !ok {
+					continue
+				}
+				call = &CallHierarchyIncomingCall{From: from}
+				calls[caller] = call
+			}
+			call.FromRanges = append(call.FromRanges, ls.Range(fset, site.callee))
+		}
+	}
+
+	result = []CallHierarchyIncomingCall{}
+	for _, call := range calls {
+		sortRanges(call.FromRanges)
+		result = append(result, *call)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].From.URI != result[b].From.URI {
+			return result[a].From.URI < result[b].From.URI
+		}
+		return CmpPositions(result[a].From.Range.Start, result[b].From.Range.Start) < 0
+	})
+	return result, nil
+}
+
+// OutgoingCalls finds the calls made from a function, or from the top level
+// of a module, grouped by the function they call.
+func (ls *LanguageServer) OutgoingCalls(ctx context.Context, params *CallHierarchyOutgoingCallsParams) (result []CallHierarchyOutgoingCall, err error) {
+	ls.logger.Sugar().Debugf("OutgoingCalls: %s", params.Item.Name)
+	key, ok := itemKey(params.Item)
+	if !ok {
+		return nil, nil
+	}
+
+	calls := map[declKey]*CallHierarchyOutgoingCall{}
+	seen := map[declKey]bool{}
+	for _, v := range ls.validators {
+		fset := v.Fset()
+		resolved := v.Resolved()
+		for _, site := range callSites(v) {
+			if callerKey(fset, site) != key {
+				continue
+			}
+			decl, ok := resolved[site.callee]
+			if !ok {
+				continue
+			}
+			if seen[siteKey(fset, site)] {
+				continue
+			}
+			seen[siteKey(fset, site)] = true
+
+			callee := keyOf(fset, decl)
+			call, ok := calls[callee]
+			if !ok {
+				to, ok := ls.callItem(fset, decl)
+				if !ok {
+					continue
+				}
+				call = &CallHierarchyOutgoingCall{To: to}
+				calls[callee] = call
+			}
+			call.FromRanges = append(call.FromRanges, ls.Range(fset, site.callee))
+		}
+	}
+
+	result = []CallHierarchyOutgoingCall{}
+	for _, call := range calls {
+		sortRanges(call.FromRanges)
+		result = append(result, *call)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return CmpPositions(result[a].FromRanges[0].Start, result[b].FromRanges[0].Start) < 0
+	})
+	return result, nil
+}
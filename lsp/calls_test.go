@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "go.lsp.dev/protocol"
+)
+
+func TestCallHierarchy(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"lib.goose": "export fn double(x) -> x * 2\n",
+		"main.goose": `import "./lib.goose" show { double }
+
+fn quad(x)
+  return double(double(x))
+end
+
+struct Box(value) init
+  #value = quad(#value)
+end
+
+println(quad(1), Box(2))
+`,
+	}
+	dir := writeWorkspace(t, files)
+	ls, main := checkedServer(t, filepath.Join(dir, "main.goose"))
+	ctx := context.Background()
+
+	// quad, at its declaration
+	items, err := ls.PrepareCallHierarchy(ctx, &CallHierarchyPrepareParams{TextDocumentPositionParams: TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: main},
+		Position:     Position{Line: 2, Character: 4},
+	}})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("got %v, %v; want one item", items, err)
+	}
+	quad := items[0]
+	if quad.Name != "quad" || quad.Kind != SymbolKindFunction || quad.Detail != "fn quad(x)" {
+		t.Errorf("got item %+v", quad)
+	}
+
+	incoming, err := ls.IncomingCalls(ctx, &CallHierarchyIncomingCallsParams{Item: quad})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var from []string
+	for _, call := range incoming {
+		from = append(from, call.From.Name)
+		if len(call.FromRanges) != 1 {
+			t.Errorf("%s: got %d calls, want 1", call.From.Name, len(call.FromRanges))
+		}
+	}
+	if len(from) != 2 || from[0] != "main.goose" || from[1] != "Box" {
+		t.Fatalf("got calls from %v, want main.goose and Box", from)
+	}
+
+	outgoing, err := ls.OutgoingCalls(ctx, &CallHierarchyOutgoingCallsParams{Item: quad})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outgoing) != 1 || outgoing[0].To.Name != "double" || len(outgoing[0].FromRanges) != 2 {
+		t.Fatalf("got %+v, want two calls of double", outgoing)
+	}
+	if got := outgoing[0].To.URI.Filename(); got != filepath.Join(dir, "lib.goose") {
+		t.Errorf("got double in %s", got)
+	}
+
+	// the top level calls quad and Box; println is a builtin
+	top, err := ls.OutgoingCalls(ctx, &CallHierarchyOutgoingCallsParams{Item: incoming[0].From})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].To.Name != "quad" || top[1].To.Name != "Box" || top[1].To.Kind != SymbolKindStruct {
+		t.Errorf("got %+v, want calls of quad and Box", top)
+	}
+}
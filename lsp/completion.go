@@ -0,0 +1,313 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/lib"
+	"github.com/calico32/goose/token"
+	. "go.lsp.dev/protocol"
+)
+
+var (
+	importPattern   = regexp.MustCompile(`\bimport\s+"([^"]*)$`)
+	memberPattern   = regexp.MustCompile(`((?:[A-Za-z_$][\w$]*\.)+)[\w$]*$`)
+	propertyPattern = regexp.MustCompile(`#[\w$]*$`)
+)
+
+// snippets are offered alongside keywords when completing at the start of a
+// statement.
+var snippets = []struct {
+	label  string
+	detail string
+	body   string
+}{
+	{"fn", "function declaration", "fn ${1:name}(${2})\n\t$0\nend"},
+	{"generator", "generator declaration", "generator ${1:name}(${2})\n\t$0\nend"},
+	{"struct", "struct declaration", "struct ${1:Name}(${2})"},
+	{"if", "if statement", "if ${1:condition}\n\t$0\nend"},
+	{"if else", "if/else statement", "if ${1:condition}\n\t$2\nelse\n\t$0\nend"},
+	{"for", "for loop", "for ${1:x} in ${2:iterable}\n\t$0\nend"},
+	{"repeat while", "while loop", "repeat while ${1:condition}\n\t$0\nend"},
+	{"repeat times", "counted loop", "repeat ${1:n} times\n\t$0\nend"},
+	{"repeat forever", "infinite loop", "repeat forever\n\t$0\nend"},
+	{"match", "match expression", "match ${1:value}\n\t${2:pattern} -> ${3:result}\n\telse -> ${0:null}\nend"},
+	{"do", "do block", "do\n\t$0\nend"},
+}
+
+func (ls *LanguageServer) Completion(ctx context.Context, params *CompletionParams) (result *CompletionList, err error) {
+	ls.logger.Sugar().Debugf("Completion: %s", params.TextDocument.URI.Filename())
+	sourceMu, ok := ls.sourceFiles[params.TextDocument.URI]
+	if !ok {
+		ls.logger.Sugar().Errorf("document not found: %s", params.TextDocument.URI.Filename())
+		return nil, errors.New("document not found")
+	}
+	source := sourceMu.Lock()
+	line := lineBefore(source, params.Position, ls.encoding)
+	sourceMu.Unlock()
+
+	items := []CompletionItem{}
+	if m := importPattern.FindStringSubmatch(line); m != nil {
+		items = ls.importCompletions(params.TextDocument.URI, params.Position, m[1])
+	} else if inCommentOrString(line) {
+		// nothing to complete
+	} else if m := memberPattern.FindStringSubmatch(line); m != nil {
+		names := strings.Split(strings.TrimSuffix(m[1], "."), ".")
+		items = ls.memberCompletions(params.TextDocument.URI, params.Position, names)
+	} else if propertyPattern.MatchString(line) {
+		items = ls.memberCompletions(params.TextDocument.URI, params.Position, []string{"this"})
+	} else {
+		items = append(ls.scopeCompletions(params.TextDocument.URI, params.Position), keywordCompletions()...)
+	}
+
+	return &CompletionList{Items: items}, nil
+}
+
+// lineBefore returns the text of the line containing position, up to position.
+func lineBefore(source *TextBuffer, position Position, encoding PositionEncoding) string {
+	line := source.Line(int(position.Line))
+	return line[:byteOffset(line, position.Character, encoding)]
+}
+
+// inCommentOrString reports whether the end of line is inside a line comment
+// or an unterminated string literal.
+func inCommentOrString(line string) bool {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && inString:
+			i++
+		case line[i] == '"':
+			inString = !inString
+		case !inString && strings.HasPrefix(line[i:], "//"):
+			return true
+		}
+	}
+	return inString
+}
+
+// scopeAt returns the validator scope at position, or nil if the document
+// has not been validated.
+func (ls *LanguageServer) scopeAt(uri DocumentURI, position Position) *Scope {
+	v, ok := ls.validators[uri]
+	if !ok {
+		return nil
+	}
+	pos := ls.Pos(v.Fset(), uri, position)
+	if pos == token.NoPos {
+		return nil
+	}
+	return v.ScopeAt(pos)
+}
+
+func (ls *LanguageServer) scopeCompletions(uri DocumentURI, position Position) []CompletionItem {
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	depth := 0
+	for scope := ls.scopeAt(uri, position); scope != nil; scope = scope.Parent() {
+		for name, variable := range scope.Idents() {
+			if seen[name] || variable == nil || strings.HasPrefix(name, "#") {
+				continue
+			}
+			seen[name] = true
+			items = append(items, CompletionItem{
+				Label:    name,
+				Kind:     variableKind(variable),
+				Detail:   valueDetail(variable.Value),
+				SortText: fmt.Sprintf("%02d%s", depth, name),
+			})
+		}
+		depth++
+	}
+	return items
+}
+
+func (ls *LanguageServer) memberCompletions(uri DocumentURI, position Position, names []string) []CompletionItem {
+	scope := ls.scopeAt(uri, position)
+	if scope == nil {
+		return []CompletionItem{}
+	}
+	variable := scope.Get(names[0])
+	if variable == nil {
+		return []CompletionItem{}
+	}
+	value := variable.Value
+	for _, name := range names[1:] {
+		if value == nil {
+			return []CompletionItem{}
+		}
+		value = GetProperty(value, NewString(name))
+		if value == NullValue {
+			value = nil
+		}
+	}
+	if value == nil {
+		return []CompletionItem{}
+	}
+
+	var c *Composite
+	if composite, ok := value.(*Composite); ok {
+		c = composite
+	} else {
+		c = value.Prototype()
+	}
+
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	for depth := 0; c != nil; depth++ {
+		for name, member := range c.Properties[PKString] {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			kind := CompletionItemKindField
+			if fn, ok := member.(*Func); ok && fn != nil {
+				kind = CompletionItemKindMethod
+				if fn.NewableProto != nil {
+					kind = CompletionItemKindStruct
+				}
+			}
+			items = append(items, CompletionItem{
+				Label:    name,
+				Kind:     kind,
+				Detail:   valueDetail(member),
+				SortText: fmt.Sprintf("%02d%s", depth, name),
+			})
+		}
+		c = c.Proto
+	}
+	return items
+}
+
+func keywordCompletions() []CompletionItem {
+	items := []CompletionItem{}
+	for tok := token.KeywordStart + 1; tok < token.KeywordEnd; tok++ {
+		items = append(items, CompletionItem{
+			Label:    tok.String(),
+			Kind:     CompletionItemKindKeyword,
+			SortText: "50" + tok.String(),
+		})
+	}
+	for _, snippet := range snippets {
+		items = append(items, CompletionItem{
+			Label:            snippet.label,
+			Kind:             CompletionItemKindSnippet,
+			Detail:           snippet.detail,
+			InsertText:       snippet.body,
+			InsertTextFormat: InsertTextFormatSnippet,
+			SortText:         "51" + snippet.label,
+		})
+	}
+	return items
+}
+
+// importCompletions completes a module specifier. prefix is the part of the
+// specifier before the cursor.
+func (ls *LanguageServer) importCompletions(uri DocumentURI, position Position, prefix string) []CompletionItem {
+	editRange := Range{
+		Start: Position{Line: position.Line, Character: position.Character - encodedLen(prefix, ls.encoding)},
+		End:   position,
+	}
+	item := func(label string, kind CompletionItemKind, specifier string) CompletionItem {
+		return CompletionItem{
+			Label:    label,
+			Kind:     kind,
+			TextEdit: &TextEdit{Range: editRange, NewText: specifier},
+		}
+	}
+
+	items := []CompletionItem{}
+	switch {
+	case strings.HasPrefix(prefix, "std:"):
+		entries, _ := lib.Stdlib.ReadDir("std")
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".goose")
+			if name == "language" {
+				continue
+			}
+			items = append(items, item(name, CompletionItemKindModule, "std:"+name))
+		}
+	case strings.HasPrefix(prefix, "pkg:"):
+		if v, ok := ls.validators[uri]; ok {
+			entries, _ := os.ReadDir(filepath.Join(v.GooseRoot(), "pkg"))
+			for _, entry := range entries {
+				if entry.IsDir() {
+					items = append(items, item(entry.Name(), CompletionItemKindModule, "pkg:"+entry.Name()))
+				}
+			}
+		}
+	case strings.HasPrefix(prefix, "file:") || strings.HasPrefix(prefix, ".") || strings.HasPrefix(prefix, "/"):
+		path := strings.TrimPrefix(prefix, "file:")
+		dir := path[:strings.LastIndex(path, "/")+1]
+		base := dir
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(uri.Filename()), dir)
+		}
+		entries, _ := os.ReadDir(base)
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") {
+				continue
+			}
+			if entry.IsDir() {
+				items = append(items, item(name+"/", CompletionItemKindFolder, strings.TrimSuffix(prefix, path)+dir+name+"/"))
+			} else if strings.HasSuffix(name, ".goose") && uri.Filename() != filepath.Join(base, name) {
+				items = append(items, item(name, CompletionItemKindFile, strings.TrimSuffix(prefix, path)+dir+name))
+			}
+		}
+	default:
+		for _, scheme := range []string{"std:", "pkg:", "file:", "./", "../"} {
+			items = append(items, item(scheme, CompletionItemKindModule, scheme))
+		}
+	}
+
+	sort.Slice(items, func(a, b int) bool {
+		return items[a].Label < items[b].Label
+	})
+	return items
+}
+
+func variableKind(variable *Variable) CompletionItemKind {
+	if fn, ok := variable.Value.(*Func); ok && fn != nil {
+		if fn.NewableProto != nil {
+			return CompletionItemKindStruct
+		}
+		return CompletionItemKindFunction
+	}
+	if variable.Constant {
+		return CompletionItemKindConstant
+	}
+	return CompletionItemKindVariable
+}
+
+func valueDetail(value Value) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case *Func:
+		if value == nil {
+			return ""
+		}
+		if value.NewableProto != nil {
+			return "struct"
+		}
+		return "fn"
+	case *Composite:
+		if value == nil {
+			return ""
+		}
+		if value.Proto != nil && value.Proto.Name != "" {
+			return value.Proto.Name
+		}
+		return "composite"
+	default:
+		return value.Type()
+	}
+}
@@ -0,0 +1,130 @@
+package lsp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "go.lsp.dev/protocol"
+)
+
+func TestCompletion(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"lib.goose":   "export fn double(x) -> x * 2\n",
+		"sub/a.goose": "",
+		"notes.txt":   "",
+		"main.goose": `import "std:math"
+import "./lib.goose"
+
+let scale = 2
+const origin = 0
+
+struct Point(x, y)
+
+fn Point.norm()
+  return math.sqrt(#x * #x + #y * #y)
+end
+
+fn area(w, h)
+  let inner = w * h
+  return inner * scale
+end
+
+let p = Point(3, 4)
+println(p.norm(), math.pi) // p.
+let s = "p.x"
+`,
+	}
+	dir := writeWorkspace(t, files)
+	ls, u := openServer(t, filepath.Join(dir, "main.goose"))
+
+	tests := []struct {
+		name    string
+		pos     Position
+		want    map[string]CompletionItemKind
+		exclude []string
+	}{
+		// scope names, including those of enclosing scopes, and keywords
+		{"function scope", Position{Line: 14, Character: 2}, map[string]CompletionItemKind{
+			"inner":   CompletionItemKindVariable,
+			"w":       CompletionItemKindVariable,
+			"scale":   CompletionItemKindVariable,
+			"origin":  CompletionItemKindConstant,
+			"area":    CompletionItemKindFunction,
+			"Point":   CompletionItemKindStruct,
+			"math":    CompletionItemKindConstant,
+			"return":  CompletionItemKindKeyword,
+			"if else": CompletionItemKindSnippet,
+		}, nil},
+		{"top level", Position{Line: 17, Character: 0}, map[string]CompletionItemKind{
+			"scale": CompletionItemKindVariable,
+			"p":     CompletionItemKindVariable,
+		}, []string{"inner", "w"}},
+		// members of values, modules and this
+		{"instance", Position{Line: 18, Character: 10}, map[string]CompletionItemKind{
+			"x":    CompletionItemKindField,
+			"norm": CompletionItemKindMethod,
+		}, []string{"scale"}},
+		{"module", Position{Line: 18, Character: 23}, map[string]CompletionItemKind{
+			"sqrt": CompletionItemKindMethod,
+			"pi":   CompletionItemKindField,
+		}, []string{"x"}},
+		{"this", Position{Line: 9, Character: 20}, map[string]CompletionItemKind{
+			"x":    CompletionItemKindField,
+			"norm": CompletionItemKindMethod,
+		}, nil},
+		// module specifiers
+		{"std import", Position{Line: 0, Character: 12}, map[string]CompletionItemKind{
+			"math":   CompletionItemKindModule,
+			"random": CompletionItemKindModule,
+		}, []string{"language", "scale"}},
+		{"file import", Position{Line: 1, Character: 10}, map[string]CompletionItemKind{
+			"lib.goose": CompletionItemKindFile,
+			"sub/":      CompletionItemKindFolder,
+		}, []string{"main.goose", "notes.txt"}},
+		{"scheme", Position{Line: 1, Character: 8}, map[string]CompletionItemKind{
+			"std:": CompletionItemKindModule,
+			"./":   CompletionItemKindModule,
+		}, nil},
+		// nothing in comments and strings
+		{"comment", Position{Line: 18, Character: 33}, map[string]CompletionItemKind{}, []string{"x", "scale"}},
+		{"string", Position{Line: 19, Character: 11}, map[string]CompletionItemKind{}, []string{"x", "scale"}},
+	}
+	for _, test := range tests {
+		list, err := ls.Completion(context.Background(), &CompletionParams{TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: u},
+			Position:     test.pos,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]CompletionItemKind{}
+		for _, item := range list.Items {
+			if _, ok := got[item.Label]; !ok {
+				got[item.Label] = item.Kind
+			}
+		}
+		for label, kind := range test.want {
+			if k, ok := got[label]; !ok {
+				t.Errorf("%s: missing %s", test.name, label)
+			} else if k != kind {
+				t.Errorf("%s: %s has kind %v, want %v", test.name, label, k, kind)
+			}
+		}
+		for _, label := range test.exclude {
+			if _, ok := got[label]; ok {
+				t.Errorf("%s: unexpected %s", test.name, label)
+			}
+		}
+		for _, item := range list.Items {
+			if item.TextEdit != nil && item.TextEdit.Range.Start.Character != 8 {
+				t.Errorf("%s: %s replaces %v, want the specifier", test.name, item.Label, item.TextEdit.Range)
+			}
+		}
+		if len(test.want) == 0 && len(list.Items) != 0 {
+			t.Errorf("%s: got %d items, want none", test.name, len(list.Items))
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package lsp
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+	. "go.lsp.dev/protocol"
+)
+
+func (ls *LanguageServer) FoldingRanges(ctx context.Context, params *FoldingRangeParams) (result []FoldingRange, err error) {
+	ls.logger.Sugar().Debugf("FoldingRanges: %s", params.TextDocument.URI.Filename())
+	uri := params.TextDocument.URI
+	v, ok := ls.validators[uri]
+	if !ok {
+		return []FoldingRange{}, nil
+	}
+	src := ls.snapshots[uri]
+	file := v.Fset().File("file:" + uri.Filename())
+	if file == nil || file.Size() != len(src) {
+		return []FoldingRange{}, nil
+	}
+
+	moduleMu := ls.modules[uri]
+	module := moduleMu.Lock()
+	defer moduleMu.Unlock()
+	return foldingRanges(module, file.Base(), src), nil
+}
+
+// foldingRanges returns the ranges of src, the text of module, that can be
+// folded: blocks closed by end, split where else, catch and finally start a
+// new part; brackets spanning several lines; runs of comments; and groups of
+// imports. The last line of a range is the one before its closing keyword or
+// bracket, which stays visible.
+func foldingRanges(module *ast.Module, base int, src []byte) []FoldingRange {
+	offset := func(pos token.Pos) int { return int(pos) - base }
+	openers := map[int]bool{}  // keywords of blocks closed by end
+	exprElse := map[int]bool{} // else of if expressions and match arms
+	ast.Walk(module, func(node any) {
+		switch n := node.(type) {
+		case *ast.FuncExpr:
+			if !n.Arrow.IsValid() {
+				openers[offset(n.Func)] = true
+			}
+		case *ast.OperatorStmt:
+			if !n.Arrow.IsValid() {
+				openers[offset(n.Operator)] = true
+			}
+		case *ast.GeneratorExpr:
+			openers[offset(n.Generator)] = true
+		case *ast.IfStmt:
+			openers[offset(n.If)] = true
+		case *ast.ForStmt:
+			openers[offset(n.For)] = true
+		case *ast.RepeatWhileStmt:
+			openers[offset(n.Repeat)] = true
+		case *ast.RepeatForeverStmt:
+			openers[offset(n.Repeat)] = true
+		case *ast.RepeatCountStmt:
+			openers[offset(n.Repeat)] = true
+		case *ast.TryStmt:
+			openers[offset(n.Try)] = true
+		case *ast.DoExpr:
+			openers[offset(n.Do)] = true
+		case *ast.MatchExpr:
+			openers[offset(n.Match)] = true
+		case *ast.TraitStmt:
+			openers[offset(n.Trait)] = true
+		case *ast.EnumStmt:
+			openers[offset(n.Enum)] = true
+		case *ast.StructInit:
+			openers[offset(n.Init)] = true
+		case *ast.IfExpr:
+			if n.ElsePos.IsValid() {
+				exprElse[offset(n.ElsePos)] = true
+			}
+		case *ast.MatchElse:
+			exprElse[offset(n.Else)] = true
+		}
+	})
+
+	ranges := []FoldingRange{}
+	fold := func(first int, last int, kind FoldingRangeKind) {
+		if last > first {
+			// token lines start at 1
+			ranges = append(ranges, FoldingRange{StartLine: uint32(first - 1), EndLine: uint32(last - 1), Kind: kind})
+		}
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	s := scanner.Scanner{}
+	s.Init(file, src, func(token.Position, string) {})
+
+	var blocks []int   // the first line of the current part of each open block
+	var brackets []int // the line of each open bracket
+	commentFirst, commentLast := 0, -1
+	prevLine := 0
+	afterElse := false // the last token is the else of a block
+	for {
+		pos, t, lit := s.Scan()
+		if t == token.EOF {
+			break
+		}
+		line := file.Line(pos)
+
+		if t == token.Comment {
+			last := line + strings.Count(lit, "\n")
+			switch {
+			case prevLine == line:
+				// a comment after code doesn't start a run
+			case line == commentLast+1:
+				commentLast = last
+			default:
+				fold(commentFirst, commentLast, CommentFoldingRange)
+				commentFirst, commentLast = line, last
+			}
+			prevLine = last
+			continue
+		}
+		prevLine = line
+
+		switch {
+		case openers[file.Offset(pos)]:
+			if t == token.If && afterElse {
+				// else if continues the block of the first if
+				break
+			}
+			blocks = append(blocks, line)
+		case t == token.Else && !exprElse[file.Offset(pos)], t == token.Catch, t == token.Finally:
+			if len(blocks) > 0 {
+				fold(blocks[len(blocks)-1], line-1, "")
+				blocks[len(blocks)-1] = line
+			}
+		case t == token.End:
+			if len(blocks) > 0 {
+				fold(blocks[len(blocks)-1], line-1, "")
+				blocks = blocks[:len(blocks)-1]
+			}
+		case t == token.LParen, t == token.LBracket, t == token.LBrace, t == token.HashLBracket:
+			brackets = append(brackets, line)
+		case t == token.RParen, t == token.RBracket, t == token.RBrace:
+			if len(brackets) > 0 {
+				fold(brackets[len(brackets)-1], line-1, "")
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+		afterElse = t == token.Else && !exprElse[file.Offset(pos)]
+	}
+	fold(commentFirst, commentLast, CommentFoldingRange)
+
+	// consecutive imports fold together
+	var group []ast.Stmt
+	foldImports := func() {
+		if len(group) > 1 {
+			first := file.Line(file.Pos(offset(group[0].Pos())))
+			last := file.Line(file.Pos(offset(group[len(group)-1].End()) - 1))
+			ranges = append(ranges, FoldingRange{StartLine: uint32(first - 1), EndLine: uint32(last - 1), Kind: ImportsFoldingRange})
+		}
+		group = nil
+	}
+	for _, stmt := range module.Stmts {
+		if _, ok := stmt.(*ast.ImportStmt); ok {
+			group = append(group, stmt)
+		} else {
+			foldImports()
+		}
+	}
+	foldImports()
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].StartLine != ranges[j].StartLine {
+			return ranges[i].StartLine < ranges[j].StartLine
+		}
+		return ranges[i].EndLine > ranges[j].EndLine
+	})
+	return ranges
+}
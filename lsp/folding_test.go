@@ -0,0 +1,61 @@
+package lsp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+	. "go.lsp.dev/protocol"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	t.Parallel()
+
+	src := `import "std:fs"
+import "std:math"
+
+// a comment
+// in two lines
+fn f(x) // not part of the run
+  if x
+    return [
+      1,
+    ]
+  else if x > 1
+    return if x > 2 then 2 else 1
+  else
+    return 0
+  end
+end
+
+try
+  f(1)
+catch as e
+  /* a block
+     comment */
+end
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", []byte(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := foldingRanges(module, fset.File("test.goose").Base(), []byte(src))
+	want := []FoldingRange{
+		{StartLine: 0, EndLine: 1, Kind: ImportsFoldingRange},
+		{StartLine: 3, EndLine: 4, Kind: CommentFoldingRange},
+		{StartLine: 5, EndLine: 14},
+		{StartLine: 6, EndLine: 9},
+		{StartLine: 7, EndLine: 8},
+		{StartLine: 10, EndLine: 11},
+		{StartLine: 12, EndLine: 13},
+		{StartLine: 17, EndLine: 18},
+		{StartLine: 19, EndLine: 21},
+		{StartLine: 20, EndLine: 21, Kind: CommentFoldingRange},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/calico32/goose/format"
+	. "go.lsp.dev/protocol"
+)
+
+// formatLines formats the document at uri and returns the edits that bring
+// its lines from first to last, inclusive, in line with the result.
+func (ls *LanguageServer) formatLines(uri DocumentURI, first int, last int) ([]TextEdit, error) {
+	sourceMu, ok := ls.sourceFiles[uri]
+	if !ok {
+		return nil, errors.New("document not found")
+	}
+	buffer := sourceMu.Lock()
+	defer sourceMu.Unlock()
+
+	formatted, err := format.Source("file:"+uri.Filename(), buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return lineEdits(buffer, strings.Split(string(formatted), "\n"), first, last, ls.encoding), nil
+}
+
+// lineEdits returns the edits that turn the lines from first to last of
+// buffer into the matching lines of formatted. The formatter only changes the
+// whitespace of a document, so every line with text in one has a
+// counterpart in the other, and only the runs of blank lines between them can
+// differ in length.
+func lineEdits(buffer *TextBuffer, formatted []string, first int, last int, encoding PositionEncoding) []TextEdit {
+	original := make([]string, buffer.LineCount())
+	for i := range original {
+		original[i] = buffer.Line(i)
+	}
+	src, dst := textLines(original), textLines(formatted)
+	if len(src) != len(dst) {
+		end := buffer.Position(buffer.Len(), encoding)
+		return []TextEdit{{Range: Range{End: end}, NewText: strings.Join(formatted, "\n")}}
+	}
+
+	edits := []TextEdit{}
+	inRange := func(line int) bool { return line >= first && line <= last }
+	endOf := func(line int) Position {
+		return Position{Line: uint32(line), Character: encodedLen(original[line], encoding)}
+	}
+	replace := func(line int, text string) {
+		if original[line] != text && inRange(line) {
+			edits = append(edits, TextEdit{Range: Range{Start: Position{Line: uint32(line)}, End: endOf(line)}, NewText: text})
+		}
+	}
+
+	for k := 0; k <= len(src); k++ {
+		// the blank lines before the k-th line with text
+		srcStart, dstStart := 0, 0
+		if k > 0 {
+			srcStart, dstStart = src[k-1]+1, dst[k-1]+1
+		}
+		srcEnd, dstEnd := len(original), len(formatted)
+		if k < len(src) {
+			srcEnd, dstEnd = src[k], dst[k]
+		}
+
+		n := 0
+		for ; srcStart+n < srcEnd && dstStart+n < dstEnd; n++ {
+			replace(srcStart+n, formatted[dstStart+n])
+		}
+		switch from, to := srcStart+n, srcEnd-1; {
+		case from <= to:
+			if from < first {
+				from = first
+			}
+			if to > last {
+				to = last
+			}
+			if from > to {
+				break
+			}
+			if from == 0 {
+				edits = append(edits, TextEdit{Range: Range{End: Position{Line: uint32(to + 1)}}})
+			} else {
+				edits = append(edits, TextEdit{Range: Range{Start: endOf(from - 1), End: endOf(to)}})
+			}
+		case dstStart+n < dstEnd && from > 0 && inRange(from-1):
+			inserted := "\n" + strings.Join(formatted[dstStart+n:dstEnd], "\n")
+			edits = append(edits, TextEdit{Range: Range{Start: endOf(from - 1), End: endOf(from - 1)}, NewText: inserted})
+		}
+
+		if k < len(src) {
+			replace(src[k], formatted[dst[k]])
+		}
+	}
+	return edits
+}
+
+// textLines returns the indexes of the lines that are not blank.
+func textLines(lines []string) []int {
+	indexes := []int{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (ls *LanguageServer) Formatting(ctx context.Context, params *DocumentFormattingParams) (result []TextEdit, err error) {
+	ls.logger.Sugar().Debugf("Formatting: %s", params.TextDocument.URI.Filename())
+	return ls.formatLines(params.TextDocument.URI, 0, math.MaxInt)
+}
+
+func (ls *LanguageServer) RangeFormatting(ctx context.Context, params *DocumentRangeFormattingParams) (result []TextEdit, err error) {
+	ls.logger.Sugar().Debugf("RangeFormatting: %s", params.TextDocument.URI.Filename())
+	last := int(params.Range.End.Line)
+	if params.Range.End.Character == 0 && last > int(params.Range.Start.Line) {
+		// a selection of whole lines ends at the start of the next one
+		last--
+	}
+	return ls.formatLines(params.TextDocument.URI, int(params.Range.Start.Line), last)
+}
+
+// OnTypeFormatting reindents the line that was just ended by a newline, or the
+// current line once an end is typed. Documents that don't parse while they are
+// being typed are left alone.
+func (ls *LanguageServer) OnTypeFormatting(ctx context.Context, params *DocumentOnTypeFormattingParams) (result []TextEdit, err error) {
+	ls.logger.Sugar().Debugf("OnTypeFormatting: %s", params.TextDocument.URI.Filename())
+	sourceMu, ok := ls.sourceFiles[params.TextDocument.URI]
+	if !ok {
+		return []TextEdit{}, nil
+	}
+
+	line := int(params.Position.Line)
+	switch params.Ch {
+	case "\n":
+		line--
+	case "d":
+		text := sourceMu.Lock().Line(line)
+		sourceMu.Unlock()
+		text = text[:byteOffset(text, params.Position.Character, ls.encoding)]
+		if strings.TrimSpace(text) != "end" {
+			return []TextEdit{}, nil
+		}
+	}
+	if line < 0 {
+		return []TextEdit{}, nil
+	}
+
+	edits, err := ls.formatLines(params.TextDocument.URI, line, line)
+	if err != nil {
+		return []TextEdit{}, nil
+	}
+	return edits, nil
+}
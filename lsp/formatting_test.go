@@ -0,0 +1,62 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/format"
+	. "go.lsp.dev/protocol"
+)
+
+// applyEdits applies non-overlapping edits, which are in document order.
+func applyEdits(t *testing.T, buffer *TextBuffer, edits []TextEdit, encoding PositionEncoding) {
+	for i := len(edits) - 1; i >= 0; i-- {
+		if err := buffer.Replace(edits[i].Range, edits[i].NewText, encoding); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLineEdits(t *testing.T) {
+	t.Parallel()
+
+	sources := []string{
+		"",
+		"let x=1",
+		"\n\n// é\nlet x=1\n\n\n\nfn f(a,b)\nreturn a+b\n  end\n\n\n",
+		"if x\n      println( \"d²\" )\n\n\n  else\n    println(x)\nend",
+	}
+
+	for _, src := range sources {
+		formatted, err := format.Source("test.goose", []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(string(formatted), "\n")
+
+		for _, encoding := range []PositionEncoding{PositionEncodingUTF8, PositionEncodingUTF16} {
+			buffer := NewTextBuffer(src)
+			applyEdits(t, buffer, lineEdits(buffer, lines, 0, buffer.LineCount(), encoding), encoding)
+			if buffer.String() != string(formatted) {
+				t.Errorf("%s: got %q, want %q", encoding, buffer.String(), formatted)
+			}
+
+			// the edits for each line stay on that line and add up to the
+			// edits for the whole document
+			buffer = NewTextBuffer(src)
+			var edits []TextEdit
+			for line := 0; line < buffer.LineCount(); line++ {
+				for _, edit := range lineEdits(buffer, lines, line, line, encoding) {
+					if int(edit.Range.Start.Line) < line-1 || int(edit.Range.End.Line) > line+1 {
+						t.Errorf("%s: edit of %v is outside of line %d in %q", encoding, edit.Range, line, src)
+					}
+					edits = append(edits, edit)
+				}
+			}
+			applyEdits(t, buffer, edits, encoding)
+			if buffer.String() != string(formatted) {
+				t.Errorf("%s: line by line, got %q, want %q", encoding, buffer.String(), formatted)
+			}
+		}
+	}
+}
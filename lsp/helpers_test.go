@@ -0,0 +1,77 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+// writeWorkspace writes files, keyed by slash-separated paths, to a new
+// temporary directory and returns it.
+func writeWorkspace(t *testing.T, files map[string]string) (dir string) {
+	t.Helper()
+	dir = t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// checkedServer returns a language server that has checked the document at
+// path, which may import other files.
+func checkedServer(t *testing.T, path string) (*LanguageServer, DocumentURI) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "file:"+path, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	u := uri.File(path)
+	ls := &LanguageServer{
+		logger:     zap.NewNop(),
+		encoding:   PositionEncodingUTF16,
+		uris:       map[string]DocumentURI{path: u},
+		snapshots:  map[DocumentURI][]byte{u: src},
+		validators: map[DocumentURI]*validator.Validator{u: v},
+	}
+	return ls, u
+}
+
+// openServer is checkedServer with the document also open in the editor, as
+// requests that read the buffer or the parsed module expect.
+func openServer(t *testing.T, path string) (*LanguageServer, DocumentURI) {
+	ls, u := checkedServer(t, path)
+	src := ls.snapshots[u]
+	ls.sourceFiles = map[DocumentURI]*Mutexed[*TextBuffer]{u: {v: NewTextBuffer(string(src))}}
+	module, ok := ls.validators[u].Modules()["file:"+path]
+	if !ok {
+		t.Fatalf("%s was not checked", path)
+	}
+	ls.modules = map[DocumentURI]*Mutexed[*ast.Module]{u: {v: module.Module}}
+	return ls, u
+}
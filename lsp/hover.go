@@ -0,0 +1,354 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/interpreter"
+	"github.com/calico32/goose/lib"
+	std_language "github.com/calico32/goose/lib/std/language"
+	"github.com/calico32/goose/lib/types"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+)
+
+var selectorPattern = regexp.MustCompile(`[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*$`)
+var wordPattern = regexp.MustCompile(`^[\w$]*`)
+
+func (ls *LanguageServer) Hover(ctx context.Context, params *HoverParams) (result *Hover, err error) {
+	ls.logger.Sugar().Debugf("Hover: %s", params.TextDocument.URI.Filename())
+	v, ok := ls.validators[params.TextDocument.URI]
+	if !ok {
+		ls.logger.Sugar().Debugf("module not validated: %s", params.TextDocument.URI.Filename())
+		return nil, nil
+	}
+
+	fset := v.Fset()
+	pos := ls.Pos(fset, params.TextDocument.URI, params.Position)
+	if pos == token.NoPos {
+		return nil, nil
+	}
+
+	ident, decl := v.DeclarationAt(pos)
+	if decl != nil {
+		r := ls.Range(fset, ident)
+		return &Hover{
+			Contents: MarkupContent{Kind: Markdown, Value: ls.describe(v, decl)},
+			Range:    &r,
+		}, nil
+	}
+
+	// names without a declaration may be builtins
+	name := ls.selectorAt(params.TextDocument.URI, params.Position)
+	if name == "" {
+		return nil, nil
+	}
+	if doc, ok := builtinDoc(name); ok {
+		return &Hover{
+			Contents: MarkupContent{Kind: Markdown, Value: describeBuiltin(doc)},
+		}, nil
+	}
+	return nil, nil
+}
+
+// selectorAt returns the dotted name (e.g. "int.parse") ending with the word
+// under position, using the current text of the document.
+func (ls *LanguageServer) selectorAt(uri DocumentURI, position Position) string {
+	sourceMu, ok := ls.sourceFiles[uri]
+	if !ok {
+		return ""
+	}
+	source := sourceMu.Lock()
+	defer sourceMu.Unlock()
+
+	before := lineBefore(source, position, ls.encoding)
+	after := source.Line(int(position.Line))[len(before):]
+	return selectorPattern.FindString(before) + wordPattern.FindString(after)
+}
+
+func builtinDoc(name string) (types.BuiltinDoc, bool) {
+	for _, docs := range [][]types.BuiltinDoc{interpreter.GlobalDocs, std_language.Builtins} {
+		for _, doc := range docs {
+			if doc.Name == name {
+				return doc, true
+			}
+		}
+	}
+	return types.BuiltinDoc{}, false
+}
+
+func describeBuiltin(doc types.BuiltinDoc) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "```goose\n(builtin) %s\n```\n", doc.Signature)
+	if doc.Description != "" {
+		sb.WriteString("\n" + doc.Description + "\n")
+	} else if doc.Desc != "" {
+		sb.WriteString("\n" + doc.Desc + "\n")
+	}
+	return sb.String()
+}
+
+// describe renders the signature and documentation of a declaration as
+// markdown.
+func (ls *LanguageServer) describe(v *validator.Validator, decl *validator.Declaration) string {
+	fset := v.Fset()
+	file := fset.Position(decl.Decl.Pos()).Filename
+	source := ls.source(file)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "```goose\n%s\n```\n", signature(fset, source, decl))
+	if decl.Kind == validator.DeclParameter || decl.Kind == validator.DeclField {
+		// only show the tag that documents them
+		if doc := declDoc(decl).Param(decl.Name); doc != "" {
+			sb.WriteString("\n" + doc + "\n")
+		}
+	} else if doc := renderDoc(declDoc(decl)); doc != "" {
+		sb.WriteString("\n" + doc)
+	}
+	if decl.Module != nil && file != "" && !strings.HasPrefix(file, "file:") {
+		fmt.Fprintf(&sb, "\n*from %s*\n", strings.TrimSuffix(file, "/index.goose"))
+	}
+	return sb.String()
+}
+
+// source returns the contents of the module with the given specifier. Open
+// documents use the text that was last checked, so that positions match.
+func (ls *LanguageServer) source(specifier string) []byte {
+	switch {
+	case strings.HasPrefix(specifier, "file:"):
+		path := strings.TrimPrefix(specifier, "file:")
+		if uri, ok := ls.uris[path]; ok {
+			if src, ok := ls.snapshots[uri]; ok {
+				return src
+			}
+		}
+		src, _ := os.ReadFile(path)
+		return src
+	case strings.HasPrefix(specifier, "std:"):
+		src, _ := lib.Stdlib.ReadFile(filepath.Join("std", strings.TrimPrefix(specifier, "std:")))
+		return src
+	}
+	if native := interpreter.LookupNativeModule(specifier); native != nil {
+		return []byte(native.Source)
+	}
+	src, _ := os.ReadFile(specifier)
+	return src
+}
+
+// text returns the source text of node, or "" if it is not available.
+func text(fset *token.FileSet, source []byte, node ast.Node) string {
+	start := fset.Position(node.Pos()).Offset
+	end := fset.Position(node.End()).Offset
+	if start < 0 || end > len(source) || start > end {
+		return ""
+	}
+	return string(source[start:end])
+}
+
+func signature(fset *token.FileSet, source []byte, decl *validator.Declaration) string {
+	params := func(list *ast.FuncParamList) string {
+		if list == nil {
+			return "()"
+		}
+		parts := make([]string, len(list.List))
+		for i, param := range list.List {
+			parts[i] = paramSignature(fset, source, param)
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+	fields := func(list *ast.StructFieldList) string {
+		if list == nil {
+			return "()"
+		}
+		parts := make([]string, len(list.List))
+		for i, field := range list.List {
+			parts[i] = field.Ident.Name + annotation(field.Type)
+			if field.Value != nil {
+				parts[i] += " = " + text(fset, source, field.Value)
+			}
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+
+	switch node := decl.Decl.(type) {
+	case *ast.FuncExpr:
+		prefix := ""
+		if node.Async.IsValid() {
+			prefix += "async "
+		}
+		if node.Memo.IsValid() {
+			prefix += "memo "
+		}
+		name := node.Name.Name
+		if node.Receiver != nil {
+			name = node.Receiver.Name + typeParams(node.ReceiverTypeParams) + "." + name
+		}
+		return prefix + "fn " + name + typeParams(node.TypeParams) + params(node.Params) + annotation(node.Result)
+	case *ast.NativeFunc:
+		prefix := "native "
+		if node.Async.IsValid() {
+			prefix += "async "
+		}
+		if node.Memo.IsValid() {
+			prefix += "memo "
+		}
+		name := node.Name.Name
+		if node.Receiver != nil {
+			name = node.Receiver.Name + "." + name
+		}
+		return prefix + "fn " + name + typeParams(node.TypeParams) + params(node.Params) + annotation(node.Result)
+	case *ast.StructStmt:
+		return "struct " + node.Name.Name + typeParams(node.TypeParams) + fields(node.Fields)
+	case *ast.TraitStmt:
+		return "trait " + node.Name.Name
+	case *ast.EnumStmt:
+		return "enum " + node.Name.Name
+	case *ast.EnumVariant:
+		if node.Fields == nil {
+			return "(variant) " + node.Name.Name
+		}
+		return "(variant) " + node.Name.Name + fields(node.Fields)
+	case *ast.MethodSpec:
+		prefix := ""
+		if node.Async.IsValid() {
+			prefix += "async "
+		}
+		if node.Memo.IsValid() {
+			prefix += "memo "
+		}
+		return prefix + "fn " + node.Name.Name + typeParams(node.TypeParams) + params(node.Params) + annotation(node.Result)
+	case *ast.TypeStmt:
+		return "type " + node.Name.Name + typeParams(node.TypeParams) + " = " + ast.TypeString(node.Value)
+	case *ast.NativeType:
+		return "native type " + node.Name.Name
+	case *ast.MacroStmt:
+		return "macro " + node.Name.Name + "!(" + strings.Join(node.Params.Names(), ", ") + ")"
+	case *ast.ProcMacroStmt:
+		return "procmacro " + node.Name.Name + "!(" + strings.Join(node.Params.Names(), ", ") + ")"
+	case *ast.NativeStruct:
+		return "native struct " + node.Name.Name + fields(node.Fields)
+	case *ast.NativeConst:
+		return "native const " + node.Ident.Name
+	case *ast.FuncParam:
+		return "(param) " + paramSignature(fset, source, node)
+	case *ast.StructField:
+		s := "(field) " + node.Ident.Name + annotation(node.Type)
+		if node.Value != nil {
+			s += " = " + text(fset, source, node.Value)
+		}
+		return s
+	case *ast.LetStmt:
+		if node.Pattern != nil {
+			return "let " + text(fset, source, node.Pattern) + annotation(node.Type) + initializer(fset, source, node.Value)
+		}
+		return "let " + node.Ident.Name + annotation(node.Type) + initializer(fset, source, node.Value)
+	case *ast.ConstStmt:
+		if node.Pattern != nil {
+			return "const " + text(fset, source, node.Pattern) + annotation(node.Type) + initializer(fset, source, node.Value)
+		}
+		return "const " + node.Ident.Name + annotation(node.Type) + initializer(fset, source, node.Value)
+	case *ast.ForStmt:
+		return "(loop variable) " + decl.Name
+	case ast.ModuleSpec:
+		return fmt.Sprintf("module %s\nimport %q", decl.Name, node.ModuleSpecifier())
+	}
+	return decl.Kind.String() + " " + decl.Name
+}
+
+func paramSignature(fset *token.FileSet, source []byte, param *ast.FuncParam) string {
+	var s string
+	if param.Pattern != nil {
+		s = text(fset, source, param.Pattern)
+	} else {
+		s = param.Ident.Name
+	}
+	if param.Colon.IsValid() {
+		s = ":" + s
+	}
+	if param.Ellipsis.IsValid() {
+		s = "..." + s
+	}
+	s += annotation(param.Type)
+	if param.Value != nil {
+		s += " = " + text(fset, source, param.Value)
+	}
+	return s
+}
+
+// annotation returns ": type" for a type annotation, or "" if there is none.
+func annotation(t ast.TypeExpr) string {
+	if t == nil {
+		return ""
+	}
+	return ": " + ast.TypeString(t)
+}
+
+// typeParams returns "<T, U>" for a list of type parameters, or "" if there
+// is none.
+func typeParams(list *ast.TypeParamList) string {
+	if list == nil {
+		return ""
+	}
+	return "<" + strings.Join(list.Names(), ", ") + ">"
+}
+
+// initializer returns " = value" for short, single-line initializers.
+func initializer(fset *token.FileSet, source []byte, value ast.Expr) string {
+	if value == nil {
+		return ""
+	}
+	s := text(fset, source, value)
+	if s == "" || len(s) > 60 || strings.Contains(s, "\n") {
+		return ""
+	}
+	return " = " + s
+}
+
+// declDoc returns the doc comment of decl. Parameters and fields are
+// documented by the @param tags of the function or struct that declares them.
+func declDoc(decl *validator.Declaration) *ast.Doc {
+	if decl.Kind != validator.DeclParameter && decl.Kind != validator.DeclField {
+		return ast.ParseDoc(ast.DocOf(decl.Decl))
+	}
+	if decl.Module == nil || decl.Module.Module == nil {
+		return nil
+	}
+	pos := decl.Decl.Pos()
+	var owner ast.Node
+	ast.Walk(decl.Module.Module, func(node any) {
+		// the innermost documented node around the declaration
+		if n, ok := node.(ast.Node); ok && ast.DocOf(n) != nil && n.Pos() <= pos && pos < n.End() {
+			owner = n
+		}
+	})
+	return ast.ParseDoc(ast.DocOf(owner))
+}
+
+// renderDoc converts a doc comment to markdown, with the @param and @returns
+// tags in their own sections.
+func renderDoc(doc *ast.Doc) string {
+	if doc == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(doc.Text)
+	if len(doc.Params) > 0 {
+		sb.WriteString("\n\n**Parameters**\n")
+		for _, param := range doc.Params {
+			fmt.Fprintf(&sb, "\n- `%s` — %s", param.Name, param.Text)
+		}
+	}
+	if doc.Returns != "" {
+		sb.WriteString("\n\n**Returns** " + doc.Returns)
+	}
+	if strings.TrimSpace(sb.String()) == "" {
+		return ""
+	}
+	return strings.TrimSpace(sb.String()) + "\n"
+}
@@ -0,0 +1,73 @@
+package lsp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "go.lsp.dev/protocol"
+)
+
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	src := `import "std:math"
+
+/// Scale a value.
+/// @param x the value
+/// @param by the factor
+/// @returns the scaled value
+fn scale(x, :by = 2) -> x * by
+
+/// A point in the plane.
+/// @param x the horizontal position
+struct Point(x, y = 0)
+
+let p = Point(1)
+println(scale(p.x), math.sqrt(4), int.parse("1"))
+`
+	path := filepath.Join(writeWorkspace(t, map[string]string{"main.goose": src}), "main.goose")
+	ls, u := openServer(t, path)
+
+	tests := []struct {
+		pos    Position
+		want   string // empty if there is nothing to show
+		prefix bool   // want is only the start of the hover
+	}{
+		// declaration with doc tags
+		{Position{Line: 6, Character: 4}, "```goose\nfn scale(x, :by = 2)\n```\n\nScale a value.\n\n**Parameters**\n\n- `x` — the value\n- `by` — the factor\n\n**Returns** the scaled value\n", false},
+		// a use of it
+		{Position{Line: 13, Character: 9}, "```goose\nfn scale(x, :by = 2)\n```\n\nScale a value.\n\n**Parameters**\n\n- `x` — the value\n- `by` — the factor\n\n**Returns** the scaled value\n", false},
+		// parameters and fields show only their own tag
+		{Position{Line: 6, Character: 14}, "```goose\n(param) :by = 2\n```\n\nthe factor\n", false},
+		{Position{Line: 10, Character: 13}, "```goose\n(field) x\n```\n\nthe horizontal position\n", false},
+		{Position{Line: 10, Character: 16}, "```goose\n(field) y = 0\n```\n", false},
+		{Position{Line: 10, Character: 8}, "```goose\nstruct Point(x, y = 0)\n```\n\nA point in the plane.\n\n**Parameters**\n\n- `x` — the horizontal position\n", false},
+		{Position{Line: 12, Character: 4}, "```goose\nlet p = Point(1)\n```\n", false},
+		// declarations in other modules name their module
+		{Position{Line: 13, Character: 26}, "```goose\nnative fn sqrt(x)\n```\n\n*from std:math*\n", false},
+		// builtins
+		{Position{Line: 13, Character: 2}, "```goose\n(builtin) println(", true},
+		{Position{Line: 13, Character: 43}, "```goose\n(builtin) int.parse(s: str", true},
+		// nothing to show
+		{Position{Line: 1, Character: 0}, "", false},
+		{Position{Line: 13, Character: 47}, "", false},
+	}
+	for _, test := range tests {
+		hover, err := ls.Hover(context.Background(), &HoverParams{TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: u},
+			Position:     test.pos,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if hover != nil {
+			got = hover.Contents.Value
+		}
+		if got != test.want && !(test.prefix && strings.HasPrefix(got, test.want)) {
+			t.Errorf("%d:%d: got\n%q\nwant\n%q", test.pos.Line, test.pos.Character, got, test.want)
+		}
+	}
+}
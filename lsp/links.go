@@ -0,0 +1,136 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/lib"
+	"github.com/calico32/goose/token"
+	. "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// DocumentLink links the specifiers of imports and export specs to the files
+// of the modules they name.
+func (ls *LanguageServer) DocumentLink(ctx context.Context, params *DocumentLinkParams) (result []DocumentLink, err error) {
+	ls.logger.Sugar().Debugf("DocumentLink: %s", params.TextDocument.URI.Filename())
+	documentUri := params.TextDocument.URI
+	v, ok := ls.validators[documentUri]
+	if !ok {
+		return []DocumentLink{}, nil
+	}
+	moduleMu := ls.modules[documentUri]
+	module := moduleMu.Lock()
+	defer moduleMu.Unlock()
+
+	fset := v.Fset()
+	dir := filepath.Dir(documentUri.Filename())
+	result = []DocumentLink{}
+	var link func(spec ast.ModuleSpec, name string)
+	link = func(spec ast.ModuleSpec, name string) {
+		if target, ok := moduleFile(name, v.GooseRoot()); ok {
+			// the specifier without its quotes
+			start := spec.Pos() + 1
+			end := start + token.Pos(len(spec.ModuleSpecifier()))
+			result = append(result, DocumentLink{
+				Range: Range{
+					Start: ls.position(fset.Position(start)),
+					End:   ls.position(fset.Position(end)),
+				},
+				Target: uri.File(target),
+			})
+		}
+		// modules shown from a module are named relative to it
+		if show, ok := spec.(*ast.ModuleSpecShow); ok && show.Show != nil {
+			for _, field := range show.Show.Fields {
+				if field, ok := field.(*ast.ShowFieldSpec); ok {
+					link(field.Spec, name+"/"+field.Spec.ModuleSpecifier())
+				}
+			}
+		}
+	}
+	ast.Walk(module, func(node any) {
+		switch stmt := node.(type) {
+		case *ast.ImportStmt:
+			link(stmt.Spec, moduleName(stmt.Spec.ModuleSpecifier(), dir))
+		case *ast.ExportSpecStmt:
+			link(stmt.Spec, moduleName(stmt.Spec.ModuleSpecifier(), dir))
+		}
+	})
+	return result, nil
+}
+
+// moduleName returns the full name of the module imported by specifier from
+// a module in dir, like file:/home/goose/foo.goose, pkg:foo/bar or std:fs.
+func moduleName(specifier string, dir string) string {
+	switch {
+	case strings.Contains(specifier, ":"):
+		return specifier
+	case specifier == "." || specifier == ".." || strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") || strings.HasPrefix(specifier, "/"):
+		if !filepath.IsAbs(specifier) {
+			specifier = filepath.Join(dir, specifier)
+		}
+		return "file:" + specifier
+	}
+	return "pkg:" + specifier
+}
+
+// moduleFile returns the path of the file that holds the module name, or
+// false if there is none. Modules in the standard library are embedded in
+// the binary, so they are unpacked into GOOSEROOT to be opened.
+func moduleFile(name string, gooseRoot string) (string, bool) {
+	scheme, name, _ := strings.Cut(name, ":")
+	switch scheme {
+	case "file":
+		return indexFile(name)
+	case "pkg":
+		return indexFile(filepath.Join(gooseRoot, "pkg", name))
+	case "std":
+		return unpackStd(name, gooseRoot)
+	}
+	// native modules have no file
+	return "", false
+}
+
+// indexFile returns file, or its index.goose if it is a directory, if it
+// exists.
+func indexFile(file string) (string, bool) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return indexFile(filepath.Join(file, "index.goose"))
+	}
+	return file, true
+}
+
+// unpackStd writes the source of the std module name to GOOSEROOT, unless it
+// is already there, and returns its path.
+func unpackStd(name string, gooseRoot string) (string, bool) {
+	embedded := path.Join("std", name)
+	src, err := lib.Stdlib.ReadFile(embedded)
+	if err != nil {
+		embedded = path.Join(embedded, "index.goose")
+		if src, err = lib.Stdlib.ReadFile(embedded); err != nil {
+			return "", false
+		}
+	}
+
+	file := filepath.Join(gooseRoot, filepath.FromSlash(embedded))
+	if existing, err := os.ReadFile(file); err == nil && bytes.Equal(existing, src) {
+		return file, true
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", false
+	}
+	if err := os.WriteFile(file, src, 0644); err != nil {
+		return "", false
+	}
+	return file, true
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleFile(t *testing.T) {
+	t.Parallel()
+
+	root := writeWorkspace(t, map[string]string{
+		"src/util/index.goose": "",
+		"src/lib.goose":        "",
+		"pkg/foo/bar.goose":    "",
+		"pkg/foo/index.goose":  "",
+	})
+	dir := filepath.Join(root, "src")
+
+	tests := []struct {
+		specifier string
+		want      string // relative to root, empty if there is no file
+	}{
+		{"./lib.goose", "src/lib.goose"},
+		{"./util", "src/util/index.goose"},
+		{"../src/util/", "src/util/index.goose"},
+		{"./missing.goose", ""},
+		{"foo", "pkg/foo/index.goose"},
+		{"pkg:foo/bar.goose", "pkg/foo/bar.goose"},
+		{"std:fs", "std/fs/index.goose"},
+		{"std:language/builtin.goose", "std/language/builtin.goose"},
+		{"std:missing", ""},
+		{"test:native", ""},
+	}
+	for _, test := range tests {
+		got, ok := moduleFile(moduleName(test.specifier, dir), root)
+		want := ""
+		if test.want != "" {
+			want = filepath.Join(root, test.want)
+		}
+		if got != want || ok != (want != "") {
+			t.Errorf("%s: got %q, %v; want %q", test.specifier, got, ok, want)
+		}
+	}
+
+	// std modules are unpacked from the binary
+	if src, err := os.ReadFile(filepath.Join(root, "std/fs/index.goose")); err != nil || len(src) == 0 {
+		t.Errorf("std:fs was not unpacked: %v", err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/calico32/goose/ast"
 	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
@@ -27,11 +28,17 @@ func Start(ctx context.Context, stream jsonrpc2.Stream) (*LanguageServer, error)
 	s := &LanguageServer{
 		id:          id,
 		state:       ServerStateIdle,
-		sourceFiles: make(map[protocol.DocumentURI]*Mutexed[[]byte]),
+		encoding:    PositionEncodingUTF16,
+		sourceFiles: make(map[protocol.DocumentURI]*Mutexed[*TextBuffer]),
 		modules:     make(map[protocol.DocumentURI]*Mutexed[*ast.Module]),
 		uris:        make(map[string]protocol.DocumentURI),
 		fsets:       make(map[protocol.DocumentURI]*token.FileSet),
 		parseErrors: make(map[protocol.DocumentURI][]protocol.Diagnostic),
+		validators:  make(map[protocol.DocumentURI]*validator.Validator),
+		snapshots:   make(map[protocol.DocumentURI][]byte),
+		imports:     make(map[protocol.DocumentURI][]string),
+
+		semanticResults: make(map[protocol.DocumentURI]*protocol.SemanticTokens),
 	}
 
 	zap.RegisterSink(id, func(url *url.URL) (zap.Sink, error) {
@@ -57,7 +64,11 @@ func Start(ctx context.Context, stream jsonrpc2.Stream) (*LanguageServer, error)
 	// 	jsonrpc2.WithCapacity(protocol.DefaultBufferSize),
 	// 	jsonrpc2.WithLogger(s.logger.Named("jsonrpc2")),
 	// }
-	_, s.conn, s.client = protocol.NewServer(ctx, s, stream, zap.NewNop())
+	s.conn = jsonrpc2.NewConn(stream)
+	s.client = protocol.ClientDispatcher(s.conn, zap.NewNop())
+	s.conn.Go(protocol.WithClient(ctx, s.client), protocol.Handlers(
+		s.handler(protocol.ServerHandler(s, jsonrpc2.MethodNotFoundHandler)),
+	))
 
 	logger.Sugar().Infof("goose language server started (pid: %d)", os.Getpid())
 
@@ -102,12 +113,22 @@ type LanguageServer struct {
 	state       ServerState
 	traceWriter io.Writer
 	timer       *timer
+	encoding    PositionEncoding
 
 	fsets       map[protocol.DocumentURI]*token.FileSet
-	sourceFiles map[protocol.DocumentURI]*Mutexed[[]byte]
+	sourceFiles map[protocol.DocumentURI]*Mutexed[*TextBuffer]
 	modules     map[protocol.DocumentURI]*Mutexed[*ast.Module]
 	uris        map[string]protocol.DocumentURI
 	parseErrors map[protocol.DocumentURI][]protocol.Diagnostic
+	validators  map[protocol.DocumentURI]*validator.Validator
+	snapshots   map[protocol.DocumentURI][]byte
+	imports     map[protocol.DocumentURI][]string // the files each checked document imports, see workspace.go
+
+	folders    []string // the paths of the workspace folders
+	watchFiles bool     // the client watches the files of the workspace for the server
+
+	semanticResults  map[protocol.DocumentURI]*protocol.SemanticTokens
+	semanticResultID int
 }
 
 type ServerState int
@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// declKey identifies a declaration across validators. Every open document is
+// checked by its own validator, so a module imported by several documents has
+// a separate Declaration in each of them; they are matched by the file and
+// offset of the declaring identifier.
+type declKey struct {
+	file   string
+	offset int
+}
+
+func keyOf(fset *token.FileSet, decl *validator.Declaration) declKey {
+	var pos token.Position
+	if decl.Ident != nil {
+		pos = fset.Position(decl.Ident.Pos())
+	} else {
+		pos = fset.Position(decl.Decl.Pos())
+	}
+	return declKey{file: strings.TrimPrefix(pos.Filename, "file:"), offset: pos.Offset}
+}
+
+// reference is a use of a declaration in some file.
+type reference struct {
+	ident       *ast.Ident
+	uri         DocumentURI
+	rng         Range
+	declaration bool
+}
+
+// declarationAt returns the validator for uri along with the identifier at
+// position and its declaration.
+func (ls *LanguageServer) declarationAt(uri DocumentURI, position Position) (*validator.Validator, *ast.Ident, *validator.Declaration) {
+	v, ok := ls.validators[uri]
+	if !ok {
+		return nil, nil, nil
+	}
+	pos := ls.Pos(v.Fset(), uri, position)
+	if pos == token.NoPos {
+		return nil, nil, nil
+	}
+	ident, decl := v.DeclarationAt(pos)
+	return v, ident, decl
+}
+
+// documentURI returns the URI of a file checked by a validator, or false if it
+// is not a file on disk (e.g. a std: module). Open documents are named with a
+// file: prefix, while imported modules are named by their path.
+func (ls *LanguageServer) documentURI(filename string) (DocumentURI, bool) {
+	path := strings.TrimPrefix(filename, "file:")
+	if !filepath.IsAbs(path) {
+		return "", false
+	}
+	if u, ok := ls.uris[path]; ok {
+		return u, true
+	}
+	return uri.File(path), true
+}
+
+// references collects every use of the declaration identified by key in all
+// checked documents and the modules they import.
+func (ls *LanguageServer) references(key declKey) []reference {
+	refs := []reference{}
+	seen := map[declKey]bool{}
+	for _, v := range ls.validators {
+		fset := v.Fset()
+		for _, decl := range v.Declarations() {
+			if keyOf(fset, decl) != key {
+				continue
+			}
+			for _, ident := range v.References(decl) {
+				pos := fset.Position(ident.Pos())
+				u, ok := ls.documentURI(pos.Filename)
+				if !ok || seen[declKey{u.Filename(), pos.Offset}] {
+					continue
+				}
+				seen[declKey{u.Filename(), pos.Offset}] = true
+				refs = append(refs, reference{
+					ident:       ident,
+					uri:         u,
+					rng:         ls.Range(fset, ident),
+					declaration: ident == decl.Ident,
+				})
+			}
+		}
+	}
+	sort.Slice(refs, func(a, b int) bool {
+		if refs[a].uri != refs[b].uri {
+			return refs[a].uri < refs[b].uri
+		}
+		return CmpPositions(refs[a].rng.Start, refs[b].rng.Start) < 0
+	})
+	return refs
+}
+
+func (ls *LanguageServer) References(ctx context.Context, params *ReferenceParams) (result []Location, err error) {
+	ls.logger.Sugar().Debugf("References: %s", params.TextDocument.URI.Filename())
+	v, _, decl := ls.declarationAt(params.TextDocument.URI, params.Position)
+	if decl == nil {
+		return []Location{}, nil
+	}
+
+	result = []Location{}
+	for _, ref := range ls.references(keyOf(v.Fset(), decl)) {
+		if ref.declaration && !params.Context.IncludeDeclaration {
+			continue
+		}
+		result = append(result, Location{URI: ref.uri, Range: ref.rng})
+	}
+	return result, nil
+}
+
+func (ls *LanguageServer) DocumentHighlight(ctx context.Context, params *DocumentHighlightParams) (result []DocumentHighlight, err error) {
+	ls.logger.Sugar().Debugf("DocumentHighlight: %s", params.TextDocument.URI.Filename())
+	v, _, decl := ls.declarationAt(params.TextDocument.URI, params.Position)
+	if decl == nil {
+		return []DocumentHighlight{}, nil
+	}
+
+	result = []DocumentHighlight{}
+	for _, ref := range ls.references(keyOf(v.Fset(), decl)) {
+		if ref.uri != params.TextDocument.URI {
+			continue
+		}
+		kind := DocumentHighlightKindRead
+		if ref.declaration {
+			kind = DocumentHighlightKindWrite
+		}
+		result = append(result, DocumentHighlight{Range: ref.rng, Kind: kind})
+	}
+	return result, nil
+}
+
+// renameRange returns the range of the name in an identifier, leaving out the
+// # of property shorthands.
+func renameRange(ident *ast.Ident, rng Range) Range {
+	if strings.HasPrefix(ident.Name, "#") {
+		rng.Start.Character++
+	}
+	return rng
+}
+
+// renamable returns an error if the declaration cannot be renamed.
+func renamable(fset *token.FileSet, decl *validator.Declaration) error {
+	if decl.Ident == nil {
+		return errors.New("cannot rename an import without an alias")
+	}
+	if file := fset.Position(decl.Ident.Pos()).Filename; !filepath.IsAbs(strings.TrimPrefix(file, "file:")) {
+		return fmt.Errorf("cannot rename %s: it is declared in %s", decl.Name, file)
+	}
+	return nil
+}
+
+func (ls *LanguageServer) PrepareRename(ctx context.Context, params *PrepareRenameParams) (result *Range, err error) {
+	ls.logger.Sugar().Debugf("PrepareRename: %s", params.TextDocument.URI.Filename())
+	v, ident, decl := ls.declarationAt(params.TextDocument.URI, params.Position)
+	if decl == nil {
+		return nil, nil
+	}
+	if err := renamable(v.Fset(), decl); err != nil {
+		return nil, err
+	}
+	rng := renameRange(ident, ls.Range(v.Fset(), ident))
+	return &rng, nil
+}
+
+func (ls *LanguageServer) Rename(ctx context.Context, params *RenameParams) (result *WorkspaceEdit, err error) {
+	ls.logger.Sugar().Debugf("Rename: %s", params.TextDocument.URI.Filename())
+	v, _, decl := ls.declarationAt(params.TextDocument.URI, params.Position)
+	if decl == nil {
+		return nil, errors.New("no symbol to rename")
+	}
+	if err := renamable(v.Fset(), decl); err != nil {
+		return nil, err
+	}
+	if !identPattern.MatchString(params.NewName) || token.IsKeyword(params.NewName) {
+		return nil, fmt.Errorf("%q is not a valid name", params.NewName)
+	}
+
+	changes := map[DocumentURI][]TextEdit{}
+	for _, ref := range ls.references(keyOf(v.Fset(), decl)) {
+		edit := TextEdit{Range: renameRange(ref.ident, ref.rng), NewText: params.NewName}
+		changes[ref.uri] = append(changes[ref.uri], edit)
+	}
+	return &WorkspaceEdit{Changes: changes}, nil
+}
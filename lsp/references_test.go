@@ -0,0 +1,205 @@
+package lsp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "go.lsp.dev/protocol"
+)
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"lib.goose": "export fn double(x) -> x * 2\n",
+		"main.goose": `import "./lib.goose" show { double }
+
+let x = double(1)
+
+fn f(double)
+  // a different double
+  return double + x
+end
+
+fn g(x)
+  let y = x
+  return double(y)
+end
+
+struct Box(x) init
+  #x = double(#x)
+end
+
+println(f(x), g(x), Box(x).x)
+`,
+	}
+	dir := writeWorkspace(t, files)
+	path := filepath.Join(dir, "main.goose")
+	ls, u := checkedServer(t, path)
+	ctx := context.Background()
+
+	tests := []struct {
+		pos     Position
+		newName string
+		refs    int // not including the declaration
+		want    map[string]string
+	}{
+		// an imported function, renamed in the import list and in lib.goose
+		// but not where a parameter shadows it
+		{Position{Line: 2, Character: 8}, "twice", 4, map[string]string{
+			"lib.goose": "export fn twice(x) -> x * 2\n",
+			"main.goose": `import "./lib.goose" show { twice }
+
+let x = twice(1)
+
+fn f(double)
+  // a different double
+  return double + x
+end
+
+fn g(x)
+  let y = x
+  return twice(y)
+end
+
+struct Box(x) init
+  #x = twice(#x)
+end
+
+println(f(x), g(x), Box(x).x)
+`,
+		}},
+		// the shadowing parameter only
+		{Position{Line: 4, Character: 5}, "n", 1, map[string]string{
+			"main.goose": `import "./lib.goose" show { double }
+
+let x = double(1)
+
+fn f(n)
+  // a different double
+  return n + x
+end
+
+fn g(x)
+  let y = x
+  return double(y)
+end
+
+struct Box(x) init
+  #x = double(#x)
+end
+
+println(f(x), g(x), Box(x).x)
+`,
+		}},
+		// the top-level x, not the parameter or field named x
+		{Position{Line: 18, Character: 10}, "total", 4, map[string]string{
+			"main.goose": `import "./lib.goose" show { double }
+
+let total = double(1)
+
+fn f(double)
+  // a different double
+  return double + total
+end
+
+fn g(x)
+  let y = x
+  return double(y)
+end
+
+struct Box(x) init
+  #x = double(#x)
+end
+
+println(f(total), g(total), Box(total).x)
+`,
+		}},
+		// a field, whose shorthand keeps its #
+		{Position{Line: 14, Character: 11}, "value", 3, map[string]string{
+			"main.goose": `import "./lib.goose" show { double }
+
+let x = double(1)
+
+fn f(double)
+  // a different double
+  return double + x
+end
+
+fn g(x)
+  let y = x
+  return double(y)
+end
+
+struct Box(value) init
+  #value = double(#value)
+end
+
+println(f(x), g(x), Box(x).value)
+`,
+		}},
+	}
+	for _, test := range tests {
+		position := TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: u}, Position: test.pos}
+
+		refs, err := ls.References(ctx, &ReferenceParams{TextDocumentPositionParams: position})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refs) != test.refs {
+			t.Errorf("%v: got %d references, want %d: %v", test.pos, len(refs), test.refs, refs)
+		}
+		all, err := ls.References(ctx, &ReferenceParams{TextDocumentPositionParams: position, Context: ReferenceContext{IncludeDeclaration: true}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != test.refs+1 {
+			t.Errorf("%v: got %d references including the declaration, want %d", test.pos, len(all), test.refs+1)
+		}
+
+		edit, err := ls.Rename(ctx, &RenameParams{TextDocumentPositionParams: position, NewName: test.newName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(edit.Changes) != len(test.want) {
+			t.Errorf("%v: got edits to %d files, want %d", test.pos, len(edit.Changes), len(test.want))
+		}
+		for name, want := range test.want {
+			buffer := NewTextBuffer(files[name])
+			applyEdits(t, buffer, edit.Changes[DocumentURI("file://"+filepath.Join(dir, name))], PositionEncodingUTF16)
+			if got := buffer.String(); got != want {
+				t.Errorf("%v: %s: got\n%s\nwant\n%s", test.pos, name, got, want)
+			}
+		}
+	}
+
+	// invalid names, and builtins, which have no declaration to rename
+	for _, test := range []struct {
+		line, character uint32
+		newName         string
+	}{
+		{2, 4, "1x"},
+		{2, 4, "fn"},
+		{18, 0, "log"},
+	} {
+		_, err := ls.Rename(ctx, &RenameParams{
+			TextDocumentPositionParams: TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: u}, Position: Position{Line: test.line, Character: test.character}},
+			NewName:                    test.newName,
+		})
+		if err == nil {
+			t.Errorf("%d:%d: renaming to %s: expected an error", test.line, test.character, test.newName)
+		}
+	}
+
+	highlights, err := ls.DocumentHighlight(ctx, &DocumentHighlightParams{TextDocumentPositionParams: TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: u},
+		Position:     Position{Line: 10, Character: 6},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(highlights) != 2 || highlights[0].Kind != DocumentHighlightKindWrite || highlights[1].Kind != DocumentHighlightKindRead {
+		t.Errorf("highlights of y: got %+v", highlights)
+	}
+}
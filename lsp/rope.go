@@ -0,0 +1,245 @@
+package lsp
+
+import "strings"
+
+// maxLeaf is the largest number of bytes a rope leaf holds. Smaller leaves
+// make edits cheaper but the tree deeper.
+const maxLeaf = 1024
+
+// rope is a node of a balanced binary tree of text. Leaves hold the text,
+// and every node holds the length and number of line breaks of the text
+// below it, so that offsets and lines can be found without visiting every
+// leaf. A nil *rope is empty; leaves are never empty.
+//
+// A line break is "\n", "\r\n" or a lone "\r". A "\r\n" may be split between
+// two leaves, so each node also records whether its text starts with "\n" and
+// ends with "\r": its breaks count the "\r" at its end, and a parent whose
+// children meet at a "\r\n" counts one break fewer than its children do.
+type rope struct {
+	left, right *rope
+	text        string // leaves only
+	length      int
+	breaks      int
+	height      int
+	startsLF    bool
+	endsCR      bool
+}
+
+func (r *rope) leaf() bool { return r.left == nil }
+
+func (r *rope) len() int {
+	if r == nil {
+		return 0
+	}
+	return r.length
+}
+
+func (r *rope) lineBreaks() int {
+	if r == nil {
+		return 0
+	}
+	return r.breaks
+}
+
+func (r *rope) depth() int {
+	if r == nil {
+		return 0
+	}
+	return r.height
+}
+
+func newLeaf(text string) *rope {
+	if text == "" {
+		return nil
+	}
+	return &rope{
+		text:     text,
+		length:   len(text),
+		breaks:   countBreaks(text),
+		height:   1,
+		startsLF: text[0] == '\n',
+		endsCR:   text[len(text)-1] == '\r',
+	}
+}
+
+// countBreaks returns the number of line breaks in text, counting a "\r" at
+// its end as one.
+func countBreaks(text string) int {
+	n := strings.Count(text, "\n") + strings.Count(text, "\r")
+	return n - strings.Count(text, "\r\n")
+}
+
+// newNode returns the concatenation of two non-empty ropes, without
+// rebalancing them.
+func newNode(left, right *rope) *rope {
+	breaks := left.breaks + right.breaks
+	if left.endsCR && right.startsLF {
+		breaks--
+	}
+	height := left.height
+	if right.height > height {
+		height = right.height
+	}
+	return &rope{
+		left:     left,
+		right:    right,
+		length:   left.length + right.length,
+		breaks:   breaks,
+		height:   height + 1,
+		startsLF: left.startsLF,
+		endsCR:   right.endsCR,
+	}
+}
+
+// newRope returns a balanced rope of text.
+func newRope(text string) *rope {
+	if len(text) <= maxLeaf {
+		return newLeaf(text)
+	}
+	// split on a leaf boundary, so that the leaves are as full as possible
+	mid := (len(text)/maxLeaf + 1) / 2 * maxLeaf
+	return newNode(newRope(text[:mid]), newRope(text[mid:]))
+}
+
+// join returns the concatenation of left and right, rebalancing the tree.
+func join(left, right *rope) *rope {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.leaf() && right.leaf() && left.length+right.length <= maxLeaf:
+		return newLeaf(left.text + right.text)
+	case left.height > right.height+1:
+		return balance(newNode(left.left, join(left.right, right)))
+	case right.height > left.height+1:
+		return balance(newNode(join(left, right.left), right.right))
+	}
+	return newNode(left, right)
+}
+
+// balance rotates a node whose children differ in height by two.
+func balance(r *rope) *rope {
+	switch {
+	case r.left.height > r.right.height+1:
+		left := r.left
+		if left.left.depth() < left.right.depth() {
+			left = rotateLeft(left)
+		}
+		return rotateRight(newNode(left, r.right))
+	case r.right.height > r.left.height+1:
+		right := r.right
+		if right.right.depth() < right.left.depth() {
+			right = rotateRight(right)
+		}
+		return rotateLeft(newNode(r.left, right))
+	}
+	return r
+}
+
+func rotateLeft(r *rope) *rope {
+	return newNode(newNode(r.left, r.right.left), r.right.right)
+}
+
+func rotateRight(r *rope) *rope {
+	return newNode(r.left.left, newNode(r.left.right, r.right))
+}
+
+// split returns the text before and after offset.
+func (r *rope) split(offset int) (*rope, *rope) {
+	switch {
+	case r == nil:
+		return nil, nil
+	case offset <= 0:
+		return nil, r
+	case offset >= r.length:
+		return r, nil
+	case r.leaf():
+		return newLeaf(r.text[:offset]), newLeaf(r.text[offset:])
+	case offset < r.left.length:
+		left, right := r.left.split(offset)
+		return left, join(right, r.right)
+	}
+	left, right := r.right.split(offset - r.left.length)
+	return join(r.left, left), right
+}
+
+// lineStart returns the offset after the n-th line break, counting from one.
+// n must be at most the number of line breaks.
+func (r *rope) lineStart(n int) int {
+	if r.leaf() {
+		for i := 0; i < len(r.text); i++ {
+			if r.text[i] == '\n' || r.text[i] == '\r' && (i+1 == len(r.text) || r.text[i+1] != '\n') {
+				if n--; n == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(r.text)
+	}
+
+	crlf := r.left.endsCR && r.right.startsLF
+	switch {
+	case n < r.left.breaks || n == r.left.breaks && !crlf:
+		return r.left.lineStart(n)
+	case n == r.left.breaks:
+		// the break is the "\r\n" between the children
+		return r.left.length + 1
+	}
+	if crlf {
+		n++
+	}
+	return r.left.length + r.right.lineStart(n-r.left.breaks)
+}
+
+// breaksBefore returns the number of line breaks in the text before offset,
+// counting a "\r" at its end as one.
+func (r *rope) breaksBefore(offset int) int {
+	switch {
+	case r == nil || offset <= 0:
+		return 0
+	case offset >= r.length:
+		return r.breaks
+	case r.leaf():
+		return countBreaks(r.text[:offset])
+	case offset <= r.left.length:
+		return r.left.breaksBefore(offset)
+	}
+	n := r.left.breaks + r.right.breaksBefore(offset-r.left.length)
+	if r.left.endsCR && r.right.startsLF {
+		n--
+	}
+	return n
+}
+
+// byteAt returns the byte at offset, which must be inside the text.
+func (r *rope) byteAt(offset int) byte {
+	for !r.leaf() {
+		if offset < r.left.length {
+			r = r.left
+		} else {
+			offset -= r.left.length
+			r = r.right
+		}
+	}
+	return r.text[offset]
+}
+
+// slice writes the text from start to end to sb.
+func (r *rope) slice(sb *strings.Builder, start, end int) {
+	if r == nil || start >= end || end <= 0 || start >= r.length {
+		return
+	}
+	if r.leaf() {
+		if start < 0 {
+			start = 0
+		}
+		if end > r.length {
+			end = r.length
+		}
+		sb.WriteString(r.text[start:end])
+		return
+	}
+	r.left.slice(sb, start, end)
+	r.right.slice(sb, start-r.left.length, end-r.left.length)
+}
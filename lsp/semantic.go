@@ -0,0 +1,290 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+)
+
+// semanticTokenTypes is the token type legend. Symbols (@foo) use a custom
+// type that the extension maps onto enumMember.
+var semanticTokenTypes = []SemanticTokenTypes{
+	SemanticTokenNamespace,
+	SemanticTokenStruct,
+	SemanticTokenParameter,
+	SemanticTokenVariable,
+	SemanticTokenProperty,
+	SemanticTokenFunction,
+	SemanticTokenMethod,
+	"symbol",
+	SemanticTokenType,
+	SemanticTokenMacro,
+	SemanticTokenInterface,
+	SemanticTokenEnum,
+	SemanticTokenEnumMember,
+}
+
+const (
+	tokenNamespace = iota
+	tokenStruct
+	tokenParameter
+	tokenVariable
+	tokenProperty
+	tokenFunction
+	tokenMethod
+	tokenSymbol
+	tokenType
+	tokenMacro
+	tokenInterface
+	tokenEnum
+	tokenEnumMember
+)
+
+var semanticTokenModifiers = []SemanticTokenModifiers{
+	SemanticTokenModifierDeclaration,
+	SemanticTokenModifierReadonly,
+	SemanticTokenModifierDefaultLibrary,
+	SemanticTokenModifierAsync,
+	"native",
+}
+
+const (
+	modifierDeclaration = 1 << iota
+	modifierReadonly
+	modifierDefaultLibrary
+	modifierAsync
+	modifierNative
+)
+
+var declTokenTypes = map[validator.DeclKind]uint32{
+	validator.DeclVariable:  tokenVariable,
+	validator.DeclConstant:  tokenVariable,
+	validator.DeclFunction:  tokenFunction,
+	validator.DeclMethod:    tokenMethod,
+	validator.DeclStruct:    tokenStruct,
+	validator.DeclField:     tokenProperty,
+	validator.DeclParameter: tokenParameter,
+	validator.DeclModule:    tokenNamespace,
+	validator.DeclType:      tokenType,
+	validator.DeclMacro:     tokenMacro,
+	validator.DeclTrait:     tokenInterface,
+	validator.DeclEnum:      tokenEnum,
+	validator.DeclVariant:   tokenEnumMember,
+}
+
+// semanticTokensOptions is SemanticTokensOptions with the fields that
+// go.lsp.dev/protocol leaves out.
+type semanticTokensOptions struct {
+	Legend SemanticTokensLegend `json:"legend"`
+	Range  bool                 `json:"range"`
+	Full   struct {
+		Delta bool `json:"delta"`
+	} `json:"full"`
+}
+
+func newSemanticTokensOptions() *semanticTokensOptions {
+	options := &semanticTokensOptions{
+		Legend: SemanticTokensLegend{
+			TokenTypes:     semanticTokenTypes,
+			TokenModifiers: semanticTokenModifiers,
+		},
+		Range: true,
+	}
+	options.Full.Delta = true
+	return options
+}
+
+type semanticToken struct {
+	line      uint32
+	char      uint32
+	length    uint32
+	tokenType uint32
+	modifiers uint32
+}
+
+// semanticTokens classifies the identifiers of a checked document.
+// Identifiers come from scanning the text that was checked, so that those the
+// validator could not resolve (symbols and builtins) are included too.
+func (ls *LanguageServer) semanticTokens(uri DocumentURI) []semanticToken {
+	v, ok := ls.validators[uri]
+	if !ok {
+		return nil
+	}
+	src := ls.snapshots[uri]
+	file := v.Fset().File("file:" + uri.Filename())
+	if file == nil || file.Size() != len(src) {
+		return nil
+	}
+
+	resolved := map[token.Pos]*validator.Declaration{}
+	declaring := map[token.Pos]bool{}
+	for ident, decl := range v.Resolved() {
+		if int(ident.Pos()) < file.Base() || int(ident.Pos()) > file.Base()+file.Size() {
+			continue
+		}
+		resolved[ident.Pos()] = decl
+		declaring[ident.Pos()] = ident == decl.Ident
+	}
+
+	fset := token.NewFileSet()
+	f := fset.AddFile(file.Specifier(), -1, len(src))
+	s := scanner.Scanner{}
+	s.Init(f, src, func(token.Position, string) {})
+
+	tokens := []semanticToken{}
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.Ident {
+			continue
+		}
+
+		t := semanticToken{length: encodedLen(lit, ls.encoding)}
+		vpos := file.Pos(f.Offset(pos))
+		if decl, ok := resolved[vpos]; ok {
+			t.tokenType = declTokenTypes[decl.Kind]
+			t.modifiers = declModifiers(v.Fset(), decl)
+			if declaring[vpos] {
+				t.modifiers |= modifierDeclaration
+			}
+		} else if strings.HasPrefix(lit, "@") {
+			t.tokenType = tokenSymbol
+		} else if builtin, ok := builtinType(v, vpos, lit); ok {
+			t.tokenType = builtin
+			t.modifiers = modifierDefaultLibrary
+		} else {
+			continue
+		}
+
+		position := lspPosition(src, f.Position(pos), ls.encoding)
+		t.line = position.Line
+		t.char = position.Character
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+func declModifiers(fset *token.FileSet, decl *validator.Declaration) uint32 {
+	var modifiers uint32
+	if decl.Kind == validator.DeclConstant || decl.Kind == validator.DeclModule {
+		modifiers |= modifierReadonly
+	}
+	if strings.HasPrefix(fset.Position(decl.Decl.Pos()).Filename, "std:") {
+		modifiers |= modifierDefaultLibrary
+	}
+	switch node := decl.Decl.(type) {
+	case *ast.FuncExpr:
+		if node.Async.IsValid() {
+			modifiers |= modifierAsync
+		}
+	case *ast.NativeFunc:
+		modifiers |= modifierNative
+		if node.Async.IsValid() {
+			modifiers |= modifierAsync
+		}
+	case *ast.NativeStruct, *ast.NativeConst:
+		modifiers |= modifierNative
+	}
+	return modifiers
+}
+
+// builtinType returns the token type of name if it refers to a builtin at pos.
+func builtinType(v *validator.Validator, pos token.Pos, name string) (uint32, bool) {
+	variable := v.ScopeAt(pos).Get(name)
+	if variable == nil || v.Global().Idents()[name] != variable {
+		return 0, false
+	}
+	if variableKind(variable) == CompletionItemKindFunction {
+		return tokenFunction, true
+	}
+	if variableKind(variable) == CompletionItemKindStruct {
+		return tokenStruct, true
+	}
+	return tokenVariable, true
+}
+
+// encodeSemanticTokens encodes tokens relative to each other as described by
+// the LSP specification.
+func encodeSemanticTokens(tokens []semanticToken) []uint32 {
+	data := make([]uint32, 0, len(tokens)*5)
+	var line, char uint32
+	for _, t := range tokens {
+		deltaLine := t.line - line
+		deltaChar := t.char
+		if deltaLine == 0 {
+			deltaChar = t.char - char
+		}
+		data = append(data, deltaLine, deltaChar, t.length, t.tokenType, t.modifiers)
+		line, char = t.line, t.char
+	}
+	return data
+}
+
+// remember stores the tokens for uri so that the next request can be
+// answered with a delta.
+func (ls *LanguageServer) remember(uri DocumentURI, data []uint32) *SemanticTokens {
+	ls.semanticResultID++
+	result := &SemanticTokens{
+		ResultID: fmt.Sprint(ls.semanticResultID),
+		Data:     data,
+	}
+	ls.semanticResults[uri] = result
+	return result
+}
+
+func (ls *LanguageServer) SemanticTokensFull(ctx context.Context, params *SemanticTokensParams) (result *SemanticTokens, err error) {
+	ls.logger.Sugar().Debugf("SemanticTokensFull: %s", params.TextDocument.URI.Filename())
+	data := encodeSemanticTokens(ls.semanticTokens(params.TextDocument.URI))
+	return ls.remember(params.TextDocument.URI, data), nil
+}
+
+func (ls *LanguageServer) SemanticTokensFullDelta(ctx context.Context, params *SemanticTokensDeltaParams) (result interface{} /* SemanticTokens | SemanticTokensDelta */, err error) {
+	ls.logger.Sugar().Debugf("SemanticTokensFullDelta: %s", params.TextDocument.URI.Filename())
+	data := encodeSemanticTokens(ls.semanticTokens(params.TextDocument.URI))
+	previous, ok := ls.semanticResults[params.TextDocument.URI]
+	if !ok || previous.ResultID != params.PreviousResultID {
+		return ls.remember(params.TextDocument.URI, data), nil
+	}
+
+	// a single edit replacing everything between the common prefix and suffix
+	old := previous.Data
+	start := 0
+	for start < len(old) && start < len(data) && old[start] == data[start] {
+		start++
+	}
+	end := 0
+	for end < len(old)-start && end < len(data)-start && old[len(old)-1-end] == data[len(data)-1-end] {
+		end++
+	}
+
+	current := ls.remember(params.TextDocument.URI, data)
+	delta := &SemanticTokensDelta{ResultID: current.ResultID, Edits: []SemanticTokensEdit{}}
+	if start != len(old) || start != len(data) {
+		delta.Edits = append(delta.Edits, SemanticTokensEdit{
+			Start:       uint32(start),
+			DeleteCount: uint32(len(old) - start - end),
+			Data:        data[start : len(data)-end],
+		})
+	}
+	return delta, nil
+}
+
+func (ls *LanguageServer) SemanticTokensRange(ctx context.Context, params *SemanticTokensRangeParams) (result *SemanticTokens, err error) {
+	ls.logger.Sugar().Debugf("SemanticTokensRange: %s", params.TextDocument.URI.Filename())
+	tokens := []semanticToken{}
+	for _, t := range ls.semanticTokens(params.TextDocument.URI) {
+		position := Position{Line: t.line, Character: t.char}
+		if CmpPositions(position, params.Range.Start) >= 0 && CmpPositions(position, params.Range.End) < 0 {
+			tokens = append(tokens, t)
+		}
+	}
+	return &SemanticTokens{Data: encodeSemanticTokens(tokens)}, nil
+}
@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "go.lsp.dev/protocol"
+)
+
+func TestEncodeSemanticTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tokens []semanticToken
+		want   []uint32
+	}{
+		{nil, []uint32{}},
+		{
+			[]semanticToken{{line: 2, char: 5, length: 3, tokenType: tokenFunction, modifiers: modifierDeclaration}},
+			[]uint32{2, 5, 3, tokenFunction, modifierDeclaration},
+		},
+		// the character is relative to the previous token on the same line
+		{
+			[]semanticToken{
+				{line: 0, char: 4, length: 1, tokenType: tokenVariable},
+				{line: 0, char: 10, length: 2, tokenType: tokenParameter},
+				{line: 3, char: 2, length: 5, tokenType: tokenStruct, modifiers: modifierReadonly | modifierNative},
+				{line: 3, char: 9, length: 1, tokenType: tokenSymbol},
+			},
+			[]uint32{
+				0, 4, 1, tokenVariable, 0,
+				0, 6, 2, tokenParameter, 0,
+				3, 2, 5, tokenStruct, modifierReadonly | modifierNative,
+				0, 7, 1, tokenSymbol, 0,
+			},
+		},
+	}
+	for _, test := range tests {
+		if got := encodeSemanticTokens(test.tokens); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.tokens, got, test.want)
+		}
+	}
+}
+
+// decodeSemanticTokens renders encoded tokens as "line:char text type
+// modifiers", one per token.
+func decodeSemanticTokens(src string, data []uint32) []string {
+	lines := strings.Split(src, "\n")
+	var out []string
+	var line, char uint32
+	for i := 0; i+5 <= len(data); i += 5 {
+		if data[i] != 0 {
+			char = 0
+		}
+		line += data[i]
+		char += data[i+1]
+		var modifiers []string
+		for bit, name := range semanticTokenModifiers {
+			if data[i+4]&(1<<bit) != 0 {
+				modifiers = append(modifiers, string(name))
+			}
+		}
+		text := lines[line][char : char+data[i+2]]
+		out = append(out, fmt.Sprintf("%d:%d %s %s %s", line, char, text, semanticTokenTypes[data[i+3]], strings.Join(modifiers, ",")))
+	}
+	return out
+}
+
+func TestSemanticTokens(t *testing.T) {
+	t.Parallel()
+
+	src := `import "std:math"
+
+const limit = 10
+struct Point(x, y)
+
+fn scale(p, by)
+  return Point(p.x * by, #y ?? @none)
+end
+
+println(scale(Point(1, 2), math.pi), limit)
+`
+	path := filepath.Join(writeWorkspace(t, map[string]string{"main.goose": src}), "main.goose")
+	ls, u := checkedServer(t, path)
+	ls.semanticResults = map[DocumentURI]*SemanticTokens{}
+	ctx := context.Background()
+
+	full, err := ls.SemanticTokensFull(ctx, &SemanticTokensParams{TextDocument: TextDocumentIdentifier{URI: u}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2:6 limit variable declaration,readonly",
+		"3:7 Point struct declaration",
+		"3:13 x property declaration",
+		"3:16 y property declaration",
+		"5:3 scale function declaration",
+		"5:9 p parameter declaration",
+		"5:12 by parameter declaration",
+		"6:9 Point struct ",
+		"6:15 p parameter ",
+		"6:21 by parameter ",
+		"6:31 @none symbol ",
+		"9:0 println function defaultLibrary",
+		"9:8 scale function ",
+		"9:14 Point struct ",
+		"9:27 math namespace readonly",
+		"9:32 pi variable readonly,defaultLibrary,native",
+		"9:37 limit variable readonly",
+	}
+	if got := decodeSemanticTokens(src, full.Data); !reflect.DeepEqual(got, want) {
+		t.Errorf("full: got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// a range includes the tokens that start inside it
+	part, err := ls.SemanticTokensRange(ctx, &SemanticTokensRangeParams{
+		TextDocument: TextDocumentIdentifier{URI: u},
+		Range:        Range{Start: Position{Line: 5, Character: 9}, End: Position{Line: 6, Character: 15}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeSemanticTokens(src, part.Data); !reflect.DeepEqual(got, want[5:8]) {
+		t.Errorf("range: got %q, want %q", got, want[5:8])
+	}
+
+	// nothing changed since the last result
+	delta, err := ls.SemanticTokensFullDelta(ctx, &SemanticTokensDeltaParams{
+		TextDocument:     TextDocumentIdentifier{URI: u},
+		PreviousResultID: full.ResultID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := delta.(*SemanticTokensDelta); !ok || len(d.Edits) != 0 || d.ResultID == full.ResultID {
+		t.Errorf("delta: got %+v, want no edits and a new result id", delta)
+	}
+
+	// an unknown result id gets all tokens
+	delta, err = ls.SemanticTokensFullDelta(ctx, &SemanticTokensDeltaParams{
+		TextDocument:     TextDocumentIdentifier{URI: u},
+		PreviousResultID: "stale",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := delta.(*SemanticTokens); !ok || !reflect.DeepEqual(d.Data, full.Data) {
+		t.Errorf("delta from a stale result: got %+v, want all tokens", delta)
+	}
+}
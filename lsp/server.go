@@ -18,37 +18,55 @@ import (
 )
 
 func (ls *LanguageServer) Location(fset *token.FileSet, node ast.Node) Location {
-	start := fset.Position(node.Pos())
-	end := fset.Position(node.End())
+	uri, _ := ls.documentURI(fset.Position(node.Pos()).Filename)
 	return Location{
-		URI: ls.uris[start.Filename],
-		Range: Range{
-			Start: Position{
-				Line:      uint32(start.Line) - 1,
-				Character: uint32(start.Column) - 1,
-			},
-			End: Position{
-				Line:      uint32(end.Line) - 1,
-				Character: uint32(end.Column) - 1,
-			},
-		},
+		URI:   uri,
+		Range: ls.Range(fset, node),
 	}
 }
 
 func (ls *LanguageServer) Range(fset *token.FileSet, node ast.Node) Range {
-	start := fset.Position(node.Pos())
-	end := fset.Position(node.End())
 	return Range{
-		Start: Position{
-			Line:      uint32(start.Line) - 1,
-			Character: uint32(start.Column) - 1,
-		},
-		End: Position{
-			Line:      uint32(end.Line) - 1,
-			Character: uint32(end.Column) - 1,
-		},
+		Start: ls.position(fset.Position(node.Pos())),
+		End:   ls.position(fset.Position(node.End())),
+	}
+}
+
+// position converts a token position to an LSP position in the negotiated
+// encoding.
+func (ls *LanguageServer) position(position token.Position) Position {
+	return lspPosition(ls.source(position.Filename), position, ls.encoding)
+}
+
+// Pos converts an LSP position to a token.Pos in fset, clamping positions
+// that lie outside the file (e.g. because the document changed since it was
+// last parsed).
+func (ls *LanguageServer) Pos(fset *token.FileSet, uri DocumentURI, position Position) token.Pos {
+	file := fset.File("file:" + uri.Filename())
+	if file == nil {
+		return token.NoPos
+	}
+	lines := file.Lines()
+	line := int(position.Line)
+	if line >= len(lines) {
+		return file.Pos(file.Size())
+	}
+	end := file.Size()
+	if line+1 < len(lines) {
+		end = lines[line+1] - 1
 	}
+	src := ls.snapshots[uri]
+	if len(src) != file.Size() {
+		// no text to measure characters with; assume they are bytes
+		offset := lines[line] + int(position.Character)
+		if offset > end {
+			offset = end
+		}
+		return file.Pos(offset)
+	}
+	return file.Pos(lines[line] + byteOffset(string(src[lines[line]:end]), position.Character, ls.encoding))
 }
+
 func (ls *LanguageServer) ensureInitialized() *jsonrpc2.Error {
 	if ls.state == ServerStateIdle {
 		err := jsonrpc2.Errorf(jsonrpc2.ServerNotInitialized, "server not initialized")
@@ -63,17 +81,56 @@ func (ls *LanguageServer) Initialize(ctx context.Context, params *InitializePara
 		ls.logger.Sugar().Errorf("Initialize called in invalid state: %s", ls.state)
 	}
 	ls.state = ServerStateRunning
+	ls.folders = workspaceFolders(params)
+	if workspace := params.Capabilities.Workspace; workspace != nil && workspace.DidChangeWatchedFiles != nil {
+		ls.watchFiles = workspace.DidChangeWatchedFiles.DynamicRegistration
+	}
 	return &InitializeResult{
 		ServerInfo: &ServerInfo{
 			Name:    "goose-lsp",
 			Version: std_platform.Version,
 		},
 		Capabilities: ServerCapabilities{
-			TextDocumentSync:       TextDocumentSyncKindIncremental,
-			HoverProvider:          true,
-			DocumentSymbolProvider: true,
-			DefinitionProvider:     true,
-			DeclarationProvider:    true,
+			TextDocumentSync:                TextDocumentSyncKindIncremental,
+			HoverProvider:                   true,
+			DocumentSymbolProvider:          true,
+			DefinitionProvider:              true,
+			DeclarationProvider:             true,
+			ReferencesProvider:              true,
+			DocumentHighlightProvider:       true,
+			RenameProvider:                  &RenameOptions{PrepareProvider: true},
+			SemanticTokensProvider:          newSemanticTokensOptions(),
+			DocumentFormattingProvider:      true,
+			DocumentRangeFormattingProvider: true,
+			DocumentOnTypeFormattingProvider: &DocumentOnTypeFormattingOptions{
+				FirstTriggerCharacter: "\n",
+				MoreTriggerCharacter:  []string{"d"},
+			},
+			SignatureHelpProvider: &SignatureHelpOptions{
+				TriggerCharacters:   []string{"(", ","},
+				RetriggerCharacters: []string{")"},
+			},
+			FoldingRangeProvider:  true,
+			DocumentLinkProvider:  &DocumentLinkOptions{},
+			CallHierarchyProvider: true,
+			CodeActionProvider: &CodeActionOptions{
+				CodeActionKinds: []CodeActionKind{QuickFix, RefactorExtract, RefactorRewrite},
+			},
+			WorkspaceSymbolProvider: true,
+			Workspace: &ServerCapabilitiesWorkspace{
+				WorkspaceFolders: &ServerCapabilitiesWorkspaceFolders{
+					Supported:           true,
+					ChangeNotifications: true,
+				},
+				FileOperations: &ServerCapabilitiesWorkspaceFileOperations{
+					DidCreate: gooseFiles,
+					DidRename: gooseFiles,
+					DidDelete: gooseFiles,
+				},
+			},
+			CompletionProvider: &CompletionOptions{
+				TriggerCharacters: []string{".", "#", "\"", "/", ":"},
+			},
 		},
 	}, nil
 }
@@ -82,7 +139,24 @@ func (ls *LanguageServer) Initialized(ctx context.Context, params *InitializedPa
 	if err := ls.ensureInitialized(); err != nil {
 		return err
 	}
-	return
+	if ls.watchFiles {
+		err = ls.client.RegisterCapability(ctx, &RegistrationParams{
+			Registrations: []Registration{{
+				ID:     "goose-watched-files",
+				Method: MethodWorkspaceDidChangeWatchedFiles,
+				RegisterOptions: DidChangeWatchedFilesRegistrationOptions{
+					Watchers: []FileSystemWatcher{{GlobPattern: "**/*.goose"}},
+				},
+			}},
+		})
+		if err != nil {
+			ls.logger.Sugar().Errorf("cannot watch files: %s", err)
+		}
+	}
+	for _, folder := range ls.folders {
+		ls.indexFolder(ctx, folder)
+	}
+	return nil
 }
 
 func (ls *LanguageServer) SetTrace(ctx context.Context, params *SetTraceParams) (err error) {
@@ -108,11 +182,6 @@ func (ls *LanguageServer) Exit(ctx context.Context) (err error) {
 	return
 }
 
-func (ls *LanguageServer) CodeAction(ctx context.Context, params *CodeActionParams) (result []CodeAction, err error) {
-	err = notImplemented("CodeAction")
-	return
-}
-
 func (ls *LanguageServer) CodeLens(ctx context.Context, params *CodeLensParams) (result []CodeLens, err error) {
 	err = notImplemented("CodeLens")
 	return
@@ -133,11 +202,6 @@ func (ls *LanguageServer) ColorPresentation(ctx context.Context, params *ColorPr
 	return
 }
 
-func (ls *LanguageServer) Completion(ctx context.Context, params *CompletionParams) (result *CompletionList, err error) {
-	err = notImplemented("Completion")
-	return
-}
-
 func (ls *LanguageServer) CompletionResolve(ctx context.Context, params *CompletionItem) (result *CompletionItem, err error) {
 	err = notImplemented("CompletionResolve")
 	return
@@ -204,61 +268,11 @@ func CmpPositions(a, b Position) int {
 }
 
 func (ls *LanguageServer) DidChange(ctx context.Context, params *DidChangeTextDocumentParams) (err error) {
-	ls.logger.Sugar().Debugf("DidChange: %s", params.TextDocument.URI.Filename())
-	ls.logger.Sugar().Debugf("changes: %#v", params.ContentChanges)
-	sourceMu, ok := ls.sourceFiles[params.TextDocument.URI]
-	if !ok {
-		ls.logger.Sugar().Errorf("document not found: %s", params.TextDocument.URI.Filename())
-		return errors.New("document not found")
+	changes := make([]contentChange, len(params.ContentChanges))
+	for i, change := range params.ContentChanges {
+		changes[i] = contentChange{Range: &change.Range, Text: change.Text}
 	}
-	source := sourceMu.Lock()
-	before := string(source)
-
-	fset, ok := ls.fsets[params.TextDocument.URI]
-	if !ok {
-		ls.logger.Sugar().Errorf("fileset not found: %s", params.TextDocument.URI.Filename())
-	}
-	file := fset.File("file:" + params.TextDocument.URI.Filename())
-
-	lineOffsets := file.Lines()
-
-	for _, change := range params.ContentChanges {
-		start := change.Range.Start
-		end := change.Range.End
-		startOffset := lineOffsets[int(start.Line)] + int(start.Character)
-		endOffset := len(source) // end of the file (because end.Line could be 1 greater than the number of lines)
-		if end.Line < uint32(len(lineOffsets)) {
-			endOffset = lineOffsets[int(end.Line)] + int(end.Character)
-		}
-
-		ls.logger.Sugar().Debugf("start: %d, end: %d, source: %d", startOffset, endOffset, len(source))
-		source = append(source[:startOffset], append([]byte(change.Text), source[endOffset:]...)...)
-		ls.logger.Sugar().Debugf("source: %s", source)
-
-		// if startOffset > endOffset {
-		// 	if len(change.Text) > 0 {
-		// 		ls.logger.Sugar().Errorf("range start > end and has content: %#v", change)
-		// 	} else {
-		// 		// start > end, delete the range
-		// 		source = append(source[:endOffset], source[startOffset-1:]...)
-		// 	}
-		// } else {
-		// if start < end, this replaces the range
-		// if start == end, this inserts at the position
-		// }
-		// } else {
-		// 	source = []byte(change.Text)
-		// }
-	}
-
-	if before == string(source) {
-		ls.logger.Sugar().Debugf("no changes")
-		return
-	}
-
-	sourceMu.Update(source)
-	err = ls.checkModule(ctx, params.TextDocument.URI)
-	return
+	return ls.didChange(ctx, params.TextDocument.URI, changes)
 }
 
 func (ls *LanguageServer) DidChangeConfiguration(ctx context.Context, params *DidChangeConfigurationParams) (err error) {
@@ -266,31 +280,6 @@ func (ls *LanguageServer) DidChangeConfiguration(ctx context.Context, params *Di
 	return
 }
 
-func (ls *LanguageServer) DidChangeWatchedFiles(ctx context.Context, params *DidChangeWatchedFilesParams) (err error) {
-	err = notImplemented("DidChangeWatchedFiles")
-	return
-}
-
-func (ls *LanguageServer) DidChangeWorkspaceFolders(ctx context.Context, params *DidChangeWorkspaceFoldersParams) (err error) {
-	err = notImplemented("DidChangeWorkspaceFolders")
-	return
-}
-
-func (ls *LanguageServer) DidClose(ctx context.Context, params *DidCloseTextDocumentParams) (err error) {
-	err = notImplemented("DidClose")
-	return
-}
-
-func (ls *LanguageServer) DidCreateFiles(ctx context.Context, params *CreateFilesParams) (err error) {
-	err = notImplemented("DidCreateFiles")
-	return
-}
-
-func (ls *LanguageServer) DidDeleteFiles(ctx context.Context, params *DeleteFilesParams) (err error) {
-	err = notImplemented("DidDeleteFiles")
-	return
-}
-
 func (ls *LanguageServer) DidOpen(ctx context.Context, params *DidOpenTextDocumentParams) (err error) {
 	ls.logger.Sugar().Debugf("DidOpen: %s", params.TextDocument.URI.Filename())
 
@@ -299,10 +288,18 @@ func (ls *LanguageServer) DidOpen(ctx context.Context, params *DidOpenTextDocume
 		return
 	}
 
-	ls.sourceFiles[params.TextDocument.URI] = &Mutexed[[]byte]{
-		v: []byte(params.TextDocument.Text),
+	ls.sourceFiles[params.TextDocument.URI] = &Mutexed[*TextBuffer]{
+		v: NewTextBuffer(params.TextDocument.Text),
 	}
 	err = ls.checkModule(ctx, params.TextDocument.URI)
+	if err != nil {
+		return
+	}
+	// the documents that import this one see its buffer from now on
+	path := params.TextDocument.URI.Filename()
+	if disk, err := os.ReadFile(path); err != nil || string(disk) != params.TextDocument.Text {
+		ls.checkDependents(ctx, path)
+	}
 	return
 }
 
@@ -314,14 +311,12 @@ func (ls *LanguageServer) checkModule(ctx context.Context, documentUri DocumentU
 	}
 
 	ls.logger.Sugar().Debugf("checking module: %s", documentUri.Filename())
-	sourceMu, ok := ls.sourceFiles[documentUri]
-	if !ok {
+	src, err := ls.readFile(documentUri.Filename())
+	if err != nil {
 		ls.logger.Sugar().Errorf("document not found: %s", documentUri.Filename())
 		return errors.New("document not found")
 	}
 	ls.uris[documentUri.Filename()] = documentUri
-	src := sourceMu.Lock()
-	defer sourceMu.Unlock()
 
 	fset := token.NewFileSet()
 	ls.fsets[documentUri] = fset
@@ -339,19 +334,11 @@ func (ls *LanguageServer) checkModule(ctx context.Context, documentUri DocumentU
 				if diagnostics[doc] == nil {
 					diagnostics[doc] = []Diagnostic{}
 				}
+				position := lspPosition(src, e.Pos, ls.encoding)
 				diagnostics[doc] = append(diagnostics[doc], Diagnostic{
 					Message:  e.Msg,
 					Severity: DiagnosticSeverityError,
-					Range: Range{
-						Start: Position{
-							Line:      uint32(e.Pos.Line) - 1,
-							Character: uint32(e.Pos.Column) - 1,
-						},
-						End: Position{
-							Line:      uint32(e.Pos.Line) - 1,
-							Character: uint32(e.Pos.Column) - 1,
-						},
-					},
+					Range:    Range{Start: position, End: position},
 				})
 			}
 
@@ -373,6 +360,7 @@ func (ls *LanguageServer) checkModule(ctx context.Context, documentUri DocumentU
 
 	// parse OK!
 	ls.parseErrors[documentUri] = nil
+	ls.snapshots[documentUri] = src
 	diagnostics := []Diagnostic{}
 
 	// move onto validation
@@ -380,6 +368,7 @@ func (ls *LanguageServer) checkModule(ctx context.Context, documentUri DocumentU
 	if err != nil {
 		return err
 	}
+	v.SetReadFile(ls.readFile)
 	_, err = v.Check()
 	if err != nil {
 		return err
@@ -388,12 +377,7 @@ func (ls *LanguageServer) checkModule(ctx context.Context, documentUri DocumentU
 	problems := v.Diagnostics()
 	if len(problems) > 0 {
 		for _, problem := range problems {
-			diagnostics = append(diagnostics, Diagnostic{
-				Message:  problem.Message,
-				Severity: problem.Severity,
-				Range:    ls.Range(fset, problem.Node),
-				Source:   "goose",
-			})
+			diagnostics = append(diagnostics, ls.diagnostic(fset, problem))
 		}
 	}
 
@@ -405,15 +389,12 @@ func (ls *LanguageServer) checkModule(ctx context.Context, documentUri DocumentU
 	ls.modules[documentUri] = &Mutexed[*ast.Module]{
 		v: module,
 	}
+	ls.validators[documentUri] = v
+	ls.imports[documentUri] = importedFiles(v)
 	ls.logger.Sugar().Debugf("finished checking module: %s", documentUri.Filename())
 	return nil
 }
 
-func (ls *LanguageServer) DidRenameFiles(ctx context.Context, params *RenameFilesParams) (err error) {
-	err = notImplemented("DidRenameFiles")
-	return
-}
-
 func (ls *LanguageServer) DidSave(ctx context.Context, params *DidSaveTextDocumentParams) (err error) {
 	err = notImplemented("DidSave")
 	return
@@ -424,16 +405,6 @@ func (ls *LanguageServer) DocumentColor(ctx context.Context, params *DocumentCol
 	return
 }
 
-func (ls *LanguageServer) DocumentHighlight(ctx context.Context, params *DocumentHighlightParams) (result []DocumentHighlight, err error) {
-	err = notImplemented("DocumentHighlight")
-	return
-}
-
-func (ls *LanguageServer) DocumentLink(ctx context.Context, params *DocumentLinkParams) (result []DocumentLink, err error) {
-	err = notImplemented("DocumentLink")
-	return
-}
-
 func (ls *LanguageServer) DocumentLinkResolve(ctx context.Context, params *DocumentLink) (result *DocumentLink, err error) {
 	err = notImplemented("DocumentLinkResolve")
 	return
@@ -469,6 +440,10 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 	for _, stmt := range module.Stmts {
 		switch stmt := stmt.(type) {
 		case *ast.ConstStmt:
+			if stmt.Pattern != nil {
+				symbols = append(symbols, ls.patternSymbols(fset, stmt, stmt.Pattern, SymbolKindConstant)...)
+				break
+			}
 			symbols = append(symbols, DocumentSymbol{
 				Name:           stmt.Ident.Name,
 				Kind:           SymbolKindConstant,
@@ -492,6 +467,10 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 				Range:          ls.Range(fset, stmt),
 				SelectionRange: ls.Range(fset, stmt.Name),
 			})
+		case *ast.TraitStmt:
+			symbols = append(symbols, ls.traitSymbol(fset, stmt, stmt))
+		case *ast.EnumStmt:
+			symbols = append(symbols, ls.enumSymbol(fset, stmt, stmt))
 		case *ast.NativeConst:
 			symbols = append(symbols, DocumentSymbol{
 				Name:           stmt.Ident.Name,
@@ -514,12 +493,37 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 				SelectionRange: ls.Range(fset, stmt.Name),
 			})
 		case *ast.LetStmt:
+			if stmt.Pattern != nil {
+				symbols = append(symbols, ls.patternSymbols(fset, stmt, stmt.Pattern, SymbolKindVariable)...)
+				break
+			}
 			symbols = append(symbols, DocumentSymbol{
 				Name:           stmt.Ident.Name,
 				Kind:           SymbolKindVariable,
 				Range:          ls.Range(fset, stmt),
 				SelectionRange: ls.Range(fset, stmt.Ident),
 			})
+		case *ast.TypeStmt:
+			symbols = append(symbols, DocumentSymbol{
+				Name:           stmt.Name.Name,
+				Kind:           SymbolKindInterface,
+				Range:          ls.Range(fset, stmt),
+				SelectionRange: ls.Range(fset, stmt.Name),
+			})
+		case *ast.MacroStmt:
+			symbols = append(symbols, DocumentSymbol{
+				Name:           stmt.Name.Name + "!",
+				Kind:           SymbolKindFunction,
+				Range:          ls.Range(fset, stmt),
+				SelectionRange: ls.Range(fset, stmt.Name),
+			})
+		case *ast.ProcMacroStmt:
+			symbols = append(symbols, DocumentSymbol{
+				Name:           stmt.Name.Name + "!",
+				Kind:           SymbolKindFunction,
+				Range:          ls.Range(fset, stmt),
+				SelectionRange: ls.Range(fset, stmt.Name),
+			})
 		case *ast.SymbolStmt:
 			symbols = append(symbols, DocumentSymbol{
 				Name:           stmt.Ident.Name,
@@ -530,6 +534,10 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 		case *ast.ExportDeclStmt:
 			switch decl := stmt.Stmt.(type) {
 			case *ast.ConstStmt:
+				if decl.Pattern != nil {
+					symbols = append(symbols, ls.patternSymbols(fset, stmt, decl.Pattern, SymbolKindConstant)...)
+					break
+				}
 				symbols = append(symbols, DocumentSymbol{
 					Name:           decl.Ident.Name,
 					Kind:           SymbolKindConstant,
@@ -552,7 +560,15 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 					Range:          ls.Range(fset, stmt),
 					SelectionRange: ls.Range(fset, decl.Name),
 				})
+			case *ast.TraitStmt:
+				symbols = append(symbols, ls.traitSymbol(fset, stmt, decl))
+			case *ast.EnumStmt:
+				symbols = append(symbols, ls.enumSymbol(fset, stmt, decl))
 			case *ast.LetStmt:
+				if decl.Pattern != nil {
+					symbols = append(symbols, ls.patternSymbols(fset, stmt, decl.Pattern, SymbolKindVariable)...)
+					break
+				}
 				symbols = append(symbols, DocumentSymbol{
 					Name:           decl.Ident.Name,
 					Kind:           SymbolKindVariable,
@@ -580,6 +596,13 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 					Range:          ls.Range(fset, stmt),
 					SelectionRange: ls.Range(fset, decl.Name),
 				})
+			case *ast.TypeStmt:
+				symbols = append(symbols, DocumentSymbol{
+					Name:           decl.Name.Name,
+					Kind:           SymbolKindInterface,
+					Range:          ls.Range(fset, stmt),
+					SelectionRange: ls.Range(fset, decl.Name),
+				})
 			}
 
 		}
@@ -590,63 +613,81 @@ func (ls *LanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSy
 	return symbols, nil
 }
 
-func (ls *LanguageServer) ExecuteCommand(ctx context.Context, params *ExecuteCommandParams) (result interface{}, err error) {
-	err = notImplemented("ExecuteCommand")
-	return
-}
-
-func (ls *LanguageServer) FoldingRanges(ctx context.Context, params *FoldingRangeParams) (result []FoldingRange, err error) {
-	err = notImplemented("FoldingRanges")
-	return
-}
-
-func (ls *LanguageServer) Formatting(ctx context.Context, params *DocumentFormattingParams) (result []TextEdit, err error) {
-	err = notImplemented("Formatting")
-	return
-}
-
-func (ls *LanguageServer) Hover(ctx context.Context, params *HoverParams) (result *Hover, err error) {
-	return nil, nil
-
-	moduleMu, ok := ls.modules[params.TextDocument.URI]
-	if !ok {
-		ls.logger.Sugar().Errorf("module not found: %s", params.TextDocument.URI.Filename())
-		return nil, errors.New("module not found")
+// patternSymbols returns a symbol for each name bound by the destructuring
+// declaration stmt.
+func (ls *LanguageServer) patternSymbols(fset *token.FileSet, stmt ast.Stmt, pattern ast.PatternExpr, kind SymbolKind) []any {
+	symbols := []any{}
+	for _, ident := range ast.Bindings(pattern) {
+		symbols = append(symbols, DocumentSymbol{
+			Name:           ident.Name,
+			Kind:           kind,
+			Range:          ls.Range(fset, stmt),
+			SelectionRange: ls.Range(fset, ident),
+		})
+	}
+	return symbols
+}
+
+// traitSymbol returns the symbol of a trait declared by stmt, with its
+// receiver functions as children.
+func (ls *LanguageServer) traitSymbol(fset *token.FileSet, stmt ast.Stmt, trait *ast.TraitStmt) DocumentSymbol {
+	children := []DocumentSymbol{}
+	for _, member := range trait.Body {
+		var name *ast.Ident
+		switch member := member.(type) {
+		case *ast.MethodSpec:
+			name = member.Name
+		case *ast.ExprStmt:
+			if fn, ok := member.X.(*ast.FuncExpr); ok {
+				name = fn.Name
+			}
+		}
+		if name != nil {
+			children = append(children, DocumentSymbol{
+				Name:           name.Name,
+				Kind:           SymbolKindMethod,
+				Range:          ls.Range(fset, member),
+				SelectionRange: ls.Range(fset, name),
+			})
+		}
 	}
-
-	module := moduleMu.Lock()
-	defer moduleMu.Unlock()
-
-	fset, ok := ls.fsets[params.TextDocument.URI]
-	if !ok {
-		ls.logger.Sugar().Errorf("fileset not found: %s", params.TextDocument.URI.Filename())
+	return DocumentSymbol{
+		Name:           trait.Name.Name,
+		Kind:           SymbolKindInterface,
+		Range:          ls.Range(fset, stmt),
+		SelectionRange: ls.Range(fset, trait.Name),
+		Children:       children,
 	}
+}
 
-	position := fset.GetPosition("file:"+params.TextDocument.URI.Filename(), int(params.Position.Line)+1, int(params.Position.Character)+1)
-	pos := fset.Pos(position)
-	if pos == token.NoPos {
-		ls.logger.Sugar().Errorf("position not found: %#v", position)
-		return &Hover{}, nil
+// enumSymbol returns the symbol of an enum declared by stmt, with its variants
+// as children.
+func (ls *LanguageServer) enumSymbol(fset *token.FileSet, stmt ast.Stmt, enum *ast.EnumStmt) DocumentSymbol {
+	children := []DocumentSymbol{}
+	for _, variant := range enum.Variants {
+		children = append(children, DocumentSymbol{
+			Name:           variant.Name.Name,
+			Kind:           SymbolKindEnumMember,
+			Range:          ls.Range(fset, variant),
+			SelectionRange: ls.Range(fset, variant.Name),
+		})
 	}
-
-	node := module.FindNode(pos)
-	if node == nil {
-		ls.logger.Sugar().Errorf("node not found: %s", pos)
-		return &Hover{}, nil
+	return DocumentSymbol{
+		Name:           enum.Name.Name,
+		Kind:           SymbolKindEnum,
+		Range:          ls.Range(fset, stmt),
+		SelectionRange: ls.Range(fset, enum.Name),
+		Children:       children,
 	}
-
-	// TODO: find the declaration of the node
-
-	return &Hover{}, nil
 }
 
-func (ls *LanguageServer) Implementation(ctx context.Context, params *ImplementationParams) (result []Location, err error) {
-	err = notImplemented("Implementation")
+func (ls *LanguageServer) ExecuteCommand(ctx context.Context, params *ExecuteCommandParams) (result interface{}, err error) {
+	err = notImplemented("ExecuteCommand")
 	return
 }
 
-func (ls *LanguageServer) IncomingCalls(ctx context.Context, params *CallHierarchyIncomingCallsParams) (result []CallHierarchyIncomingCall, err error) {
-	err = notImplemented("IncomingCalls")
+func (ls *LanguageServer) Implementation(ctx context.Context, params *ImplementationParams) (result []Location, err error) {
+	err = notImplemented("Implementation")
 	return
 }
 
@@ -660,61 +701,11 @@ func (ls *LanguageServer) Moniker(ctx context.Context, params *MonikerParams) (r
 	return
 }
 
-func (ls *LanguageServer) OnTypeFormatting(ctx context.Context, params *DocumentOnTypeFormattingParams) (result []TextEdit, err error) {
-	err = notImplemented("OnTypeFormatting")
-	return
-}
-
-func (ls *LanguageServer) OutgoingCalls(ctx context.Context, params *CallHierarchyOutgoingCallsParams) (result []CallHierarchyOutgoingCall, err error) {
-	err = notImplemented("OutgoingCalls")
-	return
-}
-
-func (ls *LanguageServer) PrepareCallHierarchy(ctx context.Context, params *CallHierarchyPrepareParams) (result []CallHierarchyItem, err error) {
-	err = notImplemented("PrepareCallHierarchy")
-	return
-}
-
-func (ls *LanguageServer) PrepareRename(ctx context.Context, params *PrepareRenameParams) (result *Range, err error) {
-	err = notImplemented("PrepareRename")
-	return
-}
-
-func (ls *LanguageServer) RangeFormatting(ctx context.Context, params *DocumentRangeFormattingParams) (result []TextEdit, err error) {
-	err = notImplemented("RangeFormatting")
-	return
-}
-
-func (ls *LanguageServer) References(ctx context.Context, params *ReferenceParams) (result []Location, err error) {
-	err = notImplemented("References")
-	return
-}
-
-func (ls *LanguageServer) Rename(ctx context.Context, params *RenameParams) (result *WorkspaceEdit, err error) {
-	err = notImplemented("Rename")
-	return
-}
-
 func (ls *LanguageServer) Request(ctx context.Context, method string, params interface{}) (result interface{}, err error) {
 	err = notImplemented("Request")
 	return
 }
 
-func (ls *LanguageServer) SemanticTokensFull(ctx context.Context, params *SemanticTokensParams) (result *SemanticTokens, err error) {
-	err = notImplemented("SemanticTokensFull")
-	return
-}
-
-func (ls *LanguageServer) SemanticTokensFullDelta(ctx context.Context, params *SemanticTokensDeltaParams) (result interface{} /* SemanticTokens | SemanticTokensDelta */, err error) {
-	err = notImplemented("SemanticTokensFullDelta")
-	return
-}
-
-func (ls *LanguageServer) SemanticTokensRange(ctx context.Context, params *SemanticTokensRangeParams) (result *SemanticTokens, err error) {
-	err = notImplemented("SemanticTokensRange")
-	return
-}
-
 func (ls *LanguageServer) SemanticTokensRefresh(ctx context.Context) (err error) {
 	err = notImplemented("SemanticTokensRefresh")
 	return
@@ -725,16 +716,6 @@ func (ls *LanguageServer) ShowDocument(ctx context.Context, params *ShowDocument
 	return
 }
 
-func (ls *LanguageServer) SignatureHelp(ctx context.Context, params *SignatureHelpParams) (result *SignatureHelp, err error) {
-	err = notImplemented("SignatureHelp")
-	return
-}
-
-func (ls *LanguageServer) Symbols(ctx context.Context, params *WorkspaceSymbolParams) (result []SymbolInformation, err error) {
-	err = notImplemented("Symbols")
-	return
-}
-
 func (ls *LanguageServer) TypeDefinition(ctx context.Context, params *TypeDefinitionParams) (result []Location, err error) {
 	err = notImplemented("TypeDefinition")
 	return
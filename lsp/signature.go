@@ -0,0 +1,344 @@
+package lsp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+)
+
+// call is a call whose arguments are being typed.
+type call struct {
+	names  []string // dotted name of the callee, like math.sqrt
+	offset int      // offset of the callee
+	arg    int      // index of the argument the cursor is in
+	named  string   // name of the argument if it is written as name: value
+}
+
+// param is a parameter of a signature.
+type param struct {
+	name  string
+	label string
+	named bool
+	rest  bool
+}
+
+// callAt finds the innermost call that the offset is in the arguments of. The
+// text up to offset is scanned rather than parsed, because a call that is
+// being typed is usually not valid syntax yet.
+func callAt(src []byte, offset int) (call, bool) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("", -1, offset)
+	s := scanner.Scanner{}
+	s.Init(f, src[:offset], func(token.Position, string) {})
+
+	type tok struct {
+		offset int
+		tok    token.Token
+		lit    string
+	}
+	type group struct {
+		open   int // index of the opening token
+		commas int
+		arg    int // index of the first token of the current argument
+	}
+	var tokens []tok
+	var groups []group
+	for {
+		pos, t, lit := s.Scan()
+		if t == token.EOF {
+			break
+		}
+		if t == token.Comment {
+			continue
+		}
+		tokens = append(tokens, tok{f.Offset(pos), t, lit})
+		i := len(tokens) - 1
+		switch t {
+		case token.LParen, token.LBracket, token.LBrace, token.HashLBracket, token.StringInterpExprStart:
+			groups = append(groups, group{open: i, arg: i + 1})
+		case token.RParen, token.RBracket, token.RBrace, token.StringInterpExprEnd:
+			if len(groups) > 0 {
+				groups = groups[:len(groups)-1]
+			}
+		case token.Comma:
+			if len(groups) > 0 {
+				groups[len(groups)-1].commas++
+				groups[len(groups)-1].arg = i + 1
+			}
+		}
+	}
+
+	for g := len(groups) - 1; g >= 0; g-- {
+		group := groups[g]
+		if tokens[group.open].tok != token.LParen {
+			continue
+		}
+
+		// the callee is a dotted name right before the parenthesis
+		i := group.open - 1
+		var names []string
+		for i >= 0 && tokens[i].tok == token.Ident {
+			names = append([]string{tokens[i].lit}, names...)
+			if i == 0 || tokens[i-1].tok != token.Period {
+				break
+			}
+			i -= 2
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if i > 0 {
+			switch tokens[i-1].tok {
+			case token.Func, token.Generator, token.Struct, token.Operator:
+				// a declaration, not a call
+				continue
+			}
+		}
+
+		c := call{names: names, offset: tokens[i].offset, arg: group.commas}
+		if group.arg+1 < len(tokens) && tokens[group.arg].tok == token.Ident && tokens[group.arg+1].tok == token.Colon {
+			c.named = tokens[group.arg].lit
+		}
+		return c, true
+	}
+	return call{}, false
+}
+
+// activeParameter returns the index of the parameter that receives the
+// argument being typed in c. Positional arguments skip named parameters and
+// are collected by the rest parameter once the others are used up.
+func activeParameter(params []param, c call) uint32 {
+	if c.named != "" {
+		for i, p := range params {
+			if p.name == c.named {
+				return uint32(i)
+			}
+		}
+		return uint32(len(params))
+	}
+
+	positional := 0
+	for i, p := range params {
+		if p.rest {
+			return uint32(i)
+		}
+		if p.named {
+			continue
+		}
+		if positional == c.arg {
+			return uint32(i)
+		}
+		positional++
+	}
+	return uint32(len(params))
+}
+
+// declaredParams returns the parameters of a function or struct declaration,
+// or false if decl does not declare something that can be called.
+func declaredParams(fset *token.FileSet, source []byte, decl *validator.Declaration) ([]param, bool) {
+	node := decl.Decl
+	switch n := node.(type) {
+	case *ast.LetStmt:
+		node = n.Value
+	case *ast.ConstStmt:
+		node = n.Value
+	}
+
+	var list *ast.FuncParamList
+	switch node := node.(type) {
+	case *ast.FuncExpr:
+		list = node.Params
+	case *ast.NativeFunc:
+		list = node.Params
+	case *ast.GeneratorExpr:
+		list = node.Params
+	case *ast.MethodSpec:
+		list = node.Params
+	case *ast.StructStmt:
+		return fieldParams(fset, source, node.Fields), true
+	case *ast.NativeStruct:
+		return fieldParams(fset, source, node.Fields), true
+	case *ast.EnumVariant:
+		if node.Fields == nil {
+			return nil, false
+		}
+		return fieldParams(fset, source, node.Fields), true
+	default:
+		return nil, false
+	}
+
+	params := []param{}
+	if list != nil {
+		for _, p := range list.List {
+			var name string
+			if p.Ident != nil {
+				name = p.Ident.Name
+			}
+			params = append(params, param{
+				name:  name,
+				label: paramSignature(fset, source, p),
+				named: p.Colon.IsValid(),
+				rest:  p.Ellipsis.IsValid(),
+			})
+		}
+	}
+	return params, true
+}
+
+// fieldParams returns the fields of a struct as the parameters of its
+// constructor.
+func fieldParams(fset *token.FileSet, source []byte, fields *ast.StructFieldList) []param {
+	params := []param{}
+	if fields == nil {
+		return params
+	}
+	for _, field := range fields.List {
+		label := field.Ident.Name
+		if field.Value != nil {
+			label += " = " + text(fset, source, field.Value)
+		}
+		params = append(params, param{name: field.Ident.Name, label: label})
+	}
+	return params
+}
+
+// builtinParams splits the parameters out of the signature of a builtin, like
+// `printf(format: string, ...obj: any[]) -> void`.
+func builtinParams(signature string) []param {
+	start := strings.IndexByte(signature, '(')
+	if start < 0 {
+		return nil
+	}
+	params := []param{}
+	depth := 0
+	from := start + 1
+	for i := start; i < len(signature); i++ {
+		switch signature[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		if (signature[i] == ',' && depth == 1) || depth == 0 {
+			label := strings.TrimSpace(signature[from:i])
+			if label != "" {
+				name := strings.TrimPrefix(label, "...")
+				name = strings.TrimRight(name[:strings.IndexAny(name+":", ":?")], " ")
+				params = append(params, param{name: name, label: label, rest: strings.HasPrefix(label, "...")})
+			}
+			from = i + 1
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	return params
+}
+
+func (ls *LanguageServer) SignatureHelp(ctx context.Context, params *SignatureHelpParams) (result *SignatureHelp, err error) {
+	ls.logger.Sugar().Debugf("SignatureHelp: %s", params.TextDocument.URI.Filename())
+	uri := params.TextDocument.URI
+	sourceMu, ok := ls.sourceFiles[uri]
+	if !ok {
+		return nil, nil
+	}
+	buffer := sourceMu.Lock()
+	src := buffer.Bytes()
+	c, ok := callAt(src, buffer.Offset(params.Position, ls.encoding))
+	sourceMu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+
+	var signature SignatureInformation
+	var parameters []param
+	name := strings.Join(c.names, ".")
+	if decl := ls.lookup(uri, c); decl != nil {
+		fset := ls.validators[uri].Fset()
+		position := fset.Position(decl.Decl.Pos())
+		source := ls.source(position.Filename)
+		parameters, ok = declaredParams(fset, source, decl)
+		if !ok {
+			return nil, nil
+		}
+
+		doc := declDoc(decl)
+		signature.Label = signatureLabel(decl, parameters)
+		if doc := renderDoc(doc); doc != "" {
+			signature.Documentation = MarkupContent{Kind: Markdown, Value: doc}
+		}
+		for _, p := range parameters {
+			info := ParameterInformation{Label: p.label}
+			if doc := doc.Param(p.name); doc != "" {
+				info.Documentation = doc
+			}
+			signature.Parameters = append(signature.Parameters, info)
+		}
+	} else if doc, ok := builtinDoc(name); ok {
+		parameters = builtinParams(doc.Signature)
+		signature.Label = doc.Signature
+		if doc.Desc != "" {
+			signature.Documentation = doc.Desc
+		}
+		for _, p := range parameters {
+			signature.Parameters = append(signature.Parameters, ParameterInformation{Label: p.label})
+		}
+	} else {
+		return nil, nil
+	}
+
+	active := activeParameter(parameters, c)
+	signature.ActiveParameter = active
+	return &SignatureHelp{
+		Signatures:      []SignatureInformation{signature},
+		ActiveParameter: active,
+	}, nil
+}
+
+// lookup resolves the callee of c with the validator of uri. The text that
+// was last checked may differ from the current text, so the scope is taken
+// at the same offset, clamped to the checked text.
+func (ls *LanguageServer) lookup(uri DocumentURI, c call) *validator.Declaration {
+	v, ok := ls.validators[uri]
+	if !ok {
+		return nil
+	}
+	file := v.Fset().File("file:" + uri.Filename())
+	if file == nil {
+		return nil
+	}
+	offset := c.offset
+	if offset > file.Size() {
+		offset = file.Size()
+	}
+	return v.Lookup(file.Pos(offset), c.names)
+}
+
+// signatureLabel returns the signature of decl as it is called, like
+// Point.move(dx, dy = 0).
+func signatureLabel(decl *validator.Declaration, params []param) string {
+	name := decl.Name
+	var receiver *ast.Ident
+	switch node := decl.Decl.(type) {
+	case *ast.FuncExpr:
+		receiver = node.Receiver
+	case *ast.NativeFunc:
+		receiver = node.Receiver
+	case *ast.GeneratorExpr:
+		receiver = node.Receiver
+	}
+	if receiver != nil {
+		name = receiver.Name + "." + name
+	}
+
+	labels := make([]string, len(params))
+	for i, p := range params {
+		labels[i] = p.label
+	}
+	return name + "(" + strings.Join(labels, ", ") + ")"
+}
@@ -0,0 +1,90 @@
+package lsp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCallAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		src   string // | marks the cursor
+		names []string
+		arg   int
+		named string
+	}{
+		{"foo(|", []string{"foo"}, 0, ""},
+		{"foo(1, |", []string{"foo"}, 1, ""},
+		{"math.sqrt(x|)", []string{"math", "sqrt"}, 0, ""},
+		{"foo(1, bar(2, 3), [4, 5], { a: 6, b: 7 }, \"${f(8, 9)}\", |", []string{"foo"}, 5, ""},
+		{"foo(bar(1, |", []string{"bar"}, 1, ""},
+		{"foo(1, c: |", []string{"foo"}, 1, "c"},
+		{"let x = Canvas(100,\n  |", []string{"Canvas"}, 1, ""},
+		{"foo(1) |", nil, 0, ""},
+		{"fn foo(a, |", nil, 0, ""},
+		{"if (a, |", nil, 0, ""},
+	}
+
+	for _, test := range tests {
+		offset := strings.Index(test.src, "|")
+		src := []byte(strings.Replace(test.src, "|", "", 1))
+		c, ok := callAt(src, offset)
+		if test.names == nil {
+			if ok {
+				t.Errorf("%q: got call of %v, want none", test.src, c.names)
+			}
+			continue
+		}
+		if !ok || !reflect.DeepEqual(c.names, test.names) || c.arg != test.arg || c.named != test.named {
+			t.Errorf("%q: got %v, %v; want call of %v at argument %d named %q", test.src, c, ok, test.names, test.arg, test.named)
+		}
+	}
+}
+
+func TestActiveParameter(t *testing.T) {
+	t.Parallel()
+
+	// fn add(a, b, :c, :d = 4, ...e)
+	params := []param{{name: "a"}, {name: "b"}, {name: "c", named: true}, {name: "d", named: true}, {name: "e", rest: true}}
+
+	tests := []struct {
+		c    call
+		want uint32
+	}{
+		{call{arg: 0}, 0},
+		{call{arg: 1}, 1},
+		{call{arg: 2}, 4},
+		{call{arg: 5}, 4},
+		{call{arg: 1, named: "d"}, 3},
+		{call{arg: 1, named: "x"}, 5},
+	}
+	for _, test := range tests {
+		if got := activeParameter(params, test.c); got != test.want {
+			t.Errorf("%+v: got %d, want %d", test.c, got, test.want)
+		}
+	}
+
+	if got := activeParameter(params[:2], call{arg: 2}); got != 2 {
+		t.Errorf("too many arguments: got %d, want 2", got)
+	}
+}
+
+func TestBuiltinParams(t *testing.T) {
+	t.Parallel()
+
+	got := builtinParams("exit(code: int[>=0,<=255], ...rest: any[]) -> never")
+	want := []param{
+		{name: "code", label: "code: int[>=0,<=255]"},
+		{name: "rest", label: "...rest: any[]", rest: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = builtinParams("int.parse(s: str, base?: int) -> int")
+	if len(got) != 2 || got[1].name != "base" {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"go.lsp.dev/jsonrpc2"
+	. "go.lsp.dev/protocol"
+)
+
+// go.lsp.dev/protocol predates position encodings and can't tell a full-text
+// change (which has no range) from an insertion at the start of the document,
+// so these messages are decoded here before they reach the generated handler.
+
+type initializeCapabilities struct {
+	Capabilities struct {
+		General struct {
+			PositionEncodings []string `json:"positionEncodings"`
+		} `json:"general"`
+	} `json:"capabilities"`
+}
+
+type contentChange struct {
+	Range *Range `json:"range"`
+	Text  string `json:"text"`
+}
+
+type didChangeParams struct {
+	TextDocument   VersionedTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []contentChange                 `json:"contentChanges"`
+}
+
+// handler wraps the protocol handler to negotiate the position encoding and
+// apply document changes.
+func (ls *LanguageServer) handler(next jsonrpc2.Handler) jsonrpc2.Handler {
+	return func(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+		switch req.Method() {
+		case MethodInitialize:
+			var params initializeCapabilities
+			if err := json.Unmarshal(req.Params(), &params); err == nil {
+				ls.encoding = negotiateEncoding(params.Capabilities.General.PositionEncodings)
+			}
+			return next(ctx, func(ctx context.Context, result interface{}, err error) error {
+				if result, ok := result.(*InitializeResult); ok && err == nil {
+					return reply(ctx, withPositionEncoding(result, ls.encoding), nil)
+				}
+				return reply(ctx, result, err)
+			}, req)
+		case MethodTextDocumentDidChange:
+			var params didChangeParams
+			if err := json.Unmarshal(req.Params(), &params); err != nil {
+				return replyParseError(ctx, reply, err)
+			}
+			return reply(ctx, nil, ls.didChange(ctx, params.TextDocument.URI, params.ContentChanges))
+		}
+		return next(ctx, reply, req)
+	}
+}
+
+// withPositionEncoding adds the negotiated encoding to the server
+// capabilities.
+func withPositionEncoding(result *InitializeResult, encoding PositionEncoding) any {
+	return struct {
+		Capabilities struct {
+			ServerCapabilities
+			PositionEncoding PositionEncoding `json:"positionEncoding"`
+		} `json:"capabilities"`
+		ServerInfo *ServerInfo `json:"serverInfo,omitempty"`
+	}{
+		Capabilities: struct {
+			ServerCapabilities
+			PositionEncoding PositionEncoding `json:"positionEncoding"`
+		}{result.Capabilities, encoding},
+		ServerInfo: result.ServerInfo,
+	}
+}
+
+func replyParseError(ctx context.Context, reply jsonrpc2.Replier, err error) error {
+	return reply(ctx, nil, jsonrpc2.Errorf(jsonrpc2.ParseError, "%s", err))
+}
+
+// didChange applies changes to the text of a document, in order, and checks
+// the result. Changes without a range replace the whole document. If a change
+// can't be applied, none of them are.
+func (ls *LanguageServer) didChange(ctx context.Context, uri DocumentURI, changes []contentChange) error {
+	ls.logger.Sugar().Debugf("DidChange: %s", uri.Filename())
+	sourceMu, ok := ls.sourceFiles[uri]
+	if !ok {
+		ls.logger.Sugar().Errorf("document not found: %s", uri.Filename())
+		return errors.New("document not found")
+	}
+	buffer := sourceMu.Lock()
+	before := buffer.String()
+	// edits don't modify the rope, so a copy of the buffer keeps its text
+	saved := *buffer
+
+	for _, change := range changes {
+		if change.Range == nil {
+			buffer.SetText(change.Text)
+			continue
+		}
+		if err := buffer.Replace(*change.Range, change.Text, ls.encoding); err != nil {
+			// the client didn't apply the batch either if it was invalid
+			*buffer = saved
+			sourceMu.Unlock()
+			ls.logger.Sugar().Errorf("cannot apply change to %s: %s", uri.Filename(), err)
+			return err
+		}
+	}
+
+	sourceMu.Update(buffer)
+	if before == buffer.String() {
+		ls.logger.Sugar().Debugf("no changes")
+		return nil
+	}
+	if err := ls.checkModule(ctx, uri); err != nil {
+		return err
+	}
+	ls.checkDependents(ctx, uri.Filename())
+	return nil
+}
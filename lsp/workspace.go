@@ -0,0 +1,402 @@
+package lsp
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// Every goose file under the workspace folders is checked when the server
+// starts, whether or not it is open, so that features that look across files
+// (references, renames, call hierarchies and workspace symbols) see the whole
+// workspace. Open documents are checked with the text in their buffers, and
+// so are the modules they import. When a file changes, on disk or in its
+// buffer, the documents that import it are checked again.
+
+// gooseFiles selects the goose files in file operations.
+var gooseFiles = &FileOperationRegistrationOptions{
+	Filters: []FileOperationFilter{{
+		Scheme:  "file",
+		Pattern: FileOperationPattern{Glob: "**/*.goose"},
+	}},
+}
+
+// workspaceFolders returns the paths of the workspace folders the client
+// opened, falling back to the root of older clients.
+func workspaceFolders(params *InitializeParams) []string {
+	var folders []string
+	for _, folder := range params.WorkspaceFolders {
+		folders = append(folders, uri.New(folder.URI).Filename())
+	}
+	if len(folders) == 0 && params.RootURI != "" {
+		folders = append(folders, params.RootURI.Filename())
+	}
+	if len(folders) == 0 && params.RootPath != "" {
+		folders = append(folders, params.RootPath)
+	}
+	return folders
+}
+
+// readFile returns the text of the file at path: the text of its buffer if
+// it is open, or its contents on disk.
+func (ls *LanguageServer) readFile(path string) ([]byte, error) {
+	if u, ok := ls.uris[path]; ok {
+		if sourceMu, ok := ls.sourceFiles[u]; ok {
+			defer sourceMu.Unlock()
+			return sourceMu.Lock().Bytes(), nil
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// inWorkspace reports whether path is in one of the workspace folders.
+func (ls *LanguageServer) inWorkspace(path string) bool {
+	for _, folder := range ls.folders {
+		if within(path, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is dir or a file under it.
+func within(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// importedFiles returns the paths of the files imported by the modules that v
+// checked, including the files of imports that could not be loaded, which
+// may be created later. Directories stand for their index.goose.
+func importedFiles(v *validator.Validator) []string {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, module := range v.Modules() {
+		if module == nil || module.Module == nil {
+			continue
+		}
+		dir := filepath.Dir(strings.TrimPrefix(module.Specifier, "file:"))
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		add := func(spec ast.ModuleSpec) {
+			name := moduleName(spec.ModuleSpecifier(), dir)
+			if path, ok := strings.CutPrefix(name, "file:"); ok && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+		for _, stmt := range module.Stmts {
+			switch stmt := stmt.(type) {
+			case *ast.ImportStmt:
+				add(stmt.Spec)
+			case *ast.ExportSpecStmt:
+				add(stmt.Spec)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// dependsOn reports whether the checked document u imports the file at path,
+// or a file under it.
+func (ls *LanguageServer) dependsOn(u DocumentURI, path string) bool {
+	for _, file := range ls.imports[u] {
+		if file == path || filepath.Join(file, "index.goose") == path || within(file, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDependents checks the documents that import the file at path again,
+// or import files in it if it is a directory.
+func (ls *LanguageServer) checkDependents(ctx context.Context, path string) {
+	var dependents []DocumentURI
+	for u := range ls.validators {
+		if u.Filename() != path && ls.dependsOn(u, path) {
+			dependents = append(dependents, u)
+		}
+	}
+	sort.Slice(dependents, func(a, b int) bool { return dependents[a] < dependents[b] })
+	for _, u := range dependents {
+		if err := ls.checkModule(ctx, u); err != nil {
+			ls.logger.Sugar().Errorf("cannot check %s: %s", u.Filename(), err)
+		}
+	}
+}
+
+// indexFolder checks every goose file under dir. Hidden directories are
+// skipped.
+func (ls *LanguageServer) indexFolder(ctx context.Context, dir string) {
+	ls.logger.Sugar().Debugf("indexing %s", dir)
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".goose") {
+			ls.indexFile(ctx, path)
+		}
+		return nil
+	})
+}
+
+// indexFile checks the file at path, unless it is open, in which case its
+// buffer has already been checked.
+func (ls *LanguageServer) indexFile(ctx context.Context, path string) {
+	u, ok := ls.documentURI(path)
+	if !ok {
+		return
+	}
+	if _, open := ls.sourceFiles[u]; open {
+		return
+	}
+	if err := ls.checkModule(ctx, u); err != nil {
+		ls.logger.Sugar().Errorf("cannot check %s: %s", path, err)
+	}
+}
+
+// forget drops what is known about the files at or under path that are not
+// open and clears their diagnostics.
+func (ls *LanguageServer) forget(ctx context.Context, path string) {
+	for _, u := range ls.checkedURIs() {
+		if _, open := ls.sourceFiles[u]; open || !within(u.Filename(), path) {
+			continue
+		}
+		delete(ls.validators, u)
+		delete(ls.modules, u)
+		delete(ls.snapshots, u)
+		delete(ls.fsets, u)
+		delete(ls.parseErrors, u)
+		delete(ls.imports, u)
+		delete(ls.semanticResults, u)
+		delete(ls.uris, u.Filename())
+		ls.client.PublishDiagnostics(ctx, &PublishDiagnosticsParams{URI: u, Diagnostics: []Diagnostic{}})
+	}
+}
+
+// checkedURIs returns the documents that have been checked or failed to
+// parse, in order.
+func (ls *LanguageServer) checkedURIs() []DocumentURI {
+	seen := map[DocumentURI]bool{}
+	var uris []DocumentURI
+	for _, u := range ls.uris {
+		if _, ok := ls.fsets[u]; ok && !seen[u] {
+			seen[u] = true
+			uris = append(uris, u)
+		}
+	}
+	sort.Slice(uris, func(a, b int) bool { return uris[a] < uris[b] })
+	return uris
+}
+
+// fileChanged brings the index up to date with the file or directory at
+// path, which was created, changed or deleted on disk.
+func (ls *LanguageServer) fileChanged(ctx context.Context, path string) {
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		ls.forget(ctx, path)
+	case info.IsDir():
+		if ls.inWorkspace(path) {
+			ls.indexFolder(ctx, path)
+		}
+	case strings.HasSuffix(path, ".goose"):
+		if ls.inWorkspace(path) {
+			ls.indexFile(ctx, path)
+		}
+	default:
+		return
+	}
+	ls.checkDependents(ctx, path)
+}
+
+func (ls *LanguageServer) DidChangeWatchedFiles(ctx context.Context, params *DidChangeWatchedFilesParams) (err error) {
+	ls.logger.Sugar().Debugf("DidChangeWatchedFiles: %d changes", len(params.Changes))
+	for _, change := range params.Changes {
+		ls.fileChanged(ctx, change.URI.Filename())
+	}
+	return nil
+}
+
+func (ls *LanguageServer) DidCreateFiles(ctx context.Context, params *CreateFilesParams) (err error) {
+	ls.logger.Sugar().Debugf("DidCreateFiles: %d files", len(params.Files))
+	for _, file := range params.Files {
+		ls.fileChanged(ctx, uri.New(file.URI).Filename())
+	}
+	return nil
+}
+
+func (ls *LanguageServer) DidDeleteFiles(ctx context.Context, params *DeleteFilesParams) (err error) {
+	ls.logger.Sugar().Debugf("DidDeleteFiles: %d files", len(params.Files))
+	for _, file := range params.Files {
+		ls.fileChanged(ctx, uri.New(file.URI).Filename())
+	}
+	return nil
+}
+
+func (ls *LanguageServer) DidRenameFiles(ctx context.Context, params *RenameFilesParams) (err error) {
+	ls.logger.Sugar().Debugf("DidRenameFiles: %d files", len(params.Files))
+	for _, file := range params.Files {
+		ls.fileChanged(ctx, uri.New(file.OldURI).Filename())
+		ls.fileChanged(ctx, uri.New(file.NewURI).Filename())
+	}
+	return nil
+}
+
+func (ls *LanguageServer) DidChangeWorkspaceFolders(ctx context.Context, params *DidChangeWorkspaceFoldersParams) (err error) {
+	ls.logger.Sugar().Debugf("DidChangeWorkspaceFolders: %d added, %d removed", len(params.Event.Added), len(params.Event.Removed))
+	for _, folder := range params.Event.Removed {
+		path := uri.New(folder.URI).Filename()
+		folders := ls.folders[:0]
+		for _, f := range ls.folders {
+			if f != path {
+				folders = append(folders, f)
+			}
+		}
+		ls.folders = folders
+		// files that are also in another folder stay
+		for _, u := range ls.checkedURIs() {
+			if within(u.Filename(), path) && !ls.inWorkspace(u.Filename()) {
+				ls.forget(ctx, u.Filename())
+			}
+		}
+	}
+	for _, folder := range params.Event.Added {
+		path := uri.New(folder.URI).Filename()
+		ls.folders = append(ls.folders, path)
+		ls.indexFolder(ctx, path)
+	}
+	return nil
+}
+
+// DidClose drops the buffer of a document. Files in the workspace are
+// checked again with their contents on disk; other files are forgotten.
+func (ls *LanguageServer) DidClose(ctx context.Context, params *DidCloseTextDocumentParams) (err error) {
+	ls.logger.Sugar().Debugf("DidClose: %s", params.TextDocument.URI.Filename())
+	path := params.TextDocument.URI.Filename()
+	delete(ls.sourceFiles, params.TextDocument.URI)
+	if _, statErr := os.Stat(path); statErr != nil || !ls.inWorkspace(path) {
+		ls.forget(ctx, path)
+	} else if err = ls.checkModule(ctx, params.TextDocument.URI); err != nil {
+		return err
+	}
+	ls.checkDependents(ctx, path)
+	return nil
+}
+
+// Symbols finds the declarations at the top level of the modules in the
+// workspace and the open documents whose names match the query.
+func (ls *LanguageServer) Symbols(ctx context.Context, params *WorkspaceSymbolParams) (result []SymbolInformation, err error) {
+	ls.logger.Sugar().Debugf("Symbols: %s", params.Query)
+	result = []SymbolInformation{}
+	seen := map[declKey]bool{}
+	for _, v := range ls.validators {
+		fset := v.Fset()
+		for _, decl := range v.Declarations() {
+			if decl.Ident == nil || !topLevel(decl) || !fuzzyMatch(decl.Name, params.Query) {
+				continue
+			}
+			path := strings.TrimPrefix(fset.Position(decl.Ident.Pos()).Filename, "file:")
+			if !filepath.IsAbs(path) {
+				// std and native modules
+				continue
+			}
+			if _, open := ls.sourceFiles[ls.uris[path]]; !open && !ls.inWorkspace(path) {
+				continue
+			}
+			key := keyOf(fset, decl)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			symbol := SymbolInformation{
+				Name:     decl.Name,
+				Kind:     SymbolKindVariable,
+				Location: ls.Location(fset, decl.Decl),
+			}
+			switch decl.Kind {
+			case validator.DeclConstant:
+				symbol.Kind = SymbolKindConstant
+			case validator.DeclFunction:
+				symbol.Kind = SymbolKindFunction
+			case validator.DeclStruct:
+				symbol.Kind = SymbolKindStruct
+			case validator.DeclType, validator.DeclTrait:
+				symbol.Kind = SymbolKindInterface
+			case validator.DeclEnum:
+				symbol.Kind = SymbolKindEnum
+			case validator.DeclVariant:
+				symbol.Kind = SymbolKindEnumMember
+			case validator.DeclMacro:
+				symbol.Kind = SymbolKindFunction
+			case validator.DeclMethod:
+				symbol.Kind = SymbolKindMethod
+				if fn, ok := decl.Decl.(*ast.FuncExpr); ok && fn.Receiver != nil {
+					symbol.ContainerName = fn.Receiver.Name
+				}
+			}
+			result = append(result, symbol)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Name != result[b].Name {
+			return result[a].Name < result[b].Name
+		}
+		if result[a].Location.URI != result[b].Location.URI {
+			return result[a].Location.URI < result[b].Location.URI
+		}
+		return CmpPositions(result[a].Location.Range.Start, result[b].Location.Range.Start) < 0
+	})
+	return result, nil
+}
+
+// topLevel reports whether decl is declared by a statement at the top level
+// of its module.
+func topLevel(decl *validator.Declaration) bool {
+	if decl.Module == nil || decl.Module.Module == nil {
+		return false
+	}
+	for _, stmt := range decl.Module.Stmts {
+		if export, ok := stmt.(*ast.ExportDeclStmt); ok {
+			stmt = export.Stmt
+		}
+		if expr, ok := stmt.(*ast.ExprStmt); ok && ast.Node(expr.X) == decl.Decl {
+			return true
+		}
+		if ast.Node(stmt) == decl.Decl {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatch reports whether the characters of query appear in name in
+// order, ignoring case.
+func fuzzyMatch(name string, query string) bool {
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	for _, c := range query {
+		i := strings.IndexRune(name, c)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(string(c)):]
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+	. "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+// diagnosticsClient records the diagnostics published to it.
+type diagnosticsClient struct {
+	Client
+	diagnostics map[DocumentURI][]Diagnostic
+}
+
+func (c *diagnosticsClient) PublishDiagnostics(ctx context.Context, params *PublishDiagnosticsParams) error {
+	c.diagnostics[params.URI] = params.Diagnostics
+	return nil
+}
+
+func TestWorkspace(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"lib.goose":          "export fn double(x) -> x * 2\n",
+		"main.goose":         "import \"./lib.goose\" show { double }\n\nlet answer = double(21)\n",
+		"sub/helpers.goose":  "fn helper() -> 1\n\nstruct Box(value)\n\nfn Box.get() -> #value\n",
+		".hidden/skip.goose": "let skipped = 1\n",
+	}
+	dir := writeWorkspace(t, files)
+	lib, main := uri.File(filepath.Join(dir, "lib.goose")), uri.File(filepath.Join(dir, "main.goose"))
+
+	client := &diagnosticsClient{diagnostics: map[DocumentURI][]Diagnostic{}}
+	ls := &LanguageServer{
+		client:      client,
+		logger:      zap.NewNop(),
+		state:       ServerStateRunning,
+		encoding:    PositionEncodingUTF16,
+		folders:     []string{dir},
+		sourceFiles: map[DocumentURI]*Mutexed[*TextBuffer]{},
+		modules:     map[DocumentURI]*Mutexed[*ast.Module]{},
+		uris:        map[string]DocumentURI{},
+		fsets:       map[DocumentURI]*token.FileSet{},
+		parseErrors: map[DocumentURI][]Diagnostic{},
+		validators:  map[DocumentURI]*validator.Validator{},
+		snapshots:   map[DocumentURI][]byte{},
+		imports:     map[DocumentURI][]string{},
+
+		semanticResults: map[DocumentURI]*SemanticTokens{},
+	}
+	ctx := context.Background()
+	if err := ls.Initialized(ctx, &InitializedParams{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.validators) != 3 {
+		t.Fatalf("indexed %d files, want 3", len(ls.validators))
+	}
+
+	symbols := func(query string) []string {
+		result, err := ls.Symbols(ctx, &WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, symbol := range result {
+			name := symbol.Name
+			if symbol.ContainerName != "" {
+				name = symbol.ContainerName + "." + name
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	if got := strings.Join(symbols(""), " "); got != "Box answer double Box.get helper" {
+		t.Errorf("got symbols %s", got)
+	}
+	if got := strings.Join(symbols("dbl"), " "); got != "double" {
+		t.Errorf("got symbols %s for dbl", got)
+	}
+
+	// main sees the buffer of lib while it is open
+	diagnosed := func(u DocumentURI, message string) bool {
+		for _, d := range client.diagnostics[u] {
+			if strings.Contains(d.Message, message) {
+				return true
+			}
+		}
+		return false
+	}
+	err := ls.DidOpen(ctx, &DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI:        lib,
+		LanguageID: "goose",
+		Text:       "fn double(x) -> x * 2\n",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diagnosed(main, "not exported") {
+		t.Errorf("got %v for main with lib open, want double not exported", client.diagnostics[main])
+	}
+	if err := ls.DidClose(ctx, &DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: lib}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.diagnostics[main]) != 0 {
+		t.Errorf("got %v for main with lib closed", client.diagnostics[main])
+	}
+
+	// deleting and creating lib on disk
+	if err := os.Remove(lib.Filename()); err != nil {
+		t.Fatal(err)
+	}
+	err = ls.DidChangeWatchedFiles(ctx, &DidChangeWatchedFilesParams{Changes: []*FileEvent{{Type: FileChangeTypeDeleted, URI: lib}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ls.validators[lib]; ok {
+		t.Errorf("lib is still indexed after it was deleted")
+	}
+	if !diagnosed(main, "not found") {
+		t.Errorf("got %v for main with lib deleted, want lib not found", client.diagnostics[main])
+	}
+	if err := os.WriteFile(lib.Filename(), []byte(files["lib.goose"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.DidCreateFiles(ctx, &CreateFilesParams{Files: []FileCreate{{URI: string(lib)}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ls.validators[lib]; !ok || len(client.diagnostics[main]) != 0 {
+		t.Errorf("got %v for main with lib created again", client.diagnostics[main])
+	}
+}
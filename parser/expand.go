@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+)
+
+// maxExpansionDepth limits how deeply macro invocations may expand into other
+// invocations, so that recursive macros fail instead of hanging.
+const maxExpansionDepth = 64
+
+// ProcMacroFunc runs the procedural macro def for an invocation and returns the
+// source code of the expression the invocation expands to. args holds the
+// tokens of each argument, or all tokens of the invocation if def has a single
+// parameter.
+type ProcMacroFunc func(def *ast.ProcMacroStmt, args [][]*ast.MacroToken) (string, error)
+
+type expander struct {
+	fset   *token.FileSet
+	macros map[string]ast.Stmt // *ast.MacroStmt or *ast.ProcMacroStmt
+	run    ProcMacroFunc
+	errors scanner.ErrorList
+}
+
+// ExpandMacros expands the macro invocations in module, setting their
+// Expansion fields. Macros are visible throughout the module they are declared
+// in. The arguments of an invocation of a declarative macro are substituted
+// into the tokens of the macro's body, which are then parsed again; tokens from
+// the body are placed in an expansion file in fset, so that they are reported
+// at the invocation. Procedural macros are run by run, and the source they
+// return is parsed in the same way; if run is nil, invoking one is an error.
+//
+// Invocations that have already been expanded are left alone, so expanding a
+// module twice is harmless. Errors are returned via a scanner.ErrorList which
+// is sorted by source position.
+func ExpandMacros(fset *token.FileSet, module *ast.Module, run ProcMacroFunc) error {
+	e := &expander{fset: fset, macros: make(map[string]ast.Stmt), run: run}
+
+	var macros []*ast.MacroStmt
+	var procMacros []*ast.ProcMacroStmt
+	ast.Walk(module, func(node any) {
+		var name *ast.Ident
+		switch stmt := node.(type) {
+		case *ast.MacroStmt:
+			macros = append(macros, stmt)
+			name = stmt.Name
+		case *ast.ProcMacroStmt:
+			procMacros = append(procMacros, stmt)
+			name = stmt.Name
+		default:
+			return
+		}
+		if _, exists := e.macros[name.Name]; exists {
+			e.error(name.Pos(), fmt.Sprintf("macro %s! redeclared", name.Name))
+			return
+		}
+		e.macros[name.Name] = node.(ast.Stmt)
+	})
+
+	// procedural macros may use other macros, which must be expanded before
+	// they run
+	for _, stmt := range procMacros {
+		for _, call := range e.calls(stmt, macros) {
+			e.expand(call, 0)
+		}
+	}
+	for _, call := range e.calls(module, macros) {
+		e.expand(call, 0)
+	}
+
+	e.errors.Sort()
+	return e.errors.Err()
+}
+
+func (e *expander) error(pos token.Pos, msg string) {
+	e.errors.Add(e.fset.Position(pos), msg)
+}
+
+// calls returns the unexpanded invocations in node, except for those in the
+// bodies of declarative macros, whose parameters have not been substituted
+// yet.
+func (e *expander) calls(node any, macros []*ast.MacroStmt) []*ast.MacroCallExpr {
+	var calls []*ast.MacroCallExpr
+	ast.Walk(node, func(node any) {
+		call, ok := node.(*ast.MacroCallExpr)
+		if !ok || call.Expansion != nil {
+			return
+		}
+		for _, stmt := range macros {
+			if stmt.Pos() <= call.Pos() && call.Pos() < stmt.End() {
+				return
+			}
+		}
+		calls = append(calls, call)
+	})
+	return calls
+}
+
+func (e *expander) expand(call *ast.MacroCallExpr, depth int) {
+	name := call.Name.Name
+	def, ok := e.macros[name]
+	if !ok {
+		e.error(call.Pos(), fmt.Sprintf("undefined macro %s!", name))
+		return
+	}
+	if depth >= maxExpansionDepth {
+		e.error(call.Pos(), fmt.Sprintf("macro %s! expands too deeply", name))
+		return
+	}
+
+	var p *Parser
+	switch def := def.(type) {
+	case *ast.MacroStmt:
+		p = e.substitute(call, def)
+	case *ast.ProcMacroStmt:
+		p = e.runProcMacro(call, def)
+	}
+	if p == nil {
+		return
+	}
+
+	x := p.ParseExpr()
+	p.expect(token.EOF)
+	for _, err := range p.errors {
+		e.errors.Add(err.Pos, fmt.Sprintf("in expansion of %s!: %s", name, err.Msg))
+	}
+	if len(p.errors) > 0 {
+		return
+	}
+
+	call.Expansion = x
+	for _, nested := range e.calls(x, nil) {
+		e.expand(nested, depth+1)
+	}
+}
+
+// args splits the tokens of an invocation into its arguments, reporting an
+// error if they do not match params.
+func (e *expander) args(call *ast.MacroCallExpr, params *ast.MacroParamList) ([][]*ast.MacroToken, bool) {
+	name := call.Name.Name
+	args := splitArgs(call.Tokens)
+	if len(args) != len(params.List) {
+		noun := "arguments"
+		if len(params.List) == 1 {
+			noun = "argument"
+		}
+		e.error(call.Pos(), fmt.Sprintf("macro %s! takes %d %s, got %d", name, len(params.List), noun, len(args)))
+		return nil, false
+	}
+	for i, param := range params.List {
+		if len(args[i]) == 0 {
+			e.error(call.Pos(), fmt.Sprintf("missing argument %s to macro %s!", param.Name, name))
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// substitute returns a parser for the expansion of an invocation of a
+// declarative macro, or nil if it cannot be expanded.
+func (e *expander) substitute(call *ast.MacroCallExpr, def *ast.MacroStmt) *Parser {
+	args, ok := e.args(call, def.Params)
+	if !ok || len(def.Tokens) == 0 {
+		// the body failed to parse if it has no tokens
+		return nil
+	}
+
+	params := make(map[string][]*ast.MacroToken, len(args))
+	for i, param := range def.Params.List {
+		params[param.Name] = args[i]
+	}
+
+	// body tokens keep their spacing in the expansion file, so that nested
+	// invocations are still recognized
+	first, last := def.Tokens[0], def.Tokens[len(def.Tokens)-1]
+	size := int(last.Pos-first.Pos) + len(last.String())
+	file := e.fset.AddExpansion("macro:"+call.Name.Name+"!", call.Pos(), size)
+
+	var tokens []*ast.MacroToken
+	for _, t := range def.Tokens {
+		if arg, ok := params[t.Lit]; ok && t.Tok == token.Ident {
+			tokens = append(tokens, arg...)
+			continue
+		}
+		tokens = append(tokens, &ast.MacroToken{Pos: file.Pos(int(t.Pos - first.Pos)), Tok: t.Tok, Lit: t.Lit})
+	}
+
+	p := &Parser{}
+	p.initTokens(e.fset, file, tokens)
+	return p
+}
+
+// runProcMacro runs a procedural macro for an invocation and returns a parser
+// for the source it returned, or nil if it failed.
+func (e *expander) runProcMacro(call *ast.MacroCallExpr, def *ast.ProcMacroStmt) *Parser {
+	name := call.Name.Name
+	if e.run == nil {
+		e.error(call.Pos(), fmt.Sprintf("procedural macro %s! cannot be expanded here", name))
+		return nil
+	}
+
+	args := [][]*ast.MacroToken{call.Tokens}
+	if len(def.Params.List) != 1 {
+		var ok bool
+		if args, ok = e.args(call, def.Params); !ok {
+			return nil
+		}
+	}
+
+	src, err := e.run(def, args)
+	if err != nil {
+		e.error(call.Pos(), fmt.Sprintf("in expansion of %s!: %s", name, err))
+		return nil
+	}
+
+	file := e.fset.AddExpansion("macro:"+name+"!", call.Pos(), len(src))
+	p := &Parser{}
+	p.init(e.fset, file, []byte(src), nil)
+	return p
+}
+
+// splitArgs splits the tokens of an invocation at the commas that are not
+// inside brackets.
+func splitArgs(tokens []*ast.MacroToken) [][]*ast.MacroToken {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var args [][]*ast.MacroToken
+	var arg []*ast.MacroToken
+	depth := 0
+	for _, t := range tokens {
+		switch t.Tok {
+		case token.LParen, token.LBracket, token.LBrace, token.HashLBracket, token.StringInterpExprStart:
+			depth++
+		case token.RParen, token.RBracket, token.RBrace, token.StringInterpExprEnd:
+			depth--
+		case token.Comma:
+			if depth == 0 {
+				args = append(args, arg)
+				arg = nil
+				continue
+			}
+		}
+		arg = append(arg, t)
+	}
+	return append(args, arg)
+}
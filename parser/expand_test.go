@@ -0,0 +1,228 @@
+package parser_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+)
+
+func TestExpandMacros(t *testing.T) {
+	t.Parallel()
+
+	src := `macro mul!(x, y) -> x * y
+macro pair!(x) -> [x, x]
+macro square!(x) -> mul!(x, x)
+macro pi!() -> 3.14
+
+mul!(2 - 3, 5 + 4)
+pair!("a${b}c")
+square!(n)
+pi!()
+fn(a) -> mul!(a, pair!(1))
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.ExpandMacros(fset, module, nil); err != nil {
+		t.Fatal(err)
+	}
+	// expanding again changes nothing
+	if err := parser.ExpandMacros(fset, module, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"mul!(2 - 3, 5 + 4) => ((2 - (3 * 5)) + 4)",
+		`pair!("a${b}c") => ["a${b}c", "a${b}c"]`,
+		"square!(n) => mul!(n , n)",
+		"mul!(n , n) => (n * n)",
+		"pi!() => 3.14",
+		"mul!(a, pair!(1)) => (a * pair!(1))",
+		"pair!(1) => [1, 1]",
+	}
+	var got []string
+	ast.Walk(module, func(node any) {
+		if call, ok := node.(*ast.MacroCallExpr); ok && call.Expansion != nil {
+			var before, after ast.NodePrinter
+			before.Print(call)
+			after.Print(call.Expansion)
+			got = append(got, before.String()+" => "+after.String())
+		}
+	})
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got expansions:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// tokens from the body are reported at the invocation, arguments where
+	// they were written
+	var call *ast.MacroCallExpr
+	ast.Walk(module, func(node any) {
+		if c, ok := node.(*ast.MacroCallExpr); ok && call == nil && c.Expansion != nil {
+			call = c
+		}
+	})
+	x := call.Expansion.(*ast.BinaryExpr)
+	inner := x.X.(*ast.BinaryExpr).Y.(*ast.BinaryExpr)
+	if pos := fset.Position(inner.OpPos); pos.Line != 6 || pos.Column != 1 {
+		t.Errorf("body operator at %s, want the invocation at 6:1", pos)
+	}
+	if fset.Site(inner.OpPos) != call.Pos() {
+		t.Errorf("site of body operator is not the invocation")
+	}
+	if pos := fset.Position(x.Y.Pos()); pos.Line != 6 || pos.Column != 17 {
+		t.Errorf("argument at %s, want 6:17", pos)
+	}
+}
+
+func TestMacroErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		src  string
+		want string
+	}{
+		{"foo!(1)\n", "test:1:1: undefined macro foo!"},
+		{"macro foo!(x) -> x\nfoo!(1, 2)\n", "test:2:1: macro foo! takes 1 argument, got 2"},
+		{"macro foo!(x, y) -> x\nfoo!(1,)\n", "test:2:1: missing argument y to macro foo!"},
+		{"macro foo!(x) -> x\nmacro foo!(y) -> y\n", "test:2:7: macro foo! redeclared"},
+		{"macro foo!(x) -> x\nfoo!(1 2)\n", "test:2:8: in expansion of foo!: expected '<EOF>', found 2"},
+		{"macro foo!(x) -> (x)\nfoo!(1 +)\n", "test:2:1: in expansion of foo!: expected operand, found ')'"},
+		{"macro foo!(x) -> foo!(x)\nfoo!(1)\n", "test:2:1: macro foo! expands too deeply"},
+	} {
+		fset := token.NewFileSet()
+		module, err := parser.ParseFile(fset, "test", test.src, nil)
+		if err != nil {
+			t.Errorf("%q: %s", test.src, err)
+			continue
+		}
+		err = parser.ExpandMacros(fset, module, nil)
+		list, ok := err.(scanner.ErrorList)
+		if !ok || len(list) == 0 {
+			t.Errorf("%q: expected an error", test.src)
+			continue
+		}
+		found := false
+		for _, e := range list {
+			found = found || e.Error() == test.want
+		}
+		if !found {
+			t.Errorf("%q: got errors %s, want %s", test.src, list, test.want)
+		}
+	}
+}
+
+func TestExpandProcMacros(t *testing.T) {
+	t.Parallel()
+
+	src := `procmacro rev!(tokens)
+  return tokens
+end
+procmacro add!(x, y) end
+
+rev!(a b, c)
+add!(1, 2 + 3)
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a fake runner: rev! joins its tokens in reverse, add! adds its arguments
+	var runs []string
+	run := func(def *ast.ProcMacroStmt, args [][]*ast.MacroToken) (string, error) {
+		var parts []string
+		for _, arg := range args {
+			parts = append(parts, ast.TokensString(arg))
+		}
+		runs = append(runs, def.Name.Name+"!"+strings.Join(parts, "|"))
+		if def.Name.Name == "rev" {
+			var lits []string
+			for i := len(args[0]) - 1; i >= 0; i-- {
+				if args[0][i].Tok == token.Ident {
+					lits = append(lits, args[0][i].Lit)
+				}
+			}
+			return "[" + strings.Join(lits, ", ") + "]", nil
+		}
+		return "(" + strings.Join(parts, ") + (") + ")", nil
+	}
+	if err := parser.ExpandMacros(fset, module, run); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "rev!a b, c\nadd!1|2 + 3"; strings.Join(runs, "\n") != want {
+		t.Errorf("got runs:\n%s\nwant:\n%s", strings.Join(runs, "\n"), want)
+	}
+
+	want := []string{
+		"[c, b, a]",
+		"(1 + (2 + 3))",
+	}
+	var got []string
+	ast.Walk(module, func(node any) {
+		if call, ok := node.(*ast.MacroCallExpr); ok && call.Expansion != nil {
+			var p ast.NodePrinter
+			p.Print(call.Expansion)
+			got = append(got, p.String())
+			if pos := fset.Position(call.Expansion.Pos()); pos != fset.Position(call.Pos()) {
+				t.Errorf("expansion at %s, want the invocation at %s", pos, fset.Position(call.Pos()))
+			}
+		}
+	})
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got expansions:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestProcMacroErrors(t *testing.T) {
+	t.Parallel()
+
+	run := func(def *ast.ProcMacroStmt, args [][]*ast.MacroToken) (string, error) {
+		switch def.Name.Name {
+		case "fail":
+			return "", errors.New("failed")
+		case "bad":
+			return "1 +", nil
+		}
+		return "1", nil
+	}
+	for _, test := range []struct {
+		src  string
+		run  parser.ProcMacroFunc
+		want string
+	}{
+		{"procmacro foo!(x) end\nfoo!(1)\n", nil, "test:2:1: procedural macro foo! cannot be expanded here"},
+		{"procmacro fail!(x) end\nfail!(1)\n", run, "test:2:1: in expansion of fail!: failed"},
+		{"procmacro bad!(x) end\nbad!(1)\n", run, "test:2:1: in expansion of bad!: expected operand, found '<EOF>'"},
+		{"procmacro foo!(x, y) end\nfoo!(1)\n", run, "test:2:1: macro foo! takes 2 arguments, got 1"},
+		{"macro foo!(x) -> x\nprocmacro foo!(x) end\n", run, "test:2:11: macro foo! redeclared"},
+	} {
+		fset := token.NewFileSet()
+		module, err := parser.ParseFile(fset, "test", test.src, nil)
+		if err != nil {
+			t.Errorf("%q: %s", test.src, err)
+			continue
+		}
+		err = parser.ExpandMacros(fset, module, test.run)
+		list, ok := err.(scanner.ErrorList)
+		if !ok || len(list) == 0 {
+			t.Errorf("%q: expected an error", test.src)
+			continue
+		}
+		found := false
+		for _, e := range list {
+			found = found || e.Error() == test.want
+		}
+		if !found {
+			t.Errorf("%q: got errors %s, want %s", test.src, list, test.want)
+		}
+	}
+}
@@ -7,7 +7,7 @@ func (p *Parser) error(pos token.Pos, msg string) {
 		defer un(trace(p, "error: "+msg))
 	}
 
-	epos := p.file.Position(pos)
+	epos := p.fset.Position(pos)
 
 	// If AllErrors is not set, discard errors reported on the same line
 	// as the last recorded error and stop parsing if there are more than
@@ -18,7 +18,14 @@ func (p *Parser) parseConstStmt() *ast.ConstStmt {
 		decl = token.Let
 	}
 
-	lhs := p.parseIdent()
+	var lhs *ast.Ident
+	var pattern ast.PatternExpr
+	if p.isBindingPattern() {
+		pattern = p.parseBindingPattern()
+	} else {
+		lhs = p.parseIdent()
+	}
+	typ := p.parseTypeAnnotation()
 	var rhs ast.Expr
 	tokPos := p.pos
 	if p.tok != token.Assign {
@@ -32,6 +39,8 @@ func (p *Parser) parseConstStmt() *ast.ConstStmt {
 	return &ast.ConstStmt{
 		ConstPos: pos,
 		Ident:    lhs,
+		Pattern:  pattern,
+		Type:     typ,
 		TokPos:   tokPos,
 		Value:    rhs,
 	}
@@ -50,7 +59,14 @@ func (p *Parser) parseLetStmt() *ast.LetStmt {
 		decl = token.Let
 	}
 
-	lhs := p.parseIdent()
+	var lhs *ast.Ident
+	var pattern ast.PatternExpr
+	if p.isBindingPattern() {
+		pattern = p.parseBindingPattern()
+	} else {
+		lhs = p.parseIdent()
+	}
+	typ := p.parseTypeAnnotation()
 
 	var tokPos token.Pos
 	var rhs ast.Expr
@@ -58,12 +74,17 @@ func (p *Parser) parseLetStmt() *ast.LetStmt {
 	if p.tok == token.Assign {
 		tokPos = p.expect(token.Assign)
 		rhs = p.ParseExpr()
+	} else if pattern != nil {
+		p.error(p.pos, "destructuring declaration must be followed by an assignment")
+		rhs = &ast.BadExpr{From: p.pos, To: p.pos}
 	}
 
 	return &ast.LetStmt{
-		LetPos: pos,
-		Ident:  lhs,
-		TokPos: tokPos,
-		Value:  rhs,
+		LetPos:  pos,
+		Ident:   lhs,
+		Pattern: pattern,
+		Type:    typ,
+		TokPos:  tokPos,
+		Value:   rhs,
 	}
 }
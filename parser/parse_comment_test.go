@@ -0,0 +1,101 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+func TestDocComments(t *testing.T) {
+	t.Parallel()
+
+	src := `// not a doc comment
+fn plain() -> 1
+
+/// Doubles x.
+/// @param x the number
+export fn double(x) -> x * 2
+
+/// A point.
+struct Point(x, y)
+
+/// Adds points.
+operator Point +(a, b) -> Point(a.x + b.x, a.y + b.y)
+
+/// The answer.
+const answer = 42
+
+/// A counter.
+let count = 0 /// trailing, documents nothing
+
+let undocumented = 1
+
+/// separated by a blank line
+
+let alsoUndocumented = 2
+
+/// Reads a file.
+native fn readFile(path)
+
+fn outer()
+  /// An inner function.
+  fn inner() -> 1
+end
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"plain":            "",
+		"double":           "Doubles x.",
+		"Point":            "A point.",
+		"+":                "Adds points.",
+		"answer":           "The answer.",
+		"count":            "A counter.",
+		"undocumented":     "",
+		"alsoUndocumented": "",
+		"readFile":         "Reads a file.",
+		"outer":            "",
+		"inner":            "An inner function.",
+	}
+	got := map[string]string{}
+	ast.Walk(module, func(node any) {
+		n, ok := node.(ast.Node)
+		if !ok {
+			return
+		}
+		var name string
+		switch n := n.(type) {
+		case *ast.FuncExpr:
+			name = n.Name.Name
+		case *ast.StructStmt:
+			name = n.Name.Name
+		case *ast.OperatorStmt:
+			name = n.Tok.String()
+		case *ast.ConstStmt:
+			name = n.Ident.Name
+		case *ast.LetStmt:
+			name = n.Ident.Name
+		case *ast.NativeFunc:
+			name = n.Name.Name
+		default:
+			return
+		}
+		if doc := ast.ParseDoc(ast.DocOf(n)); doc != nil {
+			got[name] = doc.Text
+		}
+	})
+	for name, doc := range want {
+		if got[name] != doc {
+			t.Errorf("%s: got doc %q, want %q", name, got[name], doc)
+		}
+	}
+
+	if len(module.Comments) != 10 {
+		t.Errorf("got %d comment groups, want 10", len(module.Comments))
+	}
+}
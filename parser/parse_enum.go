@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+)
+
+func (p *Parser) parseEnumStmt() *ast.EnumStmt {
+	if p.trace {
+		defer un(trace(p, "EnumStmt"))
+	}
+
+	stmt := &ast.EnumStmt{}
+	stmt.Enum = p.expect(token.Enum)
+	stmt.Name = p.parseIdent()
+
+	for p.tok != token.End && p.tok != token.EOF {
+		variant := &ast.EnumVariant{Doc: p.leadComment}
+		variant.Name = p.parseIdent()
+		if p.tok == token.LParen {
+			variant.Fields = p.parseStructFields()
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+	}
+
+	stmt.BlockEnd = p.expect(token.End)
+
+	return stmt
+}
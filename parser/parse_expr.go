@@ -38,7 +38,7 @@ func (p *Parser) parseBinaryExpr(x ast.Expr, precedence int) ast.Expr {
 
 func (p *Parser) parseUnaryExpr() ast.Expr {
 	switch p.tok {
-	case token.Add, token.Sub, token.LogNot, token.Ellipsis, token.Await:
+	case token.Add, token.Sub, token.LogNot, token.BitNot, token.Ellipsis, token.Await:
 		pos := p.pos
 		op := p.tok
 		p.next()
@@ -85,6 +85,10 @@ func (p *Parser) parseOperand() (e ast.Expr) {
 
 	switch p.tok {
 	case token.Ident:
+		if p.isMacroCall() {
+			e = p.parseMacroCall()
+			break
+		}
 		e = p.parseIdent()
 	case token.Int, token.Float, token.Null, token.True, token.False:
 		e = &ast.Literal{Value: p.lit, ValuePos: p.pos, Kind: p.tok}
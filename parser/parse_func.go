@@ -24,16 +24,26 @@ func (p *Parser) parseFuncExpr(asyncPos token.Pos) *ast.FuncExpr {
 
 	if p.tok == token.Ident {
 		part := p.parseIdent()
+		var typeParams *ast.TypeParamList
+		if p.tok == token.Lt {
+			typeParams = p.parseTypeParams()
+		}
 		if p.tok == token.Period {
 			expr.Receiver = part
+			expr.ReceiverTypeParams = typeParams
 			p.next()
 			expr.Name = p.parseIdent()
+			if p.tok == token.Lt {
+				expr.TypeParams = p.parseTypeParams()
+			}
 		} else {
 			expr.Name = part
+			expr.TypeParams = typeParams
 		}
 	}
 
 	expr.Params = p.parseParameters()
+	expr.Result = p.parseTypeAnnotation()
 
 	if p.tok == token.Arrow {
 		expr.Arrow = p.pos
@@ -59,11 +69,19 @@ func (p *Parser) parseParameters() (params *ast.FuncParamList) {
 	var fields []*ast.FuncParam
 	for p.tok != token.RParen && p.tok != token.EOF {
 		f := &ast.FuncParam{}
-		if p.tok == token.Ellipsis {
+		if p.tok == token.Colon {
+			f.Colon = p.pos
+			p.next()
+		} else if p.tok == token.Ellipsis {
 			f.Ellipsis = p.pos
 			p.next()
 		}
-		f.Ident = p.parseIdent()
+		if p.isBindingPattern() && !f.Colon.IsValid() && !f.Ellipsis.IsValid() {
+			f.Pattern = p.parseBindingPattern()
+		} else {
+			f.Ident = p.parseIdent()
+		}
+		f.Type = p.parseTypeAnnotation()
 		if p.tok == token.Assign {
 			p.next()
 			f.Value = p.ParseExpr()
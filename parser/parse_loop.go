@@ -16,7 +16,13 @@ func (p *Parser) parseForStmt() *ast.ForStmt {
 		awaitPos = p.pos
 		p.next()
 	}
-	ident := p.parseIdent()
+	var ident *ast.Ident
+	var pattern ast.PatternExpr
+	if p.isBindingPattern() {
+		pattern = p.parseBindingPattern()
+	} else {
+		ident = p.parseIdent()
+	}
 	p.expect(token.In)
 	expr := p.ParseExpr()
 
@@ -30,6 +36,7 @@ func (p *Parser) parseForStmt() *ast.ForStmt {
 		For:      pos,
 		Await:    awaitPos,
 		Var:      ident,
+		Pattern:  pattern,
 		Iterable: expr,
 		Body:     body,
 		BlockEnd: p.pos,
@@ -79,9 +86,39 @@ func (p *Parser) parseBranchStmt(tok token.Token) *ast.BranchStmt {
 
 	pos := p.expect(tok)
 	var label *ast.Ident
-	if p.tok == token.Ident {
+	// the label must be on the same line, otherwise the identifier starts the
+	// next statement
+	if p.tok == token.Ident && p.fset.Position(p.pos).Line == p.fset.Position(pos).Line {
 		label = p.parseIdent()
 	}
 
 	return &ast.BranchStmt{TokPos: pos, Tok: tok, Label: label}
 }
+
+// isLabeledStmt reports whether the current token starts a labeled loop: an
+// identifier and a colon followed by a for or repeat statement.
+func (p *Parser) isLabeledStmt() bool {
+	if p.tok != token.Ident || p.nextTok != token.Colon {
+		return false
+	}
+	next := p.peek()
+	return next == token.For || next == token.Repeat
+}
+
+func (p *Parser) parseLabeledStmt() *ast.LabeledStmt {
+	if p.trace {
+		defer un(trace(p, "LabeledStmt"))
+	}
+
+	label := p.parseIdent()
+	colon := p.expect(token.Colon)
+
+	var stmt ast.Stmt
+	if p.tok == token.For {
+		stmt = p.parseForStmt()
+	} else {
+		stmt = p.parseRepeatStmt()
+	}
+
+	return &ast.LabeledStmt{Label: label, Colon: colon, Stmt: stmt}
+}
@@ -0,0 +1,60 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+func TestLabeledStmt(t *testing.T) {
+	t.Parallel()
+
+	src := `outer: repeat forever
+  inner: for x in xs
+    continue outer
+    break
+    foo()
+  end
+end
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outer, ok := module.Stmts[0].(*ast.LabeledStmt)
+	if !ok {
+		t.Fatalf("statement is %T, want *ast.LabeledStmt", module.Stmts[0])
+	}
+	if outer.Label.Name != "outer" {
+		t.Errorf("got label %s, want outer", outer.Label.Name)
+	}
+	loop, ok := outer.Stmt.(*ast.RepeatForeverStmt)
+	if !ok {
+		t.Fatalf("labeled statement is %T, want *ast.RepeatForeverStmt", outer.Stmt)
+	}
+	inner, ok := loop.Body[0].(*ast.LabeledStmt)
+	if !ok {
+		t.Fatalf("loop body starts with %T, want *ast.LabeledStmt", loop.Body[0])
+	}
+	body := inner.Stmt.(*ast.ForStmt).Body
+	if len(body) != 3 {
+		t.Fatalf("got %d statements in the inner loop, want 3", len(body))
+	}
+	if br := body[0].(*ast.BranchStmt); br.Tok != token.Continue || br.Label == nil || br.Label.Name != "outer" {
+		t.Errorf("got %s %v, want continue outer", br.Tok, br.Label)
+	}
+	// an identifier on the next line is not a label
+	if br := body[1].(*ast.BranchStmt); br.Label != nil {
+		t.Errorf("break took label %s from the next line", br.Label.Name)
+	}
+	if _, ok := body[2].(*ast.ExprStmt); !ok {
+		t.Errorf("last statement is %T, want *ast.ExprStmt", body[2])
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "test", "outer: if x end\n", nil); err == nil {
+		t.Error("expected an error for a label on a non-loop statement")
+	}
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+)
+
+func (p *Parser) parseMacroStmt() *ast.MacroStmt {
+	if p.trace {
+		defer un(trace(p, "MacroStmt"))
+	}
+
+	stmt := &ast.MacroStmt{}
+	// macro and procmacro are not keywords, so that std:macro can still be
+	// imported by name
+	stmt.Macro = p.expectMsg(token.Ident, "macro")
+	stmt.Name = p.parseIdent()
+	stmt.Bang = p.expect(token.LogNot)
+	stmt.Params = p.parseMacroParams()
+
+	stmt.Arrow = p.expect(token.Arrow)
+	p.record = &stmt.Tokens
+	stmt.Body = p.ParseExpr()
+	p.record = nil
+
+	return stmt
+}
+
+func (p *Parser) parseProcMacroStmt() *ast.ProcMacroStmt {
+	if p.trace {
+		defer un(trace(p, "ProcMacroStmt"))
+	}
+
+	stmt := &ast.ProcMacroStmt{}
+	stmt.ProcMacro = p.expectMsg(token.Ident, "procmacro")
+	stmt.Name = p.parseIdent()
+	stmt.Bang = p.expect(token.LogNot)
+	stmt.Params = p.parseMacroParams()
+
+	for p.tok != token.EOF && p.tok != token.End {
+		stmt.Body = append(stmt.Body, p.parseStmt())
+	}
+	stmt.BlockEnd = p.expect(token.End)
+
+	return stmt
+}
+
+func (p *Parser) parseMacroParams() *ast.MacroParamList {
+	params := &ast.MacroParamList{Opening: p.expect(token.LParen)}
+	for p.tok != token.RParen && p.tok != token.EOF {
+		params.List = append(params.List, p.parseIdent())
+		if p.tok != token.Comma {
+			break
+		}
+		p.next()
+	}
+	params.Closing = p.expect(token.RParen)
+	return params
+}
+
+// isMacroStmt reports whether the current token starts a macro statement.
+func (p *Parser) isMacroStmt() bool {
+	return p.tok == token.Ident && p.lit == "macro" && p.nextTok == token.Ident && p.peek() == token.LogNot
+}
+
+// isProcMacroStmt reports whether the current token starts a procedural macro
+// statement.
+func (p *Parser) isProcMacroStmt() bool {
+	return p.tok == token.Ident && p.lit == "procmacro" && p.nextTok == token.Ident && p.peek() == token.LogNot
+}
+
+// isMacroCall reports whether the current token starts a macro invocation: an
+// identifier directly followed by a bang and an opening parenthesis.
+func (p *Parser) isMacroCall() bool {
+	return p.tok == token.Ident && p.nextTok == token.LogNot && p.nextPos == p.pos+token.Pos(len(p.lit)) && p.peek() == token.LParen
+}
+
+func (p *Parser) parseMacroCall() *ast.MacroCallExpr {
+	if p.trace {
+		defer un(trace(p, "MacroCall"))
+	}
+
+	call := &ast.MacroCallExpr{Name: p.parseIdent()}
+	call.Bang = p.expect(token.LogNot)
+	call.Opening = p.expect(token.LParen)
+
+	// the arguments are kept as tokens until the macro is expanded, so only
+	// brackets have to balance
+	depth := 0
+loop:
+	for {
+		switch p.tok {
+		case token.LParen, token.LBracket, token.LBrace, token.HashLBracket:
+			depth++
+		case token.RParen, token.RBracket, token.RBrace:
+			if depth == 0 {
+				if p.tok == token.RParen {
+					break loop
+				}
+				p.errorExpected(p.pos, "')'")
+			} else {
+				depth--
+			}
+		case token.EOF:
+			break loop
+		}
+		call.Tokens = append(call.Tokens, &ast.MacroToken{Pos: p.pos, Tok: p.tok, Lit: p.lit})
+		p.next()
+	}
+	call.Closing = p.expect(token.RParen)
+
+	return call
+}
+
+// Initialize the parser to parse the expansion of a macro from tokens.
+func (p *Parser) initTokens(fset *token.FileSet, file *token.File, tokens []*ast.MacroToken) {
+	p.fset = fset
+	p.file = file
+	p.expanding = true
+	p.tokens = tokens
+
+	p.next()
+	p.next()
+}
+
+// Return the next token of a macro expansion.
+func (p *Parser) scanToken() (token.Pos, token.Token, string) {
+	if len(p.tokens) == 0 {
+		return token.Pos(p.file.Base() + p.file.Size()), token.EOF, ""
+	}
+	t := p.tokens[0]
+	p.tokens = p.tokens[1:]
+	return t.Pos, t.Tok, t.Lit
+}
@@ -63,11 +63,61 @@ func (p *Parser) parsePatternExpr() ast.PatternExpr {
 		bind := p.expect(token.MatchBind)
 		ident := p.parseIdent()
 		return &ast.PatternBinding{Bind: bind, Ident: ident}
+	case token.Ident:
+		if !p.isMacroCall() {
+			return p.parsePatternName()
+		}
+		fallthrough
 	default:
 		return &ast.PatternNormal{X: p.parseBinaryExpr(nil, token.Arrow.Precedence()+1)}
 	}
 }
 
+// parsePatternName parses a pattern starting with a name. A call whose
+// arguments bind names or match variants, like Shape.Circle($r), is a variant
+// pattern; anything else, including Shape.Circle(1), is an expression compared
+// with ==.
+func (p *Parser) parsePatternName() ast.PatternExpr {
+	var x ast.Expr = p.parseIdent()
+	for p.tok == token.Period {
+		x = p.parseSelectorExpr(x)
+	}
+
+	if p.tok == token.LParen {
+		lparen := p.expect(token.LParen)
+		var args []ast.PatternExpr
+		variant := false
+		for p.tok != token.RParen && p.tok != token.EOF {
+			var arg ast.PatternExpr
+			if p.tok == token.MatchBind || p.tok == token.Ident && !p.isMacroCall() {
+				arg = p.parsePatternExpr()
+			} else {
+				arg = &ast.PatternNormal{X: p.ParseExpr()}
+			}
+			if _, ok := arg.(*ast.PatternNormal); !ok {
+				variant = true
+			}
+			args = append(args, arg)
+			if p.tok != token.RParen {
+				p.expect(token.Comma)
+			}
+		}
+		rparen := p.expect(token.RParen)
+
+		if variant {
+			return &ast.PatternVariant{Variant: x, LParen: lparen, Args: args, RParen: rparen}
+		}
+
+		exprs := make([]ast.Expr, len(args))
+		for i, arg := range args {
+			exprs[i] = arg.(*ast.PatternNormal).X
+		}
+		x = &ast.CallExpr{Func: x, LParen: lparen, Args: exprs, RParen: rparen}
+	}
+
+	return &ast.PatternNormal{X: p.parseBinaryExpr(p.parsePrimaryExpr(x), token.Arrow.Precedence()+1)}
+}
+
 func (p *Parser) parsePatternTuple() *ast.PatternTuple {
 	defer un(trace(p, "PatternTuple"))
 
@@ -117,9 +167,20 @@ func (p *Parser) parsePatternCompositeField() (s *ast.PatternCompositeField) {
 		defer un(trace(p, "CompositeField"))
 	}
 
-	var key ast.PatternExpr
-	var value ast.PatternExpr
+	key := p.parsePatternKey()
+	colon := p.expect(token.Colon)
+	value := p.parsePatternExpr()
+
+	return &ast.PatternCompositeField{
+		Key:   key,
+		Colon: colon,
+		Value: value,
+	}
+}
 
+// parsePatternKey parses the key of a composite pattern field: an identifier,
+// a string or number literal, or a computed key in brackets.
+func (p *Parser) parsePatternKey() (key ast.PatternExpr) {
 	if p.tok == token.LBracket {
 		p.next()
 		key = p.parsePatternExpr()
@@ -132,12 +193,67 @@ func (p *Parser) parsePatternCompositeField() (s *ast.PatternCompositeField) {
 	} else {
 		key = &ast.PatternNormal{X: p.parseIdent()}
 	}
+	return
+}
 
-	p.expect(token.Colon)
-	value = p.parsePatternExpr()
+// isBindingPattern reports whether the current token starts a destructuring
+// pattern rather than a plain name.
+func (p *Parser) isBindingPattern() bool {
+	return p.tok == token.LBracket || p.tok == token.LBrace
+}
 
-	return &ast.PatternCompositeField{
-		Key:   key,
-		Value: value,
+// parseBindingPattern parses the target of a destructuring declaration. It
+// uses the match pattern nodes, but bare identifiers bind names instead of
+// comparing values, array patterns may end with a rest element, and a
+// composite field without a value binds the key's name.
+func (p *Parser) parseBindingPattern() ast.PatternExpr {
+	if p.trace {
+		defer un(trace(p, "BindingPattern"))
+	}
+
+	switch p.tok {
+	case token.LBracket:
+		lbracket := p.expect(token.LBracket)
+		var list []ast.PatternExpr
+		for p.tok != token.RBracket && p.tok != token.EOF {
+			if p.tok == token.Ellipsis {
+				ellipsis := p.expect(token.Ellipsis)
+				list = append(list, &ast.PatternRest{Ellipsis: ellipsis, Ident: p.parseIdent()})
+				if p.tok != token.RBracket {
+					p.error(ellipsis, "rest element must be last")
+				}
+			} else {
+				list = append(list, p.parseBindingPattern())
+			}
+			if p.tok != token.RBracket {
+				p.expect(token.Comma)
+			}
+		}
+		rbracket := p.expect(token.RBracket)
+		return &ast.PatternTuple{Opening: lbracket, List: list, Closing: rbracket}
+	case token.LBrace:
+		lbrace := p.expect(token.LBrace)
+		var fields []*ast.PatternCompositeField
+		for p.tok != token.RBrace && p.tok != token.EOF {
+			field := &ast.PatternCompositeField{}
+			if p.tok == token.Ident && p.nextTok != token.Colon {
+				// { x } binds x to the field x
+				ident := p.parseIdent()
+				field.Key = &ast.PatternNormal{X: ident}
+				field.Value = &ast.PatternBinding{Ident: ident}
+			} else {
+				field.Key = p.parsePatternKey()
+				field.Colon = p.expect(token.Colon)
+				field.Value = p.parseBindingPattern()
+			}
+			fields = append(fields, field)
+			if p.tok != token.RBrace {
+				p.expect(token.Comma)
+			}
+		}
+		rbrace := p.expect(token.RBrace)
+		return &ast.PatternComposite{Opening: lbrace, Fields: fields, Closing: rbrace}
+	default:
+		return &ast.PatternBinding{Ident: p.parseIdent()}
 	}
 }
@@ -0,0 +1,100 @@
+package parser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+func TestBindingPatterns(t *testing.T) {
+	t.Parallel()
+
+	src := `let [a, [b, _], ...rest] = xs
+const { x, y: { z }, "w": w } = p
+for [k, v] in entries
+end
+fn f([first], { scale }, c) end
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(pattern ast.PatternExpr) (s []string) {
+		for _, ident := range ast.Bindings(pattern) {
+			s = append(s, ident.Name)
+		}
+		return
+	}
+	fn := module.Stmts[3].(*ast.ExprStmt).X.(*ast.FuncExpr)
+	tests := []struct {
+		pattern ast.PatternExpr
+		want    []string
+	}{
+		{module.Stmts[0].(*ast.LetStmt).Pattern, []string{"a", "b", "rest"}},
+		{module.Stmts[1].(*ast.ConstStmt).Pattern, []string{"x", "z", "w"}},
+		{module.Stmts[2].(*ast.ForStmt).Pattern, []string{"k", "v"}},
+		{fn.Params.List[0].Pattern, []string{"first"}},
+		{fn.Params.List[1].Pattern, []string{"scale"}},
+	}
+	for i, tt := range tests {
+		if got := names(tt.pattern); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("pattern %d binds %v, want %v", i, got, tt.want)
+		}
+	}
+	if fn.Params.List[2].Ident == nil || fn.Params.List[2].Pattern != nil {
+		t.Errorf("plain parameter parsed as a pattern")
+	}
+
+	for _, src := range []string{
+		"let [...rest, last] = xs\n",
+		"let [a, b]\n",
+		"let { x: } = p\n",
+	} {
+		if _, err := parser.ParseFile(token.NewFileSet(), "test", src, nil); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestVariantPatterns(t *testing.T) {
+	t.Parallel()
+
+	src := `match s
+  Shape.Circle($r) -> r
+  Shape.Rect(1, $h) -> h
+  Shape.Circle(1) -> 1
+  Shape.Empty -> 0
+  Opt.Some(Shape.Circle($r)) -> r
+end
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := module.Stmts[0].(*ast.ExprStmt).X.(*ast.MatchExpr)
+	want := []string{"*ast.PatternVariant", "*ast.PatternVariant", "*ast.PatternNormal", "*ast.PatternNormal", "*ast.PatternVariant"}
+	for i, clause := range match.Clauses {
+		pattern := clause.(*ast.MatchPattern).Pattern
+		if got := fmt.Sprintf("%T", pattern); got != want[i] {
+			t.Errorf("pattern %d is %s, want %s", i, got, want[i])
+		}
+	}
+
+	// a call without bindings is compared with ==
+	if _, ok := match.Clauses[2].(*ast.MatchPattern).Pattern.(*ast.PatternNormal).X.(*ast.CallExpr); !ok {
+		t.Errorf("pattern 2 is not a call")
+	}
+	nested := match.Clauses[4].(*ast.MatchPattern).Pattern.(*ast.PatternVariant)
+	if _, ok := nested.Args[0].(*ast.PatternVariant); !ok {
+		t.Errorf("nested pattern is %T, want *ast.PatternVariant", nested.Args[0])
+	}
+	if got := ast.Bindings(nested); len(got) != 1 || got[0].Name != "r" {
+		t.Errorf("nested pattern binds %v, want [r]", got)
+	}
+}
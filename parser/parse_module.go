@@ -41,13 +41,20 @@ func (p *Parser) parseExportStmt() ast.Stmt {
 		token.Func, token.Async, token.Memo,
 		token.Let, token.Const,
 		token.Struct, token.Generator,
-		token.Native:
+		token.Trait, token.Enum, token.Native:
 		return &ast.ExportDeclStmt{
 			Export: exportPos,
 			Stmt:   p.parseStmt(),
 		}
 	}
 
+	if p.isTypeStmt() {
+		return &ast.ExportDeclStmt{
+			Export: exportPos,
+			Stmt:   p.parseTypeStmt(),
+		}
+	}
+
 	p.errorExpected(p.pos, "module specifier, export list, or declaration")
 	return &ast.BadStmt{From: exportPos, To: p.pos}
 }
@@ -18,8 +18,10 @@ func (p *Parser) parseNativeStmt() ast.NativeStmt {
 
 		stmt.Ident = p.parseIdent()
 		return stmt
-	case token.Async:
-		async = p.expect(token.Async)
+	case token.Async, token.Operator:
+		if p.tok == token.Async {
+			async = p.expect(token.Async)
+		}
 		if p.tok == token.Operator {
 			stmt := &ast.NativeOperator{
 				Native:   native,
@@ -42,6 +44,7 @@ func (p *Parser) parseNativeStmt() ast.NativeStmt {
 			}
 
 			stmt.Params = p.parseParameters()
+			stmt.Result = p.parseTypeAnnotation()
 
 			return stmt
 		}
@@ -72,9 +75,13 @@ func (p *Parser) parseNativeStmt() ast.NativeStmt {
 			} else {
 				stmt.Name = part
 			}
+			if p.tok == token.Lt {
+				stmt.TypeParams = p.parseTypeParams()
+			}
 		}
 
 		stmt.Params = p.parseParameters()
+		stmt.Result = p.parseTypeAnnotation()
 
 		return stmt
 	case token.Struct:
@@ -87,8 +94,17 @@ func (p *Parser) parseNativeStmt() ast.NativeStmt {
 		stmt.Fields = p.parseStructFields()
 
 		return stmt
+	case token.Ident:
+		if p.lit == "type" {
+			return &ast.NativeType{
+				Native: native,
+				Type:   p.expect(token.Ident),
+				Name:   p.parseIdent(),
+			}
+		}
+		fallthrough
 	default:
-		p.errorExpected(native, "const/struct declaration or function/operator signature")
+		p.errorExpected(native, "const/struct/type declaration or function/operator signature")
 		return nil
 	}
 }
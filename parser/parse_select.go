@@ -75,6 +75,7 @@ func (p *Parser) parseIndexOrSlice(x ast.Expr) ast.Expr {
 		}
 	} else {
 		isSlicing = true
+		p.next()
 	}
 
 	if p.tok != token.RBracket {
@@ -13,6 +13,9 @@ func (p *Parser) parseStructStmt() *ast.StructStmt {
 	stmt := &ast.StructStmt{}
 	stmt.Struct = p.expect(token.Struct)
 	stmt.Name = p.parseIdent()
+	if p.tok == token.Lt {
+		stmt.TypeParams = p.parseTypeParams()
+	}
 	stmt.Fields = p.parseStructFields()
 
 	if p.tok == token.Init {
@@ -31,7 +34,7 @@ func (p *Parser) parseStructFields() (params *ast.StructFieldList) {
 	var fields []*ast.StructField
 	for p.tok != token.RParen && p.tok != token.EOF {
 		ident := p.parseIdent()
-		f := &ast.StructField{Ident: ident}
+		f := &ast.StructField{Ident: ident, Type: p.parseTypeAnnotation()}
 		if p.tok == token.Assign {
 			p.next()
 			f.Value = p.ParseExpr()
@@ -95,20 +98,14 @@ func (p *Parser) parseOperatorStmt() *ast.OperatorStmt {
 	}
 	stmt.Operator = p.expect(token.Operator)
 	stmt.Receiver = p.parseIdent()
-
-	if p.tok < token.OverloadAllowedStart || p.tok > token.OverloadAllowedEnd {
-		p.errorExpected(stmt.Pos(), "overloadable operator")
+	if p.tok == token.Lt {
+		stmt.ReceiverTypeParams = p.parseTypeParams()
 	}
 
-	stmt.TokPos = p.pos
-	stmt.Tok = p.tok
-	// do not advance if the token is a parenthesis because it is part of the
-	// parameters
-	if p.tok != token.LParen {
-		p.next()
-	}
+	stmt.TokPos, stmt.Tok = p.parseOverloadedOperator(stmt.Pos())
 
 	stmt.Params = p.parseParameters()
+	stmt.Result = p.parseTypeAnnotation()
 
 	if p.tok == token.Arrow {
 		stmt.Arrow = p.pos
@@ -124,3 +121,33 @@ func (p *Parser) parseOperatorStmt() *ast.OperatorStmt {
 
 	return stmt
 }
+
+// parseOverloadedOperator parses the operator of an operator declaration
+// starting at pos.
+func (p *Parser) parseOverloadedOperator(pos token.Pos) (tokPos token.Pos, tok token.Token) {
+	tokPos = p.pos
+	tok = p.tok
+	switch {
+	case p.tok == token.LBracket:
+		// index operators: [] and []=
+		p.next()
+		p.expect(token.RBracket)
+		tok = token.Index
+		if p.tok == token.Assign {
+			tok = token.IndexAssign
+			p.next()
+		}
+	case p.tok == token.Colon:
+		// slice operator
+		p.next()
+	case p.tok == token.LParen:
+		// do not advance because the parenthesis is part of the parameters
+		p.errorExpected(pos, "overloadable operator")
+	default:
+		if p.tok <= token.OverloadAllowedStart || p.tok >= token.OverloadAllowedEnd {
+			p.errorExpected(pos, "overloadable operator")
+		}
+		p.next()
+	}
+	return
+}
@@ -0,0 +1,166 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+func TestOperatorStmts(t *testing.T) {
+	t.Parallel()
+
+	src := `operator Point +(other) -> other
+operator Point <=>(other) -> 0
+operator Point [](i) -> i
+operator Point []=(i, value) end
+operator Point :(low, high) -> low
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []token.Token{token.Add, token.Spaceship, token.Index, token.IndexAssign, token.Colon}
+	if len(module.Stmts) != len(want) {
+		t.Fatalf("got %d statements, want %d", len(module.Stmts), len(want))
+	}
+	for i, stmt := range module.Stmts {
+		op, ok := stmt.(*ast.OperatorStmt)
+		if !ok {
+			t.Errorf("statement %d is %T, want *ast.OperatorStmt", i, stmt)
+			continue
+		}
+		if op.Tok != want[i] {
+			t.Errorf("statement %d overloads %s, want %s", i, op.Tok, want[i])
+		}
+	}
+
+	for _, src := range []string{
+		"operator Point &&(other) end\n",
+		"operator Point (other) end\n",
+		"operator Point [(i) end\n",
+	} {
+		if _, err := parser.ParseFile(token.NewFileSet(), "test", src, nil); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestSpaceshipPrecedence(t *testing.T) {
+	t.Parallel()
+
+	x, err := parser.ParseExpr("a + 1 <=> b * 2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p ast.NodePrinter
+	p.Print(x)
+	if want := "((a + 1) <=> (b * 2))"; p.String() != want {
+		t.Errorf("got %s, want %s", p.String(), want)
+	}
+}
+
+func TestTraitStmt(t *testing.T) {
+	t.Parallel()
+
+	src := `trait Shape
+  fn area(): float
+  fn name() -> "shape"
+  fn describe()
+    return this.name()
+  end
+  operator ==(other)
+  operator <=>(other) -> 0
+end
+impl Shape for Square
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(module.Stmts) != 2 {
+		t.Fatalf("got %d statements, want 2", len(module.Stmts))
+	}
+	trait, ok := module.Stmts[0].(*ast.TraitStmt)
+	if !ok {
+		t.Fatalf("statement 0 is %T, want *ast.TraitStmt", module.Stmts[0])
+	}
+
+	want := []string{"*ast.MethodSpec", "*ast.ExprStmt", "*ast.ExprStmt", "*ast.OperatorSpec", "*ast.OperatorStmt"}
+	if len(trait.Body) != len(want) {
+		t.Fatalf("got %d members, want %d", len(trait.Body), len(want))
+	}
+	for i, member := range trait.Body {
+		if got := fmt.Sprintf("%T", member); got != want[i] {
+			t.Errorf("member %d is %s, want %s", i, got, want[i])
+		}
+	}
+
+	impl, ok := module.Stmts[1].(*ast.ImplStmt)
+	if !ok {
+		t.Fatalf("statement 1 is %T, want *ast.ImplStmt", module.Stmts[1])
+	}
+	if impl.Trait.Name != "Shape" || impl.Struct.Name != "Square" {
+		t.Errorf("got impl %s for %s, want impl Shape for Square", impl.Trait.Name, impl.Struct.Name)
+	}
+
+	for _, src := range []string{
+		"trait Shape\n  let x = 1\nend\n",
+		"trait Shape\n  fn area()\n",
+		"impl Shape Square\n",
+	} {
+		if _, err := parser.ParseFile(token.NewFileSet(), "test", src, nil); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestEnumStmt(t *testing.T) {
+	t.Parallel()
+
+	src := `enum Shape
+  Circle(r: float)
+  Rect(w, h = 1)
+  Empty
+end
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enum, ok := module.Stmts[0].(*ast.EnumStmt)
+	if !ok {
+		t.Fatalf("statement 0 is %T, want *ast.EnumStmt", module.Stmts[0])
+	}
+
+	want := []struct {
+		name   string
+		fields int
+	}{{"Circle", 1}, {"Rect", 2}, {"Empty", -1}}
+	if len(enum.Variants) != len(want) {
+		t.Fatalf("got %d variants, want %d", len(enum.Variants), len(want))
+	}
+	for i, variant := range enum.Variants {
+		fields := -1
+		if variant.Fields != nil {
+			fields = len(variant.Fields.List)
+		}
+		if variant.Name.Name != want[i].name || fields != want[i].fields {
+			t.Errorf("variant %d is %s with %d fields, want %s with %d", i, variant.Name.Name, fields, want[i].name, want[i].fields)
+		}
+	}
+
+	for _, src := range []string{
+		"enum Shape\n  Circle(r)\n",
+		"enum Shape\n  1\nend\n",
+	} {
+		if _, err := parser.ParseFile(token.NewFileSet(), "test", src, nil); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
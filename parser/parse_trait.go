@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+)
+
+func (p *Parser) parseTraitStmt() *ast.TraitStmt {
+	if p.trace {
+		defer un(trace(p, "TraitStmt"))
+	}
+
+	stmt := &ast.TraitStmt{}
+	stmt.Trait = p.expect(token.Trait)
+	stmt.Name = p.parseIdent()
+
+	for p.tok != token.End && p.tok != token.EOF {
+		stmt.Body = append(stmt.Body, p.parseTraitMember())
+	}
+
+	stmt.BlockEnd = p.expect(token.End)
+
+	return stmt
+}
+
+func (p *Parser) parseTraitMember() (s ast.Stmt) {
+	if p.trace {
+		defer un(trace(p, "TraitMember"))
+	}
+
+	doc := p.leadComment
+	defer func() { setDoc(s, doc) }()
+
+	var async, memo token.Pos
+	if p.tok == token.Async {
+		async = p.pos
+		p.next()
+	}
+	if p.tok == token.Memo {
+		memo = p.pos
+		p.next()
+	}
+
+	switch p.tok {
+	case token.Func:
+		return p.parseTraitMethod(async, memo)
+	case token.Operator:
+		return p.parseTraitOperator(async, memo)
+	}
+
+	pos := p.pos
+	p.errorExpected(pos, "receiver function or operator")
+	p.next() // make progress
+	return &ast.BadStmt{From: pos, To: p.pos}
+}
+
+// atMemberEnd reports whether the signature of a trait member is not followed
+// by a body, making it a requirement rather than a default implementation.
+func (p *Parser) atMemberEnd() bool {
+	switch p.tok {
+	case token.Func, token.Operator, token.Async, token.Memo, token.End, token.EOF:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseTraitMethod(async, memo token.Pos) ast.Stmt {
+	fn := p.expect(token.Func)
+	name := p.parseIdent()
+	var typeParams *ast.TypeParamList
+	if p.tok == token.Lt {
+		typeParams = p.parseTypeParams()
+	}
+	params := p.parseParameters()
+	result := p.parseTypeAnnotation()
+
+	if p.tok != token.Arrow && p.atMemberEnd() {
+		return &ast.MethodSpec{
+			Async:      async,
+			Memo:       memo,
+			Fn:         fn,
+			Name:       name,
+			TypeParams: typeParams,
+			Params:     params,
+			Result:     result,
+		}
+	}
+
+	expr := &ast.FuncExpr{
+		Async:      async,
+		Memo:       memo,
+		Func:       fn,
+		Name:       name,
+		TypeParams: typeParams,
+		Params:     params,
+		Result:     result,
+	}
+
+	if p.tok == token.Arrow {
+		expr.Arrow = p.pos
+		p.next()
+		expr.ArrowExpr = p.ParseExpr()
+	} else {
+		for p.tok != token.EOF && p.tok != token.End {
+			expr.Body = append(expr.Body, p.parseStmt())
+		}
+
+		expr.BlockEnd = p.expect(token.End)
+	}
+
+	return &ast.ExprStmt{X: expr}
+}
+
+func (p *Parser) parseTraitOperator(async, memo token.Pos) ast.Stmt {
+	operator := p.expect(token.Operator)
+	pos := operator
+	if async.IsValid() {
+		pos = async
+	}
+	tokPos, tok := p.parseOverloadedOperator(pos)
+	params := p.parseParameters()
+	result := p.parseTypeAnnotation()
+
+	if p.tok != token.Arrow && p.atMemberEnd() {
+		if memo.IsValid() {
+			p.error(memo, "memo operator must have a body")
+		}
+		return &ast.OperatorSpec{
+			Async:    async,
+			Operator: operator,
+			TokPos:   tokPos,
+			Tok:      tok,
+			Params:   params,
+			Result:   result,
+		}
+	}
+
+	stmt := &ast.OperatorStmt{
+		Async:    async,
+		Memo:     memo,
+		Operator: operator,
+		TokPos:   tokPos,
+		Tok:      tok,
+		Params:   params,
+		Result:   result,
+	}
+
+	if p.tok == token.Arrow {
+		stmt.Arrow = p.pos
+		p.next()
+		stmt.ArrowExpr = p.ParseExpr()
+	} else {
+		for p.tok != token.EOF && p.tok != token.End {
+			stmt.Body = append(stmt.Body, p.parseStmt())
+		}
+
+		stmt.BlockEnd = p.expect(token.End)
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseImplStmt() *ast.ImplStmt {
+	if p.trace {
+		defer un(trace(p, "ImplStmt"))
+	}
+
+	stmt := &ast.ImplStmt{}
+	stmt.Impl = p.expect(token.Impl)
+	stmt.Trait = p.parseIdent()
+	stmt.For = p.expect(token.For)
+	stmt.Struct = p.parseIdent()
+
+	return stmt
+}
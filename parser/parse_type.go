@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/token"
+)
+
+func (p *Parser) parseTypeStmt() *ast.TypeStmt {
+	if p.trace {
+		defer un(trace(p, "TypeStmt"))
+	}
+
+	stmt := &ast.TypeStmt{}
+	// type is not a keyword, so that it can still name variables and fields
+	stmt.Type = p.expectMsg(token.Ident, "type")
+	stmt.Name = p.parseIdent()
+	if p.tok == token.Lt {
+		stmt.TypeParams = p.parseTypeParams()
+	}
+	stmt.Assign = p.expect(token.Assign)
+	stmt.Value = p.parseType()
+
+	return stmt
+}
+
+// isTypeStmt reports whether the current token starts a type statement.
+func (p *Parser) isTypeStmt() bool {
+	return p.tok == token.Ident && p.lit == "type" && p.nextTok == token.Ident
+}
+
+// parseTypeAnnotation parses the type after the colon of an annotation, if
+// there is one.
+func (p *Parser) parseTypeAnnotation() ast.TypeExpr {
+	if p.tok != token.Colon {
+		return nil
+	}
+	p.next()
+	return p.parseType()
+}
+
+func (p *Parser) parseType() ast.TypeExpr {
+	if p.trace {
+		defer un(trace(p, "Type"))
+	}
+
+	x := p.parseNonUnionType()
+	if p.tok != token.BitOr {
+		return x
+	}
+
+	union := &ast.UnionType{Types: []ast.TypeExpr{x}}
+	for p.tok == token.BitOr {
+		p.next()
+		union.Types = append(union.Types, p.parseNonUnionType())
+	}
+	return union
+}
+
+func (p *Parser) parseNonUnionType() ast.TypeExpr {
+	var x ast.TypeExpr
+	switch p.tok {
+	case token.Ident:
+		x = p.parseTypeName()
+	case token.Null:
+		x = &ast.TypeName{Name: &ast.Ident{NamePos: p.pos, Name: "null"}}
+		p.next()
+	case token.LBrace:
+		x = p.parseObjectType()
+	case token.Func:
+		x = p.parseFuncType()
+	case token.LParen:
+		paren := &ast.ParenType{Opening: p.pos}
+		p.next()
+		paren.X = p.parseType()
+		paren.Closing = p.expect(token.RParen)
+		x = paren
+	default:
+		pos := p.pos
+		p.errorExpected(pos, "type")
+		return &ast.BadType{From: pos, To: pos}
+	}
+
+	// T[]
+	for p.tok == token.LBracket && p.nextTok == token.RBracket {
+		array := &ast.ArrayType{Elem: x, LBracket: p.pos}
+		p.next()
+		array.RBracket = p.expect(token.RBracket)
+		x = array
+	}
+	return x
+}
+
+func (p *Parser) parseTypeName() *ast.TypeName {
+	if p.trace {
+		defer un(trace(p, "TypeName"))
+	}
+
+	x := &ast.TypeName{Name: p.parseIdent()}
+	if p.tok == token.Period {
+		p.next()
+		x.Qualifier, x.Name = x.Name, p.parseIdent()
+	}
+	if p.tok == token.Lt {
+		x.Args = &ast.TypeArgList{Opening: p.pos}
+		p.next()
+		for p.tok != token.Gt && p.tok != token.EOF {
+			x.Args.List = append(x.Args.List, p.parseType())
+			if p.tok != token.Comma {
+				break
+			}
+			p.next()
+		}
+		x.Args.Closing = p.expectClosingAngle()
+	}
+	return x
+}
+
+func (p *Parser) parseObjectType() *ast.ObjectType {
+	if p.trace {
+		defer un(trace(p, "ObjectType"))
+	}
+
+	x := &ast.ObjectType{Opening: p.expect(token.LBrace)}
+	for p.tok != token.RBrace && p.tok != token.EOF {
+		field := &ast.ObjectTypeField{Ident: p.parseIdent()}
+		p.expect(token.Colon)
+		field.Type = p.parseType()
+		x.Fields = append(x.Fields, field)
+		if p.tok != token.Comma {
+			break
+		}
+		p.next()
+	}
+	x.Closing = p.expect(token.RBrace)
+	return x
+}
+
+func (p *Parser) parseFuncType() *ast.FuncType {
+	if p.trace {
+		defer un(trace(p, "FuncType"))
+	}
+
+	x := &ast.FuncType{Func: p.expect(token.Func)}
+	x.Opening = p.expect(token.LParen)
+	for p.tok != token.RParen && p.tok != token.EOF {
+		param := &ast.FuncTypeParam{}
+		if p.tok == token.Ellipsis {
+			param.Ellipsis = p.pos
+			p.next()
+		}
+		// parameter names are optional
+		if p.tok == token.Ident && p.nextTok == token.Colon {
+			param.Ident = p.parseIdent()
+			p.next()
+		}
+		param.Type = p.parseType()
+		x.Params = append(x.Params, param)
+		if p.tok != token.Comma {
+			break
+		}
+		p.next()
+	}
+	x.Closing = p.expect(token.RParen)
+	if p.tok == token.Arrow {
+		x.Arrow = p.pos
+		p.next()
+		x.Result = p.parseType()
+	}
+	return x
+}
+
+// parseTypeParams parses the type parameters of a generic declaration, like
+// <T, U>.
+func (p *Parser) parseTypeParams() *ast.TypeParamList {
+	if p.trace {
+		defer un(trace(p, "TypeParams"))
+	}
+
+	list := &ast.TypeParamList{Opening: p.expect(token.Lt)}
+	for p.tok != token.Gt && p.tok != token.EOF {
+		list.List = append(list.List, p.parseIdent())
+		if p.tok != token.Comma {
+			break
+		}
+		p.next()
+	}
+	list.Closing = p.expectClosingAngle()
+	return list
+}
+
+// expectClosingAngle expects the > that closes type parameters or arguments.
+// The scanner reads the >> that ends nested arguments like Array<Array<int>>
+// as one token, as well as >= and >>= before the = of an initializer, so the
+// > is split off and the rest of the token is left to be parsed.
+func (p *Parser) expectClosingAngle() token.Pos {
+	pos := p.pos
+	switch p.tok {
+	case token.BitShr:
+		p.tok = token.Gt
+	case token.Gte:
+		p.tok = token.Assign
+	case token.BitShrAssign:
+		p.tok = token.Gte
+	default:
+		return p.expectMsg(token.Gt, "'>'")
+	}
+	p.pos++
+	return pos
+}
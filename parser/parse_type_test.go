@@ -0,0 +1,108 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+)
+
+func TestTypeAnnotations(t *testing.T) {
+	t.Parallel()
+
+	src := `let a: int = 1
+const b: int | float | null = 2
+let c: Array<Array<int>>= []
+let d: { x: int, y: int }[]
+let e: fn(int, ...rest: string[]) -> bool | null = fn(x) -> true
+let f: (int | float)[] = []
+let g: collections.Map<string, int> = collections.Map()
+type Pair<T> = { first: T, second: T }
+fn add<T>(a: T, :b: int = 2): T -> a
+struct Box<T>(value: T, label: string = "")
+fn Box<T>.get(): T -> #value
+operator Box<T> +(a: Box<T>, b: Box<T>): Box<T> -> a
+native fn parse<T>(s: string): T
+native type Handle
+let type = 1
+println(type)
+`
+	module, err := parser.ParseFile(token.NewFileSet(), "test", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"int",
+		"int | float | null",
+		"Array<Array<int>>",
+		"{ x: int, y: int }[]",
+		"fn(int, ...rest: string[]) -> bool | null",
+		"(int | float)[]",
+		"collections.Map<string, int>",
+		"{ first: T, second: T }",
+		"T", "int", "T",
+		"T", "string",
+		"T",
+		"Box<T>", "Box<T>", "Box<T>",
+		"string", "T",
+	}
+	var got []string
+	add := func(t ast.TypeExpr) {
+		if t != nil {
+			got = append(got, ast.TypeString(t))
+		}
+	}
+	params := func(list *ast.FuncParamList) {
+		for _, param := range list.List {
+			add(param.Type)
+		}
+	}
+	ast.Walk(module, func(node any) {
+		switch n := node.(type) {
+		case *ast.LetStmt:
+			add(n.Type)
+		case *ast.ConstStmt:
+			add(n.Type)
+		case *ast.TypeStmt:
+			add(n.Value)
+		case *ast.FuncExpr:
+			params(n.Params)
+			add(n.Result)
+		case *ast.StructStmt:
+			for _, field := range n.Fields.List {
+				add(field.Type)
+			}
+		case *ast.OperatorStmt:
+			params(n.Params)
+			add(n.Result)
+		case *ast.NativeFunc:
+			params(n.Params)
+			add(n.Result)
+		}
+	})
+	if len(got) != len(want) {
+		t.Fatalf("got %d annotations %q, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("annotation %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{
+		"let x: = 1\n",
+		"let x: Array<int = 1\n",
+		"type = 1\ntype T\n",
+		"fn f(x: ) -> x\n",
+	} {
+		if _, err := parser.ParseFile(token.NewFileSet(), "test", src, nil); err == nil {
+			t.Errorf("expected an error for %q", src)
+		}
+	}
+}
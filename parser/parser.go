@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/calico32/goose/ast"
 	"github.com/calico32/goose/scanner"
@@ -10,6 +11,7 @@ import (
 )
 
 type Parser struct {
+	fset    *token.FileSet
 	file    *token.File
 	errors  scanner.ErrorList
 	scanner scanner.Scanner
@@ -26,6 +28,13 @@ type Parser struct {
 	nextTok token.Token
 	nextLit string
 
+	comments    []*ast.CommentGroup
+	leadComment *ast.CommentGroup // the doc comment group directly above the current token
+
+	expanding bool               // tokens come from tokens instead of the scanner
+	tokens    []*ast.MacroToken  // the rest of a macro expansion
+	record    *[]*ast.MacroToken // if set, consumed tokens are appended here
+
 	// syncPos   token.Pos
 	// syncCount int
 
@@ -34,13 +43,25 @@ type Parser struct {
 }
 
 func (p *Parser) Init(fset *token.FileSet, specifier string, src []byte, trace io.Writer) {
-	p.file = fset.AddFile(specifier, -1, len(src))
+	p.init(fset, fset.AddFile(specifier, -1, len(src)), src, trace)
+}
+
+// Initialize the parser to parse src, the contents of file.
+func (p *Parser) init(fset *token.FileSet, file *token.File, src []byte, trace io.Writer) {
+	p.fset = fset
+	p.file = file
 	p.trace = trace != nil
 	if p.trace {
 		p.traceWriter = trace
 	}
 
-	errorHandler := func(pos token.Position, msg string) { p.errors.Add(pos, msg) }
+	errorHandler := func(pos token.Position, msg string) {
+		if file.Site().IsValid() {
+			// report errors in the expansion of a macro at its invocation
+			pos = fset.Position(file.Pos(pos.Offset))
+		}
+		p.errors.Add(pos, msg)
+	}
 	p.scanner.Init(p.file, src, errorHandler)
 
 	p.next()
@@ -98,16 +119,83 @@ func (p *Parser) _next() {
 		}
 	}
 
+	if p.record != nil && p.tok != token.Comment {
+		*p.record = append(*p.record, &ast.MacroToken{Pos: p.pos, Tok: p.tok, Lit: p.lit})
+	}
+
 	p.pos, p.tok, p.lit = p.nextPos, p.nextTok, p.nextLit
-	p.nextPos, p.nextTok, p.nextLit = p.scanner.Scan()
+	if p.expanding {
+		p.nextPos, p.nextTok, p.nextLit = p.scanToken()
+	} else {
+		p.nextPos, p.nextTok, p.nextLit = p.scanner.Scan()
+	}
 }
 
+// Return the token after the next one without consuming anything.
+func (p *Parser) peek() token.Token {
+	if p.expanding {
+		if len(p.tokens) == 0 {
+			return token.EOF
+		}
+		return p.tokens[0].Tok
+	}
+	_, tok, _ := p.scanner.Peek()
+	return tok
+}
+
+// Consume a comment and return it and the line on which it ends.
+func (p *Parser) consumeComment() (comment *ast.Comment, endline int) {
+	// /*-style comments may end on a different line than where they start
+	endline = p.file.Line(p.pos) + strings.Count(p.lit, "\n")
+	comment = &ast.Comment{Slash: p.pos, Text: p.lit}
+	p._next()
+	return
+}
+
+// Consume a group of adjacent comments, add it to the parser's comments list,
+// and return it together with the line at which the last comment in the group
+// ends. A non-comment token or n empty lines terminate a comment group.
+func (p *Parser) consumeCommentGroup(n int) (comments *ast.CommentGroup, endline int) {
+	var list []*ast.Comment
+	endline = p.file.Line(p.pos)
+	for p.tok == token.Comment && p.file.Line(p.pos) <= endline+n {
+		var comment *ast.Comment
+		comment, endline = p.consumeComment()
+		list = append(list, comment)
+	}
+
+	comments = &ast.CommentGroup{List: list}
+	p.comments = append(p.comments, comments)
+	return
+}
+
+// Advance to the next non-comment token, collecting the comments on the way.
+// A group of `///` comments that ends on the line directly above the next
+// token, and does not share a line with the previous one, becomes the lead
+// comment of that token.
 func (p *Parser) next() {
+	p.leadComment = nil
+	prev := p.pos
 	p._next()
 
-	// skip comments
-	for p.tok == token.Comment {
-		p._next()
+	if p.tok == token.Comment {
+		var comment *ast.CommentGroup
+		var endline int
+
+		if prev.IsValid() && p.file.Line(p.pos) == p.file.Line(prev) {
+			// a comment on the same line as the previous token does not
+			// document the next one
+			_, endline = p.consumeCommentGroup(0)
+		}
+
+		endline = -1
+		for p.tok == token.Comment {
+			comment, endline = p.consumeCommentGroup(1)
+		}
+
+		if endline+1 == p.file.Line(p.pos) && comment.IsDoc() {
+			p.leadComment = comment
+		}
 	}
 }
 
@@ -134,6 +222,22 @@ func (p *Parser) parseStmt() (s ast.Stmt) {
 		defer un(trace(p, "Statement"))
 	}
 
+	doc := p.leadComment
+	defer func() { setDoc(s, doc) }()
+
+	if p.isTypeStmt() {
+		return p.parseTypeStmt()
+	}
+	if p.isMacroStmt() {
+		return p.parseMacroStmt()
+	}
+	if p.isProcMacroStmt() {
+		return p.parseProcMacroStmt()
+	}
+	if p.isLabeledStmt() {
+		return p.parseLabeledStmt()
+	}
+
 	switch p.tok {
 	case
 		// tokens that may start an expression
@@ -176,6 +280,12 @@ func (p *Parser) parseStmt() (s ast.Stmt) {
 		s = p.parseStructStmt()
 	case token.Operator:
 		s = p.parseOperatorStmt()
+	case token.Trait:
+		s = p.parseTraitStmt()
+	case token.Impl:
+		s = p.parseImplStmt()
+	case token.Enum:
+		s = p.parseEnumStmt()
 	case token.Memo:
 		if p.nextTok == token.Operator {
 			s = p.parseOperatorStmt()
@@ -194,8 +304,7 @@ func (p *Parser) parseStmt() (s ast.Stmt) {
 
 		// async memo
 		if p.nextTok == token.Memo {
-			_, tok, _ := p.scanner.Peek()
-			if tok == token.Operator {
+			if p.peek() == token.Operator {
 				// async memo operator
 				s = p.parseOperatorStmt()
 				break
@@ -217,6 +326,56 @@ func (p *Parser) parseStmt() (s ast.Stmt) {
 	return
 }
 
+// setDoc attaches a doc comment to the declaration made by s, if any.
+func setDoc(s ast.Stmt, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	switch s := s.(type) {
+	case *ast.ExportDeclStmt:
+		setDoc(s.Stmt, doc)
+	case *ast.ExprStmt:
+		switch x := s.X.(type) {
+		case *ast.FuncExpr:
+			x.Doc = doc
+		case *ast.GeneratorExpr:
+			x.Doc = doc
+		}
+	case *ast.StructStmt:
+		s.Doc = doc
+	case *ast.OperatorStmt:
+		s.Doc = doc
+	case *ast.TraitStmt:
+		s.Doc = doc
+	case *ast.EnumStmt:
+		s.Doc = doc
+	case *ast.MethodSpec:
+		s.Doc = doc
+	case *ast.OperatorSpec:
+		s.Doc = doc
+	case *ast.ConstStmt:
+		s.Doc = doc
+	case *ast.LetStmt:
+		s.Doc = doc
+	case *ast.NativeConst:
+		s.Doc = doc
+	case *ast.NativeStruct:
+		s.Doc = doc
+	case *ast.NativeFunc:
+		s.Doc = doc
+	case *ast.NativeOperator:
+		s.Doc = doc
+	case *ast.NativeType:
+		s.Doc = doc
+	case *ast.TypeStmt:
+		s.Doc = doc
+	case *ast.MacroStmt:
+		s.Doc = doc
+	case *ast.ProcMacroStmt:
+		s.Doc = doc
+	}
+}
+
 func (p *Parser) parseSimpleStmt() ast.Stmt {
 	if p.trace {
 		defer un(trace(p, "SimpleStmt"))
@@ -272,6 +431,7 @@ func (p *Parser) ParseFile() (f *ast.Module, err error) {
 		Stmts:     stmts,
 		Specifier: p.file.Specifier(),
 		Scheme:    p.file.Scheme(),
+		Comments:  p.comments,
 	}
 
 	if p.errors.Len() > 0 {
@@ -121,8 +121,11 @@ var tokenTable = tokens{
 		},
 	},
 	'<': tokens{
-		0:   token.Lt,
-		'=': token.Lte,
+		0: token.Lt,
+		'=': tokens{
+			0:   token.Lte,
+			'>': token.Spaceship,
+		},
 		'<': tokens{
 			0:   token.BitShl,
 			'=': token.BitShlAssign,
@@ -16,6 +16,7 @@ type File struct {
 	scheme    string // file, pkg, std
 	base      int
 	size      int
+	site      Pos // position of the macro invocation an expansion file was made for
 
 	mutex sync.Mutex
 	lines []int
@@ -42,6 +43,12 @@ func (f *File) Scheme() string {
 	return f.scheme
 }
 
+// Site returns the position of the macro invocation that file f holds the
+// expansion of, or NoPos if f is a source file.
+func (f *File) Site() Pos {
+	return f.site
+}
+
 // Base returns the base offset of file f as registered with AddFile.
 func (f *File) Base() int {
 	return f.base
@@ -61,26 +61,51 @@ func (s *FileSet) AddFile(specifier string, base, size int) *File {
 	return f
 }
 
-func (s *FileSet) Position(pos Pos) Position {
-	if !pos.IsValid() {
-		return Position{Filename: "", Line: 0, Column: 0}
-	}
+// AddExpansion adds a file holding the expansion of the macro invoked at site.
+// Positions in the file are reported at site by Position.
+func (s *FileSet) AddExpansion(specifier string, site Pos, size int) *File {
+	f := s.AddFile(specifier, -1, size)
+	f.site = site
+	return f
+}
 
-	var file *File
+func (s *FileSet) file(pos Pos) *File {
 	for _, f := range s.files {
 		if f.base <= int(pos) && int(pos) <= f.base+f.size {
-			file = f
-			break
+			return f
 		}
 	}
+	return nil
+}
 
+func (s *FileSet) Position(pos Pos) Position {
+	if !pos.IsValid() {
+		return Position{Filename: "", Line: 0, Column: 0}
+	}
+
+	file := s.file(pos)
 	if file == nil {
 		return Position{Filename: "unknown", Line: 1, Column: 1}
 	}
+	if file.site.IsValid() {
+		return s.Position(file.site)
+	}
 
 	return file.Position(pos)
 }
 
+// Site returns the position in a source file that pos was expanded from: the
+// outermost macro invocation if pos is in an expansion, or pos itself.
+func (s *FileSet) Site(pos Pos) Pos {
+	for {
+		file := s.file(pos)
+		if file == nil || !file.site.IsValid() {
+			return pos
+		}
+		pos = file.site
+	}
+}
+
 func (s *FileSet) GetPosition(specifier string, line, column int) Position {
 	for _, f := range s.files {
 		if f.specifier == specifier {
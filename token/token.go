@@ -52,6 +52,8 @@ const (
 	Eq
 	Neq
 	Spaceship
+	Index       // [], only in operator statements
+	IndexAssign // []=, only in operator statements
 	OverloadAllowedEnd
 	AddAssign
 	SubAssign
@@ -132,6 +134,9 @@ const (
 	Match
 	When
 	Frozen
+	Trait
+	Impl
+	Enum
 	KeywordEnd
 
 	None
@@ -184,6 +189,9 @@ var tokens = [...]string{
 	Gt:            ">",
 	Lte:           "<=",
 	Gte:           ">=",
+	Spaceship:     "<=>",
+	Index:         "[]",
+	IndexAssign:   "[]=",
 	BitNot:        "~",
 	BitAnd:        "&",
 	BitOr:         "|",
@@ -255,6 +263,9 @@ var tokens = [...]string{
 	Match:     "match",
 	When:      "when",
 	Frozen:    "frozen",
+	Trait:     "trait",
+	Impl:      "impl",
+	Enum:      "enum",
 }
 
 func (tok Token) String() string {
@@ -292,11 +303,11 @@ func (tok Token) Precedence() int {
 		return 7
 	case BitAnd:
 		return 8
-	case Eq, Neq, Lt, Gt, Lte, Gte:
+	case Eq, Neq, Lt, Gt, Lte, Gte, Spaceship:
 		return 9
 	case Add, Sub:
 		return 10
-	case Mul, Quo, Rem:
+	case Mul, Quo, Rem, BitShl, BitShr:
 		return 11
 	case Pow:
 		return 12
@@ -0,0 +1,216 @@
+package validator
+
+import (
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+)
+
+type DeclKind int
+
+const (
+	DeclVariable DeclKind = iota
+	DeclConstant
+	DeclFunction
+	DeclMethod
+	DeclStruct
+	DeclField
+	DeclParameter
+	DeclModule
+	DeclType
+	DeclMacro
+	DeclTrait
+	DeclEnum
+	DeclVariant
+)
+
+var declKindNames = [...]string{
+	DeclVariable:  "let",
+	DeclConstant:  "const",
+	DeclFunction:  "fn",
+	DeclMethod:    "fn",
+	DeclStruct:    "struct",
+	DeclField:     "field",
+	DeclParameter: "param",
+	DeclModule:    "module",
+	DeclType:      "type",
+	DeclMacro:     "macro",
+	DeclTrait:     "trait",
+	DeclEnum:      "enum",
+	DeclVariant:   "variant",
+}
+
+func (k DeclKind) String() string {
+	return declKindNames[k]
+}
+
+// Declaration is a name declared in a checked module. Ident is the identifier
+// that names it (nil for plain imports, which are named after their specifier)
+// and Decl is the declaring statement, expression, parameter, field or import
+// spec.
+type Declaration struct {
+	Name   string
+	Kind   DeclKind
+	Ident  *ast.Ident
+	Decl   ast.Node
+	Module *Module
+}
+
+// Declarations returns every declaration in the checked modules.
+func (v *Validator) Declarations() []*Declaration {
+	return v.declList
+}
+
+// DeclarationAt returns the identifier at pos and the declaration it refers
+// to, or nil if there is no resolved identifier at pos.
+func (v *Validator) DeclarationAt(pos token.Pos) (*ast.Ident, *Declaration) {
+	for ident, decl := range v.refs {
+		if ident.Pos() <= pos && pos <= ident.End() {
+			return ident, decl
+		}
+	}
+	return nil, nil
+}
+
+// Resolved returns every identifier that refers to a declaration, mapped to
+// that declaration.
+func (v *Validator) Resolved() map[*ast.Ident]*Declaration {
+	return v.refs
+}
+
+// References returns every identifier that refers to decl, including the
+// identifier that declares it.
+func (v *Validator) References(decl *Declaration) []*ast.Ident {
+	refs := []*ast.Ident{}
+	for ident, d := range v.refs {
+		if d == decl {
+			refs = append(refs, ident)
+		}
+	}
+	return refs
+}
+
+// Lookup resolves a dotted name, like math.sqrt or list.push, in the scope at
+// pos and returns the declaration it refers to, or nil if it is unknown.
+func (v *Validator) Lookup(pos token.Pos, names []string) *Declaration {
+	if len(names) == 0 {
+		return nil
+	}
+	variable := v.ScopeAt(pos).Get(names[0])
+	if variable == nil || len(names) == 1 {
+		return v.variableDecl(variable)
+	}
+	value := variable.Value
+	for _, name := range names[1 : len(names)-1] {
+		c, ok := value.(*Composite)
+		if !ok || c == nil {
+			return nil
+		}
+		value = GetProperty(c, NewString(name))
+	}
+	return v.memberDecl(value, names[len(names)-1])
+}
+
+// Fields returns the field declarations of a struct, in order.
+func (v *Validator) Fields(decl *Declaration) []*Declaration {
+	stmt, ok := decl.Decl.(*ast.StructStmt)
+	if !ok {
+		return nil
+	}
+	fields := []*Declaration{}
+	for _, field := range stmt.Fields.List {
+		if s, ok := v.refs[field.Ident]; ok {
+			fields = append(fields, s)
+		}
+	}
+	return fields
+}
+
+// declare records a declaration and binds it to the variable
+// named by ident in scope (if scope is not nil) and to value (if it is a
+// function).
+func (v *Validator) declare(scope *Scope, ident *ast.Ident, node ast.Node, kind DeclKind, value Value) *Declaration {
+	d := &Declaration{
+		Kind:   kind,
+		Ident:  ident,
+		Decl:   node,
+		Module: v.CurrentModule(),
+	}
+	if ident != nil {
+		d.Name = ident.Name
+		v.resolve(ident, d)
+		if scope != nil {
+			if variable, ok := scope.Idents()[ident.Name]; ok && variable != nil {
+				v.varDecls[variable] = d
+			}
+		}
+	}
+	if fn, ok := value.(*Func); ok && fn != nil {
+		v.funcDecls[fn] = d
+	}
+	v.declList = append(v.declList, d)
+	return d
+}
+
+// aliasKind returns the kind of a declaration that renames variable.
+func (v *Validator) aliasKind(variable *Variable) DeclKind {
+	if d, ok := v.varDecls[variable]; ok && d.Kind != DeclModule {
+		return d.Kind
+	}
+	return DeclConstant
+}
+
+// reference records ident as a reference to the declaration of variable.
+func (v *Validator) reference(ident *ast.Ident, variable *Variable) {
+	if decl := v.variableDecl(variable); decl != nil {
+		v.resolve(ident, decl)
+	}
+}
+
+// referenceMember records sel as a reference to the member name of value,
+// which is either a struct field or a function with a known declaration.
+func (v *Validator) referenceMember(sel *ast.Ident, value Value, name string) {
+	if decl := v.memberDecl(value, name); decl != nil {
+		v.resolve(sel, decl)
+	}
+}
+
+// resolve records ident as referring to decl, unless ident was expanded from
+// the body of a macro and so appears nowhere in the source.
+func (v *Validator) resolve(ident *ast.Ident, decl *Declaration) {
+	if v.fset.Site(ident.Pos()) == ident.Pos() {
+		v.refs[ident] = decl
+	}
+}
+
+// variableDecl returns the declaration of variable, or nil if it is unknown.
+func (v *Validator) variableDecl(variable *Variable) *Declaration {
+	if variable == nil {
+		return nil
+	}
+	if decl, ok := v.varDecls[variable]; ok {
+		return decl
+	}
+	if fn, ok := variable.Value.(*Func); ok && fn != nil {
+		return v.funcDecls[fn]
+	}
+	return nil
+}
+
+// memberDecl returns the declaration of the member name of value, or nil if
+// it is unknown.
+func (v *Validator) memberDecl(value Value, name string) *Declaration {
+	c, ok := value.(*Composite)
+	if !ok || c == nil {
+		return nil
+	}
+	for proto := c; proto != nil; proto = proto.Proto {
+		if decl, ok := v.fieldDecls[proto][name]; ok {
+			return decl
+		}
+	}
+	if fn, ok := GetProperty(c, NewString(name)).(*Func); ok && fn != nil {
+		return v.funcDecls[fn]
+	}
+	return nil
+}
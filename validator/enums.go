@@ -0,0 +1,173 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+	"go.lsp.dev/protocol"
+)
+
+func (v *Validator) checkEnumStmt(scope *Scope, stmt *ast.EnumStmt) StmtResult {
+	defer pop(push(v, stmt))
+
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Name, "cannot redefine enum %s", stmt.Name.Name)
+	}
+
+	enum := NewEnum(stmt.Name.Name)
+	decl := v.declare(nil, stmt.Name, stmt, DeclEnum, nil)
+	enumType := &structType{proto: enum.Proto}
+	v.typeAliases[enum] = enumType
+
+	// the types of fields can refer to the enum itself
+	self := scope.Fork(ScopeOwnerClosure)
+	self.Set(stmt.Name.Name, &Variable{
+		Constant: true,
+		Value:    enum,
+	})
+
+	variants := make(map[string]*Declaration)
+	for _, variant := range stmt.Variants {
+		if _, ok := variants[variant.Name.Name]; ok {
+			v.Report(protocol.DiagnosticSeverityError, variant.Name, "duplicate variant %s", variant.Name.Name)
+			continue
+		}
+
+		var fields []*ast.StructField
+		if variant.Fields != nil {
+			fields = variant.Fields.List
+		}
+
+		fieldNames := make([]string, len(fields))
+		seen := map[string]bool{}
+		for i, field := range fields {
+			if seen[field.Ident.Name] {
+				v.Report(protocol.DiagnosticSeverityError, field, "duplicate field %s", field.Ident.Name)
+			}
+			seen[field.Ident.Name] = true
+			fieldNames[i] = field.Ident.Name
+		}
+
+		kind := AddVariant(enum, variant.Name.Name, fieldNames)
+		v.variants[kind.Proto] = kind
+		v.enums[enum.Proto] = append(v.enums[enum.Proto], kind)
+		if len(fields) == 0 {
+			variants[variant.Name.Name] = v.declare(nil, variant.Name, variant, DeclVariant, nil)
+			continue
+		}
+
+		constructor := &Func{NewableProto: kind.Proto}
+		enum.Properties[PKString][variant.Name.Name] = constructor
+		variants[variant.Name.Name] = v.declare(nil, variant.Name, variant, DeclVariant, constructor)
+
+		sig := &funcType{params: []*paramType{}, result: enumType}
+		for _, field := range fields {
+			sig.params = append(sig.params, &paramType{
+				name: field.Ident.Name,
+				typ:  v.resolveType(self, field.Type),
+			})
+		}
+		v.funcTypes[constructor] = sig
+
+		for i, field := range fields {
+			if field.Value != nil {
+				v.checkExpr(scope, field.Value)
+				v.checkAssignable(scope, field.Value, sig.params[i].typ, "field "+field.Ident.Name)
+			}
+		}
+
+		// placeholder instance, so that the fields of values created by the
+		// constructor can be found
+		obj := &Composite{
+			Proto:      kind.Proto,
+			Properties: make(Properties),
+			Operators:  make(Operators),
+		}
+		obj.Properties[PKString] = make(map[string]Value)
+		fieldDecls := make(map[string]*Declaration)
+		for _, field := range fields {
+			obj.Properties[PKString][field.Ident.Name] = NullValue
+			fieldDecls[field.Ident.Name] = v.declare(nil, field.Ident, field, DeclField, nil)
+		}
+		v.instances[kind.Proto] = obj
+		v.fieldDecls[kind.Proto] = fieldDecls
+	}
+	v.fieldDecls[enum] = variants
+	enum.Freeze()
+
+	variable := &Variable{
+		Constant: true,
+		Value:    enum,
+	}
+	scope.Set(stmt.Name.Name, variable)
+	v.varDecls[variable] = decl
+
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: enum,
+	}
+}
+
+// checkPatternExpr checks the expressions and variants in a match pattern. It
+// returns the enum variant the pattern matches, if any, and whether the
+// pattern matches every value of that variant (or every value at all, if it
+// matches no particular variant).
+func (v *Validator) checkPatternExpr(scope *Scope, pattern ast.PatternExpr) (kind *VariantKind, total bool) {
+	switch pattern := pattern.(type) {
+	case *ast.PatternBinding:
+		return nil, true
+	case *ast.PatternParen:
+		return v.checkPatternExpr(scope, pattern.X)
+	case *ast.PatternNormal:
+		if variant, ok := v.checkExpr(scope, pattern.X).(*Variant); ok {
+			return variant.Kind, true
+		}
+		return nil, false
+	case *ast.PatternVariant:
+		x := v.checkExpr(scope, pattern.Variant)
+		if fn, ok := x.(*Func); ok && fn != nil {
+			kind = v.variants[fn.NewableProto]
+		}
+		if kind == nil && x != nil {
+			v.Report(protocol.DiagnosticSeverityError, pattern.Variant, "%s is not an enum variant", PrintExpr(pattern.Variant))
+		}
+
+		total = kind != nil
+		if kind != nil && len(pattern.Args) != len(kind.Fields) {
+			v.Report(protocol.DiagnosticSeverityError, pattern, "variant %s has %d fields, got %d", kind.Name, len(kind.Fields), len(pattern.Args))
+			total = false
+		}
+		for _, arg := range pattern.Args {
+			// a field is only matched entirely by a pattern that matches any
+			// value, not by one that matches a whole variant
+			argKind, argTotal := v.checkPatternExpr(scope, arg)
+			if argKind != nil || !argTotal {
+				total = false
+			}
+		}
+		return kind, total
+	default:
+		return nil, false
+	}
+}
+
+// checkExhaustive reports a match over an enum that has no arm for some of its
+// variants. covered are the variants with an arm that matches all of their
+// values.
+func (v *Validator) checkExhaustive(expr *ast.MatchExpr, enum *Composite, covered map[*VariantKind]bool) {
+	kinds := v.enums[enum]
+	missing := []string{}
+	for _, kind := range kinds {
+		if !covered[kind] {
+			missing = append(missing, kind.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	r := &ast.PosRange{From: expr.Match, To: expr.Match + token.Pos(len(token.Match.String()))}
+	v.Report(protocol.DiagnosticSeverityWarning, r, "match over %s is not exhaustive: missing %s", kinds[0].Enum, strings.Join(missing, ", "))
+}
@@ -0,0 +1,113 @@
+package validator
+
+import (
+	"github.com/calico32/goose/ast"
+	"github.com/calico32/goose/interpreter"
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/scanner"
+	"github.com/calico32/goose/token"
+	"go.lsp.dev/protocol"
+)
+
+// expandMacros expands the macro invocations in module, reports the errors at
+// the invocations they happened in and records which macro each invocation
+// uses.
+func (v *Validator) expandMacros(module *Module) {
+	var calls []*ast.MacroCallExpr
+	collect := func() {
+		calls = calls[:0]
+		ast.Walk(module.Module, func(node any) {
+			if call, ok := node.(*ast.MacroCallExpr); ok {
+				calls = append(calls, call)
+			}
+		})
+	}
+
+	if errs, ok := parser.ExpandMacros(v.fset, module.Module, interpreter.ProcMacroRunner(v.fset, nil, nil)).(scanner.ErrorList); ok {
+		collect()
+		for _, err := range errs {
+			v.Report(protocol.DiagnosticSeverityError, macroErrorNode(calls, v.fset.Pos(err.Pos)), "%s", err.Msg)
+		}
+	}
+
+	decls := make(map[string]*Declaration)
+	ast.Walk(module.Module, func(node any) {
+		var name *ast.Ident
+		switch stmt := node.(type) {
+		case *ast.MacroStmt:
+			name = stmt.Name
+		case *ast.ProcMacroStmt:
+			name = stmt.Name
+		default:
+			return
+		}
+		if _, exists := decls[name.Name]; !exists {
+			decls[name.Name] = v.declare(nil, name, node.(ast.Node), DeclMacro, nil)
+		}
+	})
+	collect()
+	for _, call := range calls {
+		if decl, ok := decls[call.Name.Name]; ok {
+			v.resolve(call.Name, decl)
+		}
+	}
+}
+
+// macroErrorNode returns the invocation at pos, or an empty range at pos if
+// the error is not about a whole invocation.
+func macroErrorNode(calls []*ast.MacroCallExpr, pos token.Pos) ast.Node {
+	for _, call := range calls {
+		if call.Pos() == pos {
+			return call
+		}
+	}
+	return &ast.PosRange{From: pos, To: pos}
+}
+
+func (v *Validator) checkMacroCallExpr(scope *Scope, expr *ast.MacroCallExpr) Value {
+	if expr.Expansion == nil {
+		return nil
+	}
+	return v.checkExpr(scope, expr.Expansion)
+}
+
+func (v *Validator) checkProcMacroStmt(scope *Scope, stmt *ast.ProcMacroStmt) StmtResult {
+	defer pop(push(v, stmt))
+
+	// the body runs at the top level of its own module, where std:macro is
+	// bound to macro and returns end the procmacro
+	funcScope := scope.Fork(ScopeOwnerFunc)
+	bodyScope := v.record(stmt, funcScope.Fork(ScopeOwnerModule))
+
+	if _, std := v.loadModule("std:macro", bodyScope); std != nil {
+		exports := NewComposite()
+		for name, value := range std.Exports {
+			SetProperty(exports, NewString(name), value.Value)
+		}
+		exports.Frozen = true
+		bodyScope.Set("macro", &Variable{Value: exports, Constant: true})
+	}
+
+	params := map[string]bool{}
+	for _, param := range stmt.Params.List {
+		if params[param.Name] {
+			v.Report(protocol.DiagnosticSeverityError, param, "duplicate parameter %s", param.Name).Problem = ProblemDuplicateParameter
+		}
+		params[param.Name] = true
+		v.setParam(bodyScope, param.Name, nil)
+		v.declare(bodyScope, param, param, DeclParameter, nil)
+	}
+
+	for _, s := range stmt.Body {
+		if imp, ok := s.(*ast.ImportStmt); ok && !interpreter.SandboxAllows(imp.Spec.ModuleSpecifier()) {
+			v.Report(protocol.DiagnosticSeverityError, imp, "procmacros cannot import %s", imp.Spec.ModuleSpecifier())
+		}
+	}
+
+	v.results = append(v.results, nil)
+	v.checkStmts(bodyScope, stmt.Body)
+	v.results = v.results[:len(v.results)-1]
+
+	return &Void{}
+}
@@ -0,0 +1,109 @@
+package validator_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+)
+
+func TestMacros(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `macro add!(x) -> x + y
+let a: string = add!(1)
+let b = missing!(2)
+let y: int = 1
+let c: string = add!(1)
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"3:9: undefined macro missing!",
+		"2:17: y is not defined",
+		"5:17: cannot use int as string in declaration of c",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// invocations refer to the macro, identifiers expanded from its body do
+	// not appear in the source
+	var refs []string
+	for ident, decl := range v.Resolved() {
+		if decl.Kind == validator.DeclMacro {
+			refs = append(refs, fset.Position(ident.Pos()).String())
+		}
+		if ident.Name == "y" && fset.Position(ident.Pos()).Line != 4 {
+			t.Errorf("expanded identifier y resolved at %s", fset.Position(ident.Pos()))
+		}
+	}
+	if len(refs) != 3 {
+		t.Errorf("got macro references %q, want the declaration and 2 invocations", refs)
+	}
+}
+
+func TestProcMacros(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `procmacro sql!(tokens)
+  import "std:json" as json
+  return json.encode(macro.eval(tokens))
+end
+procmacro bad!(tokens)
+  import "std:os" as os
+  return "1"
+end
+procmacro spin!()
+  repeat forever
+  end
+end
+let a: string = sql!(SELECT * FROM users)
+let b: int = sql!(SELECT 1)
+bad!(x)
+spin!()
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"15:1: in expansion of bad!: test.goose:6:3: Goose error: procmacros cannot import std:os",
+		"16:1: in expansion of spin!: test.goose:10:3: Goose error: procmacro exceeded its step limit",
+		"6:3: procmacros cannot import std:os",
+		"14:14: cannot use string as int in declaration of b",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+	"go.lsp.dev/protocol"
+)
+
+// traitImpl is an impl statement whose struct is checked for the required
+// members of the trait once the module it is in has been checked.
+type traitImpl struct {
+	stmt  *ast.ImplStmt
+	trait *Trait
+	proto *Composite
+}
+
+func (v *Validator) checkTraitStmt(scope *Scope, stmt *ast.TraitStmt) StmtResult {
+	defer pop(push(v, stmt))
+
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Name, "cannot redefine trait %s", stmt.Name.Name)
+	}
+
+	trait := NewTrait(stmt.Name.Name)
+	v.traits[trait] = stmt
+	decl := v.declare(nil, stmt.Name, stmt, DeclTrait, nil)
+
+	methods := map[string]bool{}
+	operators := map[token.Token]bool{}
+	method := func(name *ast.Ident) {
+		if methods[name.Name] {
+			v.Report(protocol.DiagnosticSeverityError, name, "duplicate receiver function %s", name.Name)
+		}
+		methods[name.Name] = true
+	}
+	operator := func(tokPos token.Pos, tok token.Token) {
+		if operators[tok] {
+			r := &ast.PosRange{From: tokPos, To: tokPos + token.Pos(len(tok.String()))}
+			v.Report(protocol.DiagnosticSeverityError, r, "duplicate operator %s", tok)
+		}
+		operators[tok] = true
+	}
+
+	for _, member := range stmt.Body {
+		switch member := member.(type) {
+		case *ast.MethodSpec:
+			method(member.Name)
+			v.declare(nil, member.Name, member, DeclMethod, nil)
+			v.pushTypeParams(member.TypeParams)
+			v.checkSpecParams(scope, member.Params, member.Result)
+			v.popTypeParams()
+		case *ast.OperatorSpec:
+			operator(member.TokPos, member.Tok)
+			v.checkSpecParams(scope, member.Params, member.Result)
+		case *ast.ExprStmt:
+			fn := member.X.(*ast.FuncExpr)
+			method(fn.Name)
+			// defaults are only reachable through the structs implementing
+			// the trait, not by name
+			value, _ := v.checkFuncExpr(scope.Fork(ScopeOwnerClosure), fn).(*Func)
+			if value == nil {
+				continue
+			}
+			if d := v.funcDecls[value]; d != nil {
+				d.Kind = DeclMethod
+			}
+			trait.Defaults.Properties[PKString][fn.Name.Name] = value
+		case *ast.OperatorStmt:
+			operator(member.TokPos, member.Tok)
+			v.checkOperatorStmt(scope, member)
+			trait.Defaults.Operators[member.Tok] = &OperatorFunc{
+				Async:   member.Async.IsValid(),
+				Builtin: false,
+			}
+		}
+	}
+
+	variable := &Variable{
+		Constant: true,
+		Value:    trait,
+	}
+	scope.Set(stmt.Name.Name, variable)
+	v.varDecls[variable] = decl
+
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: trait,
+	}
+}
+
+// checkSpecParams checks the parameters and result type of a required member
+// of a trait.
+func (v *Validator) checkSpecParams(scope *Scope, params *ast.FuncParamList, result ast.TypeExpr) {
+	paramNames := map[string]bool{}
+	for _, param := range params.List {
+		for _, ident := range param.Bindings() {
+			if paramNames[ident.Name] {
+				v.Report(protocol.DiagnosticSeverityError, ident, "duplicate parameter %s", ident.Name).Problem = ProblemDuplicateParameter
+			}
+			paramNames[ident.Name] = true
+		}
+	}
+	v.signature(scope, params.List, result)
+}
+
+func (v *Validator) checkImplStmt(scope *Scope, stmt *ast.ImplStmt) StmtResult {
+	defer pop(push(v, stmt))
+
+	traitVar := scope.Get(stmt.Trait.Name)
+	v.reference(stmt.Trait, traitVar)
+	var trait *Trait
+	if traitVar == nil {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Trait, "undefined trait %s", stmt.Trait.Name)
+	} else if t, ok := traitVar.Value.(*Trait); !ok || t == nil {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Trait, "value %s is not a trait", stmt.Trait.Name)
+	} else {
+		trait = t
+	}
+
+	constructor := scope.Get(stmt.Struct.Name)
+	v.reference(stmt.Struct, constructor)
+	var proto *Composite
+	if constructor == nil {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Struct, "undefined type %s", stmt.Struct.Name)
+	} else if c, ok := constructor.Value.(*Func); !ok || c == nil || c.NewableProto == nil {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Struct, "value %s is not a type", stmt.Struct.Name)
+	} else {
+		proto = c.NewableProto
+	}
+
+	if trait == nil || proto == nil {
+		return &Void{}
+	}
+
+	if trait.Impls[proto] {
+		v.Report(protocol.DiagnosticSeverityError, stmt, "%s already implements %s", stmt.Struct.Name, stmt.Trait.Name)
+		return &Void{}
+	}
+
+	trait.Implement(proto)
+	v.impls = append(v.impls, &traitImpl{stmt: stmt, trait: trait, proto: proto})
+
+	return &Void{}
+}
+
+// checkImpl reports the required members of a trait that the struct of impl
+// neither declares nor gets from the default implementations of a trait.
+func (v *Validator) checkImpl(impl *traitImpl) {
+	stmt := v.traits[impl.trait]
+	if stmt == nil {
+		return
+	}
+
+	missing := map[string]bool{}
+	for _, member := range stmt.Body {
+		switch member := member.(type) {
+		case *ast.MethodSpec:
+			if !v.hasMethod(impl.proto, member.Name.Name) && !missing[member.Name.Name] {
+				missing[member.Name.Name] = true
+				v.Report(protocol.DiagnosticSeverityError, impl.stmt, "%s does not implement %s: missing receiver function %s", impl.stmt.Struct.Name, impl.stmt.Trait.Name, member.Name.Name)
+			}
+		case *ast.OperatorSpec:
+			if !hasOperator(impl.proto, member.Tok) {
+				v.Report(protocol.DiagnosticSeverityError, impl.stmt, "%s does not implement %s: missing operator %s", impl.stmt.Struct.Name, impl.stmt.Trait.Name, member.Tok)
+			}
+		}
+	}
+}
+
+// hasMethod reports whether instances of proto have a member name that is not
+// inherited from Object.
+func (v *Validator) hasMethod(proto *Composite, name string) bool {
+	if _, ok := v.fieldDecls[proto][name]; ok {
+		return true
+	}
+	for ; proto != nil && proto != Object; proto = proto.Proto {
+		if proto.Properties[PKString][name] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOperator reports whether proto declares tok or gets it from the default
+// implementations of a trait.
+func hasOperator(proto *Composite, tok token.Token) bool {
+	for ; proto != nil && proto != Object; proto = proto.Proto {
+		if proto.Operators[tok] != nil {
+			return true
+		}
+	}
+	return false
+}
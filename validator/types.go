@@ -0,0 +1,582 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/calico32/goose/ast"
+	. "github.com/calico32/goose/interpreter/lib"
+	"github.com/calico32/goose/token"
+	"go.lsp.dev/protocol"
+)
+
+// Type is the static type of an expression or annotation. Checking is gradual:
+// a nil Type is unknown and is compatible with every other type, so code
+// without annotations is never reported.
+type Type interface {
+	String() string
+}
+
+type (
+	basicType  string // int, float, string, bool, null or never
+	arrayType  struct{ elem Type }
+	unionType  []Type
+	objectType struct {
+		names  []string
+		fields map[string]Type
+	}
+	funcType struct {
+		params []*paramType // nil if the parameters are unknown
+		result Type
+	}
+	structType struct{ proto *Composite }
+)
+
+type paramType struct {
+	name    string
+	named   bool // named parameters can't be passed by position
+	typ     Type
+	pattern ast.PatternExpr // destructuring pattern, if the parameter has one
+}
+
+var (
+	intType    Type = basicType("int")
+	floatType  Type = basicType("float")
+	stringType Type = basicType("string")
+	boolType   Type = basicType("bool")
+	nullType   Type = basicType("null")
+	neverType  Type = basicType("never")
+)
+
+func (t basicType) String() string { return string(t) }
+
+func (t *arrayType) String() string {
+	if t.elem == nil {
+		return "any[]"
+	}
+	if _, ok := t.elem.(unionType); ok {
+		return "(" + t.elem.String() + ")[]"
+	}
+	return t.elem.String() + "[]"
+}
+
+func (t unionType) String() string {
+	parts := make([]string, len(t))
+	for i, t := range t {
+		parts[i] = t.String()
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (t *objectType) String() string {
+	parts := make([]string, len(t.names))
+	for i, name := range t.names {
+		parts[i] = name + ": " + typeString(t.fields[name])
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+func (t *funcType) String() string {
+	if t.params == nil && t.result == nil {
+		return "Function"
+	}
+	parts := make([]string, len(t.params))
+	for i, param := range t.params {
+		parts[i] = typeString(param.typ)
+		if param.name != "" {
+			parts[i] = param.name + ": " + parts[i]
+		}
+	}
+	return "fn(" + strings.Join(parts, ", ") + ") -> " + typeString(t.result)
+}
+
+func (t *structType) String() string { return t.proto.Name }
+
+// typeString is t.String(), or any if t is unknown.
+func typeString(t Type) string {
+	if t == nil {
+		return "any"
+	}
+	return t.String()
+}
+
+// assignable reports whether a value of type from can be used where type to
+// is expected.
+func assignable(from, to Type) bool {
+	if from == nil || to == nil || from == neverType {
+		return true
+	}
+	if from, ok := from.(unionType); ok {
+		for _, t := range from {
+			if !assignable(t, to) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch to := to.(type) {
+	case unionType:
+		for _, t := range to {
+			if assignable(from, t) {
+				return true
+			}
+		}
+		return false
+	case basicType:
+		return from == to
+	case *arrayType:
+		from, ok := from.(*arrayType)
+		return ok && assignable(from.elem, to.elem)
+	case *objectType:
+		switch from := from.(type) {
+		case *objectType:
+			for _, name := range to.names {
+				field, ok := from.fields[name]
+				if !ok || !assignable(field, to.fields[name]) {
+					return false
+				}
+			}
+			return true
+		case *structType:
+			// the fields of instances are not tracked
+			return true
+		}
+		return false
+	case *funcType:
+		from, ok := from.(*funcType)
+		return ok && (to.result == nil || assignable(from.result, to.result))
+	case *structType:
+		from, ok := from.(*structType)
+		return ok && from.proto == to.proto
+	}
+	return true
+}
+
+// union returns the union of types, or nil if any of them is unknown.
+func union(types ...Type) Type {
+	var result unionType
+	for _, t := range types {
+		if t == nil {
+			return nil
+		}
+		members := unionType{t}
+		if u, ok := t.(unionType); ok {
+			members = u
+		}
+	add:
+		for _, m := range members {
+			for _, r := range result {
+				if sameType(m, r) {
+					continue add
+				}
+			}
+			result = append(result, m)
+		}
+	}
+	switch len(result) {
+	case 0:
+		return neverType
+	case 1:
+		return result[0]
+	}
+	return result
+}
+
+func sameType(a, b Type) bool {
+	return assignable(a, b) && assignable(b, a)
+}
+
+// pushTypeParams makes the type parameters of a generic declaration visible
+// to the annotations inside it. They are unknown types.
+func (v *Validator) pushTypeParams(lists ...*ast.TypeParamList) {
+	params := map[string]bool{}
+	for _, list := range lists {
+		for _, name := range list.Names() {
+			params[name] = true
+		}
+	}
+	v.typeParams = append(v.typeParams, params)
+}
+
+func (v *Validator) popTypeParams() {
+	v.typeParams = v.typeParams[:len(v.typeParams)-1]
+}
+
+func (v *Validator) isTypeParam(name string) bool {
+	for _, params := range v.typeParams {
+		if params[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveType returns the type an annotation names, reporting names that are
+// not types. It returns nil for a missing annotation.
+func (v *Validator) resolveType(scope *Scope, t ast.TypeExpr) Type {
+	switch t := t.(type) {
+	case *ast.TypeName:
+		return v.resolveTypeName(scope, t)
+	case *ast.ArrayType:
+		return &arrayType{elem: v.resolveType(scope, t.Elem)}
+	case *ast.UnionType:
+		types := make([]Type, len(t.Types))
+		for i, t := range t.Types {
+			types[i] = v.resolveType(scope, t)
+		}
+		return union(types...)
+	case *ast.ObjectType:
+		obj := &objectType{fields: map[string]Type{}}
+		for _, field := range t.Fields {
+			obj.names = append(obj.names, field.Ident.Name)
+			obj.fields[field.Ident.Name] = v.resolveType(scope, field.Type)
+		}
+		return obj
+	case *ast.FuncType:
+		fn := &funcType{params: []*paramType{}, result: v.resolveType(scope, t.Result)}
+		for _, param := range t.Params {
+			p := &paramType{typ: v.resolveType(scope, param.Type)}
+			if param.Ident != nil {
+				p.name = param.Ident.Name
+			}
+			fn.params = append(fn.params, p)
+		}
+		return fn
+	case *ast.ParenType:
+		return v.resolveType(scope, t.X)
+	}
+	return nil
+}
+
+func (v *Validator) resolveTypeName(scope *Scope, t *ast.TypeName) Type {
+	var args []Type
+	if t.Args != nil {
+		for _, arg := range t.Args.List {
+			args = append(args, v.resolveType(scope, arg))
+		}
+	}
+	if t.Qualifier != nil {
+		// the types of other modules are only known by name
+		v.checkIdent(scope, t.Qualifier)
+		return nil
+	}
+	if v.isTypeParam(t.Name.Name) {
+		return nil
+	}
+
+	switch t.Name.Name {
+	case "any":
+		return nil
+	case "never":
+		return neverType
+	case "null", "void":
+		return nullType
+	case "int":
+		return intType
+	case "float":
+		return floatType
+	case "string":
+		return stringType
+	case "bool":
+		return boolType
+	case "Array":
+		if len(args) == 1 {
+			return &arrayType{elem: args[0]}
+		}
+		return &arrayType{}
+	case "Function":
+		return &funcType{}
+	}
+
+	variable := scope.Get(t.Name.Name)
+	if variable == nil {
+		v.Report(protocol.DiagnosticSeverityError, t.Name, "unknown type %s", t.Name.Name).Problem = ProblemUndefined
+		return nil
+	}
+	v.reference(t.Name, variable)
+	switch value := variable.Value.(type) {
+	case *Composite:
+		if alias, ok := v.typeAliases[value]; ok {
+			return alias
+		}
+	case *Func:
+		if value != nil && value.NewableProto != nil {
+			return &structType{proto: value.NewableProto}
+		}
+	case nil:
+		// a name whose value is unknown, like a parameter, could be anything
+		return nil
+	}
+	v.Report(protocol.DiagnosticSeverityError, t.Name, "%s is not a type", t.Name.Name)
+	return nil
+}
+
+func (v *Validator) checkTypeStmt(scope *Scope, stmt *ast.TypeStmt) StmtResult {
+	defer pop(push(v, stmt))
+
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Name, "cannot redefine type %s", stmt.Name.Name)
+	}
+
+	// the alias is bound before its value is resolved, so that it can refer to
+	// itself; it is unknown within its own definition
+	value := v.declareType(scope, stmt.Name, stmt)
+	v.pushTypeParams(stmt.TypeParams)
+	v.typeAliases[value] = v.resolveType(scope, stmt.Value)
+	v.popTypeParams()
+
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: value,
+	}
+}
+
+// checkNativeType declares a type provided by the interpreter. Nothing is
+// known about its values.
+func (v *Validator) checkNativeType(scope *Scope, stmt *ast.NativeType) StmtResult {
+	if scope.IsDefinedInCurrentScope(stmt.Name.Name) {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Name, "cannot redefine type %s", stmt.Name.Name)
+	}
+
+	value := v.declareType(scope, stmt.Name, stmt)
+	return &Decl{
+		Name:  stmt.Name.Name,
+		Value: value,
+	}
+}
+
+// declareType binds the name of a type to a placeholder value, so that types
+// can be exported and imported like other declarations. The type starts out
+// unknown.
+func (v *Validator) declareType(scope *Scope, ident *ast.Ident, node ast.Node) *Composite {
+	value := NewComposite()
+	value.Name = ident.Name
+	v.typeAliases[value] = nil
+	scope.Set(ident.Name, &Variable{
+		Constant: true,
+		Value:    value,
+	})
+	v.declare(scope, ident, node, DeclType, value)
+	return value
+}
+
+// setParam binds a parameter or field in the scope of a function body and
+// returns its variable, which has the type typ.
+func (v *Validator) setParam(scope *Scope, name string, typ Type) *Variable {
+	variable := &Variable{
+		Constant: false,
+	}
+	scope.Set(name, variable)
+	if typ != nil {
+		v.varTypes[variable] = typ
+	}
+	return variable
+}
+
+// signature resolves the annotations of the parameters and result of a
+// function or struct constructor.
+func (v *Validator) signature(scope *Scope, params []*ast.FuncParam, result ast.TypeExpr) *funcType {
+	fn := &funcType{params: []*paramType{}, result: v.resolveType(scope, result)}
+	for _, param := range params {
+		p := &paramType{
+			named:   param.Colon.IsValid(),
+			typ:     v.resolveType(scope, param.Type),
+			pattern: param.Pattern,
+		}
+		if param.Ident != nil {
+			p.name = param.Ident.Name
+		}
+		fn.params = append(fn.params, p)
+	}
+	return fn
+}
+
+// paramWhat describes the i-th parameter in diagnostics. Destructured
+// parameters have no name, so they are described by position like arguments.
+func paramWhat(i int, param *ast.FuncParam) string {
+	if param.Ident == nil {
+		return fmt.Sprintf("argument %d", i+1)
+	}
+	return "parameter " + param.Ident.Name
+}
+
+// checkAssignable reports expr if its type can't be used as the type want.
+func (v *Validator) checkAssignable(scope *Scope, expr ast.Expr, want Type, what string) {
+	if want == nil || expr == nil {
+		return
+	}
+	if got := v.typeOf(scope, expr); !assignable(got, want) {
+		v.Report(protocol.DiagnosticSeverityError, expr, "cannot use %s as %s in %s", got, want, what)
+	}
+}
+
+// checkArgs checks the arguments of a call to a function with a known
+// signature. Arguments are passed to the parameters that are not named, in
+// order.
+func (v *Validator) checkArgs(scope *Scope, fn *funcType, args []ast.Expr) {
+	var positional []*paramType
+	for _, param := range fn.params {
+		if !param.named {
+			positional = append(positional, param)
+		}
+	}
+	for i, arg := range args {
+		if i >= len(positional) {
+			break
+		}
+		if _, ok := arg.(*ast.EllipsisExpr); ok {
+			// spread arguments fill an unknown number of parameters
+			break
+		}
+		what := fmt.Sprintf("argument %d", i+1)
+		if name := positional[i].name; name != "" {
+			what = "parameter " + name
+		}
+		v.checkAssignable(scope, arg, positional[i].typ, what)
+		v.checkArity(positional[i].pattern, arg)
+	}
+}
+
+// checkResult checks a returned expression against the result annotation of
+// the function being checked, if there is one. A nil expr returns null.
+func (v *Validator) checkResult(scope *Scope, node ast.Node, expr ast.Expr) {
+	if len(v.results) == 0 {
+		return
+	}
+	want := v.results[len(v.results)-1]
+	if want == nil {
+		return
+	}
+	if expr != nil {
+		v.checkAssignable(scope, expr, want, "return value")
+	} else if !assignable(nullType, want) {
+		v.Report(protocol.DiagnosticSeverityError, node, "cannot use null as %s in return value", want)
+	}
+}
+
+// typeOf returns the static type of expr, or nil if it is unknown. expr must
+// already have been checked.
+func (v *Validator) typeOf(scope *Scope, expr ast.Expr) Type {
+	switch expr := expr.(type) {
+	case *ast.Literal:
+		switch expr.Kind {
+		case token.Int:
+			return intType
+		case token.Float:
+			return floatType
+		case token.Null:
+			return nullType
+		case token.True, token.False:
+			return boolType
+		}
+	case *ast.StringLiteral:
+		return stringType
+	case *ast.ParenExpr:
+		return v.typeOf(scope, expr.X)
+	case *ast.FrozenExpr:
+		return v.typeOf(scope, expr.X)
+	case *ast.MacroCallExpr:
+		if expr.Expansion != nil {
+			return v.typeOf(scope, expr.Expansion)
+		}
+	case *ast.ArrayLiteral:
+		if len(expr.List) == 0 {
+			return &arrayType{elem: neverType}
+		}
+		elems := make([]Type, len(expr.List))
+		for i, elem := range expr.List {
+			elems[i] = v.typeOf(scope, elem)
+		}
+		return &arrayType{elem: union(elems...)}
+	case *ast.CompositeLiteral:
+		obj := &objectType{fields: map[string]Type{}}
+		for _, field := range expr.Fields {
+			var name string
+			switch key := field.Key.(type) {
+			case *ast.StringLiteral:
+				if len(key.Parts) != 0 {
+					// interpolated keys could name any field
+					return nil
+				}
+				name = key.StringStart.Content + key.StringEnd.Content
+			default:
+				// computed keys could name any field
+				return nil
+			}
+			obj.names = append(obj.names, name)
+			obj.fields[name] = v.typeOf(scope, field.Value)
+		}
+		return obj
+	case *ast.Ident:
+		variable := scope.Get(expr.Name)
+		if variable == nil {
+			return nil
+		}
+		if t, ok := v.varTypes[variable]; ok {
+			return t
+		}
+		if fn, ok := variable.Value.(*Func); ok && fn != nil {
+			if t, ok := v.funcTypes[fn]; ok {
+				return t
+			}
+		}
+	case *ast.FuncExpr:
+		if t, ok := v.signatures[expr]; ok {
+			return t
+		}
+	case *ast.CallExpr:
+		if fn, ok := v.typeOf(scope, expr.Func).(*funcType); ok {
+			return fn.result
+		}
+	case *ast.IfExpr:
+		if expr.Else == nil {
+			return nil
+		}
+		return union(v.typeOf(scope, expr.Then), v.typeOf(scope, expr.Else))
+	case *ast.UnaryExpr:
+		x := v.typeOf(scope, expr.X)
+		switch expr.Op {
+		case token.LogNot:
+			return boolType
+		case token.Add, token.Sub:
+			if x == intType || x == floatType {
+				return x
+			}
+		}
+	case *ast.BinaryExpr:
+		return v.binaryType(scope, expr)
+	}
+	return nil
+}
+
+func (v *Validator) binaryType(scope *Scope, expr *ast.BinaryExpr) Type {
+	switch expr.Op {
+	case token.Eq, token.Neq, token.Lt, token.Lte, token.Gt, token.Gte:
+		return boolType
+	}
+
+	x, y := v.typeOf(scope, expr.X), v.typeOf(scope, expr.Y)
+	numeric := func(t Type) bool { return t == intType || t == floatType }
+	switch expr.Op {
+	case token.Spaceship:
+		if !numeric(x) || !numeric(y) {
+			return nil
+		}
+		return intType
+	case token.Add:
+		if x == stringType && y == stringType {
+			return stringType
+		}
+		fallthrough
+	case token.Sub, token.Mul, token.Rem, token.Pow:
+		if !numeric(x) || !numeric(y) {
+			return nil
+		}
+		if x == floatType || y == floatType {
+			return floatType
+		}
+		return intType
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package validator_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+)
+
+func TestTypeChecking(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `type Point = { x: int, y: int }
+struct Node<T>(value: T, next: Node<T> | null = null)
+fn sum(a: int, b: int): int -> a + b
+fn name(): string
+  return 5
+end
+fn id<T>(x: T): T -> x
+const three = 3
+
+let p: Point = { x: 1, y: "2" }
+let q: Point = { x: 1 }
+let ok: Point = { x: 1, y: 2, z: 3 }
+let s: string = sum(1, 2)
+let n: int | null = null
+n = "x"
+let w: Wat = 1
+let notType: sum = 1
+let f: float = 1.5 * 2
+let arr: int[] = [1, 2.5]
+let c: string = three
+let node: Node<int> = Node(1, 2)
+let g: fn(int) -> int = sum
+g("a")
+let x: string = id(1)
+let untyped = 1
+let y: string = untyped
+println(sum(1, "x"))
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"5:10: cannot use int as string in return value",
+		"10:16: cannot use { x: int, y: string } as { x: int, y: int } in declaration of p",
+		"11:16: cannot use { x: int } as { x: int, y: int } in declaration of q",
+		"13:17: cannot use int as string in declaration of s",
+		"15:5: cannot use string as int | null in assignment to n",
+		"16:8: unknown type Wat",
+		"17:14: sum is not a type",
+		"19:18: cannot use (int | float)[] as int[] in declaration of arr",
+		"20:17: cannot use int as string in declaration of c",
+		"21:31: cannot use int as Node | null in parameter next",
+		"23:3: cannot use string as int in argument 1",
+		"27:16: cannot use string as int in parameter b",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -28,7 +28,37 @@ type Validator struct {
 	stdout      io.Writer
 	stderr      io.Writer
 	gooseRoot   string
+	readFile    func(path string) ([]byte, error)
 	diagnostics []*Diagnostic
+	scopes      []scopeRange
+	instances   map[*Composite]*Composite
+
+	// declarations, see declarations.go
+	varDecls   map[*Variable]*Declaration
+	funcDecls  map[*Func]*Declaration
+	fieldDecls map[*Composite]map[string]*Declaration
+	refs       map[*ast.Ident]*Declaration
+	declList   []*Declaration
+
+	// static types, see types.go
+	typeAliases map[*Composite]Type
+	varTypes    map[*Variable]Type
+	funcTypes   map[*Func]*funcType
+	signatures  map[*ast.FuncExpr]*funcType
+	typeParams  []map[string]bool
+	results     []Type
+
+	// loop scopes of labeled loops
+	labels map[*Scope]*ast.Ident
+
+	// traits and the impls to check at the end of their module, see traits.go
+	traits map[*Trait]*ast.TraitStmt
+	impls  []*traitImpl
+
+	// variants by their prototype, and the variants of each enum by the
+	// prototype they share, see enums.go
+	variants map[*Composite]*VariantKind
+	enums    map[*Composite][]*VariantKind
 
 	// internal state
 	trace    bool
@@ -37,13 +67,37 @@ type Validator struct {
 	stack    []ast.Node
 }
 
+// scopeRange records the scope in effect within a node, so that tools can
+// find the names visible at a position after checking.
+type scopeRange struct {
+	node  ast.Node
+	scope *Scope
+}
+
 type Diagnostic struct {
 	Module   *Module
 	Node     ast.Node
 	Severity protocol.DiagnosticSeverity
 	Message  string
+
+	// Problem and Related describe the diagnostics that tools know how to
+	// fix. Related is the declaration the problem is about, if it is known.
+	Problem Problem
+	Related *Declaration
 }
 
+// Problem is the kind of a diagnostic that can be fixed automatically.
+type Problem int
+
+const (
+	ProblemNone               Problem = iota
+	ProblemUndefined                  // a name is not defined
+	ProblemAlreadyDefined             // an import binds a name that is already defined
+	ProblemUndefinedExport            // a shown name is not exported by its module
+	ProblemUnexported                 // a shown name is defined in its module but not exported
+	ProblemDuplicateParameter         // a parameter has the name of an earlier one
+)
+
 func (v *Validator) Fset() *token.FileSet        { return v.fset }
 func (v *Validator) ImportStack() []*Module      { return v.moduleStack }
 func (v *Validator) Diagnostics() []*Diagnostic  { return v.diagnostics }
@@ -54,6 +108,56 @@ func (v *Validator) Stdout() io.Writer           { return v.stdout }
 func (v *Validator) Stderr() io.Writer           { return v.stderr }
 func (v *Validator) GooseRoot() string           { return v.gooseRoot }
 
+// SetReadFile replaces the function that reads the files of imported
+// modules, so that tools can check them with text that is not saved yet.
+func (v *Validator) SetReadFile(readFile func(path string) ([]byte, error)) {
+	v.readFile = readFile
+}
+
+// ScopeAt returns the innermost scope containing pos, or the scope of the
+// module being checked if pos is not inside any recorded node.
+func (v *Validator) ScopeAt(pos token.Pos) *Scope {
+	var best *scopeRange
+	for i := range v.scopes {
+		r := &v.scopes[i]
+		if pos < r.node.Pos() || pos > r.node.End() {
+			continue
+		}
+		if best == nil || r.node.End()-r.node.Pos() <= best.node.End()-best.node.Pos() {
+			best = r
+		}
+	}
+	if best != nil {
+		return best.scope
+	}
+	for _, m := range v.modules {
+		if m.Scope != nil && len(m.Stmts) > 0 && v.fset.Position(m.Pos()).Filename == v.fset.Position(pos).Filename {
+			return m.Scope
+		}
+	}
+	return v.global
+}
+
+// record marks scope as the scope in effect within node and returns it.
+func (v *Validator) record(node ast.Node, scope *Scope) *Scope {
+	if node != nil && node.Pos().IsValid() && node.End().IsValid() {
+		v.scopes = append(v.scopes, scopeRange{node: node, scope: scope})
+	}
+	return scope
+}
+
+// bodyRange returns a node spanning a list of statements, or nil if the list
+// is empty.
+func bodyRange(body []ast.Stmt, end token.Pos) ast.Node {
+	if len(body) == 0 {
+		return nil
+	}
+	if !end.IsValid() {
+		end = body[len(body)-1].End()
+	}
+	return &ast.PosRange{From: body[0].Pos(), To: end}
+}
+
 func (v *Validator) CurrentModule() *Module {
 	if len(v.moduleStack) == 0 {
 		if len(v.modules) == 1 {
@@ -68,13 +172,17 @@ func (v *Validator) CurrentModule() *Module {
 	return v.moduleStack[len(v.moduleStack)-1]
 }
 
-func (v *Validator) Report(severity protocol.DiagnosticSeverity, node ast.Node, message string, parts ...any) {
-	v.diagnostics = append(v.diagnostics, &Diagnostic{
+// Report records a diagnostic about node and returns it, so that the kind of
+// problem can be filled in.
+func (v *Validator) Report(severity protocol.DiagnosticSeverity, node ast.Node, message string, parts ...any) *Diagnostic {
+	d := &Diagnostic{
 		Module:   v.CurrentModule(),
 		Node:     node,
 		Severity: severity,
 		Message:  fmt.Sprintf(message, parts...),
-	})
+	}
+	v.diagnostics = append(v.diagnostics, d)
+	return d
 }
 
 func (v *Validator) Throw(msg string, parts ...any) {
@@ -102,7 +210,21 @@ func pop(v *Validator) {
 
 func New(file *ast.Module, fset *token.FileSet, trace bool, stdin io.Reader, stdout io.Writer, stderr io.Writer) (i *Validator, err error) {
 	i = &Validator{
-		modules:     make(map[string]*Module),
+		modules:   make(map[string]*Module),
+		instances: make(map[*Composite]*Composite),
+
+		varDecls:    make(map[*Variable]*Declaration),
+		funcDecls:   make(map[*Func]*Declaration),
+		fieldDecls:  make(map[*Composite]map[string]*Declaration),
+		refs:        make(map[*ast.Ident]*Declaration),
+		typeAliases: make(map[*Composite]Type),
+		varTypes:    make(map[*Variable]Type),
+		funcTypes:   make(map[*Func]*funcType),
+		signatures:  make(map[*ast.FuncExpr]*funcType),
+		labels:      make(map[*Scope]*ast.Ident),
+		traits:      make(map[*Trait]*ast.TraitStmt),
+		variants:    make(map[*Composite]*VariantKind),
+		enums:       make(map[*Composite][]*VariantKind),
 		global:      NewGlobalScope(interpreter.GlobalConstants),
 		trace:       trace,
 		fset:        fset,
@@ -110,6 +232,7 @@ func New(file *ast.Module, fset *token.FileSet, trace bool, stdin io.Reader, std
 		stdout:      stdout,
 		stderr:      stderr,
 		gooseRoot:   os.Getenv("GOOSEROOT"),
+		readFile:    os.ReadFile,
 		moduleStack: make([]*Module, 0, 10),
 	}
 
@@ -185,6 +308,9 @@ func (v *Validator) checkBuiltins() {
 func (v *Validator) checkModule(module *Module) {
 	defer pop(push(v, module.Module))
 	v.moduleStack = append(v.moduleStack, module)
+	v.record(module.Module, module.Scope)
+	v.expandMacros(module)
+	impls := len(v.impls)
 
 	for _, stmt := range module.Stmts {
 		result := v.checkStmt(module.Scope, stmt)
@@ -198,6 +324,12 @@ func (v *Validator) checkModule(module *Module) {
 		}
 	}
 
+	// receiver functions may be declared after the impl, anywhere in the module
+	for _, impl := range v.impls[impls:] {
+		v.checkImpl(impl)
+	}
+	v.impls = v.impls[:impls]
+
 	v.moduleStack = v.moduleStack[:len(v.moduleStack)-1]
 }
 
@@ -218,6 +350,10 @@ func (v *Validator) checkStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 		return v.checkImportStmt(scope, stmt)
 	case *ast.ExportDeclStmt:
 		return v.checkExportDeclStmt(scope, stmt)
+	case *ast.ExportListStmt:
+		return v.checkExportListStmt(scope, stmt)
+	case *ast.ExportSpecStmt:
+		return v.checkExportSpecStmt(scope, stmt)
 	case *ast.ConstStmt:
 		return v.checkConstStmt(scope, stmt)
 	case *ast.LetStmt:
@@ -234,18 +370,33 @@ func (v *Validator) checkStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 		return v.checkReturnStmt(scope, stmt)
 	case *ast.IfStmt:
 		return v.checkIfStmt(scope, stmt)
+	case *ast.LabeledStmt:
+		return v.checkLabeledStmt(scope, stmt)
 	case *ast.ForStmt:
-		return v.checkForStmt(scope, stmt)
+		return v.checkForStmt(scope, stmt, nil)
 	case *ast.RepeatForeverStmt:
-		return v.checkRepeatForeverStmt(scope, stmt)
+		return v.checkRepeatForeverStmt(scope, stmt, nil)
 	case *ast.RepeatWhileStmt:
-		return v.checkRepeatWhileStmt(scope, stmt)
+		return v.checkRepeatWhileStmt(scope, stmt, nil)
 	case *ast.RepeatCountStmt:
-		return v.checkRepeatCountStmt(scope, stmt)
+		return v.checkRepeatCountStmt(scope, stmt, nil)
 	case *ast.IncDecStmt:
 		return v.checkIncDecStmt(scope, stmt)
 	case *ast.OperatorStmt:
 		return v.checkOperatorStmt(scope, stmt)
+	case *ast.TraitStmt:
+		return v.checkTraitStmt(scope, stmt)
+	case *ast.ImplStmt:
+		return v.checkImplStmt(scope, stmt)
+	case *ast.EnumStmt:
+		return v.checkEnumStmt(scope, stmt)
+	case *ast.TypeStmt:
+		return v.checkTypeStmt(scope, stmt)
+	case *ast.MacroStmt:
+		// macros are expanded before the module is checked
+		return &Void{}
+	case *ast.ProcMacroStmt:
+		return v.checkProcMacroStmt(scope, stmt)
 	default:
 		fmt.Fprintf(os.Stderr, "unhandled statement type: %T\n", stmt)
 		return &Void{}
@@ -255,47 +406,62 @@ func (v *Validator) checkStmt(scope *Scope, stmt ast.Stmt) StmtResult {
 func (v *Validator) checkOperatorStmt(scope *Scope, stmt *ast.OperatorStmt) StmtResult {
 	defer pop(push(v, stmt))
 
-	constructor := scope.Get(stmt.Receiver.Name)
-	if constructor == nil {
-		v.Report(protocol.DiagnosticSeverityError, stmt.Receiver, "undefined type %s", stmt.Receiver.Name)
-	} else if c, ok := constructor.Value.(*Func); !ok {
-		v.Report(protocol.DiagnosticSeverityError, stmt.Receiver, "value %s is not a type", stmt.Receiver.Name)
-	} else if c.NewableProto == nil {
-		v.Report(protocol.DiagnosticSeverityError, stmt.Receiver, "value %s is not a type", stmt.Receiver.Name)
-	} else {
-		proto := c.NewableProto
+	// default operators of traits have no receiver
+	if stmt.Receiver != nil {
+		constructor := scope.Get(stmt.Receiver.Name)
+		v.reference(stmt.Receiver, constructor)
+		if constructor == nil {
+			v.Report(protocol.DiagnosticSeverityError, stmt.Receiver, "undefined type %s", stmt.Receiver.Name)
+		} else if c, ok := constructor.Value.(*Func); !ok {
+			v.Report(protocol.DiagnosticSeverityError, stmt.Receiver, "value %s is not a type", stmt.Receiver.Name)
+		} else if c.NewableProto == nil {
+			v.Report(protocol.DiagnosticSeverityError, stmt.Receiver, "value %s is not a type", stmt.Receiver.Name)
+		} else {
+			proto := c.NewableProto
 
-		if proto.Operators[stmt.Tok] != nil {
-			r := &ast.PosRange{From: stmt.TokPos, To: stmt.TokPos + token.Pos(len(stmt.Tok.String()))}
-			v.Report(protocol.DiagnosticSeverityError, r, "duplicate operator %s", stmt.Tok)
-		}
+			if proto.Operators[stmt.Tok] != nil {
+				r := &ast.PosRange{From: stmt.TokPos, To: stmt.TokPos + token.Pos(len(stmt.Tok.String()))}
+				v.Report(protocol.DiagnosticSeverityError, r, "duplicate operator %s", stmt.Tok)
+			}
 
-		proto.Operators[stmt.Tok] = &OperatorFunc{
-			Async:   stmt.Async.IsValid(),
-			Builtin: false,
+			proto.Operators[stmt.Tok] = &OperatorFunc{
+				Async:   stmt.Async.IsValid(),
+				Builtin: false,
+			}
 		}
 	}
 
+	v.pushTypeParams(stmt.ReceiverTypeParams)
+	defer v.popTypeParams()
+	sig := v.signature(scope, stmt.Params.List, stmt.Result)
+
 	// validate parameters
 	paramNames := map[string]bool{}
-	for _, param := range stmt.Params.List {
-		if paramNames[param.Ident.Name] {
-			v.Report(protocol.DiagnosticSeverityError, param.Ident, "duplicate parameter %s", param.Ident.Name)
+	for i, param := range stmt.Params.List {
+		for _, ident := range param.Bindings() {
+			if paramNames[ident.Name] {
+				v.Report(protocol.DiagnosticSeverityError, ident, "duplicate parameter %s", ident.Name).Problem = ProblemDuplicateParameter
+			}
+			paramNames[ident.Name] = true
 		}
-		paramNames[param.Ident.Name] = true
 		if param.Value != nil {
 			v.checkExpr(scope, param.Value)
+			v.checkAssignable(scope, param.Value, sig.params[i].typ, paramWhat(i, param))
 		}
 	}
 
 	closure := scope.Fork(ScopeOwnerClosure)
-	funcScope := closure.Fork(ScopeOwnerFunc)
+	funcScope := v.record(stmt, closure.Fork(ScopeOwnerFunc))
 
 	// set parameters in scope
-	for _, param := range stmt.Params.List {
-		funcScope.Set(param.Ident.Name, &Variable{
-			Constant: false,
-		})
+	for i, param := range stmt.Params.List {
+		if param.Pattern != nil {
+			v.checkArity(param.Pattern, param.Value)
+			v.declarePattern(funcScope, param.Pattern, param, DeclParameter, false)
+			continue
+		}
+		v.setParam(funcScope, param.Ident.Name, sig.params[i].typ)
+		v.declare(funcScope, param.Ident, param, DeclParameter, nil)
 	}
 
 	// TODO: better this
@@ -303,8 +469,12 @@ func (v *Validator) checkOperatorStmt(scope *Scope, stmt *ast.OperatorStmt) Stmt
 		Constant: true,
 	})
 
+	v.results = append(v.results, sig.result)
+	defer func() { v.results = v.results[:len(v.results)-1] }()
+
 	if stmt.Arrow.IsValid() {
 		v.checkExpr(funcScope, stmt.ArrowExpr)
+		v.checkResult(funcScope, stmt.ArrowExpr, stmt.ArrowExpr)
 	}
 
 	v.checkStmts(funcScope, stmt.Body)
@@ -320,27 +490,78 @@ func (v *Validator) checkIncDecStmt(scope *Scope, stmt *ast.IncDecStmt) StmtResu
 	return &Void{}
 }
 
-func (v *Validator) checkRepeatForeverStmt(scope *Scope, stmt *ast.RepeatForeverStmt) StmtResult {
+func (v *Validator) checkLabeledStmt(scope *Scope, stmt *ast.LabeledStmt) StmtResult {
+	defer pop(push(v, stmt))
+
+	if v.findLoop(scope, stmt.Label) != nil {
+		v.Report(protocol.DiagnosticSeverityError, stmt.Label, "label %s already defined", stmt.Label.Name)
+	}
+
+	switch loop := stmt.Stmt.(type) {
+	case *ast.ForStmt:
+		return v.checkForStmt(scope, loop, stmt.Label)
+	case *ast.RepeatForeverStmt:
+		return v.checkRepeatForeverStmt(scope, loop, stmt.Label)
+	case *ast.RepeatWhileStmt:
+		return v.checkRepeatWhileStmt(scope, loop, stmt.Label)
+	case *ast.RepeatCountStmt:
+		return v.checkRepeatCountStmt(scope, loop, stmt.Label)
+	default:
+		v.Report(protocol.DiagnosticSeverityError, stmt.Label, "label %s must be attached to a loop", stmt.Label.Name)
+		return v.checkStmt(scope, stmt.Stmt)
+	}
+}
+
+// loopScope forks the scope for the body of a loop, remembering its label.
+func (v *Validator) loopScope(scope *Scope, stmt ast.Stmt, owner ScopeOwner, label *ast.Ident) *Scope {
+	loopScope := v.record(stmt, scope.Fork(owner))
+	if label != nil {
+		v.labels[loopScope] = label
+	}
+	return loopScope
+}
+
+// findLoop returns the scope of the innermost loop around scope, or of the
+// loop with the given label if label is not nil. Branches can't leave
+// functions or struct initializers, so the search stops there.
+func (v *Validator) findLoop(scope *Scope, label *ast.Ident) *Scope {
+	for ; scope != nil; scope = scope.Parent() {
+		switch scope.Owner() {
+		case ScopeOwnerFunc, ScopeOwnerStruct:
+			return nil
+		case ScopeOwnerFor, ScopeOwnerRepeat:
+			if label == nil {
+				return scope
+			}
+			if l := v.labels[scope]; l != nil && l.Name == label.Name {
+				return scope
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Validator) checkRepeatForeverStmt(scope *Scope, stmt *ast.RepeatForeverStmt, label *ast.Ident) StmtResult {
 	defer pop(push(v, stmt))
 
-	loopScope := scope.Fork(ScopeOwnerRepeat)
+	loopScope := v.loopScope(scope, stmt, ScopeOwnerRepeat, label)
 	v.checkStmts(loopScope, stmt.Body)
 	return &Void{}
 }
 
-func (v *Validator) checkRepeatWhileStmt(scope *Scope, stmt *ast.RepeatWhileStmt) StmtResult {
+func (v *Validator) checkRepeatWhileStmt(scope *Scope, stmt *ast.RepeatWhileStmt, label *ast.Ident) StmtResult {
 	defer pop(push(v, stmt))
 
-	loopScope := scope.Fork(ScopeOwnerRepeat)
+	loopScope := v.loopScope(scope, stmt, ScopeOwnerRepeat, label)
 	v.checkExpr(loopScope, stmt.Cond)
 	v.checkStmts(loopScope, stmt.Body)
 	return &Void{}
 }
 
-func (v *Validator) checkRepeatCountStmt(scope *Scope, stmt *ast.RepeatCountStmt) StmtResult {
+func (v *Validator) checkRepeatCountStmt(scope *Scope, stmt *ast.RepeatCountStmt, label *ast.Ident) StmtResult {
 	defer pop(push(v, stmt))
 
-	loopScope := scope.Fork(ScopeOwnerRepeat)
+	loopScope := v.loopScope(scope, stmt, ScopeOwnerRepeat, label)
 	v.checkExpr(loopScope, stmt.Count)
 	v.checkStmts(loopScope, stmt.Body)
 	return &Void{}
@@ -362,6 +583,9 @@ func (v *Validator) checkReturnStmt(scope *Scope, stmt *ast.ReturnStmt) StmtResu
 	}
 
 	v.checkExpr(scope, stmt.Result)
+	if funcScope != nil {
+		v.checkResult(scope, stmt, stmt.Result)
+	}
 
 	return &Return{}
 }
@@ -371,11 +595,11 @@ func (v *Validator) checkIfStmt(scope *Scope, stmt *ast.IfStmt) StmtResult {
 
 	v.checkExpr(scope, stmt.Cond)
 
-	bodyScope := scope.Fork(ScopeOwnerIf)
+	bodyScope := v.record(stmt, scope.Fork(ScopeOwnerIf))
 	v.checkStmts(bodyScope, stmt.Body)
 
 	if stmt.Else != nil {
-		elseScope := scope.Fork(ScopeOwnerIf)
+		elseScope := v.record(bodyRange(stmt.Else, stmt.End()), scope.Fork(ScopeOwnerIf))
 		v.checkStmts(elseScope, stmt.Else)
 	}
 
@@ -385,18 +609,14 @@ func (v *Validator) checkIfStmt(scope *Scope, stmt *ast.IfStmt) StmtResult {
 func (v *Validator) checkBranchStmt(scope *Scope, stmt *ast.BranchStmt) StmtResult {
 	defer pop(push(v, stmt))
 
-	// find the nearest loop scope
-	var loopScope *Scope
-	for loopScope = scope; loopScope != nil; loopScope = loopScope.Parent() {
-		if loopScope.Owner() == ScopeOwnerFor || loopScope.Owner() == ScopeOwnerRepeat {
-			break
+	if v.findLoop(scope, stmt.Label) == nil {
+		if stmt.Label != nil {
+			v.Report(protocol.DiagnosticSeverityError, stmt.Label, "unknown label %s", stmt.Label.Name)
+		} else {
+			v.Report(protocol.DiagnosticSeverityError, stmt, "break/continue must be inside a loop")
 		}
 	}
 
-	if loopScope == nil {
-		v.Report(protocol.DiagnosticSeverityError, stmt, "break/continue must be inside a loop")
-	}
-
 	return &Break{}
 }
 
@@ -417,15 +637,38 @@ func (v *Validator) checkStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResu
 		fieldNames[param.Ident.Name] = true
 	}
 
+	proto := NewComposite()
+	proto.Name = stmt.Name.Name
+	value := &Func{
+		NewableProto: proto,
+	}
+	decl := v.declare(nil, stmt.Name, stmt, DeclStruct, value)
+
+	// the constructor takes the fields in order; their types can refer to the
+	// struct itself
+	v.pushTypeParams(stmt.TypeParams)
+	defer v.popTypeParams()
+	self := scope.Fork(ScopeOwnerClosure)
+	self.Set(stmt.Name.Name, &Variable{
+		Constant: true,
+		Value:    value,
+	})
+	sig := &funcType{params: []*paramType{}, result: &structType{proto: proto}}
 	for _, field := range stmt.Fields.List {
+		sig.params = append(sig.params, &paramType{
+			name: field.Ident.Name,
+			typ:  v.resolveType(self, field.Type),
+		})
+	}
+	v.funcTypes[value] = sig
+
+	for i, field := range stmt.Fields.List {
 		if field.Value != nil {
 			v.checkExpr(scope, field.Value)
+			v.checkAssignable(scope, field.Value, sig.params[i].typ, "field "+field.Ident.Name)
 		}
 	}
 
-	proto := NewComposite()
-	proto.Name = stmt.Name.Name
-
 	closure := scope.Fork(ScopeOwnerClosure)
 
 	// create new composite for testing
@@ -434,25 +677,22 @@ func (v *Validator) checkStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResu
 		Properties: make(Properties),
 		Operators:  make(Operators),
 	}
+	obj.Properties[PKString] = make(map[string]Value)
+	fields := make(map[string]*Declaration)
+	for _, param := range stmt.Fields.List {
+		obj.Properties[PKString][param.Ident.Name] = NullValue
+		fields[param.Ident.Name] = v.declare(nil, param.Ident, param, DeclField, nil)
+	}
+	v.instances[proto] = obj
+	v.fieldDecls[proto] = fields
 
 	if stmt.Init != nil {
-		newScope := closure.Fork(ScopeOwnerStruct)
+		newScope := v.record(stmt.Init, closure.Fork(ScopeOwnerStruct))
 
 		// set parameters in scope
-		for _, param := range stmt.Fields.List {
-			if stmt.Init != nil {
-				newScope.Set(param.Ident.Name, &Variable{
-					Constant: false,
-				})
-			}
-
-			if set, ok := obj.Properties[PKString]; ok {
-				set[param.Ident.Name] = NullValue
-			} else {
-				obj.Properties[PKString] = map[string]Value{
-					param.Ident.Name: NullValue,
-				}
-			}
+		for i, param := range stmt.Fields.List {
+			variable := v.setParam(newScope, param.Ident.Name, sig.params[i].typ)
+			v.varDecls[variable] = fields[param.Ident.Name]
 		}
 
 		// set this
@@ -462,17 +702,18 @@ func (v *Validator) checkStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResu
 			Value:    obj,
 		})
 
+		// returns in the init block don't return from an enclosing function
+		v.results = append(v.results, nil)
 		v.checkStmts(newScope, stmt.Init.Body)
+		v.results = v.results[:len(v.results)-1]
 	}
 
-	value := &Func{
-		NewableProto: proto,
-	}
-
-	scope.Set(stmt.Name.Name, &Variable{
+	variable := &Variable{
 		Constant: false,
 		Value:    value,
-	})
+	}
+	scope.Set(stmt.Name.Name, variable)
+	v.varDecls[variable] = decl
 
 	return &Decl{
 		Name:  stmt.Name.Name,
@@ -480,16 +721,27 @@ func (v *Validator) checkStructStmt(scope *Scope, stmt *ast.StructStmt) StmtResu
 	}
 }
 
-func (v *Validator) checkForStmt(scope *Scope, stmt *ast.ForStmt) StmtResult {
+func (v *Validator) checkForStmt(scope *Scope, stmt *ast.ForStmt, label *ast.Ident) StmtResult {
 	defer pop(push(v, stmt))
 
 	v.checkExpr(scope, stmt.Iterable)
 
-	loopScope := scope.Fork(ScopeOwnerFor)
+	loopScope := v.loopScope(scope, stmt, ScopeOwnerFor, label)
 
-	loopScope.Set(stmt.Var.Name, &Variable{
-		Constant: false,
-	})
+	if stmt.Pattern != nil {
+		v.checkBindings(stmt.Pattern)
+		if arr, ok := stmt.Iterable.(*ast.ArrayLiteral); ok {
+			for _, elem := range arr.List {
+				v.checkArity(stmt.Pattern, elem)
+			}
+		}
+		v.declarePattern(loopScope, stmt.Pattern, stmt, DeclVariable, false)
+	} else {
+		loopScope.Set(stmt.Var.Name, &Variable{
+			Constant: false,
+		})
+		v.declare(loopScope, stmt.Var, stmt, DeclVariable, nil)
+	}
 
 	v.checkStmts(loopScope, stmt.Body)
 
@@ -521,11 +773,84 @@ func (v *Validator) checkExportDeclStmt(scope *Scope, stmt *ast.ExportDeclStmt)
 	} else if _, ok := scope.Module().Exports[decl.Name]; ok {
 		v.Report(protocol.DiagnosticSeverityError, stmt, "duplicate export %s", decl.Name)
 	} else {
-		scope.Module().Exports[decl.Name] = &Variable{
+		export := &Variable{
 			Source:   VariableSourceImport,
 			Value:    decl.Value,
 			Constant: true,
 		}
+		scope.Module().Exports[decl.Name] = export
+		if d, ok := v.varDecls[scope.Get(decl.Name)]; ok {
+			v.varDecls[export] = d
+		}
+	}
+
+	return &Void{}
+}
+
+func (v *Validator) checkExportListStmt(scope *Scope, stmt *ast.ExportListStmt) StmtResult {
+	defer pop(push(v, stmt))
+	if scope != scope.ModuleScope() {
+		v.Report(protocol.DiagnosticSeverityError, stmt, "export declarations must be at the top level")
+	}
+
+	for _, field := range stmt.List.Fields {
+		var local *ast.Ident
+		var exported *ast.Ident
+		switch field := field.(type) {
+		case *ast.ExportFieldIdent:
+			local = field.Ident
+			exported = field.Ident
+		case *ast.ExportFieldAs:
+			local = field.Ident
+			exported = field.Alias
+		default:
+			v.Throw("unhandled export field type: %T", field)
+		}
+
+		if _, ok := scope.Module().Exports[exported.Name]; ok {
+			v.Report(protocol.DiagnosticSeverityError, field, "duplicate export %s", exported.Name)
+			continue
+		}
+		if !scope.IsDefinedInCurrentScope(local.Name) {
+			v.Report(protocol.DiagnosticSeverityError, local, "undefined name %s", local.Name)
+			continue
+		}
+
+		variable := scope.Get(local.Name)
+		v.reference(local, variable)
+		export := &Variable{
+			Source:   VariableSourceImport,
+			Value:    variable.Value,
+			Constant: true,
+		}
+		scope.Module().Exports[exported.Name] = export
+		if exported == local {
+			if d, ok := v.varDecls[variable]; ok {
+				v.varDecls[export] = d
+			}
+		} else {
+			v.varDecls[export] = v.declare(nil, exported, field, v.aliasKind(variable), nil)
+		}
+	}
+
+	return &Void{}
+}
+
+func (v *Validator) checkExportSpecStmt(scope *Scope, stmt *ast.ExportSpecStmt) StmtResult {
+	defer pop(push(v, stmt))
+	if scope != scope.ModuleScope() {
+		v.Report(protocol.DiagnosticSeverityError, stmt, "export declarations must be at the top level")
+	}
+
+	importScope := scope.Fork(ScopeOwnerImport)
+	v.checkImportSpec(importScope, stmt.Spec)
+
+	for name, variable := range importScope.Idents() {
+		if _, ok := scope.Module().Exports[name]; ok {
+			v.Report(protocol.DiagnosticSeverityError, stmt, "duplicate export %s", name)
+			continue
+		}
+		scope.Module().Exports[name] = variable
 	}
 
 	return &Void{}
@@ -552,10 +877,11 @@ func (v *Validator) checkImportSpec(scope *Scope, spec ast.ModuleSpec) StmtResul
 		defer pop(push(v, spec.Show))
 		if spec.Show.Ellipsis.IsValid() {
 			for name, value := range module.Exports {
-				scope.Set(name, value)
 				if scope.IsDefinedInCurrentScope(name) {
 					v.Report(protocol.DiagnosticSeverityError, spec.Show, "name %s is already defined", name)
+					continue
 				}
+				scope.Set(name, value)
 			}
 		} else {
 			imported := make(map[string]bool)
@@ -603,15 +929,15 @@ func (v *Validator) checkImportSpec(scope *Scope, spec ast.ModuleSpec) StmtResul
 						Constant: true,
 					})
 				default:
-					var localName string
+					var local *ast.Ident
 					var exportedName string
 
 					switch field := field.(type) {
 					case *ast.ShowFieldIdent:
-						localName = field.Ident.Name
+						local = field.Ident
 						exportedName = field.Ident.Name
 					case *ast.ShowFieldAs:
-						localName = field.Alias.Name
+						local = field.Alias
 						exportedName = field.Ident.Name
 					default:
 						v.Throw("unhandled show field type: %T", field)
@@ -619,25 +945,60 @@ func (v *Validator) checkImportSpec(scope *Scope, spec ast.ModuleSpec) StmtResul
 
 					if _, ok := module.Exports[exportedName]; !ok {
 						if module.Scope.IsDefinedInCurrentScope(exportedName) {
-							v.Report(protocol.DiagnosticSeverityError, field, "value %s is defined locally in module %s but is not exported", exportedName, name)
+							d := v.Report(protocol.DiagnosticSeverityError, field, "value %s is defined locally in module %s but is not exported", exportedName, name)
+							d.Problem = ProblemUnexported
+							d.Related = v.variableDecl(module.Scope.Get(exportedName))
 						} else {
-							v.Report(protocol.DiagnosticSeverityError, field, "undefined export %s", exportedName)
+							v.Report(protocol.DiagnosticSeverityError, field, "undefined export %s", exportedName).Problem = ProblemUndefinedExport
 						}
 					}
 
 					imported[exportedName] = true
 
+					localName := local.Name
+					if scope.IsDefinedInCurrentScope(localName) {
+						d := v.Report(protocol.DiagnosticSeverityError, local, "name %s is already defined", localName)
+						d.Problem = ProblemAlreadyDefined
+						d.Related = v.variableDecl(scope.Get(localName))
+						continue
+					}
+
 					value := module.Exports[exportedName]
-					scope.Set(localName, value)
+
+					switch field := field.(type) {
+					case *ast.ShowFieldIdent:
+						scope.Set(localName, value)
+						v.reference(field.Ident, value)
+					case *ast.ShowFieldAs:
+						v.reference(field.Ident, value)
+						if value == nil {
+							scope.Set(localName, value)
+							break
+						}
+						// the alias is a separate binding, so that renaming the
+						// export doesn't rename its local uses
+						alias := &Variable{
+							Source:   VariableSourceImport,
+							Value:    value.Value,
+							Constant: true,
+						}
+						scope.Set(localName, alias)
+						v.varDecls[alias] = v.declare(nil, field.Alias, field, v.aliasKind(value), nil)
+					}
 				}
 			}
 		}
 	default:
 		object := NewComposite()
+		members := map[string]*Declaration{}
 		for name, value := range module.Exports {
 			SetProperty(object, NewString(name), value.Value) // TODO: reassignment can change the value
+			if d, ok := v.varDecls[value]; ok {
+				members[name] = d
+			}
 		}
 		object.Frozen = true
+		v.fieldDecls[object] = members
 
 		var moduleName string
 
@@ -652,13 +1013,24 @@ func (v *Validator) checkImportSpec(scope *Scope, spec ast.ModuleSpec) StmtResul
 		}
 
 		if scope.IsDefinedInCurrentScope(moduleName) {
-			v.Report(protocol.DiagnosticSeverityError, spec, "name %s is already defined", moduleName)
+			d := v.Report(protocol.DiagnosticSeverityError, spec, "name %s is already defined", moduleName)
+			d.Problem = ProblemAlreadyDefined
+			d.Related = v.variableDecl(scope.Get(moduleName))
+			return &Void{}
 		}
 
-		scope.Set(moduleName, &Variable{
+		variable := &Variable{
 			Constant: true,
 			Value:    object,
-		})
+		}
+		scope.Set(moduleName, variable)
+
+		var alias *ast.Ident
+		if aliased, ok := spec.(*ast.ModuleSpecAs); ok {
+			alias = aliased.Alias
+		}
+		v.varDecls[variable] = v.declare(nil, alias, spec, DeclModule, nil)
+		v.varDecls[variable].Name = moduleName
 	}
 
 	return &Void{}
@@ -700,11 +1072,53 @@ func (v *Validator) loadModule(specifier string, scope *Scope) (string, *Module)
 	case "std":
 		module = v.loadStdModule(scheme + ":" + name)
 	default:
-		v.Throw("unknown import scheme %s", scheme)
+		native := interpreter.LookupNativeModule(scheme + ":" + name)
+		if native == nil {
+			v.Throw("unknown import scheme %s (no native module %s is registered)", scheme, scheme+":"+name)
+			return scheme + ":" + name, nil
+		}
+		module = v.loadNativeModule(native)
 	}
 	return scheme + ":" + name, module
 }
 
+func (v *Validator) loadNativeModule(native *interpreter.NativeModule) *Module {
+	if module, ok := v.modules[native.Specifier]; ok {
+		return module
+	}
+
+	file, err := parser.ParseFile(v.fset, native.Specifier, native.Source, nil)
+	if err != nil {
+		v.Throw(err.Error())
+		return nil
+	}
+
+	module := &Module{
+		Module:  file,
+		Exports: make(map[string]*Variable),
+		Scope:   v.global.Fork(ScopeOwnerModule),
+	}
+
+	module.Scope.SetModule(module)
+	v.modules[native.Specifier] = module
+	v.checkModule(module)
+
+	return module
+}
+
+// nativesFor returns the natives of a std module or a globally registered
+// native module. Modules registered on a single interpreter are not visible to
+// the validator.
+func nativesFor(specifier string) (map[string]Value, bool) {
+	if natives, ok := interpreter.Natives[specifier]; ok {
+		return natives, true
+	}
+	if module := interpreter.LookupNativeModule(specifier); module != nil {
+		return module.Natives, true
+	}
+	return nil, false
+}
+
 func (v *Validator) loadFileModule(specifier string, dir string, isPackage bool) *Module {
 	if module, ok := v.modules[specifier]; ok {
 		return module
@@ -756,7 +1170,7 @@ func (v *Validator) loadFileModule(specifier string, dir string, isPackage bool)
 		path = newPath
 	}
 
-	content, err := os.ReadFile(path)
+	content, err := v.readFile(path)
 	if err != nil {
 		v.Throw(err.Error())
 	}
@@ -895,6 +1309,8 @@ func (v *Validator) checkExpr(scope *Scope, expr ast.Expr) Value {
 		return v.checkRangeExpr(scope, expr)
 	case *ast.ArrayInitializer:
 		return v.checkArrayInitializer(scope, expr)
+	case *ast.MacroCallExpr:
+		return v.checkMacroCallExpr(scope, expr)
 	default:
 		fmt.Fprintf(os.Stderr, "unhandled expression type: %T\n", expr)
 		return nil
@@ -904,7 +1320,7 @@ func (v *Validator) checkExpr(scope *Scope, expr ast.Expr) Value {
 func (v *Validator) checkArrayInitializer(scope *Scope, expr *ast.ArrayInitializer) Value {
 	defer pop(push(v, expr))
 
-	newScope := scope.Fork(ScopeOwnerArrayInit)
+	newScope := v.record(expr.Value, scope.Fork(ScopeOwnerArrayInit))
 	newScope.Set("_", &Variable{
 		Constant: true,
 	})
@@ -931,7 +1347,7 @@ func (v *Validator) checkNativeExpr(scope *Scope, expr *ast.NativeExpr) Value {
 	defer pop(push(v, expr))
 
 	module := scope.Module()
-	natives, ok := interpreter.Natives[module.Specifier]
+	natives, ok := nativesFor(module.Specifier)
 	if !ok {
 		v.Report(protocol.DiagnosticSeverityError, expr, "module %s has no native components", module.Specifier)
 		return nil
@@ -949,7 +1365,7 @@ func (v *Validator) checkNativeExpr(scope *Scope, expr *ast.NativeExpr) Value {
 func (v *Validator) checkDoExpr(scope *Scope, expr *ast.DoExpr) Value {
 	defer pop(push(v, expr))
 
-	doScope := scope.Fork(ScopeOwnerDo)
+	doScope := v.record(expr, scope.Fork(ScopeOwnerDo))
 	v.checkStmts(doScope, expr.Body)
 
 	return nil
@@ -960,19 +1376,42 @@ func (v *Validator) checkMatchExpr(scope *Scope, expr *ast.MatchExpr) Value {
 
 	v.checkExpr(scope, expr.Expr)
 
+	// the enum matched over is the one whose variants the arms name
+	var enum *Composite
+	covered := map[*VariantKind]bool{}
+	exhaustive := false
+
 	for _, branch := range expr.Clauses {
 		switch branch := branch.(type) {
 		case *ast.MatchPattern:
-			// TODO: check pattern
-			// v.checkPatternExpr(scope, branch.Pattern)
-			v.checkExpr(scope, branch.Expr)
+			armScope := v.record(branch, scope.Fork(ScopeOwnerMatch))
+			v.checkBindings(branch.Pattern)
+			kind, total := v.checkPatternExpr(scope, branch.Pattern)
+			v.declarePattern(armScope, branch.Pattern, branch.Pattern, DeclVariable, false)
+			if kind == nil && total {
+				exhaustive = true
+			}
+			if kind != nil {
+				if enum == nil {
+					enum = kind.Proto.Proto
+				}
+				if total {
+					covered[kind] = true
+				}
+			}
+			v.checkExpr(armScope, branch.Expr)
 		case *ast.MatchElse:
+			exhaustive = true
 			v.checkExpr(scope, branch.Expr)
 		default:
 			v.Throw("unhandled match clause type: %T", branch)
 		}
 	}
 
+	if enum != nil && !exhaustive {
+		v.checkExhaustive(expr, enum, covered)
+	}
+
 	return nil
 
 }
@@ -1061,19 +1500,44 @@ func (v *Validator) checkIfExpr(scope *Scope, expr *ast.IfExpr) Value {
 func (v *Validator) checkSelectorExpr(scope *Scope, expr *ast.SelectorExpr) Value {
 	defer pop(push(v, expr))
 
-	v.checkExpr(scope, expr.X)
+	x := v.checkExpr(scope, expr.X)
+	v.referenceMember(expr.Sel, x, expr.Sel.Name)
+	if c, ok := x.(*Composite); ok && c != nil {
+		if value := GetProperty(c, NewString(expr.Sel.Name)); value != NullValue {
+			return value
+		}
+	}
 	return nil
 }
 
 func (v *Validator) checkCallExpr(scope *Scope, expr *ast.CallExpr) Value {
 	defer pop(push(v, expr))
 
-	v.checkExpr(scope, expr.Func)
+	fn := v.checkExpr(scope, expr.Func)
 	for _, arg := range expr.Args {
 		v.checkExpr(scope, arg)
 	}
+	if sig, ok := v.typeOf(scope, expr.Func).(*funcType); ok && sig.params != nil {
+		v.checkArgs(scope, sig, expr.Args)
+	}
 
-	return nil
+	return v.instanceOf(fn)
+}
+
+// instanceOf returns a placeholder instance for a struct constructor, so that
+// the fields and receiver functions of values created from it can be found.
+// It returns nil for anything else.
+func (v *Validator) instanceOf(constructor Value) Value {
+	fn, ok := constructor.(*Func)
+	if !ok || fn == nil || fn.NewableProto == nil {
+		return nil
+	}
+	if obj, ok := v.instances[fn.NewableProto]; ok {
+		return obj
+	}
+	obj := NewComposite()
+	obj.Proto = fn.NewableProto
+	return obj
 }
 
 func (v *Validator) checkBinaryExpr(scope *Scope, expr *ast.BinaryExpr) Value {
@@ -1082,7 +1546,7 @@ func (v *Validator) checkBinaryExpr(scope *Scope, expr *ast.BinaryExpr) Value {
 	v.checkExpr(scope, expr.X)
 
 	if expr.Op == token.Arrow {
-		rightScope := scope.Fork(ScopeOwnerPipeline)
+		rightScope := v.record(expr.Y, scope.Fork(ScopeOwnerPipeline))
 		rightScope.Set("_", &Variable{
 			Constant: true,
 		})
@@ -1176,12 +1640,18 @@ func (v *Validator) checkStringLiteral(scope *Scope, lit *ast.StringLiteral) Val
 func (v *Validator) checkIdent(scope *Scope, ident *ast.Ident) Value {
 	defer pop(push(v, ident))
 	if ident.Name[0] == '#' {
-		// ignore
+		if this := scope.Get("this"); this != nil {
+			v.referenceMember(ident, this.Value, ident.Name[1:])
+		}
 		return nil
 	}
 
 	if !scope.IsDefined(ident.Name) {
-		v.Report(protocol.DiagnosticSeverityError, ident, "%s is not defined", ident.Name)
+		v.Report(protocol.DiagnosticSeverityError, ident, "%s is not defined", ident.Name).Problem = ProblemUndefined
+	}
+	if variable := scope.Get(ident.Name); variable != nil {
+		v.reference(ident, variable)
+		return variable.Value
 	}
 	return nil
 }
@@ -1189,6 +1659,15 @@ func (v *Validator) checkIdent(scope *Scope, ident *ast.Ident) Value {
 func (v *Validator) checkConstStmt(scope *Scope, stmt *ast.ConstStmt) StmtResult {
 	defer pop(push(v, stmt))
 
+	if stmt.Pattern != nil {
+		v.checkExpr(scope, stmt.Value)
+		v.checkAssignable(scope, stmt.Value, v.resolveType(scope, stmt.Type), "destructuring declaration")
+		v.checkBindings(stmt.Pattern)
+		v.checkArity(stmt.Pattern, stmt.Value)
+		v.declarePattern(scope, stmt.Pattern, stmt, DeclConstant, true)
+		return &Void{}
+	}
+
 	if stmt.Ident.Name == "_" {
 		v.Report(protocol.DiagnosticSeverityError, stmt.Ident, "cannot declare _")
 	}
@@ -1199,10 +1678,22 @@ func (v *Validator) checkConstStmt(scope *Scope, stmt *ast.ConstStmt) StmtResult
 
 	value := v.checkExpr(scope, stmt.Value)
 
-	scope.Set(stmt.Ident.Name, &Variable{
+	typ := v.resolveType(scope, stmt.Type)
+	v.checkAssignable(scope, stmt.Value, typ, "declaration of "+stmt.Ident.Name)
+	if typ == nil {
+		// constants keep the type of their value
+		typ = v.typeOf(scope, stmt.Value)
+	}
+
+	variable := &Variable{
 		Constant: true,
 		Value:    value,
-	})
+	}
+	scope.Set(stmt.Ident.Name, variable)
+	if typ != nil {
+		v.varTypes[variable] = typ
+	}
+	v.declare(scope, stmt.Ident, stmt, DeclConstant, value)
 
 	return &Decl{
 		Name:  stmt.Ident.Name,
@@ -1213,6 +1704,15 @@ func (v *Validator) checkConstStmt(scope *Scope, stmt *ast.ConstStmt) StmtResult
 func (v *Validator) checkLetStmt(scope *Scope, stmt *ast.LetStmt) StmtResult {
 	defer pop(push(v, stmt))
 
+	if stmt.Pattern != nil {
+		v.checkExpr(scope, stmt.Value)
+		v.checkAssignable(scope, stmt.Value, v.resolveType(scope, stmt.Type), "destructuring declaration")
+		v.checkBindings(stmt.Pattern)
+		v.checkArity(stmt.Pattern, stmt.Value)
+		v.declarePattern(scope, stmt.Pattern, stmt, DeclVariable, false)
+		return &Void{}
+	}
+
 	if stmt.Ident.Name == "_" {
 		v.Report(protocol.DiagnosticSeverityError, stmt.Ident, "cannot declare _")
 	}
@@ -1223,18 +1723,25 @@ func (v *Validator) checkLetStmt(scope *Scope, stmt *ast.LetStmt) StmtResult {
 
 	var value Value
 
+	typ := v.resolveType(scope, stmt.Type)
 	if stmt.Value != nil {
 		value = v.checkExpr(scope, stmt.Value)
+		v.checkAssignable(scope, stmt.Value, typ, "declaration of "+stmt.Ident.Name)
 	}
 
 	if value == nil {
 		value = NullValue
 	}
 
-	scope.Set(stmt.Ident.Name, &Variable{
+	variable := &Variable{
 		Constant: false,
 		Value:    value,
-	})
+	}
+	scope.Set(stmt.Ident.Name, variable)
+	if typ != nil {
+		v.varTypes[variable] = typ
+	}
+	v.declare(scope, stmt.Ident, stmt, DeclVariable, value)
 
 	return &Decl{
 		Name:  stmt.Ident.Name,
@@ -1242,6 +1749,88 @@ func (v *Validator) checkLetStmt(scope *Scope, stmt *ast.LetStmt) StmtResult {
 	}
 }
 
+// checkBindings reports names bound more than once by a destructuring
+// pattern.
+func (v *Validator) checkBindings(pattern ast.PatternExpr) {
+	seen := map[string]bool{}
+	for _, ident := range ast.Bindings(pattern) {
+		if seen[ident.Name] {
+			v.Report(protocol.DiagnosticSeverityError, ident, "duplicate binding %s", ident.Name)
+		}
+		seen[ident.Name] = true
+	}
+}
+
+// checkArity reports array literals with a different number of elements than
+// the pattern destructuring them. Literals with spread elements have no length
+// known statically.
+func (v *Validator) checkArity(pattern ast.PatternExpr, x ast.Expr) {
+	for {
+		paren, ok := pattern.(*ast.PatternParen)
+		if !ok {
+			break
+		}
+		pattern = paren.X
+	}
+	tuple, ok := pattern.(*ast.PatternTuple)
+	if !ok {
+		return
+	}
+	arr, ok := x.(*ast.ArrayLiteral)
+	if !ok {
+		return
+	}
+	for _, elem := range arr.List {
+		if _, ok := elem.(*ast.EllipsisExpr); ok {
+			return
+		}
+	}
+
+	n, rest := tuple.Arity()
+	if len(arr.List) < n || !rest && len(arr.List) > n {
+		if rest {
+			v.Report(protocol.DiagnosticSeverityError, arr, "cannot destructure an array of length %d into a pattern of length at least %d", len(arr.List), n)
+		} else {
+			v.Report(protocol.DiagnosticSeverityError, arr, "cannot destructure an array of length %d into a pattern of length %d", len(arr.List), n)
+		}
+		return
+	}
+	for idx := 0; idx < n; idx++ {
+		v.checkArity(tuple.List[idx], arr.List[idx])
+	}
+}
+
+// declarePattern declares the names bound by a destructuring pattern. Their
+// types aren't tracked, so they are checked like unannotated variables.
+func (v *Validator) declarePattern(scope *Scope, pattern ast.PatternExpr, node ast.Node, kind DeclKind, constant bool) {
+	ast.Walk(pattern, func(n any) {
+		// keys other than names are expressions, like "a b" or "${prefix}x"
+		if field, ok := n.(*ast.PatternCompositeField); ok {
+			if key, ok := field.Key.(*ast.PatternNormal); ok {
+				if _, ok := key.X.(*ast.Ident); !ok {
+					v.checkExpr(scope, key.X)
+				}
+			}
+		}
+	})
+
+	seen := map[string]bool{}
+	for _, ident := range ast.Bindings(pattern) {
+		if seen[ident.Name] {
+			// reported as a duplicate already
+			continue
+		}
+		seen[ident.Name] = true
+		if scope.IsDefinedInCurrentScope(ident.Name) {
+			v.Report(protocol.DiagnosticSeverityError, ident, "cannot redefine variable %s", ident.Name)
+		}
+		scope.Set(ident.Name, &Variable{
+			Constant: constant,
+		})
+		v.declare(scope, ident, node, kind, nil)
+	}
+}
+
 func (v *Validator) checkAssignStmt(scope *Scope, stmt *ast.AssignStmt) StmtResult {
 	defer pop(push(v, stmt))
 
@@ -1253,6 +1842,8 @@ func (v *Validator) checkAssignStmt(scope *Scope, stmt *ast.AssignStmt) StmtResu
 			x := scope.Get("this")
 			if x == nil {
 				v.Report(protocol.DiagnosticSeverityError, lhs, "invalid property assignment: 'this' is not defined")
+			} else {
+				v.referenceMember(lhs, x.Value, ident[1:])
 			}
 			v.checkExpr(scope, stmt.Rhs)
 
@@ -1260,8 +1851,9 @@ func (v *Validator) checkAssignStmt(scope *Scope, stmt *ast.AssignStmt) StmtResu
 		}
 
 		existing := scope.Get(ident)
+		v.reference(lhs, existing)
 		if existing == nil {
-			v.Report(protocol.DiagnosticSeverityError, lhs, "%s is not defined", ident)
+			v.Report(protocol.DiagnosticSeverityError, lhs, "%s is not defined", ident).Problem = ProblemUndefined
 		} else if existing.Constant {
 			v.Report(protocol.DiagnosticSeverityError, lhs, "cannot assign to constant %s", ident)
 		}
@@ -1272,9 +1864,13 @@ func (v *Validator) checkAssignStmt(scope *Scope, stmt *ast.AssignStmt) StmtResu
 		// 	v.Report(protocol.DiagnosticSeverityError, node, "operator %s not defined for type %s", stmt.Tok, existing.Value.Type())
 		// }
 		v.checkExpr(scope, stmt.Rhs)
+		if existing != nil && !existing.Constant && stmt.Tok == token.Assign {
+			v.checkAssignable(scope, stmt.Rhs, v.varTypes[existing], "assignment to "+ident)
+		}
 		return &Void{}
 	case *ast.SelectorExpr:
-		v.checkExpr(scope, lhs.X)
+		x := v.checkExpr(scope, lhs.X)
+		v.referenceMember(lhs.Sel, x, lhs.Sel.Name)
 		v.checkExpr(scope, stmt.Rhs)
 	case *ast.BracketSelectorExpr:
 		v.checkExpr(scope, lhs.X)
@@ -1317,13 +1913,15 @@ func (v *Validator) checkNativeStmt(scope *Scope, stmt ast.NativeStmt) StmtResul
 
 	var name string
 	switch stmt := stmt.(type) {
+	case *ast.NativeType:
+		return v.checkNativeType(scope, stmt)
 	case *ast.NativeConst:
 		name = "C/" + stmt.Ident.Name
 	case *ast.NativeStruct:
 		name = "S/" + stmt.Name.Name
 	case *ast.NativeFunc:
 		if stmt.Receiver != nil {
-			name = "F/" + stmt.Receiver.Name + "." + name
+			name = "F/" + stmt.Receiver.Name + "." + stmt.Name.Name
 		} else {
 			name = "F/" + stmt.Name.Name
 		}
@@ -1335,46 +1933,83 @@ func (v *Validator) checkNativeStmt(scope *Scope, stmt ast.NativeStmt) StmtResul
 
 	specifier := scope.Module().Specifier
 
-	if moduleNatives, ok := interpreter.Natives[specifier]; ok {
-		if value, ok := moduleNatives[name]; ok {
-			if fn, ok := stmt.(*ast.NativeFunc); ok && fn.Receiver != nil {
-				// find proto
-				// TODO: limit to current module
-				constructor := scope.Get(fn.Receiver.Name)
-				if constructor == nil {
-					v.Report(protocol.DiagnosticSeverityError, stmt, "unknown type %s", fn.Receiver.Name)
-					return nil
-				} else if val, ok := constructor.Value.(*Func); !ok || val.NewableProto == nil {
-					v.Report(protocol.DiagnosticSeverityError, stmt, "%s cannot have receiver functions", fn.Receiver.Name)
-					return nil
-				}
+	var value Value
+	if moduleNatives, ok := nativesFor(specifier); ok {
+		value = moduleNatives[name]
+	}
+	if value == nil {
+		v.Report(protocol.DiagnosticSeverityError, stmt, "native %s not found", name)
+
+		// natives that are only provided by some builds (like std:canvas on
+		// the web) are still declared, so that their uses resolve
+		switch stmt := stmt.(type) {
+		case *ast.NativeConst:
+			value = NullValue
+		case *ast.NativeStruct:
+			value = &Func{NewableProto: &Composite{
+				Name:       stmt.Name.Name,
+				Properties: make(Properties),
+				Operators:  make(Operators),
+			}}
+		case *ast.NativeFunc:
+			value = &Func{}
+		default:
+			return nil
+		}
+	}
 
-				proto := constructor.Value.(*Func).NewableProto
+	if fn, ok := stmt.(*ast.NativeFunc); ok {
+		if value, ok := value.(*Func); ok && value != nil {
+			v.pushTypeParams(fn.TypeParams)
+			v.funcTypes[value] = v.signature(scope, fn.Params.List, fn.Result)
+			v.popTypeParams()
+		}
+	}
 
-				if proto.Properties[PKString] == nil {
-					proto.Properties[PKString] = make(map[string]Value)
-				}
+	if fn, ok := stmt.(*ast.NativeFunc); ok && fn.Receiver != nil {
+		// find proto
+		// TODO: limit to current module
+		constructor := scope.Get(fn.Receiver.Name)
+		if constructor == nil {
+			v.Report(protocol.DiagnosticSeverityError, stmt, "unknown type %s", fn.Receiver.Name)
+			return nil
+		} else if val, ok := constructor.Value.(*Func); !ok || val.NewableProto == nil {
+			v.Report(protocol.DiagnosticSeverityError, stmt, "%s cannot have receiver functions", fn.Receiver.Name)
+			return nil
+		}
 
-				if _, ok := proto.Properties[PKString][fn.Name.Name]; ok {
-					v.Report(protocol.DiagnosticSeverityError, stmt, "duplicate receiver function %s", fn.Name.Name)
-				}
+		proto := constructor.Value.(*Func).NewableProto
 
-				proto.Properties[PKString][fn.Name.Name] = value
-			}
+		if proto.Properties[PKString] == nil {
+			proto.Properties[PKString] = make(map[string]Value)
+		}
 
-			scope.Set(name[2:], &Variable{
-				Value:    value,
-				Constant: true,
-			})
-			return &Decl{
-				Name:  name[2:],
-				Value: value,
-			}
+		if _, ok := proto.Properties[PKString][fn.Name.Name]; ok {
+			v.Report(protocol.DiagnosticSeverityError, stmt, "duplicate receiver function %s", fn.Name.Name)
 		}
+
+		proto.Properties[PKString][fn.Name.Name] = value
+		v.reference(fn.Receiver, constructor)
+		v.declare(nil, fn.Name, fn, DeclMethod, value)
+		return &Void{}
 	}
 
-	v.Report(protocol.DiagnosticSeverityError, stmt, "native %s not found", name)
-	return nil
+	scope.Set(name[2:], &Variable{
+		Value:    value,
+		Constant: true,
+	})
+	switch stmt := stmt.(type) {
+	case *ast.NativeConst:
+		v.declare(scope, stmt.Ident, stmt, DeclConstant, value)
+	case *ast.NativeStruct:
+		v.declare(scope, stmt.Name, stmt, DeclStruct, value)
+	case *ast.NativeFunc:
+		v.declare(scope, stmt.Name, stmt, DeclFunction, value)
+	}
+	return &Decl{
+		Name:  name[2:],
+		Value: value,
+	}
 }
 
 func (v *Validator) checkFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
@@ -1389,49 +2024,73 @@ func (v *Validator) checkFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
 	// validate parameters
 	paramNames := map[string]bool{}
 	for _, param := range expr.Params.List {
-		if paramNames[param.Ident.Name] {
-			v.Report(protocol.DiagnosticSeverityError, param.Ident, "duplicate parameter %s", param.Ident.Name)
+		for _, ident := range param.Bindings() {
+			if paramNames[ident.Name] {
+				v.Report(protocol.DiagnosticSeverityError, ident, "duplicate parameter %s", ident.Name).Problem = ProblemDuplicateParameter
+			}
+			paramNames[ident.Name] = true
 		}
-		paramNames[param.Ident.Name] = true
 	}
 
-	for _, param := range expr.Params.List {
+	v.pushTypeParams(expr.ReceiverTypeParams, expr.TypeParams)
+	defer v.popTypeParams()
+	sig := v.signature(scope, expr.Params.List, expr.Result)
+	v.signatures[expr] = sig
+
+	for i, param := range expr.Params.List {
 		if param.Value != nil {
 			v.checkExpr(scope, param.Value)
+			v.checkAssignable(scope, param.Value, sig.params[i].typ, paramWhat(i, param))
 		}
 	}
 
 	closure := scope.Fork(ScopeOwnerClosure)
-	funcScope := closure.Fork(ScopeOwnerFunc)
+	funcScope := v.record(expr, closure.Fork(ScopeOwnerFunc))
 
 	// set parameters in scope
-	for _, param := range expr.Params.List {
-		funcScope.Set(param.Ident.Name, &Variable{
-			Constant: false,
-		})
+	for i, param := range expr.Params.List {
+		if param.Pattern != nil {
+			v.checkArity(param.Pattern, param.Value)
+			v.declarePattern(funcScope, param.Pattern, param, DeclParameter, false)
+			continue
+		}
+		v.setParam(funcScope, param.Ident.Name, sig.params[i].typ)
+		v.declare(funcScope, param.Ident, param, DeclParameter, nil)
 	}
 
 	// TODO: better this
+	var this Value
+	if expr.Receiver != nil {
+		if constructor := scope.Get(expr.Receiver.Name); constructor != nil {
+			this = v.instanceOf(constructor.Value)
+		}
+	}
 	funcScope.Set("this", &Variable{
 		Constant: true,
+		Value:    this,
 	})
 
+	v.results = append(v.results, sig.result)
 	if expr.Arrow.IsValid() {
 		v.checkExpr(funcScope, expr.ArrowExpr)
+		v.checkResult(funcScope, expr.ArrowExpr, expr.ArrowExpr)
 	}
 
 	v.checkStmts(funcScope, expr.Body)
+	v.results = v.results[:len(v.results)-1]
 
 	value := &Func{
 		Async:    expr.Async.IsValid(),
 		Memoized: expr.Memo.IsValid(),
 	}
+	v.funcTypes[value] = sig
 
 	if expr.Name != nil {
 		if expr.Receiver != nil {
 			// find proto
 			// TODO: limit to current module
 			constructor := scope.Get(expr.Receiver.Name)
+			v.reference(expr.Receiver, constructor)
 			exit := false
 
 			if constructor == nil {
@@ -1457,11 +2116,13 @@ func (v *Validator) checkFuncExpr(scope *Scope, expr *ast.FuncExpr) Value {
 			}
 
 			proto.Properties[PKString][expr.Name.Name] = value
+			v.declare(nil, expr.Name, expr, DeclMethod, value)
 		} else {
 			scope.Set(expr.Name.Name, &Variable{
 				Constant: true, // functions are constants
 				Value:    value,
 			})
+			v.declare(scope, expr.Name, expr, DeclFunction, value)
 		}
 	}
 
@@ -0,0 +1,225 @@
+package validator_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/calico32/goose/parser"
+	"github.com/calico32/goose/token"
+	"github.com/calico32/goose/validator"
+)
+
+func TestLabeledBranches(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `outer: for i in 0 to 3
+  repeat 3 times
+    let x = match i
+      1 -> do
+        continue outer
+      end
+      else -> i
+    end
+    break outer
+    continue inner
+  end
+  outer: repeat forever
+  end
+  fn f()
+    break outer
+  end
+end
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"10:14: unknown label inner",
+		"12:3: label outer already defined",
+		"15:11: unknown label outer",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `let a = 1
+let [a, b, b] = [1, 2, 3]
+const { c, d: c } = {}
+for [k, _, _] in []
+  println(k)
+end
+fn g([x, y], x) -> y
+let [p, ...q] = [1]
+println(p, q, undefinedName)
+const [c1, d1] = [5]
+const [c2, [d2, e2]] = [1, [2, 3, 4]]
+const [c3, d3, ...e3] = [1]
+const [c4, ...d4] = [1, ...q]
+let [c5, d5] = [5]
+for [c6, d6] in [[1, 2], [3]]
+end
+fn h([c7, d7] = [1]) -> c7
+h([1, 2, 3])
+for [k2, v2] in entries({})
+end
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"2:12: duplicate binding b",
+		"2:6: cannot redefine variable a",
+		"3:15: duplicate binding c",
+		"7:14: duplicate parameter x",
+		"9:15: undefinedName is not defined",
+		"10:18: cannot destructure an array of length 1 into a pattern of length 2",
+		"11:28: cannot destructure an array of length 3 into a pattern of length 2",
+		"12:25: cannot destructure an array of length 1 into a pattern of length at least 2",
+		"14:16: cannot destructure an array of length 1 into a pattern of length 2",
+		"15:26: cannot destructure an array of length 1 into a pattern of length 2",
+		"17:17: cannot destructure an array of length 1 into a pattern of length 2",
+		"18:3: cannot destructure an array of length 3 into a pattern of length 2",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTraits(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `trait Shape
+  fn area()
+  fn area()
+  fn name() -> "shape"
+  operator ==(other)
+end
+struct Square(side)
+struct Circle(r)
+let x = 1
+impl Shape for Square
+impl Shape for Circle
+impl Shape for Circle
+impl x for Square
+fn Circle.area() -> this.r
+operator Circle ==(other) -> true
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"3:6: duplicate receiver function area",
+		"12:1: Circle already implements Shape",
+		"13:6: value x is not a trait",
+		"10:1: Square does not implement Shape: missing receiver function area",
+		"10:1: Square does not implement Shape: missing operator ==",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEnums(t *testing.T) {
+	t.Setenv("GOOSEROOT", t.TempDir())
+
+	src := `enum Shape
+  Circle(r)
+  Rect(w, h, w)
+  Empty
+  Empty
+end
+let s = Shape.Circle(1)
+let a = match s
+  Shape.Circle($r) -> r
+  Shape.Rect($w) -> w
+end
+let b = match s
+  Shape.Circle(1) -> 1
+  Shape.Rect($w, $h, $d) -> w
+  Shape.Empty -> 0
+end
+let c = match s
+  Shape.Circle($r) -> r
+  $other -> 0
+end
+let d = match s
+  s($x) -> x
+  else -> 0
+end
+let e: Shape = Shape.Empty
+let f: Shape = 1
+`
+	fset := token.NewFileSet()
+	module, err := parser.ParseFile(fset, "test.goose", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(module, fset, false, strings.NewReader(""), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check()
+
+	want := []string{
+		"3:14: duplicate field w",
+		"5:3: duplicate variant Empty",
+		"10:3: variant Rect has 3 fields, got 1",
+		"8:9: match over Shape is not exhaustive: missing Rect, Empty",
+		"12:9: match over Shape is not exhaustive: missing Circle",
+		"22:3: s is not an enum variant",
+		"26:16: cannot use int as Shape in declaration of f",
+	}
+	var got []string
+	for _, d := range v.Diagnostics() {
+		pos := fset.Position(d.Node.Pos())
+		got = append(got, fmt.Sprintf("%d:%d: %s", pos.Line, pos.Column, d.Message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}